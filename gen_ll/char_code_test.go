@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCharCodeMode 取字编码方式：来 的主拆分含映射表中没有的部件，空跑时跳过该拆分照常构建，原拆分成为次拆分
+// 玲珑词用 fallback-secondary 时回退补入来的编码并在日志中写出与多字词的差异；同方式时不写；未知方式报错
+func TestCharCodeMode(t *testing.T) {
+	var div strings.Builder
+	for _, line := range strings.SplitAfter(readOutput(t, minimalInput("ll_div.txt")), "\n") {
+		if strings.HasPrefix(line, "来\t") {
+			div.WriteString("来\t[㊣来,lai,CJK-basic,U+6765]\n")
+		}
+		div.WriteString(line)
+	}
+	divPath := filepath.Join(t.TempDir(), "ll_div.txt")
+	if err := os.WriteFile(divPath, []byte(div.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	const difference = "取字编码方式不同: 多字词 strict，玲珑词 fallback-secondary；次拆分回退补入 1 字"
+
+	_, logs := runGenLL(t, append(minimalArgs(t.TempDir()), "-n", "-d", divPath, "-linglong-char-code", "fallback-secondary")...)
+	if !strings.Contains(logs, difference) {
+		t.Errorf("日志中没有 %q:\n%s", difference, logs)
+	}
+
+	code, logs := runGenLL(t, append(minimalArgs(t.TempDir()), "-n", "-d", divPath,
+		"-words-char-code", "fallback-secondary", "-linglong-char-code", "fallback-secondary")...)
+	if code == 0 || !strings.Contains(logs, "\n校验问题 1 项:\n") {
+		t.Errorf("退出码 %d，非法部件应作为唯一的校验问题:\n%s", code, logs)
+	}
+	if strings.Contains(logs, "取字编码方式不同") {
+		t.Errorf("取字编码方式相同时不应写出差异:\n%s", logs)
+	}
+
+	if code, _ := runGenLL(t, append(minimalArgs(t.TempDir()), "-q", "-words-char-code", "secondary")...); code == 0 {
+		t.Error("未知的取字编码方式应当失败")
+	}
+}
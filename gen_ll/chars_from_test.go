@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCharsFrom 从已有单字全码表生成：词码、简码与全量构建一致，拆分文件不重新生成；格式错误的全码表直接失败
+func TestCharsFrom(t *testing.T) {
+	baseDir, fromDir := t.TempDir(), t.TempDir()
+	generateMinimal(t, baseDir)
+	fullTable := filepath.Join(baseDir, "code_chars_full.txt")
+	generateMinimal(t, fromDir, "-chars-from", fullTable)
+	checkSameTables(t, baseDir, fromDir, codeTables...)
+	if _, err := os.Stat(filepath.Join(fromDir, "div_ll.txt")); !os.IsNotExist(err) {
+		t.Errorf("-chars-from 时不应生成拆分文件: %v", err)
+	}
+
+	var twoColumns strings.Builder
+	for _, row := range readRows(t, fullTable) {
+		twoColumns.WriteString(row[0] + "\t" + row[1] + "\n")
+	}
+	badTable := filepath.Join(t.TempDir(), "chars_two_columns.txt")
+	if err := os.WriteFile(badTable, []byte(twoColumns.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := runGenLL(t, append(minimalArgs(t.TempDir()), "-q", "-chars-from", badTable)...); code == 0 {
+		t.Error("格式错误的单字全码表应当失败")
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// citiArgs 以最小示例数据生成跟打词提的参数，词提前置表为空文件
+func citiArgs(t *testing.T, dir string) []string {
+	t.Helper()
+	citiPre := filepath.Join(dir, "ll_citi_pre.txt")
+	if err := os.WriteFile(citiPre, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return append(minimalArgs(dir), "-q", "-stable-sort", "-C",
+		"-c", citiPre,
+		"-g", filepath.Join(dir, "genda_citi.txt"),
+		"-z", filepath.Join(dir, "dazhu_code.txt"),
+	)
+}
+
+// readRows 读取制表符分隔的码表，每行取前两列
+func readRows(t *testing.T, path string) [][2]string {
+	t.Helper()
+	var rows [][2]string
+	for _, line := range strings.Split(strings.TrimSuffix(readOutput(t, path), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			t.Fatalf("%s 行格式错误: %q", path, line)
+		}
+		rows = append(rows, [2]string{fields[0], fields[1]})
+	}
+	return rows
+}
+
+// isPlaceholderWord 多字词简码补位生成的占位符 ① 至 ⑩
+func isPlaceholderWord(word string) bool {
+	char, size := utf8.DecodeRuneInString(word)
+	return size == len(word) && char >= '①' && char <= '⑩'
+}
+
+// checkCitiWords 跟打词提中的多字条目与来源词表（简码不含占位符，加全码）一一对应，编码以来源的原编码开头
+func checkCitiWords(t *testing.T, citi [][2]string, sources ...string) {
+	t.Helper()
+	codes := make(map[string][]string)
+	want := 0
+	for _, source := range sources {
+		for _, row := range readRows(t, source) {
+			if isPlaceholderWord(row[0]) {
+				continue
+			}
+			codes[row[0]] = append(codes[row[0]], row[1])
+			want++
+		}
+	}
+	got := 0
+	for _, row := range citi {
+		if utf8.RuneCountInString(row[0]) == 1 {
+			continue
+		}
+		got++
+		found := false
+		for _, code := range codes[row[0]] {
+			found = found || strings.HasPrefix(row[1], code)
+		}
+		if !found {
+			t.Errorf("跟打词提条目 %s %s 不来自 %v", row[0], row[1], sources)
+		}
+	}
+	if got != want {
+		t.Errorf("跟打词提中多字条目 %d 条，来源词表 %d 条", got, want)
+	}
+}
+
+// singleCharRows 跟打词提中的单字条目
+func singleCharRows(citi [][2]string) [][2]string {
+	var rows [][2]string
+	for _, row := range citi {
+		if utf8.RuneCountInString(row[0]) == 1 {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// TestCitiSource 跟打词提的两种词语来源：linglong 取玲珑词简码与全码，words 取多字词简码与全码（旧版四文件流程）
+// 两者共用补码后缀与出简让全，单字部分完全一致
+func TestCitiSource(t *testing.T) {
+	linglongDir := t.TempDir()
+	if code, logs := runGenLL(t, citiArgs(t, linglongDir)...); code != 0 {
+		t.Fatalf("-citi-source linglong 退出码 %d:\n%s", code, logs)
+	}
+	linglongCiti := readRows(t, filepath.Join(linglongDir, "genda_citi.txt"))
+	checkCitiWords(t, linglongCiti, filepath.Join(linglongDir, "linglong_simp.txt"), filepath.Join(linglongDir, "linglong_full.txt"))
+
+	wordsDir := t.TempDir()
+	if code, logs := runGenLL(t, append(citiArgs(t, wordsDir), "-citi-source", "words")...); code != 0 {
+		t.Fatalf("-citi-source words 退出码 %d:\n%s", code, logs)
+	}
+	wordsCiti := readRows(t, filepath.Join(wordsDir, "genda_citi.txt"))
+	checkCitiWords(t, wordsCiti, filepath.Join(wordsDir, "code_words_simp.txt"), filepath.Join(wordsDir, "code_words_full.txt"))
+
+	linglongChars, wordsChars := singleCharRows(linglongCiti), singleCharRows(wordsCiti)
+	if len(linglongChars) == 0 || !reflect.DeepEqual(linglongChars, wordsChars) {
+		t.Errorf("两种词语来源的单字条目不一致:\nlinglong: %v\nwords: %v", linglongChars, wordsChars)
+	}
+
+	if code, _ := runGenLL(t, append(citiArgs(t, t.TempDir()), "-citi-source", "both")...); code == 0 {
+		t.Error("未知的跟打词提词语来源应当失败")
+	}
+}
+
+// TestCitiSourceSort 来源排序在加补码后缀之后进行：按编码、字频重排只改变条目位置，各条目得到的编码与默认顺序相同
+// 显式写出 keep 与不指定排序的输出逐行一致
+func TestCitiSourceSort(t *testing.T) {
+	generate := func(extra ...string) [][2]string {
+		t.Helper()
+		dir := t.TempDir()
+		if code, logs := runGenLL(t, append(citiArgs(t, dir), extra...)...); code != 0 {
+			t.Fatalf("%v 退出码 %d:\n%s", extra, code, logs)
+		}
+		return readRows(t, filepath.Join(dir, "genda_citi.txt"))
+	}
+	sorted := func(rows [][2]string) [][2]string {
+		rows = append([][2]string(nil), rows...)
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i][0] != rows[j][0] {
+				return rows[i][0] < rows[j][0]
+			}
+			return rows[i][1] < rows[j][1]
+		})
+		return rows
+	}
+
+	keep := generate()
+	reordered := generate("-citi-source-sort", "chars_full:code,LL_linglong.full:freq")
+	if reflect.DeepEqual(keep, reordered) {
+		t.Error("按编码、字频排序后条目顺序应当改变")
+	}
+	if !reflect.DeepEqual(sorted(keep), sorted(reordered)) {
+		t.Errorf("排序改变了条目或编码:\nkeep: %v\nsorted: %v", keep, reordered)
+	}
+
+	if explicitKeep := generate("-citi-source-sort", "chars_simp:keep,chars_full:keep"); !reflect.DeepEqual(keep, explicitKeep) {
+		t.Errorf("显式 keep 与默认顺序不一致:\n默认: %v\nkeep: %v", keep, explicitKeep)
+	}
+}
+
+// TestGendaCodeMaxLength 编码最大长度的 drop 方式只去掉超长的跟打词提条目并给出数量，其余条目与编码不变
+// 限制为三码时没有重码、不加后缀的四码条目同样超长
+// 大竹词提随之受限，dict.yaml 不受影响
+func TestGendaCodeMaxLength(t *testing.T) {
+	keepDir, limitedDir := t.TempDir(), t.TempDir()
+	if code, logs := runGenLL(t, citiArgs(t, keepDir)...); code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	code, logs := runGenLL(t, append(citiArgs(t, limitedDir), "-genda-code-max-length", "3")...)
+	if code != 0 {
+		t.Fatalf("-genda-code-max-length 3 退出码 %d:\n%s", code, logs)
+	}
+
+	var want [][2]string
+	dropped := 0
+	for _, row := range readRows(t, filepath.Join(keepDir, "genda_citi.txt")) {
+		if len(row[1]) > 3 {
+			dropped++
+			continue
+		}
+		want = append(want, row)
+	}
+	if dropped == 0 || len(want) == 0 {
+		t.Fatalf("最小示例数据的跟打词提应当同时有超过与不超过三码的编码，超长 %d 条", dropped)
+	}
+	genda := readRows(t, filepath.Join(limitedDir, "genda_citi.txt"))
+	if !reflect.DeepEqual(genda, want) {
+		t.Errorf("限制后的跟打词提:\n%v\n期望:\n%v", genda, want)
+	}
+	if dazhu := readRows(t, filepath.Join(limitedDir, "dazhu_code.txt")); !reflect.DeepEqual(dazhu, swapColumns(want)) {
+		t.Errorf("限制后的大竹词提:\n%v", dazhu)
+	}
+	if warning := fmt.Sprintf("警告: %d 条跟打词提条目受编码最大长度 3 限制", dropped); !strings.Contains(logs, warning) {
+		t.Errorf("日志中没有 %q:\n%s", warning, logs)
+	}
+	for _, dict := range []string{"LL.chars.full.dict.yaml", "LL.words.full.dict.yaml", "LL_linglong.full.dict.yaml"} {
+		if readOutput(t, filepath.Join(keepDir, dict)) != readOutput(t, filepath.Join(limitedDir, dict)) {
+			t.Errorf("%s 不应受编码最大长度影响", dict)
+		}
+	}
+}
+
+// TestGendaCodeMaxLengthPaging 翻页后缀在编码最大长度下：12 个候选的四码重码组不限制时第 11、12 候选翻页（"=_"、"=e"）
+// 限制 5 码时 drop 丢弃翻页候选，numbered 整组改为数字选重，第 10 候选起丢弃；限制 6 码时与不限制相同
+func TestGendaCodeMaxLengthPaging(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "paging.txt")
+	out := filepath.Join(dir, "paging_out.txt")
+	var table strings.Builder
+	for i := 1; i <= 12; i++ {
+		fmt.Fprintf(&table, "词%d\tabcd\t%d\n", i, 100-i)
+	}
+	if err := os.WriteFile(in, []byte(table.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	codes := func(extra ...string) ([]string, string) {
+		t.Helper()
+		argv := append([]string{"fmt", "-q", "-in", in, "-out", out, "-fmt-add-candidates", "-fmt-strip-freq"}, extra...)
+		code, logs := runGenLL(t, argv...)
+		if code != 0 {
+			t.Fatalf("%v 退出码 %d:\n%s", extra, code, logs)
+		}
+		var codes []string
+		for _, row := range readRows(t, out) {
+			codes = append(codes, row[1])
+		}
+		return codes, logs
+	}
+
+	paged := []string{"abcd", "abcde", "abcdi", "abcd[", "abcd2", "abcd3", "abcd7", "abcd8", "abcd9", "abcd0", "abcd=_", "abcd=e"}
+	tests := []struct {
+		name    string
+		extra   []string
+		want    []string
+		warning string
+	}{
+		{"不限制", nil, paged, ""},
+		{"限制6码", []string{"-genda-code-max-length", "6"}, paged, ""},
+		{"限制5码drop", []string{"-genda-code-max-length", "5"}, paged[:10], "警告: 2 条编码受最大长度 5 限制"},
+		{"限制5码numbered", []string{"-genda-code-max-length", "5", "-genda-code-overflow", "numbered"},
+			[]string{"abcd", "abcd2", "abcd3", "abcd4", "abcd5", "abcd6", "abcd7", "abcd8", "abcd9"}, "警告: 3 条编码受最大长度 5 限制"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, logs := codes(tt.extra...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("编码 %v，期望 %v", got, tt.want)
+			}
+			if tt.warning != "" && !strings.Contains(logs, tt.warning) {
+				t.Errorf("日志中没有 %q:\n%s", tt.warning, logs)
+			}
+		})
+	}
+
+	if code, _ := runGenLL(t, "fmt", "-q", "-in", in, "-out", out, "-fmt-add-candidates", "-genda-code-overflow", "page"); code == 0 {
+		t.Error("未知的超长编码处理方式应当失败")
+	}
+}
+
+// TestCitiTargets 未开启 -C 时显式指定跟打词提输出只给出警告，不生成文件；-targets citi 与 -C 等价
+func TestCitiTargets(t *testing.T) {
+	offDir := t.TempDir()
+	genda := filepath.Join(offDir, "genda_citi.txt")
+	code, logs := runGenLL(t, append(minimalArgs(offDir), "-g", genda)...)
+	if code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	if want := "-g，但需要 -C"; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+	if _, err := os.Stat(genda); !os.IsNotExist(err) {
+		t.Errorf("未开启 -C 时不应生成 %s: %v", genda, err)
+	}
+
+	enabledDir, targetsDir := t.TempDir(), t.TempDir()
+	if code, logs := runGenLL(t, citiArgs(t, enabledDir)...); code != 0 {
+		t.Fatalf("-C 退出码 %d:\n%s", code, logs)
+	}
+	if code, logs := runGenLL(t, append(citiArgs(t, targetsDir), "-C=false", "-targets", "citi")...); code != 0 {
+		t.Fatalf("-targets citi 退出码 %d:\n%s", code, logs)
+	}
+	for _, name := range []string{"genda_citi.txt", "dazhu_code.txt"} {
+		if got, want := readOutput(t, filepath.Join(targetsDir, name)), readOutput(t, filepath.Join(enabledDir, name)); got != want {
+			t.Errorf("-targets citi 的 %s 与 -C 不一致:\n%s\n期望:\n%s", name, got, want)
+		}
+	}
+}
+
+// TestSaimaTable 极速赛码表：同一原编码下首选不加数字，其后从起始键起连续编号，编码不重复
+// 同一词在简码、全码来源中同码时与跟打词提一样重复出现；不受跟打词提键位重映射影响
+func TestSaimaTable(t *testing.T) {
+	dir := t.TempDir()
+	// 四个词全码同为 llvz、简码同为 l，各成一组四个候选的重码组
+	linglong := filepath.Join(dir, "linglong.txt")
+	if err := os.WriteFile(linglong, []byte("他们自己\t400\n他他自己\t300\n们们自己\t200\n们他自己\t100\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	saima := filepath.Join(dir, "saima.txt")
+	argv := append(citiArgs(t, dir), "-L", linglong, "-saima-out", saima, "-saima-start-key", "3")
+	if code, logs := runGenLL(t, argv...); code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	rows := readRows(t, saima)
+	genda := readRows(t, filepath.Join(dir, "genda_citi.txt"))
+
+	for _, want := range [][2]string{{"他们自己", "llvz"}, {"他他自己", "llvz3"}, {"们们自己", "llvz4"}, {"们他自己", "llvz5"}} {
+		found := false
+		for _, row := range rows {
+			found = found || row == want
+		}
+		if !found {
+			t.Errorf("极速赛码表缺少 %s %s", want[0], want[1])
+		}
+	}
+	seen := make(map[string]string)
+	counts := make(map[string]int)
+	for _, row := range rows {
+		word, code := row[0], row[1]
+		if previous, ok := seen[code]; ok {
+			if previous != word {
+				t.Errorf("极速赛码表编码 %s 重复: %s %s", code, previous, word)
+			}
+			continue
+		}
+		seen[code] = word
+		base, rank := code, 0
+		if last := code[len(code)-1]; last >= '1' && last <= '9' {
+			base, rank = code[:len(code)-1], int(last-'0')-2
+		}
+		if rank != counts[base] {
+			t.Errorf("极速赛码表选重键不连续: %s %s", word, code)
+		}
+		counts[base]++
+	}
+	words := func(rows [][2]string) []string {
+		var words []string
+		for _, row := range rows {
+			words = append(words, row[0])
+		}
+		sort.Strings(words)
+		return words
+	}
+	if !reflect.DeepEqual(words(rows), words(genda)) {
+		t.Errorf("极速赛码表与跟打词提的条目不一致:\n%v\n%v", rows, genda)
+	}
+
+	remapDir := t.TempDir()
+	remapSaima := filepath.Join(remapDir, "saima.txt")
+	argv = append(citiArgs(t, remapDir), "-L", linglong, "-saima-out", remapSaima, "-saima-start-key", "3", "-genda-key-remap", ";=1 ,=4 .=5 /=6")
+	if code, logs := runGenLL(t, argv...); code != 0 {
+		t.Fatalf("-genda-key-remap 退出码 %d:\n%s", code, logs)
+	}
+	if got := readRows(t, remapSaima); !reflect.DeepEqual(got, rows) {
+		t.Errorf("极速赛码表不应受跟打词提键位重映射影响:\n%v\n期望:\n%v", got, rows)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// leadingColumns 码表每行只保留前 n 列
+func leadingColumns(t *testing.T, path string, n int) string {
+	t.Helper()
+	var builder strings.Builder
+	for _, line := range strings.SplitAfter(readOutput(t, path), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(strings.TrimSuffix(line, "\n"), "\t")
+		builder.WriteString(strings.Join(fields[:min(n, len(fields))], "\t") + "\n")
+	}
+	return builder.String()
+}
+
+// TestOutputColumns 按码表配置输出列：配置的码表只保留所列的列，未配置的码表保持默认列数
+// -full-simp-column 的单字全码表多出简码列；列配置不以 text,code 开头、含不支持的列或未知码表时报错
+func TestOutputColumns(t *testing.T) {
+	baseDir, columnsDir := t.TempDir(), t.TempDir()
+	generateMinimal(t, baseDir)
+	generateMinimal(t, columnsDir, "-full-simp-column", "-output-columns", "words_full=text,code chars_simp=text,code linglong_simp=text,code,weight")
+
+	for _, name := range []string{"code_words_full.txt", "code_chars_simp.txt"} {
+		if got, want := readOutput(t, filepath.Join(columnsDir, name)), leadingColumns(t, filepath.Join(baseDir, name), 2); got != want {
+			t.Errorf("%s 应只有字词与编码两列:\n%s\n期望:\n%s", name, got, want)
+		}
+	}
+	charsFull := filepath.Join(columnsDir, "code_chars_full.txt")
+	if got, want := leadingColumns(t, charsFull, 3), readOutput(t, filepath.Join(baseDir, "code_chars_full.txt")); got != want {
+		t.Errorf("单字全码表的前三列:\n%s\n期望:\n%s", got, want)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(readOutput(t, charsFull), "\n"), "\n") {
+		if strings.Count(line, "\t") != 3 {
+			t.Errorf("单字全码表缺少简码列: %q", line)
+		}
+	}
+	checkSameTables(t, baseDir, columnsDir, "code_words_simp.txt", "linglong_full.txt", "linglong_simp.txt")
+
+	for _, columns := range []string{"words_full=code,text", "words_simp=text,code,simp", "chars=text,code", "chars_full=text,code"} {
+		if code, _ := runGenLL(t, append(minimalArgs(t.TempDir()), "-q", "-full-simp-column", "-output-columns", columns)...); code == 0 {
+			t.Errorf("输出列配置 %s 应当失败", columns)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInputTrailingComments 拆分表、映射表、频率表与词表的数据行带行尾注释（# 与全角 ＃，前面是制表符或空格）时，各码表与不带注释时完全一致
+func TestInputTrailingComments(t *testing.T) {
+	inputDir := t.TempDir()
+	commented := func(name string) string {
+		var builder strings.Builder
+		for i, line := range strings.Split(strings.TrimSuffix(readOutput(t, minimalInput(name)), "\n"), "\n") {
+			builder.WriteString(line)
+			if line != "" && !strings.HasPrefix(line, "#") {
+				if i%2 == 0 {
+					builder.WriteString("\t# 注释\t含制表符")
+				} else {
+					builder.WriteString(" ＃全角注释")
+				}
+			}
+			builder.WriteString("\n")
+		}
+		path := filepath.Join(inputDir, name)
+		if err := os.WriteFile(path, []byte(builder.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	plainDir, commentedDir := t.TempDir(), t.TempDir()
+	if code, logs := runGenLL(t, append(minimalArgs(plainDir), "-q")...); code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	argv := append(minimalArgs(commentedDir), "-q",
+		"-d", commented("ll_div.txt"),
+		"-m", commented("ll_map.txt"),
+		"-f", commented("freq.txt"),
+		"-w", commented("ll_words.txt"),
+		"-L", commented("linglong.txt"),
+	)
+	if code, logs := runGenLL(t, argv...); code != 0 {
+		t.Fatalf("带注释的输入退出码 %d:\n%s", code, logs)
+	}
+
+	for _, name := range []string{"code_chars_full.txt", "code_chars_simp.txt", "code_words_full.txt", "code_words_simp.txt", "linglong_full.txt", "linglong_simp.txt", "div_ll.txt", "dazhu_chai.txt", "LL.roots.dict.yaml"} {
+		if readOutput(t, filepath.Join(plainDir, name)) != readOutput(t, filepath.Join(commentedDir, name)) {
+			t.Errorf("%s 与不带注释的输入不一致", name)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestCompMapDuplicates 映射表中重复定义的字根默认警告一次、以最后一行为准，-strict 下编码不同的重复定义直接失败
+func TestCompMapDuplicates(t *testing.T) {
+	mapContent := readOutput(t, minimalInput("ll_map.txt"))
+	var comp string
+	for _, line := range strings.Split(mapContent, "\n") {
+		if fields := strings.Split(line, "\t"); len(fields) >= 2 && !strings.HasPrefix(line, "#") {
+			comp = fields[1]
+			break
+		}
+	}
+	lines := strings.Count(mapContent, "\n")
+	mapPath := filepath.Join(t.TempDir(), "ll_map.txt")
+	if err := os.WriteFile(mapPath, []byte(mapContent+"zzz\t"+comp+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	argv := func(dir string, extra ...string) []string {
+		return append(append(minimalArgs(dir), "-q", "-m", mapPath), extra...)
+	}
+
+	dir := t.TempDir()
+	code, logs := runGenLL(t, argv(dir)...)
+	if code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	if count := strings.Count(logs, "字根 "+comp+" 重复定义"); count != 1 {
+		t.Errorf("字根 %s 的重复定义应警告一次，实际 %d 次:\n%s", comp, count, logs)
+	}
+	if want := "第 " + strconv.Itoa(lines+1) + " 行 zzz（以最后一行为准）"; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+
+	code, logs = runGenLL(t, argv(t.TempDir(), "-strict")...)
+	if code == 0 {
+		t.Error("-strict 下编码不同的重复定义应当失败")
+	}
+	if want := "映射表中 1 个字根重复定义且编码不同"; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// writerOutputs 写出阶段各自在一个协程中写出的码表
+var writerOutputs = []string{
+	"code_chars_full.txt",
+	"code_chars_simp.txt",
+	"code_words_full.txt",
+	"code_words_simp.txt",
+	"linglong_full.txt",
+	"linglong_simp.txt",
+	"div_ll.txt",
+	"dazhu_chai.txt",
+}
+
+// TestGenerateConcurrentWriters 全流程跑一遍，八个码表同时写出，各协程共享同一批 CharMeta 与词码指针
+// 写出阶段只读这些指针，用 go test -race 运行时任何一处原地修改都会报竞态；结果应与逐个写出时完全一致
+func TestGenerateConcurrentWriters(t *testing.T) {
+	parallelDir := t.TempDir()
+	if code, logs := runGenLL(t, append(minimalArgs(parallelDir), "-q", "-stable-sort")...); code != 0 {
+		t.Fatalf("并行写出退出码 %d:\n%s", code, logs)
+	}
+
+	// 内存降级后 spawn 在当前协程依次执行
+	memoryDegraded = true
+	defer func() { memoryDegraded = false }()
+	sequentialDir := t.TempDir()
+	if code, logs := runGenLL(t, append(minimalArgs(sequentialDir), "-q", "-stable-sort")...); code != 0 {
+		t.Fatalf("逐个写出退出码 %d:\n%s", code, logs)
+	}
+
+	for _, name := range writerOutputs {
+		parallel := readOutput(t, filepath.Join(parallelDir, name))
+		sequential := readOutput(t, filepath.Join(sequentialDir, name))
+		if parallel == "" {
+			t.Errorf("%s 为空", name)
+		}
+		if parallel != sequential {
+			t.Errorf("%s 并行写出与逐个写出不一致:\n并行:\n%s\n逐个:\n%s", name, parallel, sequential)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestConfigFile 配置文件的键为参数字段名，YAML 与 TOML 写出的输入与简码限制同命令行参数结果一致
+// 命令行显式指定的参数覆盖配置，未被覆盖时配置生效；未知的键、类型不符、重复的键与 TOML 中不加引号的字符串报错
+func TestConfigFile(t *testing.T) {
+	baseDir := t.TempDir()
+	generateMinimal(t, baseDir, "-wL", "1:1,2:1,3:0,4:0")
+
+	configDir := t.TempDir()
+	configs := map[string]string{
+		"yaml": `# 最小示例数据
+Quiet: true
+StableSort: true
+Div: "` + minimalInput("ll_div.txt") + `"
+Map: ` + minimalInput("ll_map.txt") + `   # 不加引号的字符串
+Freq: '` + minimalInput("freq.txt") + `'
+Words: ` + minimalInput("ll_words.txt") + `
+Linglong: ` + minimalInput("linglong.txt") + `
+WordsLenCodeLimit: 1:1,2:1,3:0,4:0
+OutputColumns: words_full=text,code
+`,
+		"toml": `# 最小示例数据
+Quiet = true
+StableSort = true
+Div = '` + minimalInput("ll_div.txt") + `'
+Map = '` + minimalInput("ll_map.txt") + `'
+Freq = '` + minimalInput("freq.txt") + `'
+Words = '` + minimalInput("ll_words.txt") + `'
+Linglong = '` + minimalInput("linglong.txt") + `'
+WordsLenCodeLimit = "1:1,2:1,3:0,4:0"
+OutputColumns = "words_full=text,code"
+`,
+	}
+	for format, content := range configs {
+		config := filepath.Join(configDir, "gen_ll."+format)
+		if err := os.WriteFile(config, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			argv := append([]string{"-config", config, "-output-columns", ""}, minimalOutputArgs(dir)...)
+			if code, logs := runGenLL(t, argv...); code != 0 {
+				t.Fatalf("退出码 %d:\n%s", code, logs)
+			}
+			checkSameTables(t, baseDir, dir, codeTables...)
+		})
+	}
+
+	// -d 显式指定时配置中的 Div 不起作用，未被覆盖的 OutputColumns 只保留两列
+	dir := t.TempDir()
+	argv := append([]string{"-config", filepath.Join(configDir, "gen_ll.yaml"), "-d", minimalInput("ll_div.txt")}, minimalOutputArgs(dir)...)
+	if code, logs := runGenLL(t, argv...); code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	if got, want := readOutput(t, filepath.Join(dir, "code_words_full.txt")), leadingColumns(t, filepath.Join(baseDir, "code_words_full.txt"), 2); got != want {
+		t.Errorf("配置中的 OutputColumns 未生效:\n%s\n期望:\n%s", got, want)
+	}
+
+	for name, content := range map[string]string{
+		"unknown.yaml":   "Unknown: 1\n",
+		"list.yaml":      "Words: [a, b]\n",
+		"config.yaml":    "Config: other.yaml\n",
+		"bool.yaml":      "StableSort: maybe\n",
+		"duplicate.yaml": "StableSort: true\nStableSort: false\n",
+		"bare.toml":      "Words = ll_words.txt\n",
+	} {
+		config := filepath.Join(configDir, name)
+		if err := os.WriteFile(config, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if code, _ := runGenLL(t, append(minimalArgs(t.TempDir()), "-config", config)...); code == 0 {
+			t.Errorf("配置 %s（%q）应当失败", name, content)
+		}
+	}
+}
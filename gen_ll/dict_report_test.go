@@ -0,0 +1,111 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// dictReportPattern -dict-line-count-report 在标准错误上输出的一行
+var dictReportPattern = regexp.MustCompile(`^\[dict\] (\S+): before=(\d+) after=(\d+) added=(\d+)$`)
+
+// dictLineCounts 一个字典追加前后的条目行数
+type dictLineCounts struct {
+	before, after, added int
+}
+
+// parseDictReport 解析标准错误中的条目行数报告，按字典文件名索引
+func parseDictReport(t *testing.T, stderr string) map[string]dictLineCounts {
+	t.Helper()
+	report := make(map[string]dictLineCounts)
+	for _, line := range strings.Split(strings.TrimSpace(stderr), "\n") {
+		match := dictReportPattern.FindStringSubmatch(line)
+		if match == nil {
+			t.Fatalf("无法解析的报告行: %q", line)
+		}
+		var counts [3]int
+		for i := range counts {
+			counts[i], _ = strconv.Atoi(match[i+2])
+		}
+		report[match[1]] = dictLineCounts{counts[0], counts[1], counts[2]}
+	}
+	return report
+}
+
+// TestDictLineCountReport 每次追加字典后在标准错误上报告前后条目行数，追加到已有字典时追加前行数为上次的追加后行数
+func TestDictLineCountReport(t *testing.T) {
+	dir := t.TempDir()
+	argv := append(minimalArgs(dir), "-dict-line-count-report")
+
+	code, logs, stderr := runGenLLOutput(t, argv...)
+	if code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	if strings.Contains(logs, "[dict]") {
+		t.Errorf("条目行数报告应只输出到标准错误:\n%s", logs)
+	}
+	first := parseDictReport(t, stderr)
+	if len(first) != 7 {
+		t.Errorf("报告了 %d 个字典，期望 7 个:\n%s", len(first), stderr)
+	}
+	for name, counts := range first {
+		if counts.before != 0 || counts.after == 0 || counts.added != counts.after {
+			t.Errorf("%s 首次追加: %+v", name, counts)
+		}
+	}
+	wordsFull := strings.Count(readOutput(t, filepath.Join(dir, "code_words_full.txt")), "\n")
+	if added := first["LL.words.full.dict.yaml"].added; added != wordsFull {
+		t.Errorf("LL.words.full.dict.yaml 增加 %d 行，code_words_full.txt 有 %d 行", added, wordsFull)
+	}
+
+	// 再追加一次：追加前行数为上次的追加后行数
+	code, logs, stderr = runGenLLOutput(t, argv...)
+	if code != 0 {
+		t.Fatalf("再次追加退出码 %d:\n%s", code, logs)
+	}
+	for name, counts := range parseDictReport(t, stderr) {
+		previous := first[name]
+		if counts.before != previous.after || counts.added != previous.added || counts.after != counts.before+counts.added {
+			t.Errorf("%s 再次追加: %+v，上次 %+v", name, counts, previous)
+		}
+	}
+
+	// -q 时不报告
+	if code, logs, stderr = runGenLLOutput(t, append(argv, "-q")...); code != 0 || stderr != "" {
+		t.Errorf("-q 时退出码 %d，标准错误 %q:\n%s", code, stderr, logs)
+	}
+}
+
+// dictAppendLogPattern 每个字典追加完成后的日志：来源码表、目标字典、条目数与耗时
+var dictAppendLogPattern = regexp.MustCompile(`(\S+)追加到(\S+)完成，共 (\d+) 条，耗时: \S+\n`)
+
+// TestDictAppendLogs 七个字典追加各记录一行条目数与耗时，条目数与来源码表行数一致；结束时汇总总耗时与其中的字典追加耗时
+func TestDictAppendLogs(t *testing.T) {
+	dir := t.TempDir()
+	code, logs := runGenLL(t, minimalArgs(dir)...)
+	if code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+
+	matches := dictAppendLogPattern.FindAllStringSubmatch(logs, -1)
+	if len(matches) != 7 {
+		t.Fatalf("字典追加日志 %d 行，期望 7 行:\n%s", len(matches), logs)
+	}
+	checked := false
+	for _, match := range matches {
+		if match[1] == "code_chars_full.txt" && match[2] == "LL.chars.full.dict.yaml" {
+			checked = true
+			if lines := strings.Count(readOutput(t, filepath.Join(dir, match[1])), "\n"); match[3] != strconv.Itoa(lines) {
+				t.Errorf("%s 追加 %s 条，码表有 %d 行", match[2], match[3], lines)
+			}
+		}
+	}
+	if !checked {
+		t.Error("没有 code_chars_full.txt 追加到 LL.chars.full.dict.yaml 的日志")
+	}
+	if !regexp.MustCompile(`全部完成，总耗时: \S+（其中字典追加: \S+）\n$`).MatchString(logs) {
+		t.Errorf("日志最后应为总耗时汇总:\n%s", logs)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOutputDiff -diff 按字列出已有输出与本次结果的旧编码、新编码并汇总；已有输出不存在的码表跳过
+// 与 -n 同用时只比较，不改动已有文件
+func TestOutputDiff(t *testing.T) {
+	dir := t.TempDir()
+	if code, logs := runGenLL(t, append(minimalArgs(dir), "-q")...); code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+
+	fullPath := filepath.Join(dir, "code_chars_full.txt")
+	lines := strings.SplitAfter(readOutput(t, fullPath), "\n")
+	fields := strings.Split(lines[0], "\t")
+	char := fields[0]
+	fields[1] = "zzzz"
+	lines[0] = strings.Join(fields, "\t")
+	edited := strings.Join(lines, "")
+	if err := os.WriteFile(fullPath, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wordsFullPath := filepath.Join(dir, "code_words_full.txt")
+	if err := os.Remove(wordsFullPath); err != nil {
+		t.Fatal(err)
+	}
+
+	code, logs := runGenLL(t, append(minimalArgs(dir), "-diff", "-n", "-q=false")...)
+	if code != 0 {
+		t.Fatalf("-diff -n 退出码 %d:\n%s", code, logs)
+	}
+	for _, want := range []string{
+		"\n-" + char + "\tzzzz",
+		"\n+" + char + "\t",
+		"\n=== " + fullPath + "\n",
+		"\n=== 新增 0，删除 0，修改 1\n",
+		"比较输出: " + wordsFullPath + " 不存在，跳过",
+		"\n合计: 新增 0，删除 0，修改 1\n",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("输出中没有 %q:\n%s", want, logs)
+		}
+	}
+	if readOutput(t, fullPath) != edited {
+		t.Error("-diff -n 不应改动已有的码表")
+	}
+	if _, err := os.Stat(wordsFullPath); !os.IsNotExist(err) {
+		t.Errorf("-diff -n 不应写出缺少的码表: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDivisionPatch 多个拆分表：后面的文件整体替换前面文件中同一字的拆分（文件内的多行仍为首要拆分与次拆分），结果与手工合并成一个文件一致
+// 部件校验在合并结果上进行，补丁中的非法部件同样报错；-div-char-limit 按合并后的字数计，替换已有的字不占名额
+func TestDivisionPatch(t *testing.T) {
+	inputDir := t.TempDir()
+	patch := filepath.Join(inputDir, "div_patch.txt")
+	const patchLines = "们\t[亻门,men,CJK-basic,U+4EEC]\n们\t[门,men,CJK-basic,U+4EEC]\n也\t[也亻,ye,CJK-basic,U+4E5F]"
+	if err := os.WriteFile(patch, []byte(patchLines+" # 补丁\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var merged strings.Builder
+	for _, line := range strings.SplitAfter(readOutput(t, minimalInput("ll_div.txt")), "\n") {
+		if !strings.HasPrefix(line, "们\t") && !strings.HasPrefix(line, "也\t") {
+			merged.WriteString(line)
+		}
+	}
+	merged.WriteString(patchLines + "\n")
+	mergedPath := filepath.Join(inputDir, "div_merged.txt")
+	if err := os.WriteFile(mergedPath, []byte(merged.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	patchDir, mergedDir := t.TempDir(), t.TempDir()
+	logs := generateMinimal(t, patchDir, "-q=false", "-d", minimalInput("ll_div.txt")+","+patch)
+	if want := "拆分表合并: 后面的文件替换了 2 个字的拆分"; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+	generateMinimal(t, mergedDir, "-d", mergedPath)
+	checkSameTables(t, mergedDir, patchDir, "code_chars_full.txt", "code_chars_simp.txt", "code_words_full.txt", "code_words_simp.txt", "div_ll.txt", "dazhu_chai.txt")
+	men := 0
+	for _, row := range readRows(t, filepath.Join(patchDir, "code_chars_full.txt")) {
+		if row[0] == "们" {
+			men++
+		}
+	}
+	if men != 2 {
+		t.Errorf("补丁中 们 的两条拆分应各得一条全码，得到 %d 条", men)
+	}
+
+	badPatch := filepath.Join(inputDir, "div_patch_bad.txt")
+	if err := os.WriteFile(badPatch, []byte("也\t[龘,ye,CJK-basic,U+4E5F]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := runGenLL(t, append(minimalArgs(t.TempDir()), "-q", "-d", minimalInput("ll_div.txt")+","+badPatch)...); code == 0 {
+		t.Error("补丁拆分表含非法部件时应当失败")
+	}
+
+	// 最小示例数据的前三个字为 一、不、也，补丁中的 们 是第四个字
+	logs = generateMinimal(t, t.TempDir(), "-q=false", "-div-char-limit", "3", "-d", minimalInput("ll_div.txt")+","+patch, "-w", "", "-L", "")
+	for _, want := range []string{"拆分表加载完成，共 3 项", "拆分表合并: 后面的文件替换了 1 个字的拆分"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("日志中没有 %q:\n%s", want, logs)
+		}
+	}
+}
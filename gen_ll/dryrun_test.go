@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestDryRun 空跑照常构建但不写出任何文件、不建目录，汇总的条目数与实际写出的码表行数一致
+// 校验问题汇总后以非0退出码结束
+func TestDryRun(t *testing.T) {
+	baseDir := t.TempDir()
+	generateMinimal(t, baseDir)
+
+	dir := t.TempDir()
+	newFull := filepath.Join(dir, "new", "code_chars_full.txt")
+	code, logs := runGenLL(t, append(minimalArgs(dir), "-q", "-n", "-u", newFull, "-graph-out", filepath.Join(dir, "graph.json"))...)
+	if code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("空跑不应写出文件或建目录: %v %v", entries, err)
+	}
+	for _, want := range []string{"空跑: 不写出 -graph-out", "\n校验通过\n"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("日志中没有 %q:\n%s", want, logs)
+		}
+	}
+	summary := map[string]string{"code_chars_full.txt": newFull}
+	for _, name := range []string{"code_chars_simp.txt", "code_words_full.txt", "code_words_simp.txt", "linglong_full.txt", "linglong_simp.txt", "div_ll.txt", "dazhu_chai.txt"} {
+		summary[name] = filepath.Join(dir, name)
+	}
+	for name, path := range summary {
+		lines := strings.Count(readOutput(t, filepath.Join(baseDir, name)), "\n")
+		if want := "\n" + path + "\t" + strconv.Itoa(lines) + "\n"; !strings.Contains(logs, want) {
+			t.Errorf("空跑汇总中没有 %q:\n%s", strings.TrimSpace(want), logs)
+		}
+	}
+
+	div := filepath.Join(t.TempDir(), "ll_div.txt")
+	if err := os.WriteFile(div, []byte(readOutput(t, minimalInput("ll_div.txt"))+"㊣\t[㊣,zheng,CJK,U+32A3]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, logs = runGenLL(t, append(minimalArgs(dir), "-q", "-n", "-d", div)...)
+	if code == 0 {
+		t.Error("空跑发现校验问题时退出码应为非0")
+	}
+	for _, want := range []string{"\n校验问题 1 项:\n", filepath.Join(dir, "code_words_full.txt") + "\t"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("日志中没有 %q:\n%s", want, logs)
+		}
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("空跑不应写出文件或建目录: %v %v", entries, err)
+	}
+}
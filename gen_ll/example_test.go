@@ -0,0 +1,60 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// exampleDirPattern -example 在日志末尾给出的示例输出目录
+var exampleDirPattern = regexp.MustCompile(`示例输出目录: (.+)`)
+
+// TestExample -example 以内置的最小示例数据跑一遍全流程，输入与输出全部落在临时的示例目录中
+// 示例目录中的输入与 testdata/minimal 相同，各码表与直接以 testdata/minimal 为输入的结果一致
+func TestExample(t *testing.T) {
+	code, logs := runGenLL(t, "-example", "-stable-sort")
+	if code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	match := exampleDirPattern.FindStringSubmatch(logs)
+	if match == nil {
+		t.Fatalf("日志中没有示例输出目录:\n%s", logs)
+	}
+	exampleDir := strings.TrimSpace(match[1])
+	outputs := map[string]string{
+		"code_chars_full.txt": args.Full,
+		"code_chars_simp.txt": args.Simple,
+		"code_words_full.txt": args.WordsFull,
+		"code_words_simp.txt": args.WordsSimple,
+		"linglong_full.txt":   args.LinglongFull,
+		"linglong_simp.txt":   args.LinglongSimple,
+		"div_ll.txt":          args.Opencc,
+		"dazhu_chai.txt":      args.DazhuChai,
+	}
+	for name, path := range outputs {
+		if filepath.Dir(path) != exampleDir {
+			t.Errorf("%s 写到了 %s，不在示例目录 %s 中", name, path, exampleDir)
+		}
+	}
+
+	for _, name := range []string{"ll_div.txt", "ll_map.txt", "freq.txt", "ll_words.txt", "linglong.txt"} {
+		if got, want := readOutput(t, filepath.Join(exampleDir, name)), readOutput(t, minimalInput(name)); got != want {
+			t.Errorf("示例目录中的输入 %s 与 testdata/minimal 不一致", name)
+		}
+	}
+
+	dir := t.TempDir()
+	if code, logs := runGenLL(t, append(minimalArgs(dir), "-q", "-stable-sort")...); code != 0 {
+		t.Fatalf("以 testdata/minimal 为输入的退出码 %d:\n%s", code, logs)
+	}
+	for name, path := range outputs {
+		example := readOutput(t, path)
+		if example == "" {
+			t.Errorf("示例输出 %s 为空", name)
+		}
+		if want := readOutput(t, filepath.Join(dir, name)); example != want {
+			t.Errorf("示例输出 %s 与以 testdata/minimal 为输入的结果不一致:\n示例:\n%s\n期望:\n%s", name, example, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gen_ll/tools"
+)
+
+// readRootGraph 读取 JSON 格式的部件图
+func readRootGraph(t *testing.T, path string) *tools.RootGraph {
+	t.Helper()
+	graph := &tools.RootGraph{}
+	if err := json.Unmarshal([]byte(readOutput(t, path)), graph); err != nil {
+		t.Fatalf("解析部件图 %s: %v", path, err)
+	}
+	return graph
+}
+
+// TestRootGraph 部件图：节点与边的次数、字频权重之和与拆分表、字频表直接算出的一致（最小示例数据的部件都是单个字符）
+// json 与 dot 的边数一致，边按权重降序；只导出前 N 条边时恰为完整图的前 N 条，节点只剩这些边的端点
+func TestRootGraph(t *testing.T) {
+	freq := make(map[string]int64)
+	for _, row := range readRows(t, minimalInput("freq.txt")) {
+		value, err := strconv.ParseInt(row[1], 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		freq[row[0]] = value
+	}
+	var nodes, edges int
+	var nodeWeight, edgeWeight int64
+	for _, row := range readRows(t, minimalInput("ll_div.txt")) {
+		divs, _, _ := strings.Cut(strings.TrimPrefix(row[1], "["), ",")
+		n := len([]rune(divs))
+		nodes += n
+		nodeWeight += int64(n) * freq[row[0]]
+		edges += n - 1
+		edgeWeight += int64(n-1) * freq[row[0]]
+	}
+
+	dir := t.TempDir()
+	jsonPath, dotPath, topPath := filepath.Join(dir, "graph.json"), filepath.Join(dir, "graph.dot"), filepath.Join(dir, "graph_top.json")
+	generateMinimal(t, t.TempDir(), "-graph-out", jsonPath)
+	generateMinimal(t, t.TempDir(), "-graph-out", dotPath, "-graph-format", "dot")
+	generateMinimal(t, t.TempDir(), "-graph-out", topPath, "-graph-top-edges", "5")
+
+	graph := readRootGraph(t, jsonPath)
+	var gotNodes, gotEdges int
+	var gotNodeWeight, gotEdgeWeight int64
+	for _, node := range graph.Nodes {
+		gotNodes += node.Count
+		gotNodeWeight += node.Weight
+	}
+	for i, edge := range graph.Edges {
+		gotEdges += edge.Count
+		gotEdgeWeight += edge.Weight
+		if i > 0 && edge.Weight > graph.Edges[i-1].Weight {
+			t.Errorf("部件图的边未按权重降序: %+v", edge)
+		}
+	}
+	if got, want := [4]int64{int64(gotNodes), gotNodeWeight, int64(gotEdges), gotEdgeWeight}, [4]int64{int64(nodes), nodeWeight, int64(edges), edgeWeight}; got != want {
+		t.Errorf("部件图节点次数、节点权重、边次数、边权重为 %v，期望 %v", got, want)
+	}
+	if dotEdges := strings.Count(readOutput(t, dotPath), " -> "); dotEdges != len(graph.Edges) {
+		t.Errorf("dot 有 %d 条边，json 有 %d 条", dotEdges, len(graph.Edges))
+	}
+
+	top := readRootGraph(t, topPath)
+	if len(graph.Edges) <= 5 {
+		t.Fatalf("完整部件图只有 %d 条边", len(graph.Edges))
+	}
+	if !reflect.DeepEqual(top.Edges, graph.Edges[:5]) {
+		t.Errorf("前 5 条边与完整图不一致:\n%v\n%v", top.Edges, graph.Edges[:5])
+	}
+	endpoints := make(map[string]bool)
+	for _, edge := range top.Edges {
+		endpoints[edge.From] = true
+		endpoints[edge.To] = true
+	}
+	var want, got []string
+	for root := range endpoints {
+		want = append(want, root)
+	}
+	for _, node := range top.Nodes {
+		got = append(got, node.Root)
+	}
+	sort.Strings(want)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("只导出前 5 条边时的节点 %v，期望 %v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gzipBytes 以 gzip 压缩 content
+func gzipBytes(t *testing.T, content string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// TestGzipInputs gzip 压缩的输入按 .gz 扩展名或起始字节透明解压，缓存读取、流式解析与标准输入的结果都与原文件一致
+// 压缩流损坏时报错并指出文件
+func TestGzipInputs(t *testing.T) {
+	baseDir := t.TempDir()
+	generateMinimal(t, baseDir)
+
+	inputDir := t.TempDir()
+	freq := filepath.Join(inputDir, "freq.txt.gz")
+	words := filepath.Join(inputDir, "ll_words.gz.bin")
+	for path, name := range map[string]string{freq: "freq.txt", words: "ll_words.txt"} {
+		if err := os.WriteFile(path, gzipBytes(t, readOutput(t, minimalInput(name))), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, extra := range map[string][]string{
+		"cached": {"-f", freq, "-w", words},
+		"stream": {"-f", freq, "-w", words, "-stream-read-threshold-mb", "0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			generateMinimal(t, dir, extra...)
+			checkSameTables(t, baseDir, dir, codeTables...)
+		})
+	}
+	t.Run("stdin", func(t *testing.T) {
+		withStdin(t, gzipBytes(t, readOutput(t, minimalInput("ll_div.txt"))))
+		dir := t.TempDir()
+		generateMinimal(t, dir, "-d", "-")
+		checkSameTables(t, baseDir, dir, codeTables...)
+	})
+
+	compressed := gzipBytes(t, readOutput(t, minimalInput("freq.txt")))
+	truncated := filepath.Join(inputDir, "freq_bad.txt.gz")
+	if err := os.WriteFile(truncated, compressed[:len(compressed)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, threshold := range []string{"64", "0"} {
+		code, logs := runGenLL(t, append(minimalArgs(t.TempDir()), "-f", truncated, "-stream-read-threshold-mb", threshold)...)
+		if code == 0 {
+			t.Errorf("-stream-read-threshold-mb %s: gzip 压缩流损坏时应当失败", threshold)
+		}
+		if want := truncated + " 失败: unexpected EOF"; !strings.Contains(logs, want) {
+			t.Errorf("-stream-read-threshold-mb %s: 日志中没有 %q:\n%s", threshold, want, logs)
+		}
+	}
+}
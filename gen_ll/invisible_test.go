@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInvisibleInputs 词表与拆分表中混入零宽空格、BOM、方向控制符与全角空格时，默认剥离并逐行警告，结果与干净的表一致
+// strict 时拆分表直接失败，词表按 -words-strict 失败；不检测的类别原样保留；未知类别报错
+func TestInvisibleInputs(t *testing.T) {
+	baseDir := t.TempDir()
+	generateMinimal(t, baseDir)
+
+	inputDir := t.TempDir()
+	wordLines := strings.SplitAfter(readOutput(t, minimalInput("ll_words.txt")), "\n")
+	wordLines[0] = "\uFEFF" + wordLines[0]
+	wordLines[1] = strings.Replace(wordLines[1], "\t", "\u200B\t", 1)
+	wordLines[2] = strings.Replace(wordLines[2], "\t", "\u3000\t", 1)
+	words := filepath.Join(inputDir, "ll_words.txt")
+	divLines := strings.SplitAfter(readOutput(t, minimalInput("ll_div.txt")), "\n")
+	divLines[0] = strings.Replace(divLines[0], "\t", "\u200E\t", 1)
+	div := filepath.Join(inputDir, "ll_div.txt")
+	for path, lines := range map[string][]string{words: wordLines, div: divLines} {
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "")), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir := t.TempDir()
+	logs := generateMinimal(t, dir, "-w", words, "-d", div)
+	for _, want := range []string{words + ":1: 含不可见字符 U+FEFF，已剥离", words + ":2: 含不可见字符 U+200B，已剥离",
+		words + ":3: 含不可见字符 U+3000，已剥离", div + ":1: 含不可见字符 U+200E，已剥离"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("日志中没有 %q:\n%s", want, logs)
+		}
+	}
+	checkSameTables(t, baseDir, dir, codeTables...)
+
+	for _, extra := range [][]string{
+		{"-d", div, "-invisible-mode", "strict"},
+		{"-w", words, "-invisible-mode", "strict", "-words-strict"},
+		{"-invisible-chars", "nbsp"},
+	} {
+		if code, _ := runGenLL(t, append(append(minimalArgs(t.TempDir()), "-q"), extra...)...); code == 0 {
+			t.Errorf("%v 应当失败", extra)
+		}
+	}
+	// 方向控制符不在检测的类别中时原样保留，strict 也不失败
+	generateMinimal(t, t.TempDir(), "-d", div, "-invisible-mode", "strict", "-invisible-chars", "zero-width,bom,ideographic-space")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gen_ll/types"
+)
+
+// readJSONLines 把 JSON Lines 文件逐行解析为 T
+func readJSONLines[T any](t *testing.T, path string) []T {
+	t.Helper()
+	var entries []T
+	for _, line := range strings.Split(strings.TrimSuffix(readOutput(t, path), "\n"), "\n") {
+		var entry T
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("解析 %s 的行 %q: %v", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// TestJSONOut JSON Lines 码表与 TSV 码表同时写出，条目与顺序一致，单字带拆分信息
+func TestJSONOut(t *testing.T) {
+	dir := t.TempDir()
+	jsonDir := filepath.Join(dir, "json")
+	generateMinimal(t, dir, "-json-out", jsonDir)
+
+	for table, name := range map[string]string{"chars_full": "code_chars_full.txt", "chars_simp": "code_chars_simp.txt"} {
+		var rows [][2]string
+		for _, charMeta := range readJSONLines[types.CharMeta](t, filepath.Join(jsonDir, table+".jsonl")) {
+			rows = append(rows, [2]string{charMeta.Char, charMeta.Code})
+			if table == "chars_full" && charMeta.Division == nil {
+				t.Errorf("%s 的条目 %s 缺少拆分信息", table, charMeta.Char)
+			}
+		}
+		if want := readRows(t, filepath.Join(dir, name)); !reflect.DeepEqual(rows, want) {
+			t.Errorf("%s.jsonl 与 %s 不一致:\n%v\n期望:\n%v", table, name, rows, want)
+		}
+	}
+	for table, name := range map[string]string{
+		"words_full":    "code_words_full.txt",
+		"words_simp":    "code_words_simp.txt",
+		"linglong_full": "linglong_full.txt",
+		"linglong_simp": "linglong_simp.txt",
+	} {
+		var builder strings.Builder
+		for _, wordCode := range readJSONLines[types.WordCode](t, filepath.Join(jsonDir, table+".jsonl")) {
+			builder.WriteString(wordCode.Word + "\t" + wordCode.Code)
+			if wordCode.Weight != "" {
+				builder.WriteString("\t" + wordCode.Weight)
+			}
+			builder.WriteString("\n")
+		}
+		if got, want := builder.String(), readOutput(t, filepath.Join(dir, name)); got != want {
+			t.Errorf("%s.jsonl 与 %s 不一致:\n%s\n期望:\n%s", table, name, got, want)
+		}
+	}
+}
@@ -2,12 +2,20 @@ package main
 
 import (
 	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
 	"runtime/pprof"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,32 +25,148 @@ import (
 	"gen_ll/utils"
 )
 
+// exampleData 内置的最小示例数据，供 -example 跑通全流程
+//
+//go:embed testdata/minimal
+var exampleData embed.FS
+
 type Args struct {
-	Quiet      bool   `flag:"q" usage:"安静模式，不输出进度信息" default:"false"`
-	Div        string `flag:"d" usage:"拆分表文件"  default:"../deploy/hao/ll_div.txt"`
-	Map        string `flag:"m" usage:"映射表文件"  default:"../deploy/hao/ll_map.txt"`
-	Freq       string `flag:"f" usage:"频率表文件"  default:"../deploy/hao/freq.txt"`
-	Words      string `flag:"w" usage:"多字词文件"  default:"../deploy/hao/ll_words.txt"`
-	Linglong   string `flag:"L" usage:"玲珑多字词文件"  default:"../deploy/hao/玲珑.txt"`
-	Full       string `flag:"u" usage:"输出单字全码表文件" default:"/tmp/code_full.txt"`
-	Opencc     string `flag:"o" usage:"输出拆分表文件"  default:"/tmp/div.txt"`
-	Simple     string `flag:"s" usage:"输出单字简码表文件" default:"/tmp/code_simp.txt"`
-	WordsFull  string `flag:"W" usage:"输出多字词全码表文件" default:"/tmp/words_full.txt"`
-	WordsSimple string `flag:"S" usage:"输出多字词简码表文件" default:"/tmp/words_simp.txt"`
-	LinglongFull string `flag:"F" usage:"输出玲珑多字词全码表文件" default:"/tmp/linglong_full.txt"`
-	LinglongSimple string `flag:"Q" usage:"输出玲珑多字词简码表文件" default:"/tmp/linglong_simp.txt"`
-	DazhuChai  string `flag:"Z" usage:"输出大竹拆文件" default:"/tmp/dazhu_chai.txt"`
-	LenCodeLimit string `flag:"l" usage:"单字简码长度限制，格式：1:4,2:4,3:0,4:0" default:"1:4,2:4,3:0,4:0"`
-	WordsLenCodeLimit string `flag:"wL" usage:"多字词简码长度限制，格式：1:4,2:4,3:4,4:0" default:"1:4,2:4,3:4,4:0"`
-	LinglongLenCodeLimit string `flag:"ll" usage:"玲珑多字词简码长度限制，格式：1:4,2:4,3:4,4:0" default:"1:4,2:4,3:4,4:0"`
-	CPUProfile string `flag:"p" usage:"CPU性能分析文件" default:"/tmp/gen_ll.prof"`
-	Debug      bool   `flag:"D" usage:"调试模式" default:"false"`
-	CitiPre    string `flag:"c" usage:"输出ll_citi_pre.txt文件" default:"/tmp/ll_citi_pre.txt"`
-	GendaCiti  string `flag:"g" usage:"输出genda_citi.txt文件" default:"/tmp/genda_citi.txt"`
-	ProcessCiti bool  `flag:"C" usage:"处理citi文件" default:"false"`
-	DazhuCode   string `flag:"z" usage:"输出dazhu_code.txt文件" default:"/tmp/dazhu_code.txt"`
-	PresetData string `flag:"P" usage:"输出preset_data.txt文件" default:"/tmp/lua/chars_cand/preset_data.txt"`
-	RootsDict  string `flag:"R" usage:"输出LL.roots.dict.yaml文件" default:"/tmp/LL.roots.dict.yaml"`
+	Example                    bool     `flag:"example" usage:"用内置的最小示例数据（testdata/minimal：十几个字、几个字根、几条词）跑一遍全流程：输入表固定为示例数据，未显式指定的输出都放到新建的临时目录中，结束时提示该目录" default:"false"`
+	Config                     string   `flag:"config" usage:"配置文件（.yaml/.yml 或 .toml），键为参数的字段名（如 Words、LenCodeLimit），值作为参数默认值，命令行显式指定的参数优先；未知的键报错" default:""`
+	Quiet                      bool     `flag:"q" usage:"安静模式，不输出进度信息" default:"false"`
+	Div                        string   `flag:"d" usage:"拆分表文件（- 表示标准输入），逗号分隔多个文件时按顺序合并，后面文件中的字整体替换前面文件中该字的拆分"  default:"$EXE/../deploy/hao/ll_div.txt"`
+	Map                        string   `flag:"m" usage:"映射表文件（- 表示标准输入）"  default:"$EXE/../deploy/hao/ll_map.txt"`
+	Freq                       string   `flag:"f" usage:"频率表文件（- 表示标准输入）"  default:"$EXE/../deploy/hao/freq.txt"`
+	Words                      string   `flag:"w" usage:"多字词文件（- 表示标准输入）"  default:"$EXE/../deploy/hao/ll_words.txt"`
+	Linglong                   string   `flag:"L" usage:"玲珑多字词文件（- 表示标准输入）"  default:"$EXE/../deploy/hao/玲珑.txt"`
+	Full                       string   `flag:"u" usage:"输出单字全码表文件" default:"$TMP/code_full.txt"`
+	CharsFrom                  string   `flag:"chars-from" usage:"直接读取已有的单字全码表（code_full.txt）作为单字编码，跳过拆分表、映射表与单字全码构建，只重新生成词码、简码与跟打词提等；拆分表、大竹拆分与字根码表不重新生成" default:""`
+	Opencc                     string   `flag:"o" usage:"输出拆分表文件"  default:"$TMP/div.txt"`
+	Simple                     string   `flag:"s" usage:"输出单字简码表文件" default:"$TMP/code_simp.txt"`
+	WordsFull                  string   `flag:"W" usage:"输出多字词全码表文件" default:"$TMP/words_full.txt"`
+	WordsSimple                string   `flag:"S" usage:"输出多字词简码表文件" default:"$TMP/words_simp.txt"`
+	LinglongFull               string   `flag:"F" usage:"输出玲珑多字词全码表文件" default:"$TMP/linglong_full.txt"`
+	LinglongSimple             string   `flag:"Q" usage:"输出玲珑多字词简码表文件" default:"$TMP/linglong_simp.txt"`
+	DazhuChai                  string   `flag:"Z" usage:"输出大竹拆文件" default:"$TMP/dazhu_chai.txt"`
+	RulesFile                  string   `flag:"rules" usage:"体验规则文件（字词\t作用域\t动作），作用域 chars_simp、chars_full、citi，动作 no-simp、demote:N、pin:P；指定时替换默认规则（的、了不出简并在全码与跟打词提中下移2位）" default:""`
+	LenCodeLimit               string   `flag:"l" usage:"单字简码长度限制，格式：1:4,2:4,3:0,4:0" default:"1:4,2:4,3:0,4:0"`
+	WordsLenCodeLimit          string   `flag:"wL" usage:"多字词简码长度限制，格式：1:4,2:4,3:4,4:0" default:"1:4,2:4,3:4,4:0"`
+	WordsPlaceholderSpace      string   `flag:"words-placeholder-space" usage:"多字词简码空码位占位符的补位空间：all（24键全空间并上实际参与分配的码位）或 used（只补至少有一个词按规则算出的码位）" default:"all"`
+	LinglongLenCodeLimit       string   `flag:"ll" usage:"玲珑多字词简码长度限制，格式：1:4,2:4,3:4,4:0" default:"1:4,2:4,3:4,4:0"`
+	CPUProfile                 string   `flag:"p" usage:"CPU性能分析文件，为空不分析" default:""`
+	MemProfile                 string   `flag:"mp" usage:"内存（堆）性能分析文件，码表全部写出后记录，为空不分析" default:""`
+	MemStats                   bool     `flag:"memstats" usage:"运行结束时输出内存统计（Alloc、TotalAlloc、HeapObjects、NumGC）" default:"false"`
+	Debug                      bool     `flag:"D" usage:"调试模式" default:"false"`
+	LogTimeFormat              string   `flag:"log-time-format" usage:"日志时间戳格式（Go 时间格式），如 \"2006-01-02T15:04:05.000Z07:00\" 带毫秒与时区，为空不输出时间戳" default:"2006-01-02 15:04:05"`
+	LogUTC                     bool     `flag:"log-utc" usage:"日志时间戳使用 UTC 而非本地时间" default:"false"`
+	CitiPre                    string   `flag:"c" usage:"输出ll_citi_pre.txt文件" default:"$TMP/ll_citi_pre.txt"`
+	GendaCiti                  string   `flag:"g" usage:"输出genda_citi.txt文件" default:"$TMP/genda_citi.txt"`
+	ProcessCiti                bool     `flag:"C" usage:"处理citi文件（同 -targets citi）" default:"false"`
+	Targets                    string   `flag:"targets" usage:"额外生成的目标，逗号分隔；目前支持 citi（跟打词提与大竹词提，同 -C）" default:""`
+	DazhuCode                  string   `flag:"z" usage:"输出dazhu_code.txt文件" default:"$TMP/dazhu_code.txt"`
+	PresetData                 string   `flag:"P" usage:"输出preset_data.txt文件" default:"$TMP/lua/chars_cand/preset_data.txt"`
+	RootsDict                  string   `flag:"R" usage:"输出LL.roots.dict.yaml文件" default:"$TMP/LL.roots.dict.yaml"`
+	WordFreq                   string   `flag:"word-freq" usage:"词频表文件（词\t频率），多字词与玲珑词缺权重时查表补全，优先于 -freq-words-as-weight" default:""`
+	WordFreqDefault            string   `flag:"word-freq-default" usage:"查表后仍缺权重的词使用的权重，为空时保持缺省（按 0 处理）" default:""`
+	ExplainDir                 string   `flag:"explain-dir" usage:"explain 子命令读取该目录下的产物（按文件名），为空时读取本次参数指定的产物位置" default:""`
+	ExplainJSON                bool     `flag:"explain-json" usage:"explain 子命令以 JSON 输出" default:"false"`
+	CheckPrefixFree            bool     `flag:"check-prefix-free" usage:"校验映射表的互斥前缀约束（任何字根编码不能是另一个字根编码的前缀），违规时列出并退出" default:"false"`
+	NoSimp                     bool     `flag:"no-simp" usage:"只生成四码定长的纯全码版本：跳过单字简码、词简码、玲珑简码、占位符与preset_data，不追加quick字典，跟打词提只用全码来源" default:"false"`
+	FreqWordsAsWeight          bool     `flag:"freq-words-as-weight" usage:"频率表中的多字条目作为多字词与玲珑词缺权重时的权重（默认只计数后丢弃）" default:"false"`
+	WordsSortByWeight          bool     `flag:"words-sort-by-weight" usage:"读取词表后按权重降序排列（默认保持文件原始顺序，全码表输出顺序随之改变）" default:"false"`
+	WordSingleCharFullCode     bool     `flag:"word-single-char-full-code" usage:"词表中的单字词直接输出该字全码（默认跳过并记入报告）" default:"false"`
+	WordsCharCode              string   `flag:"words-char-code" usage:"多字词取字编码的方式：strict（只用主拆分，主拆分无法取码的字不参与组词）或 fallback-secondary（主拆分无法取码时回退到次拆分）" default:"strict"`
+	LinglongCharCode           string   `flag:"linglong-char-code" usage:"玲珑词取字编码的方式，可选值同 -words-char-code" default:"strict"`
+	WordsAllowPlaceholder      bool     `flag:"words-allow-placeholder" usage:"多字词、玲珑词文件中有与简码占位符（①至⑩）相同的词条时只警告并照常收录（默认列出后退出）；占位符按来源标记区分，不会与这些词混淆" default:"false"`
+	RootsNote                  string   `flag:"roots-note" usage:"映射表第三列字根说明的输出方式：none、inline（拼入字根码表文本）或 file（输出到 -roots-note-out）" default:"none"`
+	RootsNoteOut               string   `flag:"roots-note-out" usage:"输出字根说明注释文件" default:"$TMP/ll_roots_note.txt"`
+	CitiCodeMaxLength          int      `flag:"citi-code-max-length" usage:"跟打词提编码最大长度，超过的条目视为数据错误并跳过，0 表示不限制" default:"0"`
+	CitiStrict                 bool     `flag:"citi-strict" usage:"跟打词提遇到数据错误时直接失败" default:"false"`
+	FullSimpColumn             bool     `flag:"full-simp-column" usage:"单字全码表增加第四列，标注该字的简码（仅主拆分条目）" default:"false"`
+	OutputColumns              string   `flag:"output-columns" usage:"各码表的输出列，如 \"words_full=text,code chars_simp=text,code,weight\"（码表可选 chars_full、chars_simp、words_full、words_simp、linglong_full、linglong_simp；列须以 text,code 开头，可再加 weight，chars_full 还可加 simp）；未配置的码表保持默认列。去掉 weight 后由该码表追加的字典与跟打词提不再按词频排序" default:""`
+	ConflictReport             string   `flag:"conflict-report" usage:"输出单字简码与词简码同码冲突报告（按字频×权重排序），为空不输出" default:""`
+	WordDupReport              string   `flag:"word-dup-report" usage:"输出多字词与玲珑词中字词、编码完全相同的条目清单（两个字典都收录时 Rime 中重复候选），为空不输出" default:""`
+	WordDupLinglongFirst       bool     `flag:"word-dup-linglong-first" usage:"玲珑优先去重：多字词全码中与玲珑词字词、编码完全相同的条目不输出（多字词简码仍按去重前分配）" default:"false"`
+	Templates                  []string `flag:"template" usage:"自定义模板输出，格式为 名称=模板文件:输出路径（text/template），可重复指定" default:""`
+	WordCodeFromRadicals       bool     `flag:"word-code-from-radicals" usage:"实验：多字词与玲珑词的词码按各字部首取码（部首由 -radical-map 按首部件或字符集字段查得），不影响默认行为" default:"false"`
+	RadicalMap                 string   `flag:"radical-map" usage:"部首编码表文件（部首或字符集名	编码），供 -word-code-from-radicals 使用" default:""`
+	SuffixOrder                string   `flag:"suffix-order" usage:"单字简码末码的候选顺序，preset_data 与 -chars-quick-sort suffix 共用" default:"w,r,u,o"`
+	CharsQuickSort             string   `flag:"chars-quick-sort" usage:"LL.chars.quick.dict.yaml的排序方式：code（按编码字母序）或 suffix（同前缀按 -suffix-order 的末码顺序）" default:"code"`
+	DictAppendSlowSeconds      int      `flag:"dict-append-slow-seconds" usage:"单个字典追加耗时超过该秒数时警告可能是磁盘较慢，0 表示不提示" default:"10"`
+	DictLineCountReport        bool     `flag:"dict-line-count-report" usage:"每次追加字典后将目标文件追加前后的条目行数输出到标准错误" default:"false"`
+	StableSort                 bool     `flag:"stable-sort" usage:"所有排序使用稳定排序并固定并发合并与分组遍历顺序，相同输入得到逐字节相同的输出" default:"false"`
+	StreamReadThresholdMB      int      `flag:"stream-read-threshold-mb" usage:"词表与频率表不小于该大小（MB）时按行流式解析、不经文件缓存，0 表示总是流式解析" default:"64"`
+	MemLimitMB                 int      `flag:"mem-limit-mb" usage:"内存水位上限（MB），各大阶段开始前检查，超过时降级（单协程构建、流式读写、词简码占位符只补规则算出的码位）并警告，降级后仍超过时报错退出；0 表示不检查" default:"0"`
+	Strict                     bool     `flag:"strict" usage:"拆分表有格式错误的行（缺少制表符、拆分信息字段不足、拆分为空），或映射表中同一字根重复定义且编码不同时直接失败（默认逐项警告，跳过拆分表中的错误行，重复字根以最后一行为准）" default:"false"`
+	InvisibleMode              string   `flag:"invisible-mode" usage:"拆分表、多字词与玲珑词表中不可见字符的处理方式：strict（报错，列出行号与码位）或 lenient（剥离后照常读入并逐行警告）" default:"lenient"`
+	InvisibleChars             string   `flag:"invisible-chars" usage:"检测并剥离的不可见字符类别，逗号分隔：zero-width（零宽空格、零宽（非）连字、词连接符）、bom（U+FEFF）、bidi（方向控制符）、ideographic-space（全角空格 U+3000）；为空不检测" default:"zero-width,bom,bidi,ideographic-space"`
+	DivEncodingValidate        bool     `flag:"div-encoding-validate" usage:"校验拆分表每行字符为合法UTF-8且恰好是一个字素簇，不合格时列出行号并退出" default:"false"`
+	Diff                       bool     `flag:"diff" usage:"写出前把本次生成的单字、多字词与玲珑词的全码表与简码表同磁盘上已有的输出文件按字词比较，差异以 -（旧编码）/+（新编码）行输出到标准输出，并汇总新增、删除、修改数；已有文件不存在的码表跳过。与 -n 同用时只比较不写出" default:"false"`
+	ChangelogOut               string   `flag:"changelog-out" usage:"输出Markdown编码变更公告，需同时指定 -changelog-old-full 与 -changelog-old-simp" default:""`
+	ChangelogOldFull           string   `flag:"changelog-old-full" usage:"上一版单字全码表（code_full.txt）" default:""`
+	ChangelogOldSimp           string   `flag:"changelog-old-simp" usage:"上一版单字简码表（code_simp.txt）" default:""`
+	ChangelogOldDiv            string   `flag:"changelog-old-div" usage:"上一版拆分表，用于判断拆分变化，为空不判断" default:""`
+	ChangelogOldMap            string   `flag:"changelog-old-map" usage:"上一版映射表，用于判断字根移键，为空不判断" default:""`
+	ChangelogChars             string   `flag:"changelog-chars" usage:"常用字清单文件（每行一个字），为空时按字频取前 -changelog-top 个" default:""`
+	ChangelogTop               int      `flag:"changelog-top" usage:"未指定常用字清单时按字频取的字数" default:"3000"`
+	PresetDataCharsetFilter    string   `flag:"preset-data-charset-filter" usage:"字集文件（每行一个字），只为其中的字符生成preset_data条目，为空不过滤" default:""`
+	FullCodeKeepDuplicates     bool     `flag:"full-code-keep-duplicates" usage:"保留同字同码的重复全码条目（次拆分与主拆分取码相同时），默认去重并优先保留主拆分" default:"false"`
+	CitiCandidateBaseLengthMin int      `flag:"citi-candidate-base-length-min" usage:"跟打词提重码组编码短于该长度时不加候选后缀，只保留首选" default:"1"`
+	DisplayMap                 string   `flag:"display-map" usage:"显示替换表文件（私有区字符\t可显示字符串），应用于拆分注释、大竹拆、字根码表与preset_data" default:""`
+	DictHeaderPreserve         bool     `flag:"dict-header-preserve" usage:"追加字典前保留头部与完整旧数据重写目标文件（丢弃中断残行，原子替换）" default:"false"`
+	Deploy                     string   `flag:"deploy" usage:"按Rime用户目录约定把字典与preset_data部署到该目录（<目录>/*.dict.yaml、<目录>/lua/chars_cand/），已有文件原子替换" default:""`
+	TrimeOut                   string   `flag:"trime-out" usage:"导出同文输入法（trime）可直接部署的目录：本次生成的词典、只导入子词典的 LL_trime 主词典、最小方案骨架与 default.custom.yaml 方案列表补丁，不含主题" default:""`
+	Fcitx5Out                  string   `flag:"fcitx5-out" usage:"导出fcitx5（libime）码表文本文件：单字简码与全码合并，简码优先，KeyCode与Length由编码推导，为空不导出" default:""`
+	Fcitx5Words                bool     `flag:"fcitx5-words" usage:"fcitx5码表同时包含多字词全码与简码" default:"false"`
+	JSONOut                    string   `flag:"json-out" usage:"另把各码表以 JSON Lines 写到该目录下的 chars_full.jsonl、chars_simp.jsonl、words_full.jsonl 等（每行一个条目，字段与 types.CharMeta 或 types.WordCode 一致），TSV 码表照常写出，为空不导出" default:""`
+	SQLiteOut                  string   `flag:"sqlite" usage:"把单字全码、单字简码、多字词、玲珑多字词码表与拆分写入一个SQLite数据库文件（表 chars_full、chars_simp、words_full、words_simp、linglong_full、linglong_simp、divisions），已有文件覆盖，为空不导出" default:""`
+	ReverseDict                string   `flag:"reverse-dict" usage:"输出 Rime 反查注释词典（如 LL_reverse.dict.yaml，字典名取自文件名），每行\"字\t全码(简码)[拆分]\"，用于反查时在注释中显示离乱编码与拆分，设置 -deploy 时一并部署；为空不输出" default:""`
+	Backup                     bool     `flag:"backup" usage:"部署或字典追加替换已有文件前先备份为.bak" default:"false"`
+	NoCreateDirs               bool     `flag:"no-create-dirs" usage:"输出、部署目录不存在时直接报错退出，不自动创建（防止路径写错时建出一堆目录）" default:"false"`
+	Preview                    string   `flag:"preview" usage:"预览：照常生成码表，字典追加的结果（完整头部与合并后的数据段）与字根码表写到该目录下的同名文件供 diff，不改动输出目录中的字典，也不部署；不能与 -n 同用" default:""`
+	DryRun                     bool     `flag:"n,dry-run" usage:"空跑：照常读取、校验与构建，不写出任何文件（不建目录、不追加字典、不部署），最后汇总各码表本应写出的条目数与校验问题" default:"false"`
+	Watch                      bool     `flag:"watch" usage:"首次构建成功后监视拆分表、映射表、频率表、多字词与玲珑词表，任一文件修改后在进程内重新构建并报告耗时，按 Ctrl+C 退出；不能与标准输入同用" default:"false"`
+	CompressOutputs            bool     `flag:"compress-outputs" usage:"各码表（全码、简码、拆分、大竹拆分、多字词与玲珑词码表）写出时同时流式写出 gzip 压缩副本（原路径加 .gz）；追加到 dict.yaml 的内容不压缩" default:"false"`
+	OutputManifest             string   `flag:"output-manifest" usage:"码表清单：各码表（含 -compress-outputs 的 .gz 副本）写出后在该文件中记录一行\"路径\t字节数\tSHA-256\"，按路径排序，为空不记录；空跑不写出" default:""`
+	FmtIn                      string   `flag:"in" usage:"fmt 子命令读取的码表（字词\t编码[\t词频]）" default:""`
+	FmtOut                     string   `flag:"out" usage:"fmt 子命令写出的码表" default:""`
+	FmtSort                    string   `flag:"fmt-sort" usage:"fmt 子命令排序方式：keep（保持原顺序）、code（编码升序，同码按词频降序）或 freq（词频降序），在加候选后缀之后排序" default:"keep"`
+	FmtDedupe                  bool     `flag:"fmt-dedupe" usage:"fmt 子命令去除字词与编码都相同的重复条目，保留首次出现的一条" default:"false"`
+	FmtStripFreq               bool     `flag:"fmt-strip-freq" usage:"fmt 子命令输出时去掉词频列" default:"false"`
+	FmtAddCandidates           bool     `flag:"fmt-add-candidates" usage:"fmt 子命令为重码添加候选后缀（与跟打词提相同的补码规则，同样受 -genda-code-max-length 与 -genda-code-overflow 约束）" default:"false"`
+	FmtCSV                     bool     `flag:"fmt-csv" usage:"fmt 子命令按 CSV 写出（字词,编码[,词频]），含逗号的编码按 RFC 4180 加引号转义" default:"false"`
+	SuggestLevel               int      `flag:"level" usage:"suggest 子命令的简码级别：1（按全码首码列一简）或 2（按全码前两码列二简）" default:"1"`
+	SuggestTop                 int      `flag:"suggest-top" usage:"suggest 子命令每个前缀列出的候选字数（按字频降序）" default:"10"`
+	WordsStrict                bool     `flag:"words-strict" usage:"多字词或玲珑词表读取失败、或一条编码都生成不出来时直接失败（默认给出警告并跳过写出与追加）" default:"false"`
+	CitiSource                 string   `flag:"citi-source" usage:"跟打词提的词语来源：linglong（玲珑词简码与全码）或 words（多字词简码与全码，旧版四文件流程）；两者共用补码后缀与出简让全，多字词简码的占位符不进入词提" default:"linglong"`
+	CitiSourceSort             string   `flag:"citi-source-sort" usage:"跟打词提各来源合并前的排序方式，格式 来源:方式，逗号分隔；来源为 citi_pre、chars_simp、chars_full、LL_linglong.quick、LL_linglong.full（-citi-source words 时为 words_simp、words_full），方式为 keep（默认，保持原顺序）、freq（词频降序）或 code（编码升序），在加补码后缀之后排序" default:""`
+	CitiDryRunSections         bool     `flag:"citi-dry-run-sections" usage:"跟打词提合并前将每个来源的前10条输出到标准错误（仍正常写出文件）" default:"false"`
+	EquivTable                 string   `flag:"equiv-table" usage:"按键当量表文件（两键组合\t代价），设置后按字频加权评估当量、同指率与小指负担" default:""`
+	EquivDefaultCost           float64  `flag:"equiv-default-cost" usage:"当量表缺失组合时使用的默认代价" default:"1.5"`
+	StatsJSON                  string   `flag:"stats-json" usage:"输出统计JSON文件，为空不输出" default:""`
+	RootFreqOut                string   `flag:"root-freq-out" usage:"输出字根频率与键位负担分析文件（tsv），为空不输出" default:""`
+	GraphOut                   string   `flag:"graph-out" usage:"导出部件图（节点为部件及其键位，边为部件在主拆分中相邻出现，权重按字频累计），用于可视化分析常见字根组合，为空不输出" default:""`
+	GraphFormat                string   `flag:"graph-format" usage:"部件图格式：json 或 dot（Graphviz）" default:"json"`
+	GraphTopEdges              int      `flag:"graph-top-edges" usage:"部件图只导出权重前 N 的边（节点只保留这些边的端点），0 表示全部" default:"0"`
+	DazhuReverse               bool     `flag:"dazhu-reverse" usage:"大竹词提输出为\"字词\t编码\"，用于按字词反查编码" default:"false"`
+	DazhuSortBy                string   `flag:"dazhu-sort-by" usage:"大竹词提排序方式：none（保持跟打词提顺序）或 first-col（按第一列排序，反向输出时即按字词）" default:"none"`
+	GendaKeyRemap              string   `flag:"genda-key-remap" usage:"跟打词提编码的键位重映射，供不能处理标点编码的跟打器使用，格式 \";=1 ,=4 .=5 /=6\"（空白分隔）；替换字符不能是键位、候选后缀或大写字母，大竹词提随之重映射" default:""`
+	DazhuKeyRemap              string   `flag:"dazhu-key-remap" usage:"大竹词提编码的键位重映射，格式同 -genda-key-remap，只影响dazhu_code.txt" default:""`
+	SaimaOut                   string   `flag:"saima-out" usage:"另外输出极速赛码表（\"字词\t编码\"），重码组第2、3、4…候选在编码后追加数字选重键；随跟打词提生成，不含ll_citi_pre，不受键位重映射影响，为空不输出" default:""`
+	SaimaStartKey              string   `flag:"saima-start-key" usage:"极速赛码表第2候选的选重键（1~9），其后候选依次加一，超过9的候选不输出" default:"2"`
+	GendaCodeMaxLength         int      `flag:"genda-code-max-length" usage:"跟打词提与大竹词提写出编码的最大长度（含补码后缀与翻页的\"=\"），超过的条目不写出并统计数量，0 表示不限制；不影响dict.yaml与极速赛码表" default:"0"`
+	GendaCodeOverflow          string   `flag:"genda-code-overflow" usage:"重码组补码后缀超过 -genda-code-max-length 时的处理方式：drop（丢弃超长候选）或 numbered（整组改为数字选重，首选原编码，其后候选追加2~9）" default:"drop"`
+	SimpCodeHistogram          bool     `flag:"simp-code-histogram" usage:"输出单字简码长度分布（长度\t字数）到标准错误" default:"false"`
+	CharsQuickExcludeCodes     string   `flag:"chars-quick-exclude-codes" usage:"写入LL.chars.quick.dict.yaml时跳过编码匹配的条目，多个正则以空格分隔；在生成端直接不写入，与字典头部encoder的exclude_patterns（只影响造词）无关" default:""`
+	RootsCodePrefix            string   `flag:"roots-code-prefix" usage:"字根码表编码的引导前缀，以此开头的编码为字根反查专用，单字、多字词、玲珑词与跟打词提的编码落入时报错；修改后需同步方案中 recognizer 的 roots 规则" default:"]"`
+	RootsSkipNonCJK            bool     `flag:"roots-skip-non-cjk" usage:"字根码表跳过非汉字字根（标点、ASCII等），私有区部件保留" default:"false"`
+	CharsQuickPlaceholder      bool     `flag:"chars-quick-placeholder" usage:"为单字简码空位生成占位条目写入LL.chars.quick.dict.yaml" default:"false"`
+	TopChars                   int      `flag:"top-chars" usage:"只输出字频排名前 N 的字（全码、简码、拆分及由此生成的字典、跟打词提），用于演示或嵌入式设备；简码仍按全量分配以保证码位一致，0 表示不截取" default:"0"`
+	TopCharsWords              string   `flag:"top-chars-words" usage:"-top-chars 截取后多字词、玲珑词中含被截掉字的词：skip（跳过）或 keep（保留）" default:"skip"`
+	DivCharLimit               int      `flag:"div-char-limit" usage:"最多从拆分表读取的字符数，用于快速试跑，0 表示不限制" default:"0"`
+	DivInferUnicode            bool     `flag:"div-infer-unicode" usage:"拆分表码位缺失或错误时按字符自动填写" default:"false"`
+	WordCodeUppercase          bool     `flag:"word-code-uppercase" usage:"多字词编码输出为大写，用于区分大小写的输入法格式" default:"false"`
+	PresetPadMissingSuffixes   string   `flag:"preset-pad-missing-suffixes" usage:"preset_data缺失后缀的补位策略：placeholder（①②③④占位）或 full-code（取全码表中可达的最高频字）" default:"placeholder"`
 }
 
 var args Args
@@ -50,199 +174,389 @@ var args Args
 func main() {
 	// 设置自定义日志格式，与Shell脚本保持一致
 	log.SetFlags(0)
-	log.SetOutput(new(logWriter))
+	// 解析参数前的日志使用默认格式，解析后按 -log-time-format、-log-utc 重新设置
+	log.SetOutput(&logWriter{timeFormat: "2006-01-02 15:04:05"})
+	os.Exit(run())
+}
+
+// run 执行子命令或一次完整生成，返回退出码
+// 致命错误都记录日志后返回，不直接退出进程，保证性能分析文件的写完关闭、暂存目录清理等 defer 都能执行
+func run() int {
+
+	// 子命令：gen_ll space [参数] <前缀>、gen_ll lint [参数]、gen_ll explain [参数] <编码>、gen_ll fmt -in <码表> -out <码表>、gen_ll suggest -level 1|2，子命令名需在参数之前
+	subcommand := ""
+	if len(os.Args) > 1 && (os.Args[1] == "space" || os.Args[1] == "lint" || os.Args[1] == "explain" || os.Args[1] == "fmt" || os.Args[1] == "suggest") {
+		subcommand = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
 
 	err := utils.ParseFlags(&args)
 	if err != nil {
-		log.Fatalf("解析参数失败: %v", err)
-		return
+		log.Printf("解析参数失败: %v", err)
+		return 1
+	}
+	if args.Config != "" {
+		if err := utils.LoadConfig(args.Config, &args); err != nil {
+			log.Printf("读取配置文件失败: %v", err)
+			return 1
+		}
+	}
+	log.SetOutput(&logWriter{timeFormat: args.LogTimeFormat, utc: args.LogUTC})
+	if err := checkStdinInputs(); err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+	tools.SetStreamReadThreshold(int64(args.StreamReadThresholdMB) << 20)
+	tools.SetStableSort(args.StableSort)
+	tools.SetDryRun(args.DryRun)
+	tools.SetCompressOutputs(args.CompressOutputs)
+	tools.SetOutputManifest(args.OutputManifest)
+
+	switch subcommand {
+	case "space":
+		return runSpace(flag.Args())
+	case "lint":
+		return runLint()
+	case "explain":
+		return runExplain(flag.Args())
+	case "fmt":
+		return runFmt()
+	case "suggest":
+		return runSuggest()
+	}
+	if err := applyTargets(); err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+	if args.Example {
+		exampleDir, err := prepareExample()
+		if err != nil {
+			log.Printf("%v", err)
+			return 1
+		}
+		defer log.Printf("示例输出目录: %s\n", exampleDir)
 	}
 
 	// CPU性能分析
 	if args.CPUProfile != "" {
 		f, err := os.Create(args.CPUProfile)
 		if err != nil {
-			log.Fatalf("无法创建CPU性能分析文件: %v", err)
+			log.Printf("无法创建CPU性能分析文件: %v", err)
+			return 1
 		}
-		defer f.Close()
 		if err := pprof.StartCPUProfile(f); err != nil {
-			log.Fatalf("无法开始CPU性能分析: %v", err)
-		}
-		defer pprof.StopCPUProfile()
-	}
-
-	// 创建输出目录（如果不存在）
-	ensureOutputDir(args.Full)
-	ensureOutputDir(args.Opencc)
-	ensureOutputDir(args.Simple)
-	ensureOutputDir(args.WordsFull)
-	ensureOutputDir(args.WordsSimple)
-	ensureOutputDir(args.LinglongFull)
-	ensureOutputDir(args.LinglongSimple)
-	ensureOutputDir(args.DazhuChai)
-	ensureOutputDir(args.CitiPre)
-	ensureOutputDir(args.GendaCiti)
-	ensureOutputDir(args.DazhuCode)
-	ensureOutputDir(args.PresetData)
-	ensureOutputDir(args.RootsDict)
+			f.Close()
+			log.Printf("无法开始CPU性能分析: %v", err)
+			return 1
+		}
+		// 致命错误同样经由返回退出，性能分析文件总能写完
+		defer func() {
+			pprof.StopCPUProfile()
+			if err := f.Close(); err != nil {
+				log.Printf("写入CPU性能分析文件失败: %v", err)
+			} else if !args.Quiet && !args.DryRun {
+				log.Printf("CPU性能分析文件写入完成: %s\n", args.CPUProfile)
+			}
+		}()
+	}
 
-	// 解析简码长度限制
-	lenCodeLimit, err := tools.ParseLenCodeLimit(args.LenCodeLimit)
-	if err != nil {
-		log.Fatalf("解析单字简码长度限制失败: %v", err)
+	if args.MemStats {
+		defer logMemStats()
 	}
 
-	// 解析多字词简码长度限制
-	wordsLenCodeLimit, err := tools.ParseLenCodeLimit(args.WordsLenCodeLimit)
-	if err != nil {
-		log.Fatalf("解析多字词简码长度限制失败: %v", err)
+	// 监视模式：首次构建成功后才开始监视
+	code := generate()
+	if code != 0 || !args.Watch {
+		return code
 	}
+	return watchInputs()
+}
 
-	// 解析玲珑多字词简码长度限制
-	linglongLenCodeLimit, err := tools.ParseLenCodeLimit(args.LinglongLenCodeLimit)
+// generate 构建并写出全部码表、追加字典与部署，返回退出码；监视模式下每次输入修改后重新执行
+func generate() int {
+	suffixOrder, err := tools.ParseSuffixOrder(args.SuffixOrder)
+	if err != nil {
+		log.Printf("解析末码顺序失败: %v", err)
+		return 1
+	}
+	if args.CharsQuickSort != "code" && args.CharsQuickSort != "suffix" {
+		log.Printf("未知的LL.chars.quick.dict.yaml排序方式: %s", args.CharsQuickSort)
+		return 1
+	}
+	if args.GraphFormat != tools.RootGraphJSON && args.GraphFormat != tools.RootGraphDOT {
+		log.Printf("未知的部件图格式: %s（可选 json、dot）", args.GraphFormat)
+		return 1
+	}
+	if args.GraphTopEdges < 0 {
+		log.Printf("部件图导出的边数不能为负数: %d", args.GraphTopEdges)
+		return 1
+	}
+	if args.RootsCodePrefix == "" || strings.ContainsAny(args.RootsCodePrefix, " \t\r\n") {
+		log.Printf("字根码表引导前缀不能为空或含空白: %q", args.RootsCodePrefix)
+		return 1
+	}
+	outputColumns, err := tools.ParseOutputColumns(args.OutputColumns)
 	if err != nil {
-		log.Fatalf("解析玲珑多字词简码长度限制失败: %v", err)
+		log.Printf("解析输出列失败: %v", err)
+		return 1
+	}
+	if args.FullSimpColumn {
+		if _, exists := outputColumns[tools.OutputCharsFull]; !exists {
+			outputColumns[tools.OutputCharsFull] = append(tools.DefaultColumnLayout(), tools.ColumnSimp)
+		} else if !outputColumns.Layout(tools.OutputCharsFull).Has(tools.ColumnSimp) {
+			log.Printf("-full-simp-column 与 -output-columns 中 chars_full 的列冲突，请在 chars_full 的列中加上 simp")
+			return 1
+		}
 	}
 
-	// 记录开始时间
-	startTime := utils.Now()
+	templateSpecs := make([]*tools.TemplateSpec, 0, len(args.Templates))
+	for _, spec := range args.Templates {
+		templateSpec, err := tools.ParseTemplateSpec(spec)
+		if err != nil {
+			log.Printf("解析模板参数失败: %v", err)
+			return 1
+		}
+		templateSpecs = append(templateSpecs, templateSpec)
+	}
 
-	if !args.Quiet {
-		log.Println("开始加载表格数据...")
+	if err := checkPreviewDir(); err != nil {
+		log.Printf("%v", err)
+		return 1
 	}
 
-	divTable, err := tools.ReadDivisionTable(args.Div)
-	if err != nil {
-		log.Fatalf("读取拆分表失败: %v", err)
+	// 构建前检查并创建全部输出目录，避免构建完才因权限或路径错误失败
+	if err := prepareOutputDirs(templateSpecs); err != nil {
+		log.Printf("%v", err)
+		return 1
 	}
-	if !args.Quiet {
-		log.Printf("拆分表加载完成，共 %d 项\n", len(divTable))
+	if args.DryRun {
+		skipped := skipSideOutputs()
+		if len(templateSpecs) > 0 {
+			skipped = append(skipped, "-template")
+			templateSpecs = nil
+		}
+		// 跟打词提与部署在码表写出之后，空跑不会执行到
+		if args.ProcessCiti {
+			skipped = append(skipped, "-C")
+		}
+		if args.Deploy != "" {
+			skipped = append(skipped, "-deploy")
+		}
+		if args.TrimeOut != "" {
+			skipped = append(skipped, "-trime-out")
+		}
+		if len(skipped) > 0 {
+			log.Printf("空跑: 不写出 %s\n", strings.Join(skipped, "、"))
+		}
 	}
 
-	compMap, err := tools.ReadCompMap(args.Map)
+	// 记录开始时间
+	startTime := utils.Now()
+
+	result, err := buildResult()
 	if err != nil {
-		log.Fatalf("读取映射表失败: %v", err)
+		log.Printf("%v", err)
+		return 1
 	}
-	if !args.Quiet {
-		log.Printf("映射表加载完成，共 %d 项\n", len(compMap))
+	if args.TopChars != 0 {
+		truncated, report, err := tools.TruncateTopChars(result, tools.TopCharsOptions{N: args.TopChars, Words: args.TopCharsWords})
+		if err != nil {
+			log.Printf("截取高频字失败: %v", err)
+			return 1
+		}
+		result = truncated
+		if !args.Quiet {
+			log.Printf("按字频截取前 %d 字: 保留 %d/%d 字（全码 %d 项，简码 %d 项），跳过含被截掉字的词 %d 项\n", args.TopChars, report.KeptChars, report.Chars, report.FullEntries, report.SimpEntries, report.SkippedWords)
+		}
 	}
+	// 普通编码不得落入字根反查的专用编码空间
+	if err := tools.ValidateReservedPrefix(result, args.RootsCodePrefix); err != nil {
+		if !args.DryRun {
+			log.Printf("校验失败: %v", err)
+			return 1
+		}
+		dryRunProblems = append(dryRunProblems, err.Error())
+	}
+	fullCodeMetaList := result.FullCodeMetaList
+	simpleCodeList := result.SimpleCodeList
+	wordCodes := result.WordCodes
+	wordSimpleCodes := result.WordSimpleCodes
+	linglongCodes := result.LinglongCodes
+	linglongSimpleCodes := result.LinglongSimpleCodes
 
-	// 验证拆分部件是否在映射表中定义
-	if !args.Quiet {
-		log.Println("开始验证拆分部件...")
+	// 字根频率与键位负担分析
+	if args.RootFreqOut != "" {
+		// 映射表索引在构建编码时已读取，这里直接复用
+		compIndex, err := tools.ReadCompMapIndexed(args.Map)
+		if err != nil {
+			log.Printf("读取映射表失败: %v", err)
+			return 1
+		}
+		roots, keys := tools.BuildRootFrequency(fullCodeMetaList, compIndex)
+		if err := tools.WriteRootFrequency(args.RootFreqOut, roots, keys); err != nil {
+			log.Printf("写入字根频率文件失败: %v", err)
+		} else if !args.Quiet {
+			log.Printf("字根频率文件写入完成: %s（字根 %d 个，键 %d 个）\n", args.RootFreqOut, len(roots), len(keys))
+			for i, load := range keys {
+				if i >= 5 {
+					break
+				}
+				log.Printf("键位负担: %s\t%.2f%%\n", load.Key, load.Share*100)
+			}
+		}
 	}
-	if err := tools.ValidateDivisionComponents(divTable, compMap); err != nil {
-		log.Fatalf("验证失败: %v", err)
+
+	// 单字简码与词简码同码冲突报告
+	if args.ConflictReport != "" {
+		conflicts := tools.BuildSimpleCodeConflicts(simpleCodeList, wordSimpleCodes, linglongSimpleCodes)
+		if err := tools.WriteSimpleCodeConflicts(args.ConflictReport, conflicts); err != nil {
+			log.Printf("写入简码冲突报告失败: %v", err)
+		} else if !args.Quiet {
+			log.Printf("简码冲突报告写入完成: %s（%d 对）\n", args.ConflictReport, len(conflicts))
+		}
 	}
-	if !args.Quiet {
-		log.Println("拆分部件验证通过")
+
+	// 按键当量评估，输出到统计 JSON
+	if args.EquivTable != "" {
+		costs, err := tools.ReadEquivTable(args.EquivTable)
+		if err != nil {
+			log.Printf("读取按键当量表失败: %v", err)
+			return 1
+		}
+		equivStats := tools.EvaluateEquivalence(simpleCodeList, fullCodeMetaList, costs, args.EquivDefaultCost)
+		if len(equivStats.MissingPairs) > 0 {
+			log.Printf("警告: 当量表缺失 %d 个组合，已使用默认代价 %g: %s\n", len(equivStats.MissingPairs), args.EquivDefaultCost, strings.Join(equivStats.MissingPairs, " "))
+		}
+		if !args.Quiet {
+			log.Printf("当量: %.4f，同指率: %.2f%%，小指负担: %.2f%%\n", equivStats.Equivalence, equivStats.SameFingerRate*100, equivStats.PinkyLoad*100)
+		}
+		if args.StatsJSON != "" {
+			content, _ := json.MarshalIndent(map[string]interface{}{"equivalence": equivStats}, "", "  ")
+			if err := os.WriteFile(args.StatsJSON, append(content, '\n'), 0o644); err != nil {
+				log.Printf("写入统计JSON失败: %v", err)
+			}
+		}
 	}
 
-	freqSet, err := tools.ReadCharFreq(args.Freq)
-	if err != nil {
-		log.Fatalf("读取频率表失败: %v", err)
+	// 与上次输出比较：须在写出本次码表之前读取
+	if args.Diff {
+		if err := printOutputDiff(result); err != nil {
+			log.Printf("%v", err)
+			return 1
+		}
 	}
-	if !args.Quiet {
-		log.Printf("频率表加载完成，共 %d 项\n", len(freqSet))
+
+	// 编码变更公告：在写出本次码表前读取上一版，允许与输出路径相同
+	if args.ChangelogOut != "" {
+		if err := writeChangelog(result); err != nil {
+			log.Printf("%v", err)
+			return 1
+		}
 	}
 
-	if !args.Quiet {
-		log.Println("开始构建编码数据...")
+	// fcitx5 码表导出
+	if args.Fcitx5Out != "" {
+		fcitx5Result, err := tools.WriteFcitx5Table(args.Fcitx5Out, result, tools.Fcitx5Options{
+			IncludeWords: args.Fcitx5Words,
+			PhraseRules:  !args.WordCodeFromRadicals,
+		})
+		if err != nil {
+			log.Printf("导出fcitx5码表失败: %v", err)
+		} else if !args.Quiet {
+			log.Printf("fcitx5码表导出完成: %s（%d 项，码长 %d，键 %s）\n", args.Fcitx5Out, fcitx5Result.Entries, fcitx5Result.Length, fcitx5Result.KeyCode)
+		}
 	}
 
-	buildStartTime := utils.Now()
-	fullCodeMetaList := tools.BuildFullCodeMetaList(divTable, compMap, freqSet)
-	
-	if !args.Quiet {
-		log.Printf("构建完成，耗时: %v\n", utils.Since(buildStartTime))
-		log.Printf("fullCodeMetaList: %d\n", len(fullCodeMetaList))
-		log.Println("开始写入文件...")
+	// JSON Lines 码表导出
+	if args.JSONOut != "" {
+		jsonFiles, err := tools.WriteJSONLTables(args.JSONOut, result)
+		if err != nil {
+			log.Printf("导出JSON Lines码表失败: %v", err)
+		} else if !args.Quiet {
+			log.Printf("JSON Lines码表导出完成: %s（%d 个文件）\n", args.JSONOut, len(jsonFiles))
+		}
 	}
 
-	// 读取多字词文件并生成多字词全码和简码
-	var wordCodes []*types.WordCode
-	var wordSimpleCodes []*types.WordSimpleCode
-	if !args.Quiet {
-		log.Println("开始读取多字词文件...")
+	// SQLite 数据库导出
+	if args.SQLiteOut != "" {
+		sqliteResult, err := tools.WriteSQLiteDB(args.SQLiteOut, result)
+		if err != nil {
+			log.Printf("导出SQLite数据库失败: %v", err)
+		} else if !args.Quiet {
+			rows := sqliteResult.Rows
+			log.Printf("SQLite数据库导出完成: %s（单字全码 %d，单字简码 %d，多字词全码 %d，多字词简码 %d，玲珑全码 %d，玲珑简码 %d，拆分 %d）\n", args.SQLiteOut,
+				rows["chars_full"], rows["chars_simp"], rows["words_full"], rows["words_simp"], rows["linglong_full"], rows["linglong_simp"], rows["divisions"])
+		}
 	}
-	wordEntries, err := tools.ReadWordsFile(args.Words)
-	if err != nil {
-		log.Printf("读取多字词文件失败: %v", err)
-	} else {
-		if !args.Quiet {
-			log.Printf("多字词文件加载完成，共 %d 项\n", len(wordEntries))
-			log.Println("开始生成多字词全码...")
+
+	// 自定义模板输出
+	if len(templateSpecs) > 0 {
+		templateContext := tools.NewTemplateContext(result)
+		for _, templateSpec := range templateSpecs {
+			if err := tools.RenderTemplate(templateSpec, templateContext); err != nil {
+				log.Printf("%v", err)
+			} else if !args.Quiet {
+				log.Printf("模板 %s 输出完成: %s\n", templateSpec.Name, templateSpec.Output)
+			}
 		}
-		
-		// 创建字符编码映射
-		charCodeMap := tools.CreateCharCodeMap(fullCodeMetaList)
-		
-		// 生成多字词全码
-		wordCodes = tools.BuildWordsFullCode(wordEntries, charCodeMap)
-		
-		if !args.Quiet {
-			log.Printf("多字词全码生成完成，共 %d 项\n", len(wordCodes))
-			log.Println("开始生成多字词简码...")
+	}
+
+	// 简码长度分布，输出到标准错误
+	if args.SimpCodeHistogram {
+		histogram := tools.SimpleCodeLengthHistogram(simpleCodeList)
+		lengths := make([]int, 0, len(histogram))
+		for length := range histogram {
+			lengths = append(lengths, length)
 		}
-		
-		// 生成多字词简码
-		wordSimpleCodes = tools.BuildWordsSimpleCode(wordCodes, wordsLenCodeLimit)
-		
-		if !args.Quiet {
-			log.Printf("多字词简码生成完成，共 %d 项\n", len(wordSimpleCodes))
+		sort.Ints(lengths)
+		for _, length := range lengths {
+			fmt.Fprintf(os.Stderr, "%d\t%d\n", length, histogram[length])
 		}
 	}
 
-	// 读取玲珑多字词文件并生成玲珑多字词全码和简码
-	var linglongCodes []*types.WordCode
-	var linglongSimpleCodes []*types.WordSimpleCode
-	if !args.Quiet {
-		log.Println("开始读取玲珑多字词文件...")
-	}
-	linglongEntries, err := tools.ReadWordsFile(args.Linglong)
-	if err != nil {
-		log.Printf("读取玲珑多字词文件失败: %v", err)
-	} else {
-		if !args.Quiet {
-			log.Printf("玲珑多字词文件加载完成，共 %d 项\n", len(linglongEntries))
-			log.Println("开始生成玲珑多字词全码...")
+	// 展示性输出的显示替换表
+	var display *tools.DisplayReplacer
+	if args.DisplayMap != "" {
+		displayMap, err := tools.ReadDisplayMap(args.DisplayMap)
+		if err != nil {
+			log.Printf("读取显示替换表失败: %v", err)
+			return 1
 		}
-		
-		// 创建字符编码映射
-		charCodeMap := tools.CreateCharCodeMap(fullCodeMetaList)
-		
-		// 生成玲珑多字词全码
-		linglongCodes = tools.BuildWordsFullCode(linglongEntries, charCodeMap)
-		
+		display = tools.NewDisplayReplacer(displayMap)
 		if !args.Quiet {
-			log.Printf("玲珑多字词全码生成完成，共 %d 项\n", len(linglongCodes))
-			log.Println("开始生成玲珑多字词简码...")
+			log.Printf("显示替换表加载完成，共 %d 项\n", len(displayMap))
 		}
-		
-		// 生成玲珑多字词简码（不添加占位符）
-		linglongSimpleCodes = tools.BuildLinglongSimpleCode(linglongCodes, linglongLenCodeLimit)
-		
-		if !args.Quiet {
-			log.Printf("玲珑多字词简码生成完成，共 %d 项\n", len(linglongSimpleCodes))
+	}
+
+	// Rime 反查注释词典，拆分部件按显示替换表输出
+	if args.ReverseDict != "" {
+		count, err := tools.WriteReverseDict(args.ReverseDict, fullCodeMetaList, tools.ReverseDictOptions{Display: display})
+		if err != nil {
+			log.Printf("生成反查注释词典失败: %v", err)
+		} else if !args.Quiet {
+			log.Printf("反查注释词典生成完成: %s（%d 项）\n", args.ReverseDict, count)
 		}
 	}
 
-	// 生成简码表
-	if !args.Quiet {
-		log.Println("开始生成简码表...")
+	if err := checkMemory("写入文件"); err != nil {
+		log.Printf("%v", err)
+		return 1
 	}
-	noSimplifyChars := []string{"的", "了"} // 不出简的字符列表
-	simpleCodeList := tools.BuildSimpleCodeList(fullCodeMetaList, lenCodeLimit, noSimplifyChars)
-	
 	if !args.Quiet {
-		log.Printf("简码表生成完成，共 %d 项\n", len(simpleCodeList))
 		log.Println("开始写入文件...")
 	}
 
-
-	// 使用并行处理加速文件写入
+	// 使用并行处理加速文件写入，内存降级后逐个流式写出
 	var wg sync.WaitGroup
-	fileCount := 4 // 基础文件：FULLCHAR, SIMPLECODE, DIVISION, DAZHUCHAI
+	fileCount := 3 // 基础文件：FULLCHAR, DIVISION, DAZHUCHAI
+	// 读取已有单字全码表时没有拆分信息，不重写拆分与大竹拆分文件
+	writeDivision := args.CharsFrom == ""
+	if !writeDivision {
+		fileCount -= 2
+	}
+	if simpleCodeList != nil {
+		fileCount++
+	}
 	if wordCodes != nil {
 		fileCount++
 	}
@@ -257,220 +571,193 @@ func main() {
 	}
 	wg.Add(fileCount)
 	errChan := make(chan error, fileCount)
+	spawn := func(write func()) {
+		if memoryDegraded {
+			write()
+			return
+		}
+		go write()
+	}
 
-	// FULLCHAR - 全码表，格式为"汉字\t编码\t词频"
-	go func() {
+	// FULLCHAR - 全码表，默认格式为"汉字\t编码\t词频"，列按 -output-columns 配置
+	spawn(func() {
 		defer wg.Done()
-		buffer := bytes.Buffer{}
+		output := tools.CreateOutputFile(args.Full)
+		layout := outputColumns.Layout(tools.OutputCharsFull)
 		// 全码表已经在BuildFullCodeMetaList中排序过
 		for _, charMeta := range fullCodeMetaList {
-			buffer.WriteString(fmt.Sprintf("%s\t%s\t%d\n", charMeta.Char, charMeta.Code, charMeta.Freq))
+			// simp 列为该字的简码，仅主拆分条目填写
+			output.WriteString(layout.Row(charMeta.Char, charMeta.Code, strconv.FormatInt(charMeta.Freq, 10), charMeta.SimpCode))
 		}
-		err := os.WriteFile(args.Full, buffer.Bytes(), 0o644)
+		err := output.Close()
 		if err != nil {
 			errChan <- fmt.Errorf("写入FULLCHAR文件错误: %w", err)
-		} else if !args.Quiet {
+		} else if !args.Quiet && !args.DryRun {
 			log.Printf("FULLCHAR文件写入完成: %s\n", args.Full)
 		}
-	}()
+	})
 
-	// SIMPLECODE
-	go func() {
-		defer wg.Done()
-		buffer := bytes.Buffer{}
-		// 对简码表进行排序：编码升序，重码按词频降序
-		sortedSimpleList := make([]*types.CharMeta, len(simpleCodeList))
-		copy(sortedSimpleList, simpleCodeList)
-		sort.Slice(sortedSimpleList, func(i, j int) bool {
-			a, b := sortedSimpleList[i], sortedSimpleList[j]
-			
-			// 首先按编码升序排列
-			if a.Code != b.Code {
-				return a.Code < b.Code
-			}
-			
-			// 编码相同，按词频降序排列
-			if a.Freq != b.Freq {
-				return a.Freq > b.Freq
-			}
-			
-			// 编码和词频都相同，按字符Unicode编码升序排列
-			return a.Char < b.Char
+	// SIMPLECODE，纯全码版本没有简码表
+	if simpleCodeList != nil {
+		spawn(func() {
+			defer wg.Done()
+			output := tools.CreateOutputFile(args.Simple)
+			layout := outputColumns.Layout(tools.OutputCharsSimp)
+			// 对简码表进行排序：编码升序，重码按词频降序，再按字符Unicode编码升序
+			sortedSimpleList := tools.SortedCharMetaView(simpleCodeList, tools.CharMetaByCodeFreq)
+			for _, charMeta := range sortedSimpleList {
+				output.WriteString(layout.Row(charMeta.Char, charMeta.Code, strconv.FormatInt(charMeta.Freq, 10), ""))
+			}
+			err := output.Close()
+			if err != nil {
+				errChan <- fmt.Errorf("写入SIMPLECODE文件错误: %w", err)
+			} else if !args.Quiet && !args.DryRun {
+				log.Printf("SIMPLECODE文件写入完成: %s\n", args.Simple)
+			}
 		})
-		for _, charMeta := range sortedSimpleList {
-			buffer.WriteString(fmt.Sprintf("%s\t%s\t%d\n", charMeta.Char, charMeta.Code, charMeta.Freq))
-		}
-		err := os.WriteFile(args.Simple, buffer.Bytes(), 0o644)
-		if err != nil {
-			errChan <- fmt.Errorf("写入SIMPLECODE文件错误: %w", err)
-		} else if !args.Quiet {
-			log.Printf("SIMPLECODE文件写入完成: %s\n", args.Simple)
-		}
-	}()
+	}
 
-	// DIVISION
-	go func() {
-		defer wg.Done()
-		buffer := bytes.Buffer{}
-		// 创建一个副本用于排序，避免并发访问问题
-		sortedList := make([]*types.CharMeta, len(fullCodeMetaList))
-		copy(sortedList, fullCodeMetaList)
-		sort.Slice(sortedList, func(i, j int) bool {
-			return sortedList[i].Char < sortedList[j].Char
+	// 拆分与大竹拆分需要拆分信息
+	if writeDivision {
+		// DIVISION
+		spawn(func() {
+			defer wg.Done()
+			output := tools.CreateOutputFile(args.Opencc)
+			// 按字符Unicode顺序排序的视图，不修改共享的全码列表
+			sortedList := tools.SortedCharMetaView(fullCodeMetaList, tools.CharMetaByChar)
+			for _, charMeta := range sortedList {
+				if charMeta.Division == nil {
+					continue
+				}
+				div := display.Replace(strings.Join(charMeta.Division.Divs, ""))
+				output.WriteString(fmt.Sprintf("%s\t[%s·%s·%s·%s·%s]\n",
+					charMeta.Char,
+					div,
+					charMeta.Full,
+					charMeta.Division.Pin,
+					charMeta.Division.Set,
+					charMeta.Division.Unicode,
+				))
+			}
+			err := output.Close()
+			if err != nil {
+				errChan <- fmt.Errorf("写入DIVISION文件错误: %w", err)
+			} else if !args.Quiet && !args.DryRun {
+				log.Printf("DIVISION文件写入完成: %s\n", args.Opencc)
+			}
 		})
-		for _, charMeta := range sortedList {
-			if charMeta.Division == nil {
-				continue
-			}
-			div := strings.Join(charMeta.Division.Divs, "")
-			buffer.WriteString(fmt.Sprintf("%s\t[%s·%s·%s·%s·%s]\n",
-	   			charMeta.Char,
-	   			div,
-	   			charMeta.Full,
-	   			charMeta.Division.Pin,
-	   			charMeta.Division.Set,
-	   			charMeta.Division.Unicode,
-			))
-		}
-		err := os.WriteFile(args.Opencc, buffer.Bytes(), 0o644)
-		if err != nil {
-			errChan <- fmt.Errorf("写入DIVISION文件错误: %w", err)
-		} else if !args.Quiet {
-			log.Printf("DIVISION文件写入完成: %s\n", args.Opencc)
-		}
-	}()
 
-	// DAZHUCHAI - 大竹拆文件，格式为两行：
-	// 第一行："部件\t字"（将 Division.Divs 连接成字符串）
-	// 第二行："Unicode类别〔Unicode编码〕\t字"（将第二行和第三行整合）
-	go func() {
-		defer wg.Done()
-		buffer := bytes.Buffer{}
-		// 创建一个副本用于排序，按字符Unicode顺序排序
-		sortedList := make([]*types.CharMeta, len(fullCodeMetaList))
-		copy(sortedList, fullCodeMetaList)
-		sort.Slice(sortedList, func(i, j int) bool {
-			return sortedList[i].Char < sortedList[j].Char
-		})
-		for _, charMeta := range sortedList {
-			if charMeta.Division == nil {
-				continue
+		// DAZHUCHAI - 大竹拆文件，格式为两行：
+		// 第一行："部件\t字"（将 Division.Divs 连接成字符串）
+		// 第二行："Unicode类别〔Unicode编码〕\t字"（将第二行和第三行整合）
+		spawn(func() {
+			defer wg.Done()
+			output := tools.CreateOutputFile(args.DazhuChai)
+			// 按字符Unicode顺序排序的视图，不修改共享的全码列表
+			sortedList := tools.SortedCharMetaView(fullCodeMetaList, tools.CharMetaByChar)
+			for _, charMeta := range sortedList {
+				if charMeta.Division == nil {
+					continue
+				}
+				// 第一行：部件\t字
+				components := display.Replace(strings.Join(charMeta.Division.Divs, ""))
+				output.WriteString(fmt.Sprintf("%s\t%s\n", components, charMeta.Char))
+				// 第二行：Unicode类别〔Unicode编码〕\t字（整合第二行和第三行）
+				output.WriteString(fmt.Sprintf("%s〔%s〕\t%s\n", charMeta.Division.Set, charMeta.Division.Unicode, charMeta.Char))
 			}
-			// 第一行：部件\t字
-			components := strings.Join(charMeta.Division.Divs, "")
-			buffer.WriteString(fmt.Sprintf("%s\t%s\n", components, charMeta.Char))
-			// 第二行：Unicode类别〔Unicode编码〕\t字（整合第二行和第三行）
-			buffer.WriteString(fmt.Sprintf("%s〔%s〕\t%s\n", charMeta.Division.Set, charMeta.Division.Unicode, charMeta.Char))
-		}
-		err := os.WriteFile(args.DazhuChai, buffer.Bytes(), 0o644)
-		if err != nil {
-			errChan <- fmt.Errorf("写入DAZHUCHAI文件错误: %w", err)
-		} else if !args.Quiet {
-			log.Printf("DAZHUCHAI文件写入完成: %s\n", args.DazhuChai)
-		}
-	}()
+			err := output.Close()
+			if err != nil {
+				errChan <- fmt.Errorf("写入DAZHUCHAI文件错误: %w", err)
+			} else if !args.Quiet && !args.DryRun {
+				log.Printf("DAZHUCHAI文件写入完成: %s\n", args.DazhuChai)
+			}
+		})
+	}
 
 	// 写入多字词全码表
 	if wordCodes != nil {
-		go func() {
+		spawn(func() {
 			defer wg.Done()
-			buffer := bytes.Buffer{}
-			
+			output := tools.CreateOutputFile(args.WordsFull)
+			layout := outputColumns.Layout(tools.OutputWordsFull)
+
 			// 保持ll_words.txt的原始顺序，不进行排序
 			for _, wordCode := range wordCodes {
-				if wordCode.Weight != "" {
-					buffer.WriteString(fmt.Sprintf("%s\t%s\t%s\n", wordCode.Word, wordCode.Code, wordCode.Weight))
-				} else {
-					buffer.WriteString(fmt.Sprintf("%s\t%s\n", wordCode.Word, wordCode.Code))
-				}
+				output.WriteString(layout.Row(wordCode.Word, wordCode.Code, wordCode.Weight, ""))
 			}
-			err := os.WriteFile(args.WordsFull, buffer.Bytes(), 0o644)
+			err := output.Close()
 			if err != nil {
 				errChan <- fmt.Errorf("写入多字词全码表文件错误: %w", err)
-			} else if !args.Quiet {
+			} else if !args.Quiet && !args.DryRun {
 				log.Printf("多字词全码表文件写入完成: %s\n", args.WordsFull)
 			}
-		}()
+		})
 	}
 
-
 	// 写入多字词简码表
 	if wordSimpleCodes != nil {
-		go func() {
+		spawn(func() {
 			defer wg.Done()
-			buffer := bytes.Buffer{}
-			
+			output := tools.CreateOutputFile(args.WordsSimple)
+			layout := outputColumns.Layout(tools.OutputWordsSimp)
+
 			// 对多字词简码进行排序
 			// 先按编码升序排列，编码相同时按权重降序排列
-			sortedWordSimpleCodes := make([]*types.WordSimpleCode, len(wordSimpleCodes))
-			copy(sortedWordSimpleCodes, wordSimpleCodes)
-			tools.SortWordSimpleCodes(sortedWordSimpleCodes)
-			
+			sortedWordSimpleCodes := tools.SortedWordSimpleCodeView(wordSimpleCodes)
+
 			for _, wordSimpleCode := range sortedWordSimpleCodes {
-				if wordSimpleCode.Weight != "" {
-					buffer.WriteString(fmt.Sprintf("%s\t%s\t%s\n", wordSimpleCode.Word, wordSimpleCode.Code, wordSimpleCode.Weight))
-				} else {
-					buffer.WriteString(fmt.Sprintf("%s\t%s\n", wordSimpleCode.Word, wordSimpleCode.Code))
-				}
+				output.WriteString(layout.Row(wordSimpleCode.Word, wordSimpleCode.Code, wordSimpleCode.Weight, ""))
 			}
-			err := os.WriteFile(args.WordsSimple, buffer.Bytes(), 0o644)
+			err := output.Close()
 			if err != nil {
 				errChan <- fmt.Errorf("写入多字词简码表文件错误: %w", err)
-			} else if !args.Quiet {
+			} else if !args.Quiet && !args.DryRun {
 				log.Printf("多字词简码表文件写入完成: %s\n", args.WordsSimple)
 			}
-		}()
+		})
 	}
 
 	// 写入玲珑多字词全码表
 	if linglongCodes != nil {
-		go func() {
+		spawn(func() {
 			defer wg.Done()
-			buffer := bytes.Buffer{}
-			
+			output := tools.CreateOutputFile(args.LinglongFull)
+			layout := outputColumns.Layout(tools.OutputLinglongFull)
+
 			// 保持玲珑.txt的原始顺序，不进行排序
 			for _, wordCode := range linglongCodes {
-				if wordCode.Weight != "" {
-					buffer.WriteString(fmt.Sprintf("%s\t%s\t%s\n", wordCode.Word, wordCode.Code, wordCode.Weight))
-				} else {
-					buffer.WriteString(fmt.Sprintf("%s\t%s\n", wordCode.Word, wordCode.Code))
-				}
+				output.WriteString(layout.Row(wordCode.Word, wordCode.Code, wordCode.Weight, ""))
 			}
-			err := os.WriteFile(args.LinglongFull, buffer.Bytes(), 0o644)
+			err := output.Close()
 			if err != nil {
 				errChan <- fmt.Errorf("写入玲珑多字词全码表文件错误: %w", err)
-			} else if !args.Quiet {
+			} else if !args.Quiet && !args.DryRun {
 				log.Printf("玲珑多字词全码表文件写入完成: %s\n", args.LinglongFull)
 			}
-		}()
+		})
 	}
 
 	// 写入玲珑多字词简码表
 	if linglongSimpleCodes != nil {
-		go func() {
+		spawn(func() {
 			defer wg.Done()
-			buffer := bytes.Buffer{}
-			
+			output := tools.CreateOutputFile(args.LinglongSimple)
+			layout := outputColumns.Layout(tools.OutputLinglongSimp)
+
 			// 对玲珑多字词简码进行排序
 			// 先按编码升序排列，编码相同时按权重降序排列
-			sortedLinglongSimpleCodes := make([]*types.WordSimpleCode, len(linglongSimpleCodes))
-			copy(sortedLinglongSimpleCodes, linglongSimpleCodes)
-			tools.SortWordSimpleCodes(sortedLinglongSimpleCodes)
-			
+			sortedLinglongSimpleCodes := tools.SortedWordSimpleCodeView(linglongSimpleCodes)
+
 			for _, wordSimpleCode := range sortedLinglongSimpleCodes {
-				if wordSimpleCode.Weight != "" {
-					buffer.WriteString(fmt.Sprintf("%s\t%s\t%s\n", wordSimpleCode.Word, wordSimpleCode.Code, wordSimpleCode.Weight))
-				} else {
-					buffer.WriteString(fmt.Sprintf("%s\t%s\n", wordSimpleCode.Word, wordSimpleCode.Code))
-				}
+				output.WriteString(layout.Row(wordSimpleCode.Word, wordSimpleCode.Code, wordSimpleCode.Weight, ""))
 			}
-			err := os.WriteFile(args.LinglongSimple, buffer.Bytes(), 0o644)
+			err := output.Close()
 			if err != nil {
 				errChan <- fmt.Errorf("写入玲珑多字词简码表文件错误: %w", err)
-			} else if !args.Quiet {
+			} else if !args.Quiet && !args.DryRun {
 				log.Printf("玲珑多字词简码表文件写入完成: %s\n", args.LinglongSimple)
 			}
-		}()
+		})
 	}
 
 	// 等待所有写入操作完成
@@ -479,7 +766,19 @@ func main() {
 
 	// 检查是否有错误
 	for err := range errChan {
-		log.Fatalln(err)
+		log.Println(err)
+		return 1
+	}
+
+	// 内存性能分析：码表全部写出、写出协程都已结束后记录堆快照
+	if args.MemProfile != "" {
+		if err := writeMemProfile(args.MemProfile); err != nil {
+			log.Printf("写入内存性能分析文件失败: %v", err)
+			return 1
+		}
+		if !args.Quiet && !args.DryRun {
+			log.Printf("内存性能分析文件写入完成: %s\n", args.MemProfile)
+		}
 	}
 
 	// 输出处理时间
@@ -487,19 +786,111 @@ func main() {
 		log.Printf("处理完成，总耗时: %v\n", utils.Since(startTime))
 	}
 
+	// 空跑到此为止：跟打词提、字典追加、字根码表与部署都要读回上面写出的码表文件
+	if args.DryRun {
+		return reportDryRun()
+	}
+
+	if err := checkMemory("跟打词提"); err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+
 	// 处理跟打词提
 	if args.ProcessCiti {
 		log.Println("开始处理跟打词提文件...")
 		// 使用玲珑词库的词语部分
-		err := tools.ProcessCitiFilesWithLinglong(args.Simple, args.Full, args.LinglongSimple, args.LinglongFull, args.CitiPre, args.GendaCiti)
+		citiOpts := tools.CitiOptions{
+			CodeMaxLength:          args.CitiCodeMaxLength,
+			Strict:                 args.CitiStrict,
+			CandidateBaseLengthMin: args.CitiCandidateBaseLengthMin,
+			SimpleChars:            tools.SimpleCharLevels(fullCodeMetaList),
+			Rules:                  result.Rules,
+			OutputCodeMaxLength:    args.GendaCodeMaxLength,
+			OutputCodeOverflow:     args.GendaCodeOverflow,
+			ReservedPrefix:         args.RootsCodePrefix,
+			WordSource:             args.CitiSource,
+		}
+		switch args.CitiSource {
+		case tools.CitiWordSourceLinglong, tools.CitiWordSourceWords:
+		default:
+			log.Printf("未知的跟打词提词语来源: %s（可选 linglong、words）", args.CitiSource)
+			return 1
+		}
+		switch args.GendaCodeOverflow {
+		case tools.OverflowDrop, tools.OverflowNumbered:
+		default:
+			log.Printf("未知的超长编码处理方式: %s（可选 drop、numbered）", args.GendaCodeOverflow)
+			return 1
+		}
+		if args.GendaCodeMaxLength < 0 {
+			log.Printf("跟打词提编码最大长度不能为负数: %d", args.GendaCodeMaxLength)
+			return 1
+		}
+		if args.CitiDryRunSections {
+			citiOpts.SectionPreview = os.Stderr
+		}
+		sourceSort, err := tools.ParseCitiSourceSort(args.CitiSourceSort)
+		if err != nil {
+			log.Printf("解析跟打词提来源排序失败: %v", err)
+			return 1
+		}
+		citiOpts.SourceSort = sourceSort
+		if args.SaimaOut != "" {
+			if len(args.SaimaStartKey) != 1 || args.SaimaStartKey[0] < '1' || args.SaimaStartKey[0] > '9' {
+				log.Printf("极速赛码表选重起始键须为 1~9: %s", args.SaimaStartKey)
+				return 1
+			}
+			citiOpts.SaimaFile = args.SaimaOut
+			citiOpts.SaimaStartKey = args.SaimaStartKey[0]
+		}
+		citiOpts.KeyRemap, err = tools.ParseKeyRemap(args.GendaKeyRemap)
+		if err != nil {
+			log.Printf("解析跟打词提键位重映射失败: %v", err)
+			return 1
+		}
+		dazhuKeyRemap, err := tools.ParseKeyRemap(args.DazhuKeyRemap)
+		if err != nil {
+			log.Printf("解析大竹词提键位重映射失败: %v", err)
+			return 1
+		}
+		// 纯全码版本不读取简码来源，只用全码来源；词语来源没有结果时不读取对应来源
+		charsSimpFile, wordsQuickFile, wordsFullFile := args.Simple, args.LinglongSimple, args.LinglongFull
+		wordsQuickMissing, wordsFullMissing := linglongSimpleCodes == nil, linglongCodes == nil
+		citiOpts.Placeholders = tools.PlaceholderSet(linglongSimpleCodes)
+		if args.CitiSource == tools.CitiWordSourceWords {
+			wordsQuickFile, wordsFullFile = args.WordsSimple, args.WordsFull
+			wordsQuickMissing, wordsFullMissing = wordSimpleCodes == nil, wordCodes == nil
+			citiOpts.Placeholders = tools.PlaceholderSet(wordSimpleCodes)
+		}
+		if args.NoSimp {
+			charsSimpFile = ""
+		}
+		if wordsQuickMissing {
+			wordsQuickFile = ""
+		}
+		if wordsFullMissing {
+			wordsFullFile = ""
+		}
+		citiResult, err := tools.ProcessCitiFiles(charsSimpFile, args.Full, wordsQuickFile, wordsFullFile, args.CitiPre, args.GendaCiti, citiOpts)
+		for _, lineErr := range citiResult.LineErrors {
+			log.Printf("跳过跟打词提条目: %v", lineErr)
+		}
+		if citiResult.CodeTooLong > 0 {
+			log.Printf("警告: %d 条跟打词提条目受编码最大长度 %d 限制，未写出", citiResult.CodeTooLong, args.GendaCodeMaxLength)
+		}
 		if err != nil {
 			log.Printf("处理跟打词提文件失败: %v", err)
 		} else {
 			log.Println("跟打词提文件处理完成")
-			
+
 			// 生成大竹词提
 			log.Println("开始生成大竹词提...")
-			err := tools.CreateDazhuCode(args.GendaCiti, args.DazhuCode, 30)
+			err := tools.CreateDazhuCode(args.GendaCiti, args.DazhuCode, 30, tools.DazhuOptions{
+				Reverse:  args.DazhuReverse,
+				SortBy:   args.DazhuSortBy,
+				KeyRemap: dazhuKeyRemap,
+			})
 			if err != nil {
 				log.Printf("生成大竹词提失败: %v", err)
 			} else {
@@ -508,139 +899,1380 @@ func main() {
 		}
 	}
 
+	if err := checkMemory("追加字典"); err != nil {
+		log.Printf("%v", err)
+		return 1
+	}
+
 	// 新增功能：将生成的文件追加到输出目录的字典文件
 	if !args.Quiet {
 		log.Println("开始将生成的文件追加到字典文件...")
 	}
-	
+
 	// 获取输出目录
 	outputDir := filepath.Dir(args.Full)
-	
-	// 将div_ll.txt追加到LL_chaifen.dict.yaml
-	if !args.Quiet {
-		log.Println("将div_ll.txt追加到LL_chaifen.dict.yaml...")
+	dictAppendOpts := tools.DictAppendOptions{
+		HeaderPreserve:  args.DictHeaderPreserve,
+		SimpleCharsFile: args.Simple,
+		CountLines:      args.DictLineCountReport,
+		Rules:           result.Rules,
 	}
-	err = tools.AppendToDictFile(args.Opencc, filepath.Join(outputDir, "LL_chaifen.dict.yaml"), false, false)
-	if err != nil {
-		log.Printf("追加div_ll.txt到LL_chaifen.dict.yaml失败: %v", err)
-	} else if !args.Quiet {
-		log.Println("div_ll.txt追加到LL_chaifen.dict.yaml完成")
+	if args.NoSimp {
+		// 纯全码版本没有简码汉字，LL.chars.full.dict.yaml 不做出简让全
+		dictAppendOpts.SimpleCharsFile = ""
 	}
-	
-	// 将code_chars_simp.txt追加到LL.chars.quick.dict.yaml（需要排序和删除词频）
-	if !args.Quiet {
-		log.Println("将code_chars_simp.txt追加到LL.chars.quick.dict.yaml...")
+	// 各字典先追加到临时副本，全部成功并校验通过后统一替换，避免发布互不配套的字典集
+	dictTx := tools.NewDictTransaction(tools.DictTransactionOptions{Backup: args.Backup, PreviewDir: args.Preview})
+	// 提交前因错误返回时丢弃暂存副本，提交后为空操作
+	defer dictTx.Rollback()
+	// 追加成功后按需把目标文件前后的条目行数输出到标准错误
+	appendDict := func(sourceFile, targetFile string, needSort, removeFreq bool, opts tools.DictAppendOptions) (*tools.DictAppendResult, error) {
+		appendResult, err := dictTx.Append(sourceFile, targetFile, needSort, removeFreq, opts)
+		if err == nil && opts.CountLines && !args.Quiet {
+			fmt.Fprintf(os.Stderr, "[dict] %s: before=%d after=%d added=%d\n", filepath.Base(targetFile), appendResult.LinesBefore, appendResult.LinesAfter, appendResult.LinesAfter-appendResult.LinesBefore)
+		}
+		return appendResult, err
 	}
-	err = tools.AppendToDictFile(args.Simple, filepath.Join(outputDir, "LL.chars.quick.dict.yaml"), true, true)
-	if err != nil {
-		log.Printf("追加code_chars_simp.txt到LL.chars.quick.dict.yaml失败: %v", err)
-	} else if !args.Quiet {
-		log.Println("code_chars_simp.txt追加到LL.chars.quick.dict.yaml完成")
+
+	// 将code_chars_simp.txt追加到LL.chars.quick.dict.yaml时按选项排序、补占位条目、排除编码，纯全码版本不追加
+	charsQuickOpts := dictAppendOpts
+	if !args.NoSimp {
+		if args.CharsQuickSort == "suffix" {
+			charsQuickOpts.SuffixOrder = suffixOrder
+		}
+		if args.CharsQuickPlaceholder {
+			// 简码长度限制已在构建阶段校验过
+			lenCodeLimit, _ := tools.ParseLenCodeLimit(args.LenCodeLimit)
+			charsQuickOpts.ExtraEntries = tools.BuildCharSimplePlaceholders(simpleCodeList, lenCodeLimit)
+			if !args.Quiet {
+				log.Printf("单字简码空位占位条目: %d\n", len(charsQuickOpts.ExtraEntries))
+			}
+		}
+		for _, pattern := range strings.Fields(args.CharsQuickExcludeCodes) {
+			matcher, err := regexp.Compile(pattern)
+			if err != nil {
+				log.Printf("解析单字简码排除正则失败: %v", err)
+				return 1
+			}
+			charsQuickOpts.ExcludeCodes = append(charsQuickOpts.ExcludeCodes, matcher)
+		}
 	}
-	
-	// 将code_chars_full.txt追加到LL.chars.full.dict.yaml（需要排序和删除词频）
-	if !args.Quiet {
-		log.Println("将code_chars_full.txt追加到LL.chars.full.dict.yaml...")
+
+	// 词简码字典识别占位符：同码组内真实词在前，占位符在后；占位符按构建结果中的来源标记区分
+	wordsQuickOpts := dictAppendOpts
+	wordsQuickOpts.PlaceholderAware = true
+	wordsQuickOpts.Placeholders = tools.PlaceholderSet(wordSimpleCodes)
+	linglongQuickOpts := wordsQuickOpts
+	linglongQuickOpts.Placeholders = tools.PlaceholderSet(linglongSimpleCodes)
+
+	// 各字典按此顺序追加；排序的字典同时删除词频
+	dictAppends := []struct {
+		name    string // 日志中的来源名
+		source  string
+		target  string
+		sorted  bool
+		opts    tools.DictAppendOptions
+		enabled bool
+	}{
+		// 读取已有单字全码表时拆分表未重新生成，不追加
+		{"div_ll.txt", args.Opencc, "LL_chaifen.dict.yaml", false, dictAppendOpts, args.CharsFrom == ""},
+		// 纯全码版本不追加quick字典
+		{"code_chars_simp.txt", args.Simple, "LL.chars.quick.dict.yaml", true, charsQuickOpts, !args.NoSimp},
+		{"code_chars_full.txt", args.Full, "LL.chars.full.dict.yaml", true, dictAppendOpts, true},
+		// 多字词、玲珑词没有结果时码表未写出，对应字典不追加（原因已在构建时给出）
+		{"code_words_simp.txt", args.WordsSimple, "LL.words.quick.dict.yaml", true, wordsQuickOpts, wordSimpleCodes != nil},
+		{"code_words_full.txt", args.WordsFull, "LL.words.full.dict.yaml", true, dictAppendOpts, wordCodes != nil},
+		{"linglong_full.txt", args.LinglongFull, "LL_linglong.full.dict.yaml", true, dictAppendOpts, linglongCodes != nil},
+		{"linglong_simp.txt", args.LinglongSimple, "LL_linglong.quick.dict.yaml", true, linglongQuickOpts, linglongSimpleCodes != nil},
 	}
-	err = tools.AppendToDictFile(args.Full, filepath.Join(outputDir, "LL.chars.full.dict.yaml"), true, true)
-	if err != nil {
-		log.Printf("追加code_chars_full.txt到LL.chars.full.dict.yaml失败: %v", err)
+	slowThreshold := time.Duration(args.DictAppendSlowSeconds) * time.Second
+	var appendElapsed time.Duration
+	for _, dictAppend := range dictAppends {
+		if !dictAppend.enabled {
+			continue
+		}
+		if !args.Quiet {
+			log.Printf("将%s追加到%s...\n", dictAppend.name, dictAppend.target)
+		}
+		appendStart := utils.Now()
+		appendResult, err := appendDict(dictAppend.source, filepath.Join(outputDir, dictAppend.target), dictAppend.sorted, dictAppend.sorted, dictAppend.opts)
+		elapsed := utils.Since(appendStart)
+		appendElapsed += elapsed
+		if err != nil {
+			log.Printf("追加%s到%s失败: %v", dictAppend.name, dictAppend.target, err)
+			continue
+		}
+		if !args.Quiet {
+			if appendResult.Excluded > 0 {
+				log.Printf("%s按排除正则跳过 %d 条\n", dictAppend.target, appendResult.Excluded)
+			}
+			log.Printf("%s追加到%s完成，共 %d 条，耗时: %v\n", dictAppend.name, dictAppend.target, appendResult.Written, elapsed)
+		}
+		if slowThreshold > 0 && elapsed > slowThreshold {
+			log.Printf("警告: 追加%s耗时 %v，超过 %v，可能是磁盘较慢（如网络盘）\n", dictAppend.target, elapsed, slowThreshold)
+		}
+	}
+
+	// 统一替换生效，任何一个字典追加或校验失败都不改动已有字典
+	if err := dictTx.Commit(); err != nil {
+		log.Printf("字典追加失败，已全部回滚: %v", err)
+		return 1
+	}
+	if args.Preview != "" && !args.Quiet {
+		log.Printf("字典追加预览已写到 %s，未改动输出目录中的字典，追加耗时: %v\n", args.Preview, appendElapsed)
 	} else if !args.Quiet {
-		log.Println("code_chars_full.txt追加到LL.chars.full.dict.yaml完成")
+		log.Printf("字典追加已全部生效，追加耗时: %v\n", appendElapsed)
+	}
+	if args.Debug {
+		for _, hit := range result.Rules.Hits() {
+			log.Printf("体验规则生效: %s（编码 %s，第%d位→第%d位）\n", hit.Rule, hit.Code, hit.From, hit.To)
+		}
 	}
-	
-	// 将code_words_simp.txt追加到LL.words.quick.dict.yaml（需要排序和删除词频）
+
+	// 生成字根码表并追加到LL.roots.dict.yaml，读取已有单字全码表时不读取映射表，不重新生成
+	if args.CharsFrom == "" {
+		if !args.Quiet {
+			log.Println("开始生成字根码表...")
+		}
+		rootsDict, err := generateRootsDict(display)
+		if err != nil {
+			log.Printf("生成字根码表失败: %v", err)
+		} else if !args.Quiet {
+			log.Printf("字根码表生成完成: %s\n", rootsDict)
+		}
+	}
+
+	// preset_data 由简码表生成，纯全码版本不生成
+	if !args.NoSimp {
+		// 在追加完所有字典文件后生成 preset_data.txt
+		if !args.Quiet {
+			log.Println("开始生成 preset_data.txt...")
+		}
+		var presetCharset map[string]bool
+		if args.PresetDataCharsetFilter != "" {
+			presetCharset, err = tools.ReadCharset(args.PresetDataCharsetFilter)
+			if err != nil {
+				log.Printf("读取preset_data字集文件失败: %v", err)
+				return 1
+			}
+			if !args.Quiet {
+				log.Printf("preset_data字集加载完成，共 %d 字\n", len(presetCharset))
+			}
+		}
+		// 预览时输出目录中的全码字典未更新，读预览目录中追加后的副本
+		fullDictFile := filepath.Join(outputDir, "LL.chars.full.dict.yaml")
+		if args.Preview != "" {
+			fullDictFile = tools.PreviewPath(args.Preview, fullDictFile)
+		}
+		presetDataLines, err := tools.BuildPresetData(simpleCodeList, fullCodeMetaList, tools.PresetDataOptions{
+			PadMissingSuffixes: args.PresetPadMissingSuffixes,
+			SuffixOrder:        suffixOrder,
+			Display:            display,
+			FullDictFile:       fullDictFile,
+			Charset:            presetCharset,
+		})
+		if err != nil {
+			log.Printf("生成 preset_data.txt 失败: %v", err)
+		} else if !args.Quiet {
+			log.Printf("preset_data.txt 生成完成，共 %d 项\n", len(presetDataLines))
+		}
+
+		// 写入 preset_data.txt
+		if !args.Quiet {
+			log.Println("开始写入 preset_data.txt...")
+		}
+		err = os.WriteFile(args.PresetData, []byte(strings.Join(presetDataLines, "\n")), 0o644)
+		if err != nil {
+			log.Printf("写入 preset_data.txt 失败: %v", err)
+		} else if !args.Quiet {
+			log.Printf("preset_data.txt 写入完成: %s\n", args.PresetData)
+		}
+	}
+
+	// 显示替换统计
+	if display != nil {
+		if !args.Quiet {
+			log.Printf("显示替换命中 %d 次\n", display.Hits())
+		}
+		if missing := display.Missing(); len(missing) > 0 {
+			log.Printf("警告: 显示替换表未覆盖 %d 个私有区字符，请补表: %s\n", len(missing), strings.Join(missing, " "))
+		}
+	}
+
+	// 按 Rime 用户目录约定部署产物，或导出到同文输入法目录；预览时输出目录中的字典未更新，不部署
+	if args.Preview != "" && (args.Deploy != "" || args.TrimeOut != "") {
+		log.Println("警告: 预览模式不部署，忽略 -deploy 与 -trime-out")
+	} else if args.Deploy != "" || args.TrimeOut != "" {
+		// 纯全码版本没有追加 quick 字典与 preset_data，多字词、玲珑词没有结果时也没有追加对应字典，均不部署
+		dictFiles := []string{filepath.Join(outputDir, "LL_chaifen.dict.yaml")}
+		if !args.NoSimp {
+			dictFiles = append(dictFiles, filepath.Join(outputDir, "LL.chars.quick.dict.yaml"))
+		}
+		dictFiles = append(dictFiles, filepath.Join(outputDir, "LL.chars.full.dict.yaml"))
+		if wordSimpleCodes != nil {
+			dictFiles = append(dictFiles, filepath.Join(outputDir, "LL.words.quick.dict.yaml"))
+		}
+		if wordCodes != nil {
+			dictFiles = append(dictFiles, filepath.Join(outputDir, "LL.words.full.dict.yaml"))
+		}
+		if linglongCodes != nil {
+			dictFiles = append(dictFiles, filepath.Join(outputDir, "LL_linglong.full.dict.yaml"))
+		}
+		if linglongSimpleCodes != nil {
+			dictFiles = append(dictFiles, filepath.Join(outputDir, "LL_linglong.quick.dict.yaml"))
+		}
+		dictFiles = append(dictFiles, args.RootsDict)
+		if args.ReverseDict != "" {
+			dictFiles = append(dictFiles, args.ReverseDict)
+		}
+		if args.Deploy != "" {
+			presetData := args.PresetData
+			if args.NoSimp {
+				presetData = ""
+			}
+			deployFiles := tools.RimeDeployFiles(args.Deploy, dictFiles, presetData)
+			err := tools.DeployFiles(deployFiles, tools.DeployOptions{Backup: args.Backup})
+			if err != nil {
+				log.Printf("部署失败: %v", err)
+			} else if !args.Quiet {
+				for _, file := range deployFiles {
+					log.Printf("已部署: %s\n", file.Target)
+				}
+			}
+		}
+		if args.TrimeOut != "" {
+			exportTrime(dictFiles)
+		}
+	}
+
 	if !args.Quiet {
-		log.Println("将code_words_simp.txt追加到LL.words.quick.dict.yaml...")
+		log.Printf("全部完成，总耗时: %v（其中字典追加: %v）\n", utils.Since(startTime), appendElapsed)
+	}
+	return 0
+}
+
+// exportTrime 把本次生成的词典与最小配置骨架导出到同文输入法目录
+func exportTrime(dictFiles []string) {
+	deployOpts := tools.DeployOptions{Backup: args.Backup}
+	exportFiles := tools.TrimeExportFiles(args.TrimeOut, dictFiles)
+	if err := tools.DeployFiles(exportFiles, deployOpts); err != nil {
+		log.Printf("导出同文词典失败: %v", err)
+		return
 	}
-	err = tools.AppendToDictFile(args.WordsSimple, filepath.Join(outputDir, "LL.words.quick.dict.yaml"), true, true)
+	configFiles, err := tools.WriteTrimeConfig(args.TrimeOut, dictFiles, deployOpts)
 	if err != nil {
-		log.Printf("追加code_words_simp.txt到LL.words.quick.dict.yaml失败: %v", err)
-	} else if !args.Quiet {
-		log.Println("code_words_simp.txt追加到LL.words.quick.dict.yaml完成")
+		log.Printf("导出同文配置失败: %v", err)
+		return
 	}
-	
-	// 将code_words_full.txt追加到LL.words.full.dict.yaml（需要排序和删除词频）
 	if !args.Quiet {
-		log.Println("将code_words_full.txt追加到LL.words.full.dict.yaml...")
+		for _, file := range exportFiles {
+			log.Printf("已导出: %s\n", file.Target)
+		}
+		for _, file := range configFiles {
+			log.Printf("已导出: %s\n", file)
+		}
 	}
-	err = tools.AppendToDictFile(args.WordsFull, filepath.Join(outputDir, "LL.words.full.dict.yaml"), true, true)
+}
+
+// prepareExample 把内置的最小示例数据写到新建的临时目录，输入表改为这些文件，默认写到系统临时目录的输出也改到该目录
+func prepareExample() (string, error) {
+	dir, err := os.MkdirTemp("", "gen_ll_example_")
 	if err != nil {
-		log.Printf("追加code_words_full.txt到LL.words.full.dict.yaml失败: %v", err)
-	} else if !args.Quiet {
-		log.Println("code_words_full.txt追加到LL.words.full.dict.yaml完成")
+		return "", fmt.Errorf("创建示例目录失败: %w", err)
+	}
+	inputs := []struct {
+		name string
+		path *string
+	}{
+		{"ll_div.txt", &args.Div},
+		{"ll_map.txt", &args.Map},
+		{"freq.txt", &args.Freq},
+		{"ll_words.txt", &args.Words},
+		{"linglong.txt", &args.Linglong},
+	}
+	for _, input := range inputs {
+		content, err := exampleData.ReadFile("testdata/minimal/" + input.name)
+		if err != nil {
+			return "", fmt.Errorf("读取内置示例数据失败: %w", err)
+		}
+		*input.path = filepath.Join(dir, input.name)
+		if err := os.WriteFile(*input.path, content, 0o644); err != nil {
+			return "", fmt.Errorf("写出示例数据失败: %w", err)
+		}
+	}
+	utils.RebaseTmpDefaults(&args, dir)
+	// 跟打词提以 ll_citi_pre.txt 为输入，示例中没有时用空文件
+	if _, err := os.Stat(args.CitiPre); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(args.CitiPre), 0o755); err != nil {
+			return "", fmt.Errorf("创建示例目录失败: %w", err)
+		}
+		if err := os.WriteFile(args.CitiPre, nil, 0o644); err != nil {
+			return "", fmt.Errorf("写出示例数据失败: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// charCodeMode 取字编码方式，空值按默认的 strict
+func charCodeMode(mode string) string {
+	if mode == "" {
+		return tools.CharCodeStrict
+	}
+	return mode
+}
+
+// checkStdinInputs 检查输入参数中的标准输入"-"：一次运行最多一个输入使用标准输入
+// 映射表虽被编码与字根码表多次使用，但按路径缓存只读一次，因此也可使用标准输入
+func checkStdinInputs() error {
+	inputs := []struct {
+		name  string
+		value string
+	}{
+		{"-m", args.Map},
+		{"-f", args.Freq},
+		{"-w", args.Words},
+		{"-L", args.Linglong},
+		{"-word-freq", args.WordFreq},
+	}
+	var stdinInputs []string
+	for _, path := range divPaths() {
+		if path == tools.StdinPath {
+			stdinInputs = append(stdinInputs, "-d")
+		}
+	}
+	for _, input := range inputs {
+		if input.value == tools.StdinPath {
+			stdinInputs = append(stdinInputs, input.name)
+		}
+	}
+	if len(stdinInputs) > 1 {
+		return fmt.Errorf("一次运行最多一个输入使用标准输入，当前为: %s", strings.Join(stdinInputs, " "))
+	}
+	if args.Watch && len(stdinInputs) > 0 {
+		return fmt.Errorf("-watch 不能监视标准输入: %s", strings.Join(stdinInputs, " "))
+	}
+	return nil
+}
+
+// watchInputs 监视拆分表、映射表、频率表与词表，任一文件修改后在进程内重新构建并报告耗时，直到收到 SIGINT
+// 重新构建失败只记录日志，继续监视，修好输入后自动再次构建
+func watchInputs() int {
+	var paths []string
+	for _, path := range append(divPaths(), args.Map, args.Freq, args.Words, args.Linglong) {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	log.Printf("开始监视输入文件（Ctrl+C 退出）: %s\n", strings.Join(paths, " "))
+	err := utils.WatchFiles(paths, func() {
+		log.Println("输入文件已修改，重新构建...")
+		tools.ResetRunState()
+		dryRunProblems = nil
+		startTime := utils.Now()
+		if code := generate(); code != 0 {
+			log.Printf("重新构建失败，耗时: %v，继续监视\n", utils.Since(startTime))
+			return
+		}
+		log.Printf("重新构建完成，耗时: %v\n", utils.Since(startTime))
+	})
+	if err != nil {
+		log.Printf("监视输入文件失败: %v", err)
+		return 1
+	}
+	log.Println("已停止监视")
+	return 0
+}
+
+// applyTargets 按 -targets 打开对应的生成开关，-C 与 -targets citi 等价
+// 跟打词提未开启而显式指定了其输出路径时给出警告，避免误以为文件已生成
+func applyTargets() error {
+	for _, target := range strings.Split(args.Targets, ",") {
+		switch strings.TrimSpace(target) {
+		case "":
+		case "citi":
+			args.ProcessCiti = true
+		default:
+			return fmt.Errorf("未知的生成目标: %s（可用: citi）", target)
+		}
+	}
+	if args.ProcessCiti {
+		return nil
+	}
+	for _, name := range []string{"c", "g", "z", "saima-out"} {
+		if utils.FlagPassed(name) {
+			log.Printf("警告: 指定了 -%s，但需要 -C（或 -targets citi）才会生成跟打词提相关文件\n", name)
+		}
+	}
+	return nil
+}
+
+// runLint 只读校验输入表，输出问题清单，返回值为退出码（问题数，最大125）
+func runLint() int {
+	startTime := utils.Now()
+	issues, err := tools.LintInputs(divPaths(), args.Map, args.Words)
+	if err != nil {
+		log.Printf("校验失败: %v", err)
+		return 126
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
 	}
-	
-	// 将linglong_full.txt追加到LL_linglong.full.dict.yaml（需要排序和删除词频）
 	if !args.Quiet {
-		log.Println("将linglong_full.txt追加到LL_linglong.full.dict.yaml...")
+		log.Printf("校验完成，发现 %d 个问题，耗时: %v\n", len(issues), utils.Since(startTime))
+	}
+
+	if len(issues) > 125 {
+		return 125
 	}
-	err = tools.AppendToDictFile(args.LinglongFull, filepath.Join(outputDir, "LL_linglong.full.dict.yaml"), true, true)
+	return len(issues)
+}
+
+// runSpace 查询前缀下各长度编码的占用情况与剩余空位，不写出任何文件
+func runSpace(positional []string) int {
+	if len(positional) != 1 {
+		log.Printf("用法: gen_ll space [参数] <前缀>")
+		return 1
+	}
+	prefix := positional[0]
+
+	result, err := buildResult()
 	if err != nil {
-		log.Printf("追加linglong_full.txt到LL_linglong.full.dict.yaml失败: %v", err)
-	} else if !args.Quiet {
-		log.Println("linglong_full.txt追加到LL_linglong.full.dict.yaml完成")
+		log.Printf("%v", err)
+		return 1
+	}
+	report := result.PrefixUsage(prefix)
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString(fmt.Sprintf("前缀: %s\n", report.Prefix))
+	for _, usage := range report.Lengths {
+		buffer.WriteString(fmt.Sprintf("\n[%d码] 已占用 %d，空位 %d\n", usage.Length, len(usage.Occupied), len(usage.Free)))
+		for _, codeUsage := range usage.Occupied {
+			occupants := make([]string, 0, len(codeUsage.Occupants))
+			for _, occupant := range codeUsage.Occupants {
+				occupants = append(occupants, fmt.Sprintf("%s(%s)", occupant.Text, occupant.Source))
+			}
+			buffer.WriteString(fmt.Sprintf("%s\t%s\n", codeUsage.Code, strings.Join(occupants, " ")))
+		}
+		if len(usage.Free) > 0 {
+			buffer.WriteString(fmt.Sprintf("空位: %s\n", strings.Join(usage.Free, " ")))
+		}
+	}
+	os.Stdout.Write(buffer.Bytes())
+	return 0
+}
+
+// runExplain 列出一个编码在各产物中的全部条目、来源文件与排序位置，以及候选派生编码，不写出任何文件
+// 默认读取本次参数指定的产物位置，-explain-dir 指定时按文件名读取该目录（如已部署的 Rime 用户目录）
+func runExplain(positional []string) int {
+	if len(positional) != 1 {
+		log.Printf("用法: gen_ll explain [参数] <编码>")
+		return 1
+	}
+
+	dictDir := filepath.Dir(args.Full)
+	citiPre, gendaCiti, rootsDict := args.CitiPre, args.GendaCiti, args.RootsDict
+	if args.ExplainDir != "" {
+		dictDir = args.ExplainDir
+		citiPre = filepath.Join(dictDir, filepath.Base(citiPre))
+		gendaCiti = filepath.Join(dictDir, filepath.Base(gendaCiti))
+		rootsDict = filepath.Join(dictDir, filepath.Base(rootsDict))
+	}
+	sources := []tools.ExplainSource{
+		{Name: "chars_quick", File: filepath.Join(dictDir, "LL.chars.quick.dict.yaml")},
+		{Name: "chars_full", File: filepath.Join(dictDir, "LL.chars.full.dict.yaml")},
+		{Name: "words_quick", File: filepath.Join(dictDir, "LL.words.quick.dict.yaml")},
+		{Name: "words_full", File: filepath.Join(dictDir, "LL.words.full.dict.yaml")},
+		{Name: "linglong_quick", File: filepath.Join(dictDir, "LL_linglong.quick.dict.yaml")},
+		{Name: "linglong_full", File: filepath.Join(dictDir, "LL_linglong.full.dict.yaml")},
+		{Name: "citi_pre", File: citiPre},
+		{Name: "genda", File: gendaCiti},
+		{Name: "roots", File: rootsDict, CodePrefix: args.RootsCodePrefix},
+	}
+
+	explanation, err := tools.ExplainCode(positional[0], sources)
+	if err != nil {
+		log.Printf("查询编码归属失败: %v", err)
+		return 1
+	}
+	if args.ExplainJSON {
+		content, _ := json.MarshalIndent(explanation, "", "  ")
+		os.Stdout.Write(append(content, '\n'))
+		return 0
+	}
+	os.Stdout.Write(tools.FormatCodeExplanation(explanation))
+	return 0
+}
+
+// runFmt 只用排序、去重、去频、补码逻辑清洗一份现有码表，不读取拆分表与映射表
+func runFmt() int {
+	if args.FmtIn == "" || args.FmtOut == "" {
+		log.Printf("用法: gen_ll fmt -in <码表> -out <码表> [-fmt-sort keep|code|freq] [-fmt-dedupe] [-fmt-strip-freq] [-fmt-add-candidates] [-fmt-csv]")
+		return 1
+	}
+
+	result, err := tools.FormatCodeTable(args.FmtIn, args.FmtOut, tools.FormatOptions{
+		SortBy:        args.FmtSort,
+		Dedupe:        args.FmtDedupe,
+		StripFreq:     args.FmtStripFreq,
+		AddCandidates: args.FmtAddCandidates,
+		CSV:           args.FmtCSV,
+		CodeMaxLength: args.GendaCodeMaxLength,
+		CodeOverflow:  args.GendaCodeOverflow,
+	})
+	if err != nil {
+		log.Printf("清洗码表失败: %v", err)
+		return 1
 	}
-	
-	// 将linglong_simp.txt追加到LL_linglong.quick.dict.yaml（需要排序和删除词频）
 	if !args.Quiet {
-		log.Println("将linglong_simp.txt追加到LL_linglong.quick.dict.yaml...")
+		log.Printf("码表清洗完成: 读入 %d 项，去重 %d 项，写出 %d 项: %s\n", result.Read, result.Duplicates, result.Written, args.FmtOut)
+	}
+	if result.CodeTooLong > 0 {
+		log.Printf("警告: %d 条编码受最大长度 %d 限制，未写出", result.CodeTooLong, args.GendaCodeMaxLength)
+	}
+	return 0
+}
+
+// runSuggest 按字频列出各简码前缀的候选字与当前实际拿到简码的字，供人工决定简码，不写出任何文件
+func runSuggest() int {
+	result, err := buildResult()
+	if err != nil {
+		log.Printf("%v", err)
+		return 1
 	}
-	err = tools.AppendToDictFile(args.LinglongSimple, filepath.Join(outputDir, "LL_linglong.quick.dict.yaml"), true, true)
+	suggestions, err := result.SuggestSimpleCodes(args.SuggestLevel, args.SuggestTop)
 	if err != nil {
-		log.Printf("追加linglong_simp.txt到LL_linglong.quick.dict.yaml失败: %v", err)
-	} else if !args.Quiet {
-		log.Println("linglong_simp.txt追加到LL_linglong.quick.dict.yaml完成")
+		log.Printf("生成简码推荐失败: %v", err)
+		return 1
+	}
+	os.Stdout.Write(tools.FormatSimpleCodeSuggestions(suggestions, args.SuggestLevel))
+	return 0
+}
+
+// buildResult 加载输入表并构建全部编码数据，不写出任何文件
+func buildResult() (*tools.Result, error) {
+	// 解析简码长度限制
+	lenCodeLimit, err := tools.ParseLenCodeLimit(args.LenCodeLimit)
+	if err != nil {
+		return nil, fmt.Errorf("解析单字简码长度限制失败: %w", err)
+	}
+
+	// 解析多字词简码长度限制
+	wordsLenCodeLimit, err := tools.ParseLenCodeLimit(args.WordsLenCodeLimit)
+	if err != nil {
+		return nil, fmt.Errorf("解析多字词简码长度限制失败: %w", err)
+	}
+	switch args.WordsPlaceholderSpace {
+	case "", tools.PlaceholderSpaceAll, tools.PlaceholderSpaceUsed:
+	default:
+		return nil, fmt.Errorf("未知的多字词简码占位符补位空间: %s", args.WordsPlaceholderSpace)
+	}
+
+	// 解析玲珑多字词简码长度限制
+	linglongLenCodeLimit, err := tools.ParseLenCodeLimit(args.LinglongLenCodeLimit)
+	if err != nil {
+		return nil, fmt.Errorf("解析玲珑多字词简码长度限制失败: %w", err)
+	}
+	for _, charCode := range []struct{ name, mode string }{{"-words-char-code", args.WordsCharCode}, {"-linglong-char-code", args.LinglongCharCode}} {
+		switch charCode.mode {
+		case "", tools.CharCodeStrict, tools.CharCodeFallbackSecondary:
+		default:
+			return nil, fmt.Errorf("未知的取字编码方式 %s %s（可选 %s、%s）", charCode.name, charCode.mode, tools.CharCodeStrict, tools.CharCodeFallbackSecondary)
+		}
+	}
+
+	// 加载体验规则，未指定规则文件时使用默认规则集
+	rules := tools.DefaultExperienceRules()
+	if args.RulesFile != "" {
+		rules, err = tools.ReadExperienceRules(args.RulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取体验规则失败: %w", err)
+		}
+	}
+	if args.Debug {
+		for _, rule := range rules.Rules {
+			log.Printf("体验规则: %s\n", rule)
+		}
+	}
+
+	if err := checkMemory("加载表格数据"); err != nil {
+		return nil, err
 	}
-	
-	// 生成字根码表并追加到LL.roots.dict.yaml
 	if !args.Quiet {
-		log.Println("开始生成字根码表...")
+		log.Println("开始加载表格数据...")
+	}
+
+	// 指定了已有的单字全码表时不读取拆分表与映射表
+	if args.CharsFrom != "" && (args.WordCodeFromRadicals || args.RootFreqOut != "" || args.GraphOut != "") {
+		return nil, fmt.Errorf("-chars-from 不读取拆分表与映射表，不能与 -word-code-from-radicals、-root-freq-out、-graph-out 同时使用")
 	}
-	err = tools.GenerateRootsDict(args.Map, args.RootsDict)
+	var divTable map[string][]*types.Division
+	var compMap map[string]string
+	if args.CharsFrom == "" {
+		divTable, compMap, err = readDivisionAndMap()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	charFreq, err := tools.ReadCharFreq(args.Freq, tools.CharFreqOptions{KeepWords: args.FreqWordsAsWeight})
 	if err != nil {
-		log.Printf("生成字根码表失败: %v", err)
-	} else if !args.Quiet {
-		log.Printf("字根码表生成完成: %s\n", args.RootsDict)
+		return nil, fmt.Errorf("读取频率表失败: %w", err)
+	}
+	freqSet := charFreq.Chars
+	if !args.Quiet {
+		log.Printf("频率表加载完成，共 %d 项，跳过多字条目 %d 项\n", len(freqSet), charFreq.WordLines)
 	}
 
-	// 在追加完所有字典文件后生成 preset_data.txt
+	if err := checkMemory("构建编码数据"); err != nil {
+		return nil, err
+	}
 	if !args.Quiet {
-		log.Println("开始生成 preset_data.txt...")
+		log.Println("开始构建编码数据...")
+	}
+
+	buildStartTime := utils.Now()
+	var fullCodeMetaList []*types.CharMeta
+	if args.CharsFrom != "" {
+		fullCodeMetaList, err = tools.ReadCharCodeList(args.CharsFrom)
+		if err != nil {
+			return nil, fmt.Errorf("读取单字全码表失败: %w", err)
+		}
+		if !args.Quiet {
+			log.Printf("单字全码表加载完成，跳过单字构建: %s\n", args.CharsFrom)
+		}
+	} else {
+		fullCodeMetaList = buildFullCodeMetaList(divTable, compMap, freqSet)
+	}
+
+	// 部件图：直接取拆分表的主拆分与字频表
+	if args.GraphOut != "" {
+		graph := tools.BuildRootGraph(divTable, freqSet, compMap, args.GraphTopEdges)
+		if err := tools.WriteRootGraph(args.GraphOut, graph, args.GraphFormat); err != nil {
+			log.Printf("写入部件图失败: %v", err)
+		} else if !args.Quiet {
+			log.Printf("部件图写入完成: %s（节点 %d 个，边 %d/%d 条）\n", args.GraphOut, len(graph.Nodes), len(graph.Edges), graph.TotalEdges)
+		}
+	}
+
+	if !args.Quiet {
+		log.Printf("构建完成，耗时: %v\n", utils.Since(buildStartTime))
+		log.Printf("fullCodeMetaList: %d\n", len(fullCodeMetaList))
+		log.Println("开始写入文件...")
 	}
-	presetDataLines, err := tools.BuildPresetData(simpleCodeList, fullCodeMetaList)
+
+	invisibleOpts, err := parseInvisibleOptions()
 	if err != nil {
-		log.Printf("生成 preset_data.txt 失败: %v", err)
-	} else if !args.Quiet {
-		log.Printf("preset_data.txt 生成完成，共 %d 项\n", len(presetDataLines))
+		return nil, err
+	}
+	wordsFileOpts := tools.WordsFileOptions{
+		SortByWeight:    args.WordsSortByWeight,
+		FallbackWeights: charFreq.Words,
+		DefaultWeight:   args.WordFreqDefault,
+		Invisible:       invisibleOpts,
+	}
+	if args.WordFreq != "" {
+		wordFreq, err := tools.ReadWordFreq(args.WordFreq)
+		if err != nil {
+			return nil, fmt.Errorf("读取词频表失败: %w", err)
+		}
+		// 频率表中的多字条目只补词频表没有的词
+		for word, freq := range charFreq.Words {
+			if _, exists := wordFreq[word]; !exists {
+				wordFreq[word] = freq
+			}
+		}
+		wordsFileOpts.FallbackWeights = wordFreq
+		if !args.Quiet {
+			log.Printf("词频表加载完成，共 %d 项\n", len(wordFreq))
+		}
+	}
+	wordsFullCodeOpts := tools.WordsFullCodeOptions{
+		SingleCharFullCode: args.WordSingleCharFullCode,
+		Uppercase:          args.WordCodeUppercase,
+	}
+	if args.WordCodeFromRadicals {
+		if args.RadicalMap == "" {
+			return nil, fmt.Errorf("-word-code-from-radicals 需要指定 -radical-map")
+		}
+		radicalMap, err := tools.ReadRadicalMap(args.RadicalMap)
+		if err != nil {
+			return nil, fmt.Errorf("读取部首编码表失败: %w", err)
+		}
+		wordsFullCodeOpts.CharRadicals = tools.CreateCharRadicalMap(fullCodeMetaList, radicalMap)
+		if !args.Quiet {
+			log.Printf("部首取码（实验）: %d 字有部首编码\n", len(wordsFullCodeOpts.CharRadicals))
+		}
+	}
+
+	// 多字词与玲珑词各按自己的方式取字编码，同一方式的映射只构建一次
+	charCodeMaps := tools.NewCharCodeMaps(fullCodeMetaList)
+	if wordsMode, linglongMode := charCodeMode(args.WordsCharCode), charCodeMode(args.LinglongCharCode); wordsMode != linglongMode && !args.Quiet {
+		strictMap, err := charCodeMaps.Get(tools.CharCodeStrict)
+		if err != nil {
+			return nil, err
+		}
+		fallbackMap, err := charCodeMaps.Get(tools.CharCodeFallbackSecondary)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("多字词与玲珑词取字编码方式不同: 多字词 %s，玲珑词 %s；次拆分回退补入 %d 字\n", wordsMode, linglongMode, len(fallbackMap)-len(strictMap))
 	}
 
-	// 写入 preset_data.txt
+	if err := checkMemory("多字词"); err != nil {
+		return nil, err
+	}
+	// 读取多字词文件并生成多字词全码和简码
+	var wordCodes []*types.WordCode
+	var wordSimpleCodes []*types.WordSimpleCode
 	if !args.Quiet {
-		log.Println("开始写入 preset_data.txt...")
+		log.Println("开始读取多字词文件...")
+	}
+	wordEntries, weightReport, err := tools.ReadWordsFile(args.Words, wordsFileOpts)
+	if err != nil {
+		if err := skipWordsResult("多字词", fmt.Sprintf("读取多字词文件失败: %v", err)); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, lineErr := range weightReport.InvisibleLines {
+			log.Printf("警告: 多字词文件: %v\n", lineErr)
+		}
+		if err := checkPlaceholderWords("多字词", weightReport); err != nil {
+			return nil, err
+		}
+		if !args.Quiet {
+			log.Printf("多字词文件加载完成，共 %d 项\n", len(wordEntries))
+			if wordsFileOpts.FallbackWeights != nil {
+				log.Printf("多字词权重补全: 缺权重 %d 项，查表补全 %d 项（命中率 %.1f%%）\n", weightReport.Missing, weightReport.Filled, weightReport.HitRate())
+			}
+			log.Println("开始生成多字词全码...")
+		}
+
+		// 创建字符编码映射
+		charCodeMap, err := charCodeMaps.Get(args.WordsCharCode)
+		if err != nil {
+			return nil, err
+		}
+
+		// 生成多字词全码
+		var report *tools.WordsCodeReport
+		wordCodes, report = tools.BuildWordsFullCode(wordEntries, charCodeMap, wordsFullCodeOpts)
+		logWordsCodeReport("多字词", report)
+
+		if !args.Quiet {
+			log.Printf("多字词全码生成完成，共 %d 项\n", len(wordCodes))
+		}
+
+		// 一条都编不出来时与读取失败一样按无结果处理（wordCodes 为 nil），不写出空文件
+		if len(wordCodes) == 0 {
+			wordCodes = nil
+			if err := skipWordsResult("多字词", fmt.Sprintf("多字词文件 %s 没有生成任何全码", args.Words)); err != nil {
+				return nil, err
+			}
+		} else if !args.NoSimp {
+			// 生成多字词简码，纯全码版本跳过
+			if !args.Quiet {
+				log.Println("开始生成多字词简码...")
+			}
+			wordSimpleCodes = tools.BuildWordsSimpleCode(wordCodes, wordsLenCodeLimit, tools.WordsSimpleCodeOptions{
+				PlaceholderSpace: args.WordsPlaceholderSpace,
+			})
+			if !args.Quiet {
+				log.Printf("多字词简码生成完成，共 %d 项\n", len(wordSimpleCodes))
+			}
+		}
+	}
+
+	if err := checkMemory("玲珑多字词"); err != nil {
+		return nil, err
+	}
+	// 读取玲珑多字词文件并生成玲珑多字词全码和简码
+	var linglongCodes []*types.WordCode
+	var linglongSimpleCodes []*types.WordSimpleCode
+	if !args.Quiet {
+		log.Println("开始读取玲珑多字词文件...")
+	}
+	linglongEntries, weightReport, err := tools.ReadWordsFile(args.Linglong, wordsFileOpts)
+	if err != nil {
+		if err := skipWordsResult("玲珑多字词", fmt.Sprintf("读取玲珑多字词文件失败: %v", err)); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, lineErr := range weightReport.InvisibleLines {
+			log.Printf("警告: 玲珑多字词文件: %v\n", lineErr)
+		}
+		if err := checkPlaceholderWords("玲珑多字词", weightReport); err != nil {
+			return nil, err
+		}
+		if !args.Quiet {
+			log.Printf("玲珑多字词文件加载完成，共 %d 项\n", len(linglongEntries))
+			if wordsFileOpts.FallbackWeights != nil {
+				log.Printf("玲珑多字词权重补全: 缺权重 %d 项，查表补全 %d 项（命中率 %.1f%%）\n", weightReport.Missing, weightReport.Filled, weightReport.HitRate())
+			}
+			log.Println("开始生成玲珑多字词全码...")
+		}
+
+		// 创建字符编码映射
+		charCodeMap, err := charCodeMaps.Get(args.LinglongCharCode)
+		if err != nil {
+			return nil, err
+		}
+
+		// 生成玲珑多字词全码
+		var report *tools.WordsCodeReport
+		linglongCodes, report = tools.BuildWordsFullCode(linglongEntries, charCodeMap, wordsFullCodeOpts)
+		logWordsCodeReport("玲珑多字词", report)
+
+		if !args.Quiet {
+			log.Printf("玲珑多字词全码生成完成，共 %d 项\n", len(linglongCodes))
+		}
+
+		if len(linglongCodes) == 0 {
+			linglongCodes = nil
+			if err := skipWordsResult("玲珑多字词", fmt.Sprintf("玲珑多字词文件 %s 没有生成任何全码", args.Linglong)); err != nil {
+				return nil, err
+			}
+		} else if !args.NoSimp {
+			// 生成玲珑多字词简码（不添加占位符），纯全码版本跳过
+			if !args.Quiet {
+				log.Println("开始生成玲珑多字词简码...")
+			}
+			linglongSimpleCodes = tools.BuildLinglongSimpleCode(linglongCodes, linglongLenCodeLimit)
+			if !args.Quiet {
+				log.Printf("玲珑多字词简码生成完成，共 %d 项\n", len(linglongSimpleCodes))
+			}
+		}
+	}
+
+	// 多字词与玲珑词字词、编码完全相同时两个字典都会收录，Rime 中出现重复候选
+	if args.WordDupReport != "" || args.WordDupLinglongFirst {
+		duplicates := tools.FindWordCodeDuplicates(wordCodes, linglongCodes)
+		if args.WordDupReport != "" {
+			if err := tools.WriteWordCodeDuplicates(args.WordDupReport, duplicates); err != nil {
+				log.Printf("写入词条重复编码清单失败: %v", err)
+			} else if !args.Quiet {
+				log.Printf("词条重复编码清单写入完成: %s（%d 项）\n", args.WordDupReport, len(duplicates))
+			}
+		}
+		if args.WordDupLinglongFirst && len(duplicates) > 0 {
+			before := len(wordCodes)
+			wordCodes = tools.RemoveWordCodeDuplicates(wordCodes, duplicates)
+			if !args.Quiet {
+				log.Printf("玲珑优先去重: 多字词全码去掉 %d 项\n", before-len(wordCodes))
+			}
+		}
+	}
+
+	// 生成简码表，纯全码版本跳过（simpleCodeList 为 nil）
+	var simpleCodeList []*types.CharMeta
+	if !args.NoSimp {
+		if !args.Quiet {
+			log.Println("开始生成简码表...")
+		}
+		noSimplifyChars := rules.NoSimpChars() // 不出简的字符列表
+		simpleCodeList = tools.BuildSimpleCodeList(fullCodeMetaList, lenCodeLimit, noSimplifyChars)
+		tools.FillSimpCodes(fullCodeMetaList, simpleCodeList)
+
+		if !args.Quiet {
+			log.Printf("简码表生成完成，共 %d 项\n", len(simpleCodeList))
+		}
+	}
+
+	return &tools.Result{
+		FullCodeMetaList:    fullCodeMetaList,
+		SimpleCodeList:      simpleCodeList,
+		WordCodes:           wordCodes,
+		WordSimpleCodes:     wordSimpleCodes,
+		LinglongCodes:       linglongCodes,
+		LinglongSimpleCodes: linglongSimpleCodes,
+		CompMap:             compMap,
+		Rules:               rules,
+	}, nil
+}
+
+// memoryDegraded 内存水位超过 -mem-limit-mb 后已降级
+var memoryDegraded bool
+
+// checkMemory 在大阶段开始前检查内存水位：超过上限时先归还空闲内存再复查，仍超过则降级并警告
+// 已降级仍超过时返回错误，由调用方带提示退出，避免被系统 OOM 强杀而没有任何提示
+func checkMemory(stage string) error {
+	if args.MemLimitMB <= 0 {
+		return nil
+	}
+	limit := uint64(args.MemLimitMB) << 20
+	if tools.MemoryInUse() <= limit {
+		return nil
+	}
+	debug.FreeOSMemory()
+	inUse := tools.MemoryInUse()
+	if inUse <= limit {
+		return nil
+	}
+	if memoryDegraded {
+		return fmt.Errorf("%s前内存占用 %dMB 超过上限 %dMB，已降级仍无法满足，请调大 -mem-limit-mb 或缩小词库", stage, inUse>>20, args.MemLimitMB)
+	}
+	memoryDegraded = true
+	tools.SetConcurrency(1)
+	tools.SetStreamReadThreshold(0)
+	tools.SetStreamWrite(true)
+	args.WordsPlaceholderSpace = tools.PlaceholderSpaceUsed
+	log.Printf("警告: %s前内存占用 %dMB 超过上限 %dMB，已降级：单协程构建、流式读写、词简码占位符只补规则算出的码位\n", stage, inUse>>20, args.MemLimitMB)
+	return nil
+}
+
+// writeMemProfile 先做一次垃圾回收使堆统计反映当前存活对象，再写出堆快照
+func writeMemProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// logMemStats 输出运行结束时的内存统计
+func logMemStats() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	log.Printf("内存统计: Alloc %dMB，TotalAlloc %dMB，HeapObjects %d，NumGC %d\n", stats.Alloc>>20, stats.TotalAlloc>>20, stats.HeapObjects, stats.NumGC)
+}
+
+// generateRootsDict 生成字根码表并追加到 -R 指定的文件，预览时追加到预览目录中的副本，返回实际追加的文件
+func generateRootsDict(display *tools.DisplayReplacer) (string, error) {
+	rootsDict := args.RootsDict
+	if args.Preview != "" {
+		rootsDict = tools.PreviewPath(args.Preview, args.RootsDict)
+		if err := tools.CopyPreviewFile(args.RootsDict, rootsDict); err != nil {
+			return "", err
+		}
+	}
+	return rootsDict, tools.GenerateRootsDict(args.Map, rootsDict, tools.RootsDictOptions{
+		NoteMode:   args.RootsNote,
+		NoteFile:   args.RootsNoteOut,
+		Display:    display,
+		SkipNonCJK: args.RootsSkipNonCJK,
+		CodePrefix: args.RootsCodePrefix,
+	})
+}
+
+// checkPreviewDir 预览目录不能与输出目录相同，否则预览文件会覆盖输出目录中的字典；空跑不写文件，不能与预览同用
+func checkPreviewDir() error {
+	if args.Preview == "" {
+		return nil
+	}
+	if args.DryRun {
+		return fmt.Errorf("-preview 不能与 -n 同用")
+	}
+	previewDir, err := filepath.Abs(args.Preview)
+	if err != nil {
+		return fmt.Errorf("解析预览目录失败: %w", err)
+	}
+	for _, file := range []string{args.Full, args.RootsDict} {
+		outputDir, err := filepath.Abs(filepath.Dir(file))
+		if err != nil {
+			return fmt.Errorf("解析输出目录失败: %w", err)
+		}
+		if previewDir == outputDir {
+			return fmt.Errorf("预览目录不能是输出目录: %s", args.Preview)
+		}
+	}
+	return nil
+}
+
+// divPaths 拆分表文件列表：-d 按逗号分隔，忽略空项
+func divPaths() []string {
+	var paths []string
+	for _, path := range strings.Split(args.Div, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// readDivisionAndMap 读取拆分表与映射表，并校验拆分部件都在映射表中定义
+func readDivisionAndMap() (map[string][]*types.Division, map[string]string, error) {
+	invisibleOpts, err := parseInvisibleOptions()
+	if err != nil {
+		return nil, nil, err
+	}
+	divTable, overridden, issues, err := tools.ReadDivisionTable(divPaths(), tools.DivisionTableOptions{
+		InferUnicode:     args.DivInferUnicode,
+		CharLimit:        args.DivCharLimit,
+		ValidateEncoding: args.DivEncodingValidate,
+		Strict:           args.Strict,
+		Invisible:        invisibleOpts,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取拆分表失败: %w", err)
+	}
+	for _, lineErr := range issues {
+		log.Printf("警告: 拆分表: %v\n", lineErr)
+	}
+	if len(overridden) > 0 && !args.Quiet {
+		log.Printf("拆分表合并: 后面的文件替换了 %d 个字的拆分\n", len(overridden))
 	}
-	err = os.WriteFile(args.PresetData, []byte(strings.Join(presetDataLines, "\n")), 0o644)
+	if len(overridden) > 0 && args.Debug {
+		log.Printf("替换拆分的字: %s\n", strings.Join(overridden, " "))
+	}
+	if !args.Quiet {
+		log.Printf("拆分表加载完成，共 %d 项\n", len(divTable))
+	}
+
+	compIndex, err := tools.ReadCompMapIndexed(args.Map)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取映射表失败: %w", err)
+	}
+	if args.Strict {
+		if err := compIndex.ConflictError(); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, dup := range compIndex.Duplicates {
+		log.Printf("警告: %s（以最后一行为准）\n", dup)
+	}
+	compMap := compIndex.CompCode
+	if !args.Quiet {
+		log.Printf("映射表加载完成，共 %d 项\n", len(compMap))
+	}
+	if args.Debug {
+		for _, comp := range compIndex.Comps {
+			log.Printf("字根映射: %s\t%s\n", comp, compMap[comp])
+		}
+		keys := make([]string, 0, len(compIndex.KeyComps))
+		for key := range compIndex.KeyComps {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			log.Printf("键位字根: %s\t%s\n", key, strings.Join(compIndex.KeyComps[key], " "))
+		}
+	}
+
+	// 验证拆分部件是否在映射表中定义
+	if !args.Quiet {
+		log.Println("开始验证拆分部件...")
+	}
+	// 空跑时校验问题不中断，记下后照常构建（无法构造的全码条目会被跳过），最后一并汇总
+	if err := tools.ValidateDivisionComponents(divTable, compMap); err != nil {
+		if !args.DryRun {
+			return nil, nil, fmt.Errorf("验证失败: %w", err)
+		}
+		dryRunProblems = append(dryRunProblems, err.Error())
+	} else if !args.Quiet {
+		log.Println("拆分部件验证通过")
+	}
+	if args.CheckPrefixFree {
+		if err := tools.ValidatePrefixFree(compMap); err != nil {
+			if !args.DryRun {
+				return nil, nil, fmt.Errorf("验证失败: %w", err)
+			}
+			dryRunProblems = append(dryRunProblems, err.Error())
+		} else if !args.Quiet {
+			log.Println("映射表互斥前缀校验通过")
+		}
+	}
+
+	return divTable, compMap, nil
+}
+
+// buildFullCodeMetaList 由拆分表与映射表构建单字全码列表，默认同字同码去重
+func buildFullCodeMetaList(divTable map[string][]*types.Division, compMap map[string]string, freqSet map[string]int64) []*types.CharMeta {
+	fullCodeMetaList, skipped := tools.BuildFullCodeMetaList(divTable, compMap, freqSet)
+	for _, err := range skipped {
+		log.Printf("警告: 跳过无法构造的全码条目: %v", err)
+	}
+	if !args.FullCodeKeepDuplicates {
+		var removed int
+		fullCodeMetaList, removed = tools.DedupFullCodeMetaList(fullCodeMetaList)
+		if !args.Quiet {
+			log.Printf("全码同字同码去重，共去除 %d 条\n", removed)
+		}
+	}
+	return fullCodeMetaList
+}
+
+// prepareOutputDirs 构建前检查本次会写出的全部输出与部署目录，缺少的按需创建
+// 任一目录不可用时返回列出全部问题目录与当前用户的错误，不创建任何目录
+func prepareOutputDirs(templateSpecs []*tools.TemplateSpec) error {
+	files := []string{
+		args.Full, args.Opencc, args.Simple, args.WordsFull, args.WordsSimple, args.LinglongFull, args.LinglongSimple,
+		args.DazhuChai, args.CitiPre, args.GendaCiti, args.DazhuCode, args.PresetData, args.RootsDict,
+	}
+	if args.RootsNote == tools.RootsNoteFile {
+		files = append(files, args.RootsNoteOut)
+	}
+	if args.ProcessCiti {
+		files = append(files, args.SaimaOut)
+	}
+	if args.EquivTable != "" {
+		files = append(files, args.StatsJSON)
+	}
+	for _, templateSpec := range templateSpecs {
+		files = append(files, templateSpec.Output)
+	}
+	files = append(files, args.RootFreqOut, args.GraphOut, args.ConflictReport, args.WordDupReport, args.ChangelogOut, args.Fcitx5Out, args.SQLiteOut, args.ReverseDict, args.OutputManifest)
+
+	dirs := make([]string, 0, len(files)+3)
+	for _, file := range files {
+		if file != "" {
+			dirs = append(dirs, filepath.Dir(file))
+		}
+	}
+	// 部署与同文导出目录本身也要可写，preset_data 部署在 lua/chars_cand/ 下
+	if args.Deploy != "" {
+		dirs = append(dirs, args.Deploy)
+		if !args.NoSimp {
+			dirs = append(dirs, filepath.Join(args.Deploy, "lua", "chars_cand"))
+		}
+	}
+	if args.TrimeOut != "" {
+		dirs = append(dirs, args.TrimeOut)
+	}
+	if args.Preview != "" {
+		dirs = append(dirs, args.Preview)
+	}
+	if args.JSONOut != "" {
+		dirs = append(dirs, args.JSONOut)
+	}
+
+	err := tools.PrepareOutputDirs(dirs, tools.OutputDirOptions{NoCreate: args.NoCreateDirs, DryRun: args.DryRun})
+	var outputErr *tools.OutputDirError
+	if errors.As(err, &outputErr) {
+		hint := "请检查目录权限，或把对应输出改到可写的位置"
+		if outputErr.Missing {
+			hint = "请先创建缺少的目录，或去掉 -no-create-dirs 自动创建；" + hint
+		}
+		return fmt.Errorf("%w\n%s", err, hint)
+	}
+	return err
+}
+
+// skipSideOutputs 空跑时关掉构建过程中顺带写出的报告与导出，返回被关掉的参数名
+func skipSideOutputs() []string {
+	outputs := []struct {
+		name  string
+		value *string
+	}{
+		{"-root-freq-out", &args.RootFreqOut},
+		{"-graph-out", &args.GraphOut},
+		{"-conflict-report", &args.ConflictReport},
+		{"-word-dup-report", &args.WordDupReport},
+		{"-stats-json", &args.StatsJSON},
+		{"-changelog-out", &args.ChangelogOut},
+		{"-fcitx5-out", &args.Fcitx5Out},
+		{"-json-out", &args.JSONOut},
+		{"-sqlite", &args.SQLiteOut},
+		{"-reverse-dict", &args.ReverseDict},
+	}
+	var skipped []string
+	for _, output := range outputs {
+		if *output.value != "" {
+			skipped = append(skipped, output.name)
+			*output.value = ""
+		}
+	}
+	return skipped
+}
+
+// dryRunProblems 空跑中记下而未中断构建的校验问题
+var dryRunProblems []string
+
+// reportDryRun 输出空跑汇总：各码表本应写出的条目数与校验问题，有校验问题时退出码为 1
+func reportDryRun() int {
+	buffer := bytes.Buffer{}
+	buffer.WriteString("空跑汇总（未写出任何文件）:\n")
+	for _, output := range tools.DryRunOutputs() {
+		buffer.WriteString(fmt.Sprintf("%s\t%d\n", output.Path, output.Lines))
+	}
+	if len(dryRunProblems) == 0 {
+		buffer.WriteString("校验通过\n")
+		os.Stdout.Write(buffer.Bytes())
+		return 0
+	}
+	buffer.WriteString(fmt.Sprintf("校验问题 %d 项:\n", len(dryRunProblems)))
+	for _, problem := range dryRunProblems {
+		buffer.WriteString(problem + "\n")
+	}
+	os.Stdout.Write(buffer.Bytes())
+	return 1
+}
+
+// printOutputDiff 把本次生成的各码表与磁盘上已有的输出文件比较，差异与汇总写到标准输出
+// 本次没有结果（如词表被跳过、纯全码版本的简码表）或已有文件不存在的码表不比较
+func printOutputDiff(result *tools.Result) error {
+	tables := []struct {
+		path string
+		list []*types.CharMeta
+	}{
+		{args.Full, result.FullCodeMetaList},
+		{args.Simple, result.SimpleCodeList},
+		{args.WordsFull, tools.WordCodeMetas(result.WordCodes)},
+		{args.WordsSimple, tools.WordSimpleCodeMetas(result.WordSimpleCodes)},
+		{args.LinglongFull, tools.WordCodeMetas(result.LinglongCodes)},
+		{args.LinglongSimple, tools.WordSimpleCodeMetas(result.LinglongSimpleCodes)},
+	}
+	var added, removed, modified int
+	for _, table := range tables {
+		if len(table.list) == 0 {
+			continue
+		}
+		if _, err := os.Stat(table.path); os.IsNotExist(err) {
+			if !args.Quiet {
+				log.Printf("比较输出: %s 不存在，跳过\n", table.path)
+			}
+			continue
+		}
+		oldList, err := tools.ReadCodeTableEntries(table.path)
+		if err != nil {
+			return fmt.Errorf("读取上次输出失败: %w", err)
+		}
+		diff := tools.DiffCodeTables(oldList, table.list)
+		os.Stdout.WriteString(fmt.Sprintf("=== %s\n", table.path))
+		os.Stdout.Write(diff.Format())
+		os.Stdout.WriteString(fmt.Sprintf("=== 新增 %d，删除 %d，修改 %d\n", diff.Added, diff.Removed, diff.Modified))
+		added += diff.Added
+		removed += diff.Removed
+		modified += diff.Modified
+	}
+	os.Stdout.WriteString(fmt.Sprintf("合计: 新增 %d，删除 %d，修改 %d\n", added, removed, modified))
+	return nil
+}
+
+// writeChangelog 比较上一版码表，输出面向用户的编码变更公告
+func writeChangelog(result *tools.Result) error {
+	if args.ChangelogOldFull == "" || args.ChangelogOldSimp == "" {
+		return fmt.Errorf("生成编码变更公告需要指定 -changelog-old-full 与 -changelog-old-simp")
+	}
+	oldTable, err := tools.ReadCodeTable(args.ChangelogOldFull, args.ChangelogOldSimp)
 	if err != nil {
-		log.Printf("写入 preset_data.txt 失败: %v", err)
+		return fmt.Errorf("读取上一版码表失败: %w", err)
+	}
+
+	opts := tools.ChangelogOptions{Top: args.ChangelogTop}
+	if args.ChangelogChars != "" {
+		if opts.Chars, err = tools.ReadCharset(args.ChangelogChars); err != nil {
+			return fmt.Errorf("读取常用字清单失败: %w", err)
+		}
+	}
+	if args.ChangelogOldDiv != "" {
+		if opts.OldDivision, _, _, err = tools.ReadDivisionTable([]string{args.ChangelogOldDiv}, tools.DivisionTableOptions{}); err != nil {
+			return fmt.Errorf("读取上一版拆分表失败: %w", err)
+		}
+	}
+	if args.ChangelogOldMap != "" {
+		if opts.OldCompMap, _, err = tools.ReadCompMap(args.ChangelogOldMap); err != nil {
+			return fmt.Errorf("读取上一版映射表失败: %w", err)
+		}
+	}
+
+	changes := tools.BuildCodeChangelog(oldTable, result.FullCodeMetaList, result.SimpleCodeList, result.CompMap, opts)
+	if err := tools.WriteCodeChangelog(args.ChangelogOut, changes); err != nil {
+		log.Printf("写入编码变更公告失败: %v", err)
 	} else if !args.Quiet {
-		log.Printf("preset_data.txt 写入完成: %s\n", args.PresetData)
+		log.Printf("编码变更公告写入完成: %s（%d 字有变化）\n", args.ChangelogOut, len(changes))
+	}
+	return nil
+}
+
+// skipWordsResult 多字词或玲珑词没有结果（读取失败或一条都编不出来）时给出原因
+// 默认只警告，对应的全码、简码表不写出也不追加到字典；-words-strict 下返回错误
+func skipWordsResult(name string, reason string) error {
+	if args.WordsStrict {
+		return fmt.Errorf("%s: %s", name, reason)
+	}
+	log.Printf("警告: %s，跳过%s码表的写出与字典追加", reason, name)
+	return nil
+}
+
+// parseInvisibleOptions 解析拆分表与词表的不可见字符检测选项
+func parseInvisibleOptions() (tools.InvisibleOptions, error) {
+	if err := tools.ValidateInvisibleMode(args.InvisibleMode); err != nil {
+		return tools.InvisibleOptions{}, err
+	}
+	chars, err := tools.ParseInvisibleChars(args.InvisibleChars)
+	if err != nil {
+		return tools.InvisibleOptions{}, err
+	}
+	return tools.InvisibleOptions{Mode: args.InvisibleMode, Chars: chars}, nil
+}
+
+// checkPlaceholderWords 词表中与简码占位符字符相同的词条：逐条警告，未指定 -words-allow-placeholder 时返回要求改词的错误
+func checkPlaceholderWords(name string, report *tools.WordWeightReport) error {
+	if len(report.PlaceholderWords) == 0 {
+		return nil
+	}
+	for _, lineErr := range report.PlaceholderWords {
+		log.Printf("警告: %v\n", lineErr)
+	}
+	if !args.WordsAllowPlaceholder {
+		return fmt.Errorf("%s文件中有 %d 个词条与简码占位符（①至⑩）相同，请改词，或指定 -words-allow-placeholder 照常收录", name, len(report.PlaceholderWords))
 	}
+	return nil
 }
 
-// 确保输出目录存在
-func ensureOutputDir(path string) {
-	dir := filepath.Dir(path)
-	if dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			log.Fatalf("无法创建目录 %s: %v", dir, err)
+// logWordsCodeReport 输出词全码生成中被跳过的词条与警告
+// 非调试模式下警告只列出前几项，避免大词库刷屏
+func logWordsCodeReport(name string, report *tools.WordsCodeReport) {
+	const maxShown = 5
+	for i, warning := range report.Warnings {
+		if i >= maxShown && !args.Debug {
+			log.Printf("警告: %s共 %d 条警告，其余省略（-D 查看全部）\n", name, len(report.Warnings))
+			break
+		}
+		log.Printf("警告: %s%s\n", name, warning)
+	}
+	if len(report.Skipped) == 0 {
+		return
+	}
+	reasons := make(map[string]int)
+	for _, skipped := range report.Skipped {
+		reasons[skipped.Reason]++
+		if args.Debug {
+			log.Printf("跳过%s: %s（%s）\n", name, skipped.Word, skipped.Reason)
+		}
+	}
+	if !args.Quiet {
+		keys := make([]string, 0, len(reasons))
+		for reason := range reasons {
+			keys = append(keys, reason)
+		}
+		sort.Strings(keys)
+		for _, reason := range keys {
+			log.Printf("%s全码跳过 %d 项: %s\n", name, reasons[reason], reason)
 		}
 	}
 }
 
 // logWriter 自定义日志写入器，格式与Shell脚本保持一致
-type logWriter struct{}
+// 多行日志（如内嵌换行的错误）每行都带时间戳前缀，便于按行过滤与对齐其它系统日志
+type logWriter struct {
+	timeFormat string // 时间戳格式，为空不输出时间戳
+	utc        bool   // 时间戳使用 UTC
+}
 
-func (writer logWriter) Write(bytes []byte) (int, error) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	return fmt.Printf("[%s] %s", timestamp, string(bytes))
+func (writer *logWriter) Write(bytes []byte) (int, error) {
+	prefix := ""
+	if writer.timeFormat != "" {
+		now := time.Now()
+		if writer.utc {
+			now = now.UTC()
+		}
+		prefix = "[" + now.Format(writer.timeFormat) + "] "
+	}
+	lines := strings.SplitAfter(string(bytes), "\n")
+	var buffer strings.Builder
+	for _, line := range lines {
+		if line != "" {
+			buffer.WriteString(prefix + line)
+		}
+	}
+	if _, err := os.Stdout.WriteString(buffer.String()); err != nil {
+		return 0, err
+	}
+	return len(bytes), nil
 }
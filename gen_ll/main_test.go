@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gen_ll/tools"
+)
+
+// minimalInput 内置最小示例数据中的输入表
+func minimalInput(name string) string {
+	return filepath.Join("testdata", "minimal", name)
+}
+
+// minimalArgs 以最小示例数据为输入、全部码表写到 dir 的参数；$TMP 默认路径由 runGenLL 改到临时目录
+func minimalArgs(dir string) []string {
+	return append([]string{
+		"-d", minimalInput("ll_div.txt"),
+		"-m", minimalInput("ll_map.txt"),
+		"-f", minimalInput("freq.txt"),
+		"-w", minimalInput("ll_words.txt"),
+		"-L", minimalInput("linglong.txt"),
+	}, minimalOutputArgs(dir)...)
+}
+
+// minimalOutputArgs minimalArgs 中把全部码表写到 dir 的参数
+func minimalOutputArgs(dir string) []string {
+	return []string{
+		"-u", filepath.Join(dir, "code_chars_full.txt"),
+		"-s", filepath.Join(dir, "code_chars_simp.txt"),
+		"-W", filepath.Join(dir, "code_words_full.txt"),
+		"-S", filepath.Join(dir, "code_words_simp.txt"),
+		"-F", filepath.Join(dir, "linglong_full.txt"),
+		"-Q", filepath.Join(dir, "linglong_simp.txt"),
+		"-o", filepath.Join(dir, "div_ll.txt"),
+		"-Z", filepath.Join(dir, "dazhu_chai.txt"),
+		"-P", filepath.Join(dir, "lua", "chars_cand", "preset_data.txt"),
+		"-R", filepath.Join(dir, "LL.roots.dict.yaml"),
+	}
+}
+
+// codeTables 六份码表的文件名，与 minimalArgs 中的一致
+var codeTables = []string{"code_chars_full.txt", "code_chars_simp.txt", "code_words_full.txt", "code_words_simp.txt", "linglong_full.txt", "linglong_simp.txt"}
+
+// generateMinimal 以最小示例数据生成全部码表到 dir，extra 附加在参数最后，返回日志；退出码非0时测试终止
+func generateMinimal(t *testing.T, dir string, extra ...string) string {
+	t.Helper()
+	argv := append(append(minimalArgs(dir), "-q", "-stable-sort"), extra...)
+	code, logs := runGenLL(t, argv...)
+	if code != 0 {
+		t.Fatalf("%v 退出码 %d:\n%s", extra, code, logs)
+	}
+	return logs
+}
+
+// checkSameTables dir 中的码表 names 与 wantDir 中的同名文件逐字节一致
+func checkSameTables(t *testing.T, wantDir, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if got, want := readOutput(t, filepath.Join(dir, name)), readOutput(t, filepath.Join(wantDir, name)); got != want {
+			t.Errorf("%s 不一致:\n%s\n期望:\n%s", name, got, want)
+		}
+	}
+}
+
+// runGenLL 在进程内以命令行参数 argv 执行一次 gen_ll，返回退出码与日志（标准输出）
+// 每次执行前重新注册参数、清空上次运行的状态，$TMP 开头的默认输出路径落在本测试的临时目录中
+func runGenLL(t *testing.T, argv ...string) (int, string) {
+	t.Helper()
+	code, logs, _ := runGenLLOutput(t, argv...)
+	return code, logs
+}
+
+// runGenLLOutput 同 runGenLL，另外返回标准错误的输出
+func runGenLLOutput(t *testing.T, argv ...string) (int, string, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	for _, name := range []string{"TMPDIR", "TMP", "TEMP"} {
+		t.Setenv(name, tmpDir)
+	}
+
+	args = Args{}
+	flag.CommandLine = flag.NewFlagSet("gen_ll", flag.ContinueOnError)
+	tools.ResetRunState()
+	dryRunProblems = nil
+	oldArgs := os.Args
+	os.Args = append([]string{"gen_ll"}, argv...)
+	defer func() { os.Args = oldArgs }()
+
+	logFile := createOutputCapture(t, filepath.Join(tmpDir, "gen_ll.log"))
+	stderrFile := createOutputCapture(t, filepath.Join(tmpDir, "gen_ll.stderr"))
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = logFile, stderrFile
+	defer func() {
+		os.Stdout, os.Stderr = oldStdout, oldStderr
+		log.SetOutput(os.Stderr)
+	}()
+	log.SetFlags(0)
+	log.SetOutput(&logWriter{timeFormat: "2006-01-02 15:04:05"})
+
+	code := run()
+	return code, readOutput(t, logFile.Name()), readOutput(t, stderrFile.Name())
+}
+
+// createOutputCapture 创建承接标准输出或标准错误的文件，测试结束时关闭
+func createOutputCapture(t *testing.T, path string) *os.File {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+// readOutput 读取输出文件，不存在时测试失败
+func readOutput(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取输出 %s: %v", path, err)
+	}
+	return string(content)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCompressOutputsManifest -compress-outputs 在每份码表旁写出 .gz 副本，追加的 dict.yaml 不压缩
+// -output-manifest 记录每份码表与其 .gz 副本；空跑汇总列出 .gz 副本，不写出清单
+func TestCompressOutputsManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.tsv")
+	argv := append(minimalArgs(dir), "-q", "-compress-outputs", "-output-manifest", manifest)
+	if code, logs := runGenLL(t, argv...); code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+
+	recorded := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSuffix(readOutput(t, manifest), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			t.Fatalf("清单行格式错误: %q", line)
+		}
+		info, err := os.Stat(fields[0])
+		if err != nil || info.Size() == 0 || fields[1] == "0" || len(fields[2]) != 64 {
+			t.Errorf("清单行 %q 与磁盘上的文件不符: %v", line, err)
+		}
+		recorded[fields[0]] = true
+	}
+	tables := []string{"code_chars_full.txt", "code_chars_simp.txt", "code_words_full.txt", "code_words_simp.txt", "linglong_full.txt", "linglong_simp.txt", "div_ll.txt", "dazhu_chai.txt"}
+	for _, name := range tables {
+		path := filepath.Join(dir, name)
+		for _, file := range []string{path, path + ".gz"} {
+			if _, err := os.Stat(file); err != nil {
+				t.Errorf("缺少输出: %v", err)
+			}
+			if !recorded[file] {
+				t.Errorf("清单中没有 %s", file)
+			}
+		}
+	}
+	if len(recorded) != 2*len(tables) {
+		t.Errorf("清单记录 %d 个文件，期望 %d 个", len(recorded), 2*len(tables))
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.dict.yaml.gz")); len(matches) > 0 {
+		t.Errorf("追加的 dict.yaml 不应压缩: %v", matches)
+	}
+
+	dryDir := t.TempDir()
+	dryManifest := filepath.Join(dryDir, "manifest.tsv")
+	code, logs := runGenLL(t, append(minimalArgs(dryDir), "-n", "-compress-outputs", "-output-manifest", dryManifest)...)
+	if code != 0 {
+		t.Fatalf("-n 退出码 %d:\n%s", code, logs)
+	}
+	if want := filepath.Join(dryDir, "code_chars_full.txt.gz") + "\t"; !strings.Contains(logs, want) {
+		t.Errorf("空跑汇总中没有 %q:\n%s", want, logs)
+	}
+	if _, err := os.Stat(dryManifest); !os.IsNotExist(err) {
+		t.Errorf("空跑不应写出清单: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestGenerateDefaultOutputsInTempDir 只指定输入表（以本系统分隔符拼接）时，默认输出全部落在 os.TempDir() 下并正常写出
+// 不依赖 shell 与 /tmp，在 Windows 上同样适用
+func TestGenerateDefaultOutputsInTempDir(t *testing.T) {
+	code, logs := runGenLL(t, "-q",
+		"-d", minimalInput("ll_div.txt"),
+		"-m", minimalInput("ll_map.txt"),
+		"-f", minimalInput("freq.txt"),
+		"-w", minimalInput("ll_words.txt"),
+		"-L", minimalInput("linglong.txt"),
+	)
+	if code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+
+	outputs := map[string]string{
+		"-u": args.Full,
+		"-s": args.Simple,
+		"-W": args.WordsFull,
+		"-S": args.WordsSimple,
+		"-F": args.LinglongFull,
+		"-Q": args.LinglongSimple,
+		"-o": args.Opencc,
+		"-Z": args.DazhuChai,
+		"-P": args.PresetData,
+		"-R": args.RootsDict,
+	}
+	for name, path := range outputs {
+		relative, err := filepath.Rel(os.TempDir(), path)
+		if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			t.Errorf("%s 默认输出 %s 不在临时目录 %s 下", name, path, os.TempDir())
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("%s 默认输出 %s 未写出: %v", name, path, err)
+		}
+	}
+	// 字典追加在单字全码表所在目录中进行
+	if _, err := os.Stat(filepath.Join(filepath.Dir(args.Full), "LL.chars.full.dict.yaml")); err != nil {
+		t.Errorf("字典未追加到临时目录: %v", err)
+	}
+}
+
+// TestOutputDirsCheckedBeforeBuild 输出目录检查在构建前完成：-no-create-dirs 下缺少的目录直接失败且不创建
+// 同名文件挡住的目录也列出，有问题时不创建任何目录，也不写出码表
+func TestOutputDirsCheckedBeforeBuild(t *testing.T) {
+	dir := t.TempDir()
+	notDir := filepath.Join(dir, "not_dir")
+	if err := os.WriteFile(notDir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+	argv := append(minimalArgs(dir),
+		"-W", filepath.Join(missing, "words_full.txt"),
+		"-S", filepath.Join(notDir, "words_simp.txt"),
+	)
+
+	code, logs := runGenLL(t, append(argv, "-no-create-dirs")...)
+	if code == 0 {
+		t.Fatal("-no-create-dirs 下缺少输出目录时应当失败")
+	}
+	for _, want := range []string{missing + ": 目录不存在", notDir + ": 已存在同名文件，不是目录"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("日志中没有 %q:\n%s", want, logs)
+		}
+	}
+
+	if code, logs := runGenLL(t, argv...); code == 0 {
+		t.Fatalf("输出目录被同名文件挡住时应当失败:\n%s", logs)
+	}
+	for _, path := range []string{missing, filepath.Join(dir, "lua"), filepath.Join(dir, "code_chars_full.txt")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("输出目录有问题时不应创建 %s: %v", path, err)
+		}
+	}
+}
+
+// TestOutputDirNotWritable 缺少的输出目录无法在只读的上级目录下创建时，构建前失败并指出原因
+// root 与 Windows 不受目录权限位限制，跳过
+func TestOutputDirNotWritable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("目录权限位不限制当前用户")
+	}
+	dir := t.TempDir()
+	readonly := filepath.Join(dir, "readonly")
+	if err := os.Mkdir(readonly, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(readonly, 0o755) })
+
+	code, logs := runGenLL(t, append(minimalArgs(dir), "-W", filepath.Join(readonly, "sub", "words_full.txt"))...)
+	if code == 0 {
+		t.Fatal("输出目录不可写时应当失败")
+	}
+	if want := filepath.Join(readonly, "sub") + ": 目录不存在，且无法在 " + readonly + " 下创建: 当前用户没有写权限"; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+}
+
+// TestLogTimeFormat 日志时间戳按 -log-time-format 输出，-log-utc 时用 UTC；多行错误每行都带时间戳
+func TestLogTimeFormat(t *testing.T) {
+	dir := t.TempDir()
+	notDir := filepath.Join(dir, "not_dir")
+	if err := os.WriteFile(notDir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	argv := append(minimalArgs(dir), "-S", filepath.Join(notDir, "words_simp.txt"),
+		"-log-time-format", "2006-01-02T15:04:05.000Z07:00", "-log-utc")
+	code, logs := runGenLL(t, argv...)
+	if code == 0 {
+		t.Fatal("输出目录被同名文件挡住时应当失败")
+	}
+	lines := strings.Split(strings.TrimSuffix(logs, "\n"), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("错误应当输出多行日志:\n%s", logs)
+	}
+	timestamp := regexp.MustCompile(`^\[\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z\] `)
+	for _, line := range lines {
+		if !timestamp.MatchString(line) {
+			t.Errorf("日志行缺少 UTC 毫秒时间戳: %q", line)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestWordsPlaceholderCollision 与简码占位符同字的真实词：默认警告并退出；显式允许时按来源标记区分
+// 该词按权重排在同码组首位，不当作占位符；以多字词作词语来源时跟打词提只去掉补位生成的占位符
+func TestWordsPlaceholderCollision(t *testing.T) {
+	inputDir := t.TempDir()
+	div := filepath.Join(inputDir, "ll_div.txt")
+	words := filepath.Join(inputDir, "ll_words.txt")
+	for path, extra := range map[string]string{
+		div:   "①\t[一,yi,CJK,U+2460]\n",
+		words: "①\t99999999\n",
+	} {
+		content := readOutput(t, minimalInput(filepath.Base(path)))
+		if err := os.WriteFile(path, []byte(content+extra), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	line := strings.Count(readOutput(t, words), "\n")
+	placeholderArgs := func(dir string) []string {
+		return append(citiArgs(t, dir), "-d", div, "-w", words, "-word-single-char-full-code", "-wL", "1:2,2:1,3:0,4:0")
+	}
+
+	code, logs := runGenLL(t, placeholderArgs(t.TempDir())...)
+	if code == 0 {
+		t.Fatal("词表中有与占位符相同的词条时应当失败")
+	}
+	if want := words + ":" + strconv.Itoa(line) + ": 词条 ① 与多字词简码占位符相同"; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+
+	dir := t.TempDir()
+	if code, logs := runGenLL(t, append(placeholderArgs(dir), "-words-allow-placeholder", "-citi-source", "words")...); code != 0 {
+		t.Fatalf("-words-allow-placeholder 退出码 %d:\n%s", code, logs)
+	}
+	var realCode string
+	realSimple := 0
+	for _, line := range strings.Split(readOutput(t, filepath.Join(dir, "code_words_simp.txt")), "\n") {
+		fields := strings.Split(line, "\t")
+		if fields[0] == "①" && len(fields) == 3 && !strings.HasPrefix(fields[2], "-") {
+			realCode = fields[1]
+			realSimple++
+		}
+	}
+	if realCode == "" {
+		t.Fatal("多字词简码中没有真实的 ①")
+	}
+	for _, path := range []string{filepath.Join(dir, "code_words_simp.txt"), filepath.Join(dir, "LL.words.quick.dict.yaml")} {
+		first := ""
+		for _, line := range strings.Split(readOutput(t, path), "\n") {
+			if fields := strings.Split(line, "\t"); len(fields) >= 2 && fields[1] == realCode {
+				first = fields[0]
+				break
+			}
+		}
+		if first != "①" {
+			t.Errorf("%s: 与占位符同字的词未排在编码 %s 的同码组首位: %s", path, realCode, first)
+		}
+	}
+
+	want := realSimple
+	for _, name := range []string{"code_chars_simp.txt", "code_chars_full.txt", "code_words_full.txt"} {
+		for _, row := range readRows(t, filepath.Join(dir, name)) {
+			if row[0] == "①" {
+				want++
+			}
+		}
+	}
+	got := 0
+	for _, row := range readRows(t, filepath.Join(dir, "genda_citi.txt")) {
+		if row[0] == "①" {
+			got++
+		}
+	}
+	if got != want {
+		t.Errorf("跟打词提中 ① 条目 %d 条，期望 %d 条", got, want)
+	}
+}
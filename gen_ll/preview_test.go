@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// copyOutputs 把 src 中已生成的输出整个复制到新目录并返回该目录
+func copyOutputs(t *testing.T, src string) string {
+	t.Helper()
+	dst := filepath.Join(t.TempDir(), "outputs")
+	if err := os.CopyFS(dst, os.DirFS(src)); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+// TestPreview 预览：字典追加与字根码表写到预览目录，内容与真正追加的结果一致，输出目录中的字典不变
+// preset_data 按预览中的全码字典生成；-preview 与 -n 同用或预览目录就是输出目录时报错
+func TestPreview(t *testing.T) {
+	baseDir := t.TempDir()
+	generateMinimal(t, baseDir)
+	realDir, runDir := copyOutputs(t, baseDir), copyOutputs(t, baseDir)
+	generateMinimal(t, realDir)
+	previewDir := filepath.Join(t.TempDir(), "preview")
+	logs := generateMinimal(t, runDir, "-q=false", "-preview", previewDir)
+	if want := "字典追加预览已写到 " + previewDir; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+
+	dicts, err := filepath.Glob(filepath.Join(baseDir, "*.dict.yaml"))
+	if err != nil || len(dicts) == 0 {
+		t.Fatalf("输出目录中没有字典: %v", err)
+	}
+	for _, dict := range dicts {
+		name := filepath.Base(dict)
+		if readOutput(t, filepath.Join(runDir, name)) != readOutput(t, dict) {
+			t.Errorf("预览时输出目录中的 %s 不应改变", name)
+		}
+		if readOutput(t, filepath.Join(previewDir, name)) != readOutput(t, filepath.Join(realDir, name)) {
+			t.Errorf("预览中的 %s 与真正追加的结果不一致", name)
+		}
+	}
+	presetData := filepath.Join("lua", "chars_cand", "preset_data.txt")
+	if readOutput(t, filepath.Join(runDir, presetData)) != readOutput(t, filepath.Join(realDir, presetData)) {
+		t.Error("preset_data 应按预览中的全码字典生成")
+	}
+
+	for _, extra := range [][]string{{"-preview", previewDir, "-n"}, {"-preview", runDir}} {
+		if code, _ := runGenLL(t, append(append(minimalArgs(runDir), "-q"), extra...)...); code == 0 {
+			t.Errorf("%v 应当失败", extra)
+		}
+	}
+}
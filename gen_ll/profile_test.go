@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestMemProfileAndStats -mp 在码表写出后写出堆快照（gzip 压缩的 pprof），-memstats 在结束时输出内存统计
+func TestMemProfileAndStats(t *testing.T) {
+	dir := t.TempDir()
+	profile := filepath.Join(dir, "gen_ll.mem.prof")
+	code, logs := runGenLL(t, append(minimalArgs(dir), "-mp", profile, "-memstats")...)
+	if code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+
+	content, err := os.ReadFile(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		t.Errorf("堆快照应为 gzip 压缩的 pprof，开头为 % x", content[:min(len(content), 4)])
+	}
+	if want := "内存性能分析文件写入完成: " + profile + "\n"; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+	memStats := regexp.MustCompile(`内存统计: Alloc \d+MB，TotalAlloc \d+MB，HeapObjects \d+，NumGC \d+\n$`)
+	if !memStats.MatchString(logs) {
+		t.Errorf("日志最后应为内存统计:\n%s", logs)
+	}
+}
+
+// TestMemLimit 内存上限过低时先降级并警告，降级后仍超过则带提示退出；退出前 CPU 性能分析文件照常写完并提示路径
+func TestMemLimit(t *testing.T) {
+	dir := t.TempDir()
+	profile := filepath.Join(dir, "cpu.prof")
+	code, logs := runGenLL(t, append(minimalArgs(dir), "-mem-limit-mb", "1", "-p", profile)...)
+	if code == 0 {
+		t.Fatal("内存上限过低时应当失败")
+	}
+	for _, want := range []string{"已降级：", "已降级仍无法满足", "CPU性能分析文件写入完成: " + profile + "\n"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("日志中没有 %q:\n%s", want, logs)
+		}
+	}
+	content, err := os.ReadFile(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		t.Errorf("CPU 性能分析文件应为 gzip 压缩的 pprof，开头为 % x", content[:min(len(content), 4)])
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// punctuationKeys 编码中用到的标点键
+const punctuationKeys = ";,./"
+
+// dictDataRows 读取 dict.yaml 数据段的前两列，有 "..." 时只取其后
+func dictDataRows(t *testing.T, path string) map[[2]string]bool {
+	t.Helper()
+	rows := make(map[[2]string]bool)
+	for _, line := range strings.Split(readOutput(t, path), "\n") {
+		if line == "..." {
+			rows = make(map[[2]string]bool)
+			continue
+		}
+		if fields := strings.Split(line, "\t"); len(fields) >= 2 {
+			rows[[2]string{fields[0], fields[1]}] = true
+		}
+	}
+	return rows
+}
+
+// swapColumns 交换每行的两列
+func swapColumns(rows [][2]string) [][2]string {
+	swapped := make([][2]string, 0, len(rows))
+	for _, row := range rows {
+		swapped = append(swapped, [2]string{row[1], row[0]})
+	}
+	return swapped
+}
+
+// TestPunctuationKeyCodes 含标点键（; , . /）编码的条目从全码表到 dict.yaml、跟打词提与大竹词提都不丢不错
+func TestPunctuationKeyCodes(t *testing.T) {
+	dir := t.TempDir()
+	if code, logs := runGenLL(t, citiArgs(t, dir)...); code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+
+	charsFull := readRows(t, filepath.Join(dir, "code_chars_full.txt"))
+	punctuated := 0
+	for _, row := range charsFull {
+		if strings.ContainsAny(row[1], punctuationKeys) {
+			punctuated++
+		}
+	}
+	if punctuated == 0 {
+		t.Fatal("最小示例数据中没有含标点键的单字全码")
+	}
+
+	for table, dict := range map[string]string{
+		"code_chars_full.txt": "LL.chars.full.dict.yaml",
+		"code_words_full.txt": "LL.words.full.dict.yaml",
+		"linglong_full.txt":   "LL_linglong.full.dict.yaml",
+	} {
+		dictRows := dictDataRows(t, filepath.Join(dir, dict))
+		for _, row := range readRows(t, filepath.Join(dir, table)) {
+			if !dictRows[row] {
+				t.Errorf("%s 缺少 %s 的条目 %s %s", dict, table, row[0], row[1])
+			}
+		}
+	}
+
+	genda := readRows(t, filepath.Join(dir, "genda_citi.txt"))
+	for _, row := range charsFull {
+		if !strings.ContainsAny(row[1], punctuationKeys) {
+			continue
+		}
+		found := false
+		for _, citi := range genda {
+			found = found || citi[0] == row[0] && strings.HasPrefix(citi[1], row[1])
+		}
+		if !found {
+			t.Errorf("跟打词提缺少标点键编码条目 %s %s", row[0], row[1])
+		}
+	}
+	if dazhu := readRows(t, filepath.Join(dir, "dazhu_code.txt")); !reflect.DeepEqual(dazhu, swapColumns(genda)) {
+		t.Errorf("大竹词提与跟打词提条目不一致:\n大竹: %v\n跟打: %v", dazhu, genda)
+	}
+}
+
+// TestCitiKeyRemap 键位重映射只逐字符替换编码，条目与顺序不变
+// -genda-key-remap 同时作用于跟打词提与大竹词提，-dazhu-key-remap 只作用于大竹词提
+func TestCitiKeyRemap(t *testing.T) {
+	const remap = ";=1 ,=4 .=5 /=6"
+	replacer := strings.NewReplacer(";", "1", ",", "4", ".", "5", "/", "6")
+	generate := func(extra ...string) (genda, dazhu [][2]string) {
+		t.Helper()
+		dir := t.TempDir()
+		if code, logs := runGenLL(t, append(citiArgs(t, dir), extra...)...); code != 0 {
+			t.Fatalf("%v 退出码 %d:\n%s", extra, code, logs)
+		}
+		return readRows(t, filepath.Join(dir, "genda_citi.txt")), readRows(t, filepath.Join(dir, "dazhu_code.txt"))
+	}
+	remapped := func(rows [][2]string, column int) [][2]string {
+		result := make([][2]string, 0, len(rows))
+		for _, row := range rows {
+			row[column] = replacer.Replace(row[column])
+			result = append(result, row)
+		}
+		return result
+	}
+
+	genda, dazhu := generate()
+	if reflect.DeepEqual(remapped(genda, 1), genda) {
+		t.Fatal("跟打词提中没有含标点键的编码")
+	}
+
+	remapGenda, remapDazhu := generate("-genda-key-remap", remap)
+	if want := remapped(genda, 1); !reflect.DeepEqual(remapGenda, want) {
+		t.Errorf("-genda-key-remap 跟打词提:\n%v\n期望:\n%v", remapGenda, want)
+	}
+	if want := remapped(dazhu, 0); !reflect.DeepEqual(remapDazhu, want) {
+		t.Errorf("-genda-key-remap 大竹词提:\n%v\n期望:\n%v", remapDazhu, want)
+	}
+
+	dazhuOnlyGenda, dazhuOnlyDazhu := generate("-dazhu-key-remap", remap)
+	if !reflect.DeepEqual(dazhuOnlyGenda, genda) {
+		t.Errorf("-dazhu-key-remap 不应改变跟打词提:\n%v", dazhuOnlyGenda)
+	}
+	if want := remapped(dazhu, 0); !reflect.DeepEqual(dazhuOnlyDazhu, want) {
+		t.Errorf("-dazhu-key-remap 大竹词提:\n%v\n期望:\n%v", dazhuOnlyDazhu, want)
+	}
+}
+
+// TestFmtCSV fmt 子命令的 CSV 导出：含逗号或引号的字段加引号，引号加倍转义，缺少的词频写 0
+func TestFmtCSV(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "table.txt")
+	out := filepath.Join(dir, "table.csv")
+	if err := os.WriteFile(in, []byte("甲\ta,b\t3\n\"乙\"\tab;\n丙\t.o\t1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, logs := runGenLL(t, "fmt", "-q", "-in", in, "-out", out, "-fmt-csv"); code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	if got, want := readOutput(t, out), "甲,\"a,b\",3\n\"\"\"乙\"\"\",ab;,0\n丙,.o,1\n"; got != want {
+		t.Errorf("CSV 输出 %q，期望 %q", got, want)
+	}
+}
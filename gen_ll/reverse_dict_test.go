@@ -0,0 +1,61 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// reverseEntryPattern 反查注释："全码(简码)[拆分]"，简码可省略
+var reverseEntryPattern = regexp.MustCompile(`^([^(\[]+)(?:\(([^)]+)\))?\[[^\]]*\]$`)
+
+// TestReverseDict 反查注释词典：每个全码条目一行"字\t全码(简码)[拆分]"，简码取自单字简码表，字典名取自文件名
+func TestReverseDict(t *testing.T) {
+	dir := t.TempDir()
+	dict := filepath.Join(dir, "LL_reverse.dict.yaml")
+	generateMinimal(t, dir, "-reverse-dict", dict)
+
+	content := readOutput(t, dict)
+	if !strings.Contains(content, "\nname: \"LL_reverse\"\n") {
+		t.Errorf("字典名应取自文件名:\n%s", content)
+	}
+	full := make(map[[2]string]bool)
+	for _, row := range readRows(t, filepath.Join(dir, "code_chars_full.txt")) {
+		full[row] = true
+	}
+	simple := make(map[[2]string]bool)
+	for _, row := range readRows(t, filepath.Join(dir, "code_chars_simp.txt")) {
+		simple[row] = true
+	}
+
+	_, data, found := strings.Cut(content, "\n...\n")
+	if !found {
+		t.Fatalf("反查注释缺少数据段:\n%s", content)
+	}
+	lines, simplified := 0, 0
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		char, comment, _ := strings.Cut(line, "\t")
+		match := reverseEntryPattern.FindStringSubmatch(comment)
+		if match == nil {
+			t.Errorf("反查注释格式错误: %q", line)
+			continue
+		}
+		lines++
+		if !full[[2]string{char, match[1]}] {
+			t.Errorf("反查注释不在全码表中: %q", line)
+		}
+		if match[2] != "" {
+			simplified++
+			if !simple[[2]string{char, match[2]}] {
+				t.Errorf("反查注释的简码不在简码表中: %q", line)
+			}
+		}
+	}
+	if lines != len(full) {
+		t.Errorf("反查注释 %d 条，全码表 %d 条", lines, len(full))
+	}
+	if simplified == 0 {
+		t.Error("反查注释中没有带简码的条目")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// prefixedLines 字根码表中编码以 prefix 开头的行
+func prefixedLines(t *testing.T, path, prefix string) []string {
+	t.Helper()
+	var lines []string
+	for _, line := range strings.Split(readOutput(t, path), "\n") {
+		if strings.Contains(line, "\t"+prefix) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// TestRootsCodePrefix 字根码表按配置的反查引导前缀输出；普通编码落入前缀空间时校验失败并列出来源
+// 跟打词提前置表中落入前缀空间的条目跳过并警告，其余条目照常输出
+func TestRootsCodePrefix(t *testing.T) {
+	baseDir, prefixDir := t.TempDir(), t.TempDir()
+	generateMinimal(t, baseDir)
+	generateMinimal(t, prefixDir, "-roots-code-prefix", "~")
+	base := prefixedLines(t, filepath.Join(baseDir, "LL.roots.dict.yaml"), "]")
+	if len(base) == 0 {
+		t.Fatal("字根码表中没有默认前缀 ] 的条目")
+	}
+	want := strings.ReplaceAll(strings.Join(base, "\n"), "\t]", "\t~")
+	if got := strings.Join(prefixedLines(t, filepath.Join(prefixDir, "LL.roots.dict.yaml"), "~"), "\n"); got != want {
+		t.Errorf("-roots-code-prefix ~ 的字根码表:\n%s\n期望:\n%s", got, want)
+	}
+
+	// 最小示例数据中 的 的全码为 zyzo
+	code, logs := runGenLL(t, append(minimalArgs(t.TempDir()), "-q", "-roots-code-prefix", "z")...)
+	if code == 0 {
+		t.Fatal("单字全码落入字根反查引导前缀时应当失败")
+	}
+	for _, want := range []string{"落入字根反查引导前缀 z 的专用编码空间", "单字全码 的\tzyzo"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("日志中没有 %q:\n%s", want, logs)
+		}
+	}
+
+	citiDir := t.TempDir()
+	citiPre := filepath.Join(citiDir, "ll_citi_pre.txt")
+	argv := citiArgs(t, citiDir)
+	if err := os.WriteFile(citiPre, []byte("甲\t]ab\n乙\tab\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code, logs = runGenLL(t, argv...)
+	if code != 0 {
+		t.Fatalf("退出码 %d:\n%s", code, logs)
+	}
+	if want := "跳过跟打词提条目: " + citiPre + ":1: 编码 ]ab 落入字根反查引导前缀"; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+	genda := readRows(t, filepath.Join(citiDir, "genda_citi.txt"))
+	found := false
+	for _, row := range genda {
+		found = found || row == [2]string{"乙", "ab"}
+		if strings.HasPrefix(row[1], "]") {
+			t.Errorf("落入字根反查引导前缀的跟打词提条目未跳过: %v", row)
+		}
+	}
+	if !found {
+		t.Errorf("跟打词提缺少前置表条目 乙 ab:\n%v", genda)
+	}
+}
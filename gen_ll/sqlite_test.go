@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+// TestSQLiteExport SQLite 导出：各码表的表与写出的码表文件条目一致，拆分表每条拆分一行，没有词频的条目 freq 为 NULL
+// 再次导出时覆盖已有文件而不是重复插入
+func TestSQLiteExport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ll.db")
+	generateMinimal(t, dir, "-sqlite", path)
+	generateMinimal(t, dir, "-sqlite", path)
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	query := func(statement string) [][2]string {
+		t.Helper()
+		rows, err := db.Query(statement)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var result [][2]string
+		for rows.Next() {
+			var row [2]string
+			if err := rows.Scan(&row[0], &row[1]); err != nil {
+				t.Fatal(err)
+			}
+			result = append(result, row)
+		}
+		if err := rows.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	sorted := func(rows [][2]string) [][2]string {
+		sort.Slice(rows, func(i, j int) bool {
+			return rows[i][0]+"\t"+rows[i][1] < rows[j][0]+"\t"+rows[j][1]
+		})
+		return rows
+	}
+
+	for table, name := range map[string]string{
+		"chars_full":    "code_chars_full.txt",
+		"chars_simp":    "code_chars_simp.txt",
+		"words_full":    "code_words_full.txt",
+		"words_simp":    "code_words_simp.txt",
+		"linglong_full": "linglong_full.txt",
+		"linglong_simp": "linglong_simp.txt",
+	} {
+		got := sorted(query("SELECT text, code FROM " + table))
+		if want := sorted(readRows(t, filepath.Join(dir, name))); !reflect.DeepEqual(got, want) {
+			t.Errorf("表 %s 与 %s 的条目不一致: %d 条，期望 %d 条", table, name, len(got), len(want))
+		}
+	}
+	count := func(statement string) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(statement).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if divisions, chars := count("SELECT count(*) FROM divisions"), count("SELECT count(*) FROM chars_full"); divisions != chars {
+		t.Errorf("divisions 有 %d 行，chars_full 有 %d 行", divisions, chars)
+	}
+	missing := 0
+	for _, line := range strings.Split(strings.TrimSuffix(readOutput(t, filepath.Join(dir, "code_words_full.txt")), "\n"), "\n") {
+		if fields := strings.Split(line, "\t"); len(fields) < 3 || fields[2] == "" {
+			missing++
+		}
+	}
+	if got := count("SELECT count(*) FROM words_full WHERE freq IS NULL"); got != missing {
+		t.Errorf("words_full 中 freq 为 NULL 的有 %d 行，期望 %d 行", got, missing)
+	}
+}
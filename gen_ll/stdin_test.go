@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withStdin 把标准输入换成内容为 content 的临时文件，测试结束时恢复
+func withStdin(t *testing.T, content []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStdin := os.Stdin
+	os.Stdin = file
+	t.Cleanup(func() {
+		os.Stdin = oldStdin
+		file.Close()
+	})
+}
+
+// TestStdinInputs 拆分表或映射表以 "-" 从标准输入读取，结果与读文件一致；多个输入同时使用标准输入时报错
+func TestStdinInputs(t *testing.T) {
+	baseDir := t.TempDir()
+	generateMinimal(t, baseDir)
+
+	for flag, name := range map[string]string{"-d": "ll_div.txt", "-m": "ll_map.txt"} {
+		t.Run(name, func(t *testing.T) {
+			withStdin(t, []byte(readOutput(t, minimalInput(name))))
+			dir := t.TempDir()
+			generateMinimal(t, dir, flag, "-")
+			checkSameTables(t, baseDir, dir, codeTables...)
+		})
+	}
+
+	withStdin(t, nil)
+	if code, _ := runGenLL(t, append(minimalArgs(t.TempDir()), "-q", "-d", "-", "-m", "-")...); code == 0 {
+		t.Error("多个输入同时使用标准输入时应当失败")
+	}
+}
@@ -0,0 +1,11 @@
+package main
+
+import "testing"
+
+// TestStreamReadOutputs 流式解析路径（词表、频率表不经缓存按行读取）的输出与缓存读取完全一致
+func TestStreamReadOutputs(t *testing.T) {
+	cachedDir, streamDir := t.TempDir(), t.TempDir()
+	generateMinimal(t, cachedDir)
+	generateMinimal(t, streamDir, "-stream-read-threshold-mb", "0")
+	checkSameTables(t, cachedDir, streamDir, codeTables...)
+}
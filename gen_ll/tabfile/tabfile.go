@@ -0,0 +1,163 @@
+package tabfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// LineError 输入文件中某一行的数据错误
+type LineError struct {
+	File string // 文件路径
+	Line int    // 行号（从1开始）
+	Msg  string // 错误描述
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Options 行解析选项，各表格式之间的差异都通过选项表达
+type Options struct {
+	TrimSpace    bool // 先去掉行首尾空白再判断空行、注释与分列（默认只去掉行尾的 \r）
+	SplitSpace   bool // 按任意空白分列（strings.Fields），默认按制表符分列
+	MinColumns   int  // 列数少于该值的行直接跳过，0 表示不限制；需要报错的格式由调用方检查 Row.Len
+	StripComment bool // 分列前去掉行尾注释：前面是空白（含制表符）的 # 或全角 ＃ 及其后的全部内容
+}
+
+// Row 表格中的一个数据行
+type Row struct {
+	File    string
+	Line    int    // 行号（从1开始，空行与注释行也计数）
+	Text    string // 按选项去除空白后的整行
+	Columns []string
+}
+
+// Len 返回列数
+func (row *Row) Len() int {
+	return len(row.Columns)
+}
+
+// Column 返回第 index 列（从0开始），列不存在时返回空串
+func (row *Row) Column(index int) string {
+	if index < 0 || index >= len(row.Columns) {
+		return ""
+	}
+	return row.Columns[index]
+}
+
+// Int64 按十进制整数解析第 index 列，列不存在或不是整数时返回 false
+func (row *Row) Int64(index int) (int64, bool) {
+	if index < 0 || index >= len(row.Columns) {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(row.Columns[index], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// Reparse 按选项重新解析本行的新内容（如剥离某些字符后），保留文件与行号；新内容为空行或注释行时返回 nil
+func (row *Row) Reparse(text string, opts Options) *Row {
+	parser := &Parser{file: row.File, opts: opts, line: row.Line - 1}
+	return parser.Parse(text)
+}
+
+// Errorf 返回定位到本行的数据错误
+func (row *Row) Errorf(format string, args ...interface{}) *LineError {
+	return &LineError{File: row.File, Line: row.Line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Stop 处理函数返回 Stop 时停止读取，不视为错误
+var Stop = errors.New("tabfile: stop")
+
+// Parser 逐行解析并累计行号，供已按行读出内容的来源（缓存、标准输入、流式读取）使用
+type Parser struct {
+	file string
+	opts Options
+	line int
+}
+
+// NewParser 创建解析器，file 只用于错误定位
+func NewParser(file string, opts Options) *Parser {
+	return &Parser{file: file, opts: opts}
+}
+
+// Parse 解析下一行；空行、# 开头的注释行与列数不足 MinColumns 的行返回 nil
+func (parser *Parser) Parse(line string) *Row {
+	parser.line++
+	line = strings.TrimRight(line, "\r\n")
+	if parser.opts.StripComment {
+		line = stripComment(line)
+	}
+	if parser.opts.TrimSpace {
+		line = strings.TrimSpace(line)
+	}
+	if len(line) == 0 || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	var columns []string
+	if parser.opts.SplitSpace {
+		columns = strings.Fields(line)
+	} else {
+		columns = strings.Split(line, "\t")
+	}
+	if len(columns) < parser.opts.MinColumns {
+		return nil
+	}
+
+	return &Row{File: parser.file, Line: parser.line, Text: line, Columns: columns}
+}
+
+// stripComment 去掉行尾注释及其前面的空白；行首的 # 仍按注释行处理，不在这里去掉
+func stripComment(line string) string {
+	previous := rune(-1)
+	for i, r := range line {
+		if (r == '#' || r == '＃') && previous >= 0 && unicode.IsSpace(previous) {
+			return strings.TrimRightFunc(line[:i], unicode.IsSpace)
+		}
+		previous = r
+	}
+	return line
+}
+
+// 单行的最大长度
+const maxLineSize = 16 << 20
+
+// Scan 按行读取 reader 并逐行解析，handle 返回错误时停止并返回该错误（Stop 除外）
+func Scan(reader io.Reader, file string, opts Options, handle func(row *Row) error) error {
+	parser := NewParser(file, opts)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		row := parser.Parse(scanner.Text())
+		if row == nil {
+			continue
+		}
+		if err := handle(row); err != nil {
+			if err == Stop {
+				return nil
+			}
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ScanFile 直接打开文件逐行解析，不经任何缓存，适合运行中会被改写的生成文件
+func ScanFile(path string, opts Options, handle func(row *Row) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return Scan(file, path, opts, handle)
+}
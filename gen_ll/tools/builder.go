@@ -5,105 +5,154 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 
+	"gen_ll/tabfile"
 	"gen_ll/types"
 )
 
 const fallBackFreq = 100
 
+// stableSort 为 true 时排序一律使用稳定排序，并按固定顺序遍历映射与合并并发结果，保证输出可复现
+var stableSort bool
+
+// SetStableSort 设置是否使用稳定排序以得到可复现的输出
+func SetStableSort(stable bool) {
+	stableSort = stable
+}
+
+// sortSlice 按 stableSort 选择 sort.SliceStable 或 sort.Slice
+func sortSlice(x interface{}, less func(i, j int) bool) {
+	if stableSort {
+		sort.SliceStable(x, less)
+		return
+	}
+	sort.Slice(x, less)
+}
 
 // BuildFullCodeMetaList 构造字符四码全码编码列表
-func BuildFullCodeMetaList(table map[string][]*types.Division, mappings map[string]string, freqSet map[string]int64) (charMetaList []*types.CharMeta) {
+// 无法构造的拆分（如取不到编码）跳过并以错误返回，由调用方决定如何报告
+func BuildFullCodeMetaList(table map[string][]*types.Division, mappings map[string]string, freqSet map[string]int64) (charMetaList []*types.CharMeta, skipped []error) {
 	// 预分配足够大的切片
 	charMetaList = make([]*types.CharMeta, 0, len(table))
-	
+
 	// 并发处理以提高性能
 	var mutex sync.Mutex
 	var wg sync.WaitGroup
-	
+
 	// 将字符表分块并行处理
 	chars := make([]string, 0, len(table))
 	for char := range table {
 		chars = append(chars, char)
 	}
-	
-	// 决定并发数量，根据CPU核心数自动调整
-	concurrency := runtime.NumCPU()
+	if stableSort {
+		sort.Strings(chars)
+	}
+
+	// 决定并发数量，默认根据CPU核心数自动调整，内存降级时为 1
+	concurrency := concurrency()
 	batchSize := (len(chars) + concurrency - 1) / concurrency
-	
+	// 稳定模式下各批次结果按批次顺序合并，而不是按完成顺序
+	batchResults := make([][]*types.CharMeta, concurrency)
+	batchSkipped := make([][]error, concurrency)
+
 	for i := 0; i < concurrency; i++ {
 		start := i * batchSize
 		end := (i + 1) * batchSize
 		if end > len(chars) {
 			end = len(chars)
 		}
-		
+
 		if start >= end {
 			continue
 		}
-		
+
 		wg.Add(1)
-		go func(start, end int) {
+		go func(batch, start, end int) {
 			defer wg.Done()
 			localCharMetaList := make([]*types.CharMeta, 0, end-start)
-			
+			var localSkipped []error
+
 			// 处理当前批次的字符
 			for i := start; i < end; i++ {
 				char := chars[i]
 				divs := table[char]
-				
+
 				// 遍历字符的所有拆分表
 				for i, div := range divs {
-					full, code := calcFullCodeByDiv(div.Divs, mappings)
-					charMeta := types.CharMeta{
-						Char:     char,
-						Full:     full,
-						Code:     code,
-						Freq:     freqSet[char],
-						MDiv:     i == 0,
-						Division: div, // 绑定对应的拆分信息
+					charMeta, err := BuildCharMeta(char, div, mappings, freqSet[char], i == 0)
+					if err != nil {
+						localSkipped = append(localSkipped, err)
+						continue
 					}
-					
-					localCharMetaList = append(localCharMetaList, &charMeta)
+					localCharMetaList = append(localCharMetaList, charMeta)
 				}
 			}
-			
+
+			batchSkipped[batch] = localSkipped
+			if stableSort {
+				batchResults[batch] = localCharMetaList
+				return
+			}
 			// 合并本地结果到全局列表
 			mutex.Lock()
 			charMetaList = append(charMetaList, localCharMetaList...)
 			mutex.Unlock()
-		}(start, end)
+		}(i, start, end)
 	}
-	
+
 	// 等待所有协程完成
 	wg.Wait()
-	
+	for batch, localCharMetaList := range batchResults {
+		charMetaList = append(charMetaList, localCharMetaList...)
+		skipped = append(skipped, batchSkipped[batch]...)
+	}
+
 	// 排序结果 - 按词频降序排序
 	sortCharMetaByFreq(charMetaList)
 	return
 }
 
+// DedupFullCodeMetaList 去除同字同码的重复条目（次拆分与主拆分取码相同），返回去重后的列表与去除数量
+// 同字同码只保留一条，优先保留主拆分；列表其余顺序不变
+func DedupFullCodeMetaList(charMetaList []*types.CharMeta) ([]*types.CharMeta, int) {
+	kept := make(map[string]int, len(charMetaList))
+	result := make([]*types.CharMeta, 0, len(charMetaList))
+	for _, charMeta := range charMetaList {
+		key := charMeta.Char + "\t" + charMeta.Code
+		if index, exists := kept[key]; exists {
+			if charMeta.MDiv && !result[index].MDiv {
+				result[index] = charMeta
+			}
+			continue
+		}
+		kept[key] = len(result)
+		result = append(result, charMeta)
+	}
+	return result, len(charMetaList) - len(result)
+}
 
 func sortCharMetaByCode(charMetaList []*types.CharMeta) {
 	// 按编码升序排列，对于相同编码的重码按词频降序排列
-	sort.Slice(charMetaList, func(i, j int) bool {
+	sortSlice(charMetaList, func(i, j int) bool {
 		a, b := charMetaList[i], charMetaList[j]
-		
+
 		// 首先按编码升序排列
 		if a.Code != b.Code {
 			return a.Code < b.Code
 		}
-		
+
 		// 编码相同，按词频降序排列
 		if a.Freq != b.Freq {
 			return a.Freq > b.Freq
 		}
-		
+
 		// 编码和词频都相同，按字符Unicode编码升序排列
 		return a.Char < b.Char
 	})
@@ -111,24 +160,32 @@ func sortCharMetaByCode(charMetaList []*types.CharMeta) {
 
 func sortCharMetaByFreq(charMetaList []*types.CharMeta) {
 	// 按词频降序排列，词频相同时按编码升序排列
-	sort.Slice(charMetaList, func(i, j int) bool {
+	sortSlice(charMetaList, func(i, j int) bool {
 		a, b := charMetaList[i], charMetaList[j]
-		
+
 		// 首先按词频降序排列
 		if a.Freq != b.Freq {
 			return a.Freq > b.Freq
 		}
-		
+
 		// 词频相同，按编码升序排列
 		if a.Code != b.Code {
 			return a.Code < b.Code
 		}
-		
+
 		// 词频和编码都相同，按字符Unicode编码升序排列
 		return a.Char < b.Char
 	})
 }
 
+// BuildCharMeta 按编码规则由字符的一个拆分算出提示码与全码，并构造全码字元
+func BuildCharMeta(char string, division *types.Division, mappings map[string]string, freq int64, mainDiv bool) (*types.CharMeta, error) {
+	if division == nil {
+		return types.NewCharMeta(char, nil, "", "", freq, mainDiv)
+	}
+	full, code := calcFullCodeByDiv(division.Divs, mappings)
+	return types.NewCharMeta(char, division, full, code, freq, mainDiv)
+}
 
 func calcFullCodeByDiv(div []string, mappings map[string]string) (full string, code string) {
 	// 遍历处理每个部件，生成全码
@@ -143,7 +200,7 @@ func calcFullCodeByDiv(div []string, mappings map[string]string) (full string, c
 		}
 		full += compCode
 	}
-	
+
 	// 根据拆分部件数量生成编码
 	if len(div) == 1 {
 		// 单根字处理
@@ -151,10 +208,10 @@ func calcFullCodeByDiv(div []string, mappings map[string]string) (full string, c
 		if len(compCode) == 0 {
 			return "", ""
 		}
-		
+
 		// 第一码：取部件大码（编码第一位）
 		code = compCode[:1]
-		
+
 		// 第二码：取部件中码
 		if len(compCode) >= 2 {
 			code += compCode[1:2]
@@ -162,7 +219,7 @@ func calcFullCodeByDiv(div []string, mappings map[string]string) (full string, c
 			// 如果只有1码，重复大码
 			code += compCode[:1]
 		}
-		
+
 		// 第三码：取部件中码（重复第二码）
 		if len(compCode) >= 2 {
 			code += compCode[1:2]
@@ -170,7 +227,7 @@ func calcFullCodeByDiv(div []string, mappings map[string]string) (full string, c
 			// 如果只有1码，重复大码
 			code += compCode[:1]
 		}
-		
+
 		// 第四码：取部件小码
 		if len(compCode) >= 3 {
 			code += compCode[2:3]
@@ -181,22 +238,22 @@ func calcFullCodeByDiv(div []string, mappings map[string]string) (full string, c
 			// 如果只有1码，重复大码
 			code += compCode[:1]
 		}
-		
+
 	} else if len(div) == 2 {
 		// 双根字处理
 		firstCompCode := mappings[div[0]]
 		secondCompCode := mappings[div[1]]
-		
+
 		if len(firstCompCode) == 0 || len(secondCompCode) == 0 {
 			return "", ""
 		}
-		
+
 		// 第一码：第一部件大码
 		code = firstCompCode[:1]
-		
+
 		// 第二码：第二部件大码
 		code += secondCompCode[:1]
-		
+
 		// 第三码：第一部件中码
 		if len(firstCompCode) >= 2 {
 			code += firstCompCode[1:2]
@@ -204,7 +261,7 @@ func calcFullCodeByDiv(div []string, mappings map[string]string) (full string, c
 			// 如果只有1码，重复大码
 			code += firstCompCode[:1]
 		}
-		
+
 		// 第四码：第二部件小码
 		if len(secondCompCode) >= 3 {
 			code += secondCompCode[2:3]
@@ -215,26 +272,26 @@ func calcFullCodeByDiv(div []string, mappings map[string]string) (full string, c
 			// 如果只有1码，重复大码
 			code += secondCompCode[:1]
 		}
-		
+
 	} else {
 		// 三根字及以上多根字处理
 		firstCompCode := mappings[div[0]]
 		secondCompCode := mappings[div[1]]
 		lastCompCode := mappings[div[len(div)-1]]
-		
+
 		if len(firstCompCode) == 0 || len(secondCompCode) == 0 || len(lastCompCode) == 0 {
 			return "", ""
 		}
-		
+
 		// 第一码：第一部件大码
 		code = firstCompCode[:1]
-		
+
 		// 第二码：第二部件大码
 		code += secondCompCode[:1]
-		
+
 		// 第三码：末部件大码
 		code += lastCompCode[:1]
-		
+
 		// 第四码：末部件小码
 		if len(lastCompCode) >= 3 {
 			code += lastCompCode[2:3]
@@ -246,12 +303,12 @@ func calcFullCodeByDiv(div []string, mappings map[string]string) (full string, c
 			code += lastCompCode[:1]
 		}
 	}
-	
+
 	// 确保编码长度不超过4码
 	if len(code) > 4 {
 		code = code[:4]
 	}
-	
+
 	code = strings.ToLower(code)
 	return
 }
@@ -262,91 +319,101 @@ func ParseLenCodeLimit(limitStr string) (map[int]int, error) {
 	if limitStr == "" {
 		return limits, nil
 	}
-	
+
 	pairs := strings.Split(limitStr, ",")
 	for _, pair := range pairs {
 		parts := strings.Split(pair, ":")
 		if len(parts) != 2 {
 			continue
 		}
-		
+
 		length, err := strconv.Atoi(strings.TrimSpace(parts[0]))
 		if err != nil {
 			return nil, err
 		}
-		
+
 		limit, err := strconv.Atoi(strings.TrimSpace(parts[1]))
 		if err != nil {
 			return nil, err
 		}
-		
+
 		limits[length] = limit
 	}
-	
+
 	return limits, nil
 }
 
+// ParseSuffixOrder 解析逗号分隔的末码顺序，必须恰好是 w r u o 的一个排列
+func ParseSuffixOrder(orderStr string) ([]string, error) {
+	order := strings.Split(orderStr, ",")
+	for i := range order {
+		order[i] = strings.TrimSpace(order[i])
+	}
+	if len(order) != len(suffixKeys) {
+		return nil, fmt.Errorf("末码顺序应包含 %s 各一次: %s", strings.Join(suffixKeys, ","), orderStr)
+	}
+	seen := make(map[string]bool)
+	for _, suffix := range order {
+		if _, valid := suffixPlaceholders[suffix]; !valid || seen[suffix] {
+			return nil, fmt.Errorf("末码顺序应包含 %s 各一次: %s", strings.Join(suffixKeys, ","), orderStr)
+		}
+		seen[suffix] = true
+	}
+	return order, nil
+}
+
 // BuildSimpleCodeList 构建简码列表
 func BuildSimpleCodeList(fullCodeList []*types.CharMeta, lenCodeLimit map[int]int, noSimplifyChars []string) []*types.CharMeta {
 	// 按词频排序
 	sortedList := make([]*types.CharMeta, len(fullCodeList))
 	copy(sortedList, fullCodeList)
-	sort.Slice(sortedList, func(i, j int) bool {
+	sortSlice(sortedList, func(i, j int) bool {
 		return sortedList[i].Freq > sortedList[j].Freq
 	})
-	
+
 	// 出简不出全 - 只保留成功简化的条目
 	resultData := make([]*types.CharMeta, 0)
-	usedCodes := make(map[string]bool)
-	
+	usedCodes := newCodeTable[bool]()
+	// 各前缀下已出的同级简码数，出简时累加，不必每次回扫 resultData
+	prefixCounts := newCodeTable[int]()
+
 	// 创建不出简字符的集合
 	noSimplifySet := make(map[string]bool)
 	for _, char := range noSimplifyChars {
 		noSimplifySet[char] = true
 	}
-	
+
 	for _, charMeta := range sortedList {
 		word := charMeta.Char
 		code := charMeta.Code
 		freq := charMeta.Freq
-		
+
 		// 跳过不出简的字符
 		if noSimplifySet[word] {
 			continue
 		}
-		
+
+		// 无法取码的字不出简
+		if len(code) == 0 {
+			continue
+		}
+
 		fullCodeLastChar := string(code[len(code)-1])
 		var simplified string
-		
+
 		// 尝试生成简码
 		for i := 0; i < len(code); i++ {
 			limit := lenCodeLimit[i+1]
 			if limit == 0 {
 				continue
 			}
-			
+
 			currentPrefix := code[:i+1]
-			// 计算目标简码长度：1简和2简是前缀长度+1（因为加末码），3简及以上是前缀长度
-			var targetLength int
-			if i+1 <= 2 {
-				targetLength = (i + 1) + 1
-			} else {
-				targetLength = i + 1
-			}
-			
-			// 统计相同前缀的简码数量
-			samePrefixCount := 0
-			for _, res := range resultData {
-				resCode := res.Code
-				if len(resCode) == targetLength && strings.HasPrefix(resCode, currentPrefix) {
-					samePrefixCount++
-				}
-			}
-			
-			if samePrefixCount >= limit {
+			// 相同前缀的简码数量已达上限
+			if prefixCounts.get(currentPrefix) >= limit {
 				continue
 			}
-			
+
 			// 生成候选简码
 			var candidate string
 			if i+1 <= 2 {
@@ -354,40 +421,134 @@ func BuildSimpleCodeList(fullCodeList []*types.CharMeta, lenCodeLimit map[int]in
 			} else {
 				candidate = currentPrefix
 			}
-			
-			if !usedCodes[candidate] {
+
+			// 全码本身较短时候选可能不短于全码，这不是简化：不占用码位，继续尝试下一级
+			if len(candidate) >= len(code) {
+				continue
+			}
+
+			if !usedCodes.get(candidate) {
 				simplified = candidate
-				usedCodes[simplified] = true
+				usedCodes.set(simplified, true)
 				break
 			}
 		}
-		
-		// 如果生成了简码且与全码不同，则添加到结果
-		if simplified != "" && simplified != code {
-			newCharMeta := &types.CharMeta{
-				Char: word,
-				Code: simplified,
-				Freq: freq,
-				Simp: true,
+
+		// 如果生成了简码，则添加到结果（候选已保证短于全码）
+		if simplified != "" {
+			if newCharMeta, err := types.NewSimpCharMeta(word, simplified, freq); err == nil {
+				resultData = append(resultData, newCharMeta)
+				countSimplePrefixes(prefixCounts, simplified)
 			}
-			resultData = append(resultData, newCharMeta)
 		}
 	}
-	
+
 	// 按词频排序结果
 	sortCharMetaByFreq(resultData)
 	return resultData
 }
 
+// simpleCodeLength 前缀长度对应的简码长度：1简和2简是前缀长度+1（因为加末码），3简及以上是前缀长度
+func simpleCodeLength(prefixLength int) int {
+	if prefixLength <= 2 {
+		return prefixLength + 1
+	}
+	return prefixLength
+}
+
+// countSimplePrefixes 新出一个简码后，为以它开头、简码长度与它相同的各级前缀计数
+// 三码简码既是某个二码前缀的 2 简，也是它自身这一三码前缀的 3 简
+func countSimplePrefixes(prefixCounts *codeTable[int], simplified string) {
+	for prefixLength := 1; prefixLength <= len(simplified); prefixLength++ {
+		if simpleCodeLength(prefixLength) == len(simplified) {
+			prefix := simplified[:prefixLength]
+			prefixCounts.set(prefix, prefixCounts.get(prefix)+1)
+		}
+	}
+}
+
+// FillSimpCodes 把简码回填到全码列表中对应字的主拆分条目，同字有多个简码时取最短的
+// 在构建阶段、写出之前调用，之后全码列表仍按只读约定使用
+func FillSimpCodes(fullCodeMetaList, simpleCodeList []*types.CharMeta) {
+	simpCodes := make(map[string]string)
+	for _, charMeta := range simpleCodeList {
+		if current, exists := simpCodes[charMeta.Char]; !exists || len(charMeta.Code) < len(current) {
+			simpCodes[charMeta.Char] = charMeta.Code
+		}
+	}
+	for _, charMeta := range fullCodeMetaList {
+		if charMeta.MDiv {
+			charMeta.SimpCode = simpCodes[charMeta.Char]
+		}
+	}
+}
+
+// SimpleCharLevels 由回填的 SimpCode 得到各字的简码级别（1 为一简，2 为二简），供出简让全使用
+func SimpleCharLevels(fullCodeMetaList []*types.CharMeta) map[string]int {
+	levels := make(map[string]int)
+	for _, charMeta := range fullCodeMetaList {
+		if level := simpleCharLevel(charMeta.SimpCode); charMeta.MDiv && level > 0 {
+			levels[charMeta.Char] = level
+		}
+	}
+	return levels
+}
+
+// simpleCharLevel 根据简码长度判断简码级别
+// 一简：编码长度为1或2（一简+补码）；二简：编码长度为3（二简+补码）；其余返回0
+func simpleCharLevel(code string) int {
+	switch len(code) {
+	case 1, 2:
+		return 1
+	case 3:
+		return 2
+	}
+	return 0
+}
+
+// SimpleCodeLengthHistogram 统计各简码长度分到的字数，用于调整简码长度限制
+func SimpleCodeLengthHistogram(result []*types.CharMeta) map[int]int {
+	histogram := make(map[int]int)
+	for _, charMeta := range result {
+		histogram[len(charMeta.Code)]++
+	}
+	return histogram
+}
+
+// 词条长度上限，与字典头部 rules 中 length_in_range 的上限保持一致
+const maxWordLength = 20
+
+// WordsFullCodeOptions 多字词全码生成选项
+type WordsFullCodeOptions struct {
+	SingleCharFullCode bool              // 单字词直接使用该字的全码输出（把词表当作补充字表）
+	Uppercase          bool              // 词码转为大写，供区分大小写的输入法格式导出
+	CharRadicals       map[string]string // 实验：字符到部首编码的映射（见 CreateCharRadicalMap），非 nil 时词码由各字的部首编码取码
+}
+
+// SkippedWord 未生成编码的词条
+type SkippedWord struct {
+	Word   string // 词语
+	Reason string // 跳过原因
+}
+
+// WordsCodeReport 多字词全码生成报告
+type WordsCodeReport struct {
+	Skipped  []*SkippedWord // 被跳过的词条
+	Warnings []string       // 警告信息（空词、超长词等）
+}
 
 // BuildWordsFullCode 构建多字词全码
-func BuildWordsFullCode(wordEntries []*types.WordEntry, charCodeMap map[string]string) []*types.WordCode {
+func BuildWordsFullCode(wordEntries []*types.WordEntry, charCodeMap map[string]string, opts WordsFullCodeOptions) ([]*types.WordCode, *WordsCodeReport) {
 	wordCodes := make([]*types.WordCode, 0, len(wordEntries))
-	
+	report := &WordsCodeReport{}
+	if opts.CharRadicals != nil {
+		charCodeMap = overlayCharRadicals(charCodeMap, opts.CharRadicals)
+	}
+
 	for _, entry := range wordEntries {
 		word := entry.Word
 		chars := []rune(word)
-		
+
 		// 先去除所有标点符号，只保留可编码的汉字字符
 		var validChars []rune
 		for _, char := range chars {
@@ -396,29 +557,47 @@ func BuildWordsFullCode(wordEntries []*types.WordEntry, charCodeMap map[string]s
 				validChars = append(validChars, char)
 			}
 		}
-		
+
+		// 空词与超长词给出警告
+		if len(validChars) == 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("空词（无可编码字符）: %s", word))
+			report.Skipped = append(report.Skipped, &SkippedWord{Word: word, Reason: "无可编码字符"})
+			continue
+		}
+		if len(validChars) > maxWordLength {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("超长词（%d 字）: %s", len(validChars), word))
+		}
+
 		// 根据去除标点后的有效字符数量应用编码规则
 		var code string
 		switch len(validChars) {
+		case 1:
+			// 单字词：默认跳过，可选直接使用该字全码
+			if !opts.SingleCharFullCode {
+				report.Skipped = append(report.Skipped, &SkippedWord{Word: word, Reason: "单字词"})
+				continue
+			}
+			code = charCodeMap[string(validChars[0])]
+
 		case 2:
 			// 二字词：取每个字编码的前2位，拼接成4位编码
 			firstCode := charCodeMap[string(validChars[0])]
 			secondCode := charCodeMap[string(validChars[1])]
-			
+
 			if len(firstCode) >= 2 && len(secondCode) >= 2 {
 				code = firstCode[:2] + secondCode[:2]
 			}
-			
+
 		case 3:
 			// 三字词：前两个字各取编码的第1位，第三个字取编码的前2位
 			firstCode := charCodeMap[string(validChars[0])]
 			secondCode := charCodeMap[string(validChars[1])]
 			thirdCode := charCodeMap[string(validChars[2])]
-			
+
 			if len(firstCode) >= 1 && len(secondCode) >= 1 && len(thirdCode) >= 2 {
 				code = firstCode[:1] + secondCode[:1] + thirdCode[:2]
 			}
-			
+
 		default:
 			// 四字及以上：取第一、二、三个字和最后一个字编码的第1位
 			if len(validChars) >= 4 {
@@ -426,62 +605,187 @@ func BuildWordsFullCode(wordEntries []*types.WordEntry, charCodeMap map[string]s
 				secondCode := charCodeMap[string(validChars[1])]
 				thirdCode := charCodeMap[string(validChars[2])]
 				lastCode := charCodeMap[string(validChars[len(validChars)-1])]
-				
+
 				if len(firstCode) >= 1 && len(secondCode) >= 1 && len(thirdCode) >= 1 && len(lastCode) >= 1 {
 					code = firstCode[:1] + secondCode[:1] + thirdCode[:1] + lastCode[:1]
 				}
 			}
 		}
-		
+
 		// 如果成功生成了编码，添加到结果列表
 		if code != "" {
-			wordCodes = append(wordCodes, &types.WordCode{
-				Word:   word,
-				Code:   code,
-				Weight: entry.Weight,
-			})
+			if opts.Uppercase {
+				code = strings.ToUpper(code)
+			}
+			wordCode, err := types.NewWordCode(word, code, entry.Weight)
+			if err != nil {
+				report.Skipped = append(report.Skipped, &SkippedWord{Word: word, Reason: err.Error()})
+				continue
+			}
+			wordCodes = append(wordCodes, wordCode)
+		} else {
+			report.Skipped = append(report.Skipped, &SkippedWord{Word: word, Reason: "字符编码长度不足"})
 		}
 	}
-	
-	return wordCodes
+
+	return wordCodes, report
 }
 
+// 词表取字编码的方式
+const (
+	CharCodeStrict            = "strict"             // 只用主拆分的编码，主拆分无法取码的字不参与组词（默认）
+	CharCodeFallbackSecondary = "fallback-secondary" // 主拆分无法取码时回退到次拆分的编码，多个次拆分取编码最小的一个
+)
+
 // CreateCharCodeMap 从字符元数据列表创建字符到编码的映射
-func CreateCharCodeMap(charMetaList []*types.CharMeta) map[string]string {
+func CreateCharCodeMap(charMetaList []*types.CharMeta, mode string) (map[string]string, error) {
 	charCodeMap := make(map[string]string)
-	
+
 	for _, charMeta := range charMetaList {
 		// 只使用主要拆分的编码
 		if charMeta.MDiv {
 			charCodeMap[charMeta.Char] = charMeta.Code
 		}
 	}
-	
-	return charCodeMap
+
+	switch mode {
+	case "", CharCodeStrict:
+		return charCodeMap, nil
+	case CharCodeFallbackSecondary:
+		return fallbackSecondaryCodes(charCodeMap, charMetaList), nil
+	}
+	return nil, fmt.Errorf("未知的取字编码方式: %s（可选 %s、%s）", mode, CharCodeStrict, CharCodeFallbackSecondary)
+}
+
+// fallbackSecondaryCodes 为主拆分无法取码的字补入次拆分的编码，取编码最小的一个使结果不随列表顺序变化
+// 没有需要补入的字时直接返回主拆分映射本身
+func fallbackSecondaryCodes(charCodeMap map[string]string, charMetaList []*types.CharMeta) map[string]string {
+	fallback := make(map[string]string)
+	for _, charMeta := range charMetaList {
+		if _, exists := charCodeMap[charMeta.Char]; exists {
+			continue
+		}
+		if code, exists := fallback[charMeta.Char]; !exists || charMeta.Code < code {
+			fallback[charMeta.Char] = charMeta.Code
+		}
+	}
+	if len(fallback) == 0 {
+		return charCodeMap
+	}
+	for char, code := range charCodeMap {
+		fallback[char] = code
+	}
+	return fallback
+}
+
+// CharCodeMaps 按取字编码方式缓存字符编码映射，多字词与玲珑词共用，同一方式只构建一次
+// 返回的映射只读：BuildWordsFullCode 叠加部首编码时另建映射，不会改动缓存
+type CharCodeMaps struct {
+	charMetaList []*types.CharMeta
+	maps         map[string]map[string]string
+}
+
+// NewCharCodeMaps 由单字全码列表创建映射缓存
+func NewCharCodeMaps(charMetaList []*types.CharMeta) *CharCodeMaps {
+	return &CharCodeMaps{charMetaList: charMetaList, maps: make(map[string]map[string]string)}
+}
+
+// Get 取某种方式的字符编码映射，回退映射在主拆分映射的基础上构建
+func (charCodeMaps *CharCodeMaps) Get(mode string) (map[string]string, error) {
+	if mode == "" {
+		mode = CharCodeStrict
+	}
+	if charCodeMap, exists := charCodeMaps.maps[mode]; exists {
+		return charCodeMap, nil
+	}
+	var charCodeMap map[string]string
+	var err error
+	if mode == CharCodeFallbackSecondary {
+		var strict map[string]string
+		strict, err = charCodeMaps.Get(CharCodeStrict)
+		if err == nil {
+			charCodeMap = fallbackSecondaryCodes(strict, charCodeMaps.charMetaList)
+		}
+	} else {
+		charCodeMap, err = CreateCharCodeMap(charCodeMaps.charMetaList, mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	charCodeMaps.maps[mode] = charCodeMap
+	return charCodeMap, nil
+}
+
+// CreateCharRadicalMap 实验：为每个字取部首编码
+// 先按主拆分的首个部件查 radicalMap，查不到时再按拆分表的字符集字段（Division.Set）查；都查不到的字不在结果中
+func CreateCharRadicalMap(charMetaList []*types.CharMeta, radicalMap map[string]string) map[string]string {
+	charRadicals := make(map[string]string)
+	for _, charMeta := range charMetaList {
+		if !charMeta.MDiv || charMeta.Division == nil {
+			continue
+		}
+		if len(charMeta.Division.Divs) > 0 {
+			if code, exists := radicalMap[charMeta.Division.Divs[0]]; exists {
+				charRadicals[charMeta.Char] = code
+				continue
+			}
+		}
+		if code, exists := radicalMap[charMeta.Division.Set]; exists {
+			charRadicals[charMeta.Char] = code
+		}
+	}
+	return charRadicals
+}
+
+// overlayCharRadicals 用部首编码替换字编码的开头几码，其余码位保留原编码以满足各字数的取码长度
+// 没有部首编码的字保持原编码
+func overlayCharRadicals(charCodeMap, charRadicals map[string]string) map[string]string {
+	overlaid := make(map[string]string, len(charCodeMap))
+	for char, code := range charCodeMap {
+		radical := charRadicals[char]
+		if radical == "" {
+			overlaid[char] = code
+		} else if len(radical) >= len(code) {
+			overlaid[char] = radical
+		} else {
+			overlaid[char] = radical + code[len(radical):]
+		}
+	}
+	return overlaid
 }
 
 // SortWordCodes 对多字词编码进行排序
 // 排序规则：先按权重降序排列，权重相同时按编码升序排列
 func SortWordCodes(wordCodes []*types.WordCode) {
-	sort.Slice(wordCodes, func(i, j int) bool {
+	sortSlice(wordCodes, wordCodesLess(wordCodes))
+}
+
+// SortWordCodesStable 与 SortWordCodes 规则相同，但无论全局设置如何都使用稳定排序
+func SortWordCodesStable(wordCodes []*types.WordCode) {
+	sort.SliceStable(wordCodes, wordCodesLess(wordCodes))
+}
+
+// wordCodesLess 多字词编码的排序规则
+func wordCodesLess(wordCodes []*types.WordCode) func(i, j int) bool {
+	return func(i, j int) bool {
 		a, b := wordCodes[i], wordCodes[j]
-		
+
 		// 首先按权重降序排列
 		weightA := parseWeight(a.Weight)
 		weightB := parseWeight(b.Weight)
-		
+
 		if weightA != weightB {
 			return weightA > weightB
 		}
-		
+
 		// 权重相同，按编码升序排列
 		if a.Code != b.Code {
 			return a.Code < b.Code
 		}
-		
+
 		// 权重和编码都相同，按词语Unicode编码升序排列（保持稳定排序）
 		return a.Word < b.Word
-	})
+	}
 }
 
 // parseWeight 解析权重字符串为数值
@@ -490,31 +794,44 @@ func parseWeight(weightStr string) int64 {
 	if weightStr == "" {
 		return 0
 	}
-	
+
 	// 尝试解析为整数
 	weight, err := strconv.ParseInt(weightStr, 10, 64)
 	if err != nil {
 		return 0
 	}
-	
+
 	return weight
 }
 
+// 多字词简码占位符的补位空间
+const (
+	PlaceholderSpaceAll  = "all"  // 该长度24键全空间，加上实际参与分配的基础简码（默认）
+	PlaceholderSpaceUsed = "used" // 只补实际参与分配的基础简码，即至少有一个词按规则算出的码位
+)
+
+// WordsSimpleCodeOptions 多字词简码生成选项
+type WordsSimpleCodeOptions struct {
+	PlaceholderSpace string // 空码位占位符的补位空间：all 或 used，空同 all
+}
+
 // BuildWordsSimpleCode 构建多字词简码
-func BuildWordsSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]int) []*types.WordSimpleCode {
+// 每个词按长度取基础简码：二简取"首码+第三码"（二字词特殊规则），一简、三简取编码前缀
+// 按某一规则算出过的基础简码都计入该长度参与分配的码位，无论最终是否分给该词，占位符据此补位
+func BuildWordsSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]int, opts WordsSimpleCodeOptions) []*types.WordSimpleCode {
 	// 按权重降序排序（权重高的优先分配简码）
 	sortedWordCodes := make([]*types.WordCode, len(wordCodes))
 	copy(sortedWordCodes, wordCodes)
-	sort.Slice(sortedWordCodes, func(i, j int) bool {
+	sortSlice(sortedWordCodes, func(i, j int) bool {
 		weightA := parseWeight(sortedWordCodes[i].Weight)
 		weightB := parseWeight(sortedWordCodes[j].Weight)
 		return weightA > weightB
 	})
 
 	// 初始化每个简码长度的计数器
-	codeCounters := make(map[int]map[string]int)
+	codeCounters := make(map[int]*codeTable[int])
 	for length := 1; length <= 3; length++ {
-		codeCounters[length] = make(map[string]int)
+		codeCounters[length] = newCodeTable[int]()
 	}
 
 	// 处理每个词
@@ -560,8 +877,9 @@ func BuildWordsSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]int)
 				}
 			}
 
-			// 检查是否已达到该基础简码的限制
-			currentCount := codeCounters[codeLength][baseCode]
+			// 检查是否已达到该基础简码的限制；未分配的码位也记入计数器，作为参与分配的码位
+			currentCount := codeCounters[codeLength].get(baseCode)
+			codeCounters[codeLength].set(baseCode, currentCount)
 			if currentCount < limit {
 				// 创建新的简码条目
 				simplifiedCode = baseCode
@@ -571,7 +889,7 @@ func BuildWordsSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]int)
 					Code:   simplifiedCode,
 					Weight: weight,
 				})
-				codeCounters[codeLength][baseCode] = currentCount + 1
+				codeCounters[codeLength].set(baseCode, currentCount+1)
 				break // 找到可用的简码后就不再尝试更长的简码
 			}
 		}
@@ -581,26 +899,26 @@ func BuildWordsSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]int)
 	SortWordSimpleCodes(resultData)
 
 	// 然后在排序后的结果中添加占位符
-	resultData = addPlaceholdersAfterSort(resultData, lenCodeLimit)
+	resultData = addPlaceholdersAfterSort(resultData, lenCodeLimit, placeholderBaseCodes(codeCounters, lenCodeLimit, opts.PlaceholderSpace))
 
 	return resultData
 }
 
 // BuildLinglongSimpleCode 构建玲珑多字词简码（不添加占位符）
 func BuildLinglongSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]int) []*types.WordSimpleCode {
-	// 按权重降序排序（权重高的优先分配简码）
+	// 按权重降序排序（权重高的优先分配简码），同权重保持词表顺序，保证每次分配结果一致
 	sortedWordCodes := make([]*types.WordCode, len(wordCodes))
 	copy(sortedWordCodes, wordCodes)
-	sort.Slice(sortedWordCodes, func(i, j int) bool {
+	sort.SliceStable(sortedWordCodes, func(i, j int) bool {
 		weightA := parseWeight(sortedWordCodes[i].Weight)
 		weightB := parseWeight(sortedWordCodes[j].Weight)
 		return weightA > weightB
 	})
 
 	// 初始化每个简码长度的计数器
-	codeCounters := make(map[int]map[string]int)
-	for length := 1; length <= 3; length++ {
-		codeCounters[length] = make(map[string]int)
+	codeCounters := make(map[int]*codeTable[int])
+	for length := 1; length <= 4; length++ {
+		codeCounters[length] = newCodeTable[int]()
 	}
 
 	// 处理每个词
@@ -611,9 +929,9 @@ func BuildLinglongSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]i
 		weight := wordCode.Weight
 		wordLength := len([]rune(word)) // 获取词的长度
 
-		// 按照顺序尝试分配简码：先一简，再二简，最后三简
+		// 按照顺序尝试分配简码：先一简，再二简、三简，最后四码
 		var simplifiedCode string
-		for codeLength := 1; codeLength <= 3; codeLength++ {
+		for codeLength := 1; codeLength <= 4; codeLength++ {
 			// 检查该长度是否允许
 			limit := lenCodeLimit[codeLength]
 			if limit == 0 {
@@ -627,6 +945,9 @@ func BuildLinglongSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]i
 			if codeLength == 3 && wordLength != 3 { // 三简只适用于三字词
 				continue
 			}
+			if codeLength == 4 && wordLength < 4 { // 四码只适用于四字及以上的词
+				continue
+			}
 
 			// 获取基础简码
 			var baseCode string
@@ -647,7 +968,7 @@ func BuildLinglongSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]i
 			}
 
 			// 检查是否已达到该基础简码的限制
-			currentCount := codeCounters[codeLength][baseCode]
+			currentCount := codeCounters[codeLength].get(baseCode)
 			if currentCount < limit {
 				// 创建新的简码条目
 				simplifiedCode = baseCode
@@ -657,20 +978,48 @@ func BuildLinglongSimpleCode(wordCodes []*types.WordCode, lenCodeLimit map[int]i
 					Code:   simplifiedCode,
 					Weight: weight,
 				})
-				codeCounters[codeLength][baseCode] = currentCount + 1
+				codeCounters[codeLength].set(baseCode, currentCount+1)
 				break // 找到可用的简码后就不再尝试更长的简码
 			}
 		}
 	}
 
-	// 只排序，不添加占位符
+	// 只排序，不添加占位符（结果中没有占位符，排序只走正常词分支）
 	SortWordSimpleCodes(resultData)
 
 	return resultData
 }
 
-// addPlaceholdersAfterSort 在排序后为多字词简码添加占位符
-func addPlaceholdersAfterSort(wordSimpleCodes []*types.WordSimpleCode, lenCodeLimit map[int]int) []*types.WordSimpleCode {
+// placeholderBaseCodes 按补位空间列出各简码长度需要补占位符的基础简码
+// 参与分配的码位（codeCounters 的键）总在其中，全空间模式再并上该长度的24键全空间；全空间在前，其余按字母序
+func placeholderBaseCodes(codeCounters map[int]*codeTable[int], lenCodeLimit map[int]int, space string) map[int][]string {
+	baseCodes := make(map[int][]string)
+	for codeLength := 1; codeLength <= 3; codeLength++ {
+		if lenCodeLimit[codeLength] == 0 {
+			continue
+		}
+		var codes []string
+		inSpace := make(map[string]bool)
+		if space != PlaceholderSpaceUsed {
+			forEachBaseCode(codeLength, func(baseCode string) {
+				codes = append(codes, baseCode)
+				inSpace[baseCode] = true
+			})
+		}
+		var extra []string
+		for _, baseCode := range codeCounters[codeLength].codes() {
+			if !inSpace[baseCode] {
+				extra = append(extra, baseCode)
+			}
+		}
+		sort.Strings(extra)
+		baseCodes[codeLength] = append(codes, extra...)
+	}
+	return baseCodes
+}
+
+// addPlaceholdersAfterSort 在排序后为多字词简码添加占位符，baseCodes 为各长度需要补满的码位（见 placeholderBaseCodes）
+func addPlaceholdersAfterSort(wordSimpleCodes []*types.WordSimpleCode, lenCodeLimit map[int]int, baseCodes map[int][]string) []*types.WordSimpleCode {
 	result := make([]*types.WordSimpleCode, 0, len(wordSimpleCodes))
 
 	// 按编码分组处理
@@ -701,8 +1050,8 @@ func addPlaceholdersAfterSort(wordSimpleCodes []*types.WordSimpleCode, lenCodeLi
 		result = appendGroupPlaceholders(result, currentGroup, lenCodeLimit)
 	}
 
-	// 为所有可能的基础编码添加占位符（包括空码位）
-	result = addAllPossiblePlaceholders(result, lenCodeLimit)
+	// 为补位空间中的空码位添加占位符
+	result = addAllPossiblePlaceholders(result, lenCodeLimit, baseCodes)
 
 	return result
 }
@@ -729,9 +1078,11 @@ func appendGroupPlaceholders(result []*types.WordSimpleCode, group []*types.Word
 			// 使用硬编码的占位符权重
 			weight := getPlaceholderWeight(placeholder)
 			result = append(result, &types.WordSimpleCode{
-				Word:   placeholder,
-				Code:   group[0].Code,
-				Weight: weight,
+				Word:          placeholder,
+				Code:          group[0].Code,
+				Weight:        weight,
+				IsPlaceholder: true,
+				BaseCode:      group[0].Code,
 			})
 		}
 	}
@@ -739,11 +1090,19 @@ func appendGroupPlaceholders(result []*types.WordSimpleCode, group []*types.Word
 	return result
 }
 
-// addAllPossiblePlaceholders 为所有可能的基础编码添加占位符（包括空码位）
-func addAllPossiblePlaceholders(wordSimpleCodes []*types.WordSimpleCode, lenCodeLimit map[int]int) []*types.WordSimpleCode {
+// addAllPossiblePlaceholders 为 baseCodes 中没有实际词的空码位添加完整的占位符
+func addAllPossiblePlaceholders(wordSimpleCodes []*types.WordSimpleCode, lenCodeLimit map[int]int, baseCodes map[int][]string) []*types.WordSimpleCode {
 	result := make([]*types.WordSimpleCode, len(wordSimpleCodes))
 	copy(result, wordSimpleCodes)
 
+	// 已有实际词的编码
+	actualCodes := make(map[string]bool, len(wordSimpleCodes))
+	for _, item := range wordSimpleCodes {
+		if !item.IsPlaceholder {
+			actualCodes[item.Code] = true
+		}
+	}
+
 	// 为每个简码长度和基础简码添加占位符
 	for codeLength := 1; codeLength <= 3; codeLength++ {
 		limit := lenCodeLimit[codeLength]
@@ -751,31 +1110,29 @@ func addAllPossiblePlaceholders(wordSimpleCodes []*types.WordSimpleCode, lenCode
 			continue
 		}
 
-		// 获取该长度所有可能的基础简码
-		allBaseCodes := generateAllBaseCodes(codeLength)
-		
-		for _, baseCode := range allBaseCodes {
-			// 检查该基础编码是否已经有实际词
-			hasActualWord := false
-			for _, item := range wordSimpleCodes {
-				if item.Code == baseCode && !isPlaceholder(item.Word) {
-					hasActualWord = true
-					break
-				}
-			}
-			
+		// 同一长度下每个空码位的占位符相同，只生成一次（使用硬编码的占位符权重）
+		placeholders := generatePlaceholders(1, limit, limit)
+		weights := make([]string, len(placeholders))
+		for i, placeholder := range placeholders {
+			weights[i] = getPlaceholderWeight(placeholder)
+		}
+
+		// 占位条目从同一块预分配的存储中取，避免逐条分配；容量足够，追加时不会搬移
+		slab := make([]types.WordSimpleCode, 0, len(baseCodes[codeLength])*len(placeholders))
+		for _, baseCode := range baseCodes[codeLength] {
 			// 如果没有实际词，需要添加完整的占位符
-			if !hasActualWord {
-				placeholders := generatePlaceholders(1, limit, limit)
-				for _, placeholder := range placeholders {
-					// 使用硬编码的占位符权重
-					weight := getPlaceholderWeight(placeholder)
-					result = append(result, &types.WordSimpleCode{
-						Word:   placeholder,
-						Code:   baseCode,
-						Weight: weight,
-					})
-				}
+			if actualCodes[baseCode] {
+				continue
+			}
+			for i, placeholder := range placeholders {
+				slab = append(slab, types.WordSimpleCode{
+					Word:          placeholder,
+					Code:          baseCode,
+					Weight:        weights[i],
+					IsPlaceholder: true,
+					BaseCode:      baseCode,
+				})
+				result = append(result, &slab[len(slab)-1])
 			}
 		}
 	}
@@ -783,6 +1140,36 @@ func addAllPossiblePlaceholders(wordSimpleCodes []*types.WordSimpleCode, lenCode
 	return result
 }
 
+// BuildCharSimplePlaceholders 为单字简码的空位生成占位条目
+// 空位指"1简/2简前缀+末码"中没有字占用的编码，仅处理简码长度限制不为0的前缀长度
+// 占位符号与权重沿用多字词简码的配置，权重为负，排序时真实字在前
+func BuildCharSimplePlaceholders(simpleCodeList []*types.CharMeta, lenCodeLimit map[int]int) []*DictEntry {
+	usedCodes := make(map[string]bool, len(simpleCodeList))
+	for _, charMeta := range simpleCodeList {
+		usedCodes[charMeta.Code] = true
+	}
+
+	placeholder := generatePlaceholders(1, 1, 1)[0]
+	freq, _ := strconv.ParseInt(getPlaceholderWeight(placeholder), 10, 64)
+
+	var entries []*DictEntry
+	for prefixLength := 1; prefixLength <= 2; prefixLength++ {
+		if lenCodeLimit[prefixLength] == 0 {
+			continue
+		}
+		forEachBaseCode(prefixLength, func(prefix string) {
+			for _, suffix := range suffixKeys {
+				code := prefix + suffix
+				if !usedCodes[code] {
+					entries = append(entries, &DictEntry{Text: placeholder, Code: code, Freq: freq})
+				}
+			}
+		})
+	}
+
+	return entries
+}
+
 // addPlaceholders 为多字词简码添加占位符
 func addPlaceholders(wordSimpleCodes []*types.WordSimpleCode, codeCounters map[int]map[string]int, lenCodeLimit map[int]int) []*types.WordSimpleCode {
 	result := make([]*types.WordSimpleCode, len(wordSimpleCodes))
@@ -795,12 +1182,9 @@ func addPlaceholders(wordSimpleCodes []*types.WordSimpleCode, codeCounters map[i
 			continue
 		}
 
-		// 获取该长度所有可能的基础简码
-		allBaseCodes := generateAllBaseCodes(codeLength)
-		
-		for _, baseCode := range allBaseCodes {
+		forEachBaseCode(codeLength, func(baseCode string) {
 			currentCount := codeCounters[codeLength][baseCode]
-			
+
 			// 如果当前数量小于限制，需要添加占位符
 			if currentCount < limit {
 				// 占位符从当前数量+1开始编号
@@ -809,105 +1193,121 @@ func addPlaceholders(wordSimpleCodes []*types.WordSimpleCode, codeCounters map[i
 				placeholders := generatePlaceholders(startIndex, count, limit)
 				for _, placeholder := range placeholders {
 					result = append(result, &types.WordSimpleCode{
-						Word:   placeholder,
-						Code:   baseCode,
-						Weight: "0", // 占位符权重设为0
+						Word:          placeholder,
+						Code:          baseCode,
+						Weight:        "0", // 占位符权重设为0
+						IsPlaceholder: true,
+						BaseCode:      baseCode,
 					})
 				}
 			}
-		}
+		})
 	}
 
 	return result
 }
 
-// generateAllBaseCodes 生成所有可能的基础简码组合
+// baseCodeCache 各长度的基础简码全空间，首次用到时生成，之后只读复用
+var (
+	baseCodeCache     [4][]string
+	baseCodeCacheOnce [4]sync.Once
+)
+
+// forEachBaseCode 按字母表顺序依次回调长度为 codeLength（1 至 3）的全部基础简码
+// 全空间按长度在首次使用时生成一次并缓存，普通词表、玲珑词表与单字占位共用，不再每次新建切片
+func forEachBaseCode(codeLength int, yield func(code string)) {
+	for _, code := range generateAllBaseCodes(codeLength) {
+		yield(code)
+	}
+}
+
+// generateAllBaseCodes 返回所有可能的基础简码组合（24键，长度 1 至 3），结果为共享缓存，调用方不得修改
 func generateAllBaseCodes(codeLength int) []string {
-	// 24个键：qtypasdfghjkl;zxcvbnm,./
-	keys := []string{"q", "t", "y", "p", "a", "s", "d", "f", "g", "h", "j", "k", "l", ";", "z", "x", "c", "v", "b", "n", "m", ",", ".", "/"}
-	
-	if codeLength == 1 {
-		return keys
-	}
-	
-	// 生成所有可能的组合
-	var result []string
-	switch codeLength {
-	case 2:
-		for _, first := range keys {
-			for _, second := range keys {
-				result = append(result, first+second)
-			}
-		}
-	case 3:
-		for _, first := range keys {
-			for _, second := range keys {
-				for _, third := range keys {
-					result = append(result, first+second+third)
+	if codeLength < 1 || codeLength > 3 {
+		return nil
+	}
+	baseCodeCacheOnce[codeLength].Do(func() {
+		codes := []string{""}
+		for i := 0; i < codeLength; i++ {
+			next := make([]string, 0, len(codes)*len(codeKeys))
+			for _, code := range codes {
+				for _, key := range codeKeys {
+					next = append(next, code+key)
 				}
 			}
+			codes = next
 		}
-	default:
-		return nil
-	}
-	
-	return result
+		baseCodeCache[codeLength] = codes
+	})
+	return baseCodeCache[codeLength]
 }
 
 // SortWordSimpleCodes 对多字词简码进行排序
 // 排序规则：先按编码升序排列，编码相同时按权重降序排列，占位符排在正常词后面
 func SortWordSimpleCodes(wordSimpleCodes []*types.WordSimpleCode) {
-	sort.Slice(wordSimpleCodes, func(i, j int) bool {
+	sort.SliceStable(wordSimpleCodes, func(i, j int) bool {
 		a, b := wordSimpleCodes[i], wordSimpleCodes[j]
+		return wordSimpleCodeLess(a.Code, a.Word, a.IsPlaceholder, parseWeight(a.Weight), b.Code, b.Word, b.IsPlaceholder, parseWeight(b.Weight))
+	})
+}
 
-		// 首先按编码升序排列
-		if a.Code != b.Code {
-			return a.Code < b.Code
-		}
-
-		// 编码相同，检查是否为占位符
-		aIsPlaceholder := isPlaceholder(a.Word)
-		bIsPlaceholder := isPlaceholder(b.Word)
-		
-		// 占位符排在正常词后面
-		if aIsPlaceholder != bIsPlaceholder {
-			return !aIsPlaceholder // 如果a不是占位符而b是占位符，a排在前面
-		}
+// wordSimpleCodeLess 多字词简码的比较规则，SortWordSimpleCodes 与词简码字典的排序共用
+// 是否为占位符由调用方给出：构建结果按来源标记，字典文件没有标记时按字符判断
+func wordSimpleCodeLess(aCode, aWord string, aIsPlaceholder bool, weightA int64, bCode, bWord string, bIsPlaceholder bool, weightB int64) bool {
+	// 首先按编码升序排列
+	if aCode != bCode {
+		return aCode < bCode
+	}
 
-		// 如果都是占位符，按占位符编号升序排列
-		if aIsPlaceholder && bIsPlaceholder {
-			return getPlaceholderIndex(a.Word) < getPlaceholderIndex(b.Word)
-		}
+	// 编码相同，占位符排在正常词后面
+	if aIsPlaceholder != bIsPlaceholder {
+		return !aIsPlaceholder // 如果a不是占位符而b是占位符，a排在前面
+	}
 
-		// 都是正常词，按权重降序排列
-		weightA := parseWeight(a.Weight)
-		weightB := parseWeight(b.Weight)
+	// 如果都是占位符，按占位符编号升序排列
+	if aIsPlaceholder && bIsPlaceholder {
+		return getPlaceholderIndex(aWord) < getPlaceholderIndex(bWord)
+	}
 
-		if weightA != weightB {
-			return weightA > weightB
-		}
+	// 都是正常词，按权重降序排列
+	if weightA != weightB {
+		return weightA > weightB
+	}
 
-		// 编码和权重都相同，按词语Unicode编码升序排列（保持稳定排序）
-		return a.Word < b.Word
-	})
+	// 编码和权重都相同，按词语Unicode编码升序排列（保持稳定排序）
+	return aWord < bWord
 }
 
-// isPlaceholder 检查是否为占位符
-func isPlaceholder(word string) bool {
+// IsPlaceholder 检查是否为占位符字符（①至⑩），用于占位符编号、权重与词表冲突检查
+// 条目是否为占位符看 WordSimpleCode.IsPlaceholder，真实词也可能恰好是这些字符
+func IsPlaceholder(word string) bool {
 	// 占位符是①、②、③、④等字符
-	if len(word) == 1 {
-		r := rune(word[0])
-		return r >= '①' && r <= '⑩'
+	r, size := utf8.DecodeRuneInString(word)
+	return size == len(word) && r >= '①' && r <= '⑩'
+}
+
+// PlaceholderKey 占位符集合的键：词语与编码，同一码位上真实词与占位符不会同时存在
+func PlaceholderKey(word, code string) string {
+	return word + "\t" + code
+}
+
+// PlaceholderSet 收集简码条目中占位符的 PlaceholderKey，供读回码表文件的字典追加、跟打词提按来源区分占位符
+func PlaceholderSet(wordSimpleCodes []*types.WordSimpleCode) map[string]bool {
+	set := make(map[string]bool)
+	for _, wordSimpleCode := range wordSimpleCodes {
+		if wordSimpleCode.IsPlaceholder {
+			set[PlaceholderKey(wordSimpleCode.Word, wordSimpleCode.Code)] = true
+		}
 	}
-	return false
+	return set
 }
 
 // getPlaceholderIndex 获取占位符的编号（①=1, ②=2, ...）
 func getPlaceholderIndex(word string) int {
-	if !isPlaceholder(word) {
+	if !IsPlaceholder(word) {
 		return 0
 	}
-	r := rune(word[0])
+	r, _ := utf8.DecodeRuneInString(word)
 	return int(r - '①' + 1)
 }
 
@@ -926,11 +1326,11 @@ func getPlaceholderWeight(word string) string {
 		"⑨": "-9",
 		"⑩": "-10",
 	}
-	
+
 	if weight, exists := weightMap[word]; exists {
 		return weight
 	}
-	
+
 	// 对于未知占位符，返回默认值
 	return "-0"
 }
@@ -942,133 +1342,256 @@ type DictEntry struct {
 	Freq int64
 }
 
+// DictAppendOptions 字典追加选项
+type DictAppendOptions struct {
+	HeaderPreserve   bool             // 追加前重写目标文件：保留头部与完整的旧数据，丢弃上次中断留下的残行，再原子替换
+	ExtraEntries     []*DictEntry     // 与源文件条目合并后一同排序写入的额外条目（如占位符），不写回源文件
+	ExcludeCodes     []*regexp.Regexp // 编码匹配任一正则的条目不写入目标文件（生成端过滤，与字典头部 exclude_patterns 无关）
+	SimpleCharsFile  string           // 单字简码表路径，LL.chars.full.dict.yaml 据此下移简码汉字，为空时跳过，文件不存在时不处理
+	CountLines       bool             // 统计追加前后目标文件的条目行数，填入结果的 LinesBefore、LinesAfter
+	SuffixOrder      []string         // 非 nil 时同前缀的条目按该末码顺序排在一起，其余仍按编码字母序（用于 LL.chars.quick）
+	PlaceholderAware bool             // 同码组按多字词简码的规则排序：真实词在前按词频降序，占位符在后按编号（用于词简码字典）
+	Placeholders     map[string]bool  // PlaceholderAware 时源文件中哪些条目是占位符（PlaceholderSet），不在其中的按真实词排序
+	Rules            *ExperienceRules // LL.chars.full.dict.yaml 出简让全时应用的 chars_full 体验规则，nil 时使用默认规则集
+}
+
+// DictAppendResult 字典追加结果
+type DictAppendResult struct {
+	Written     int // 写入的条目数
+	Excluded    int // 被 ExcludeCodes 排除的条目数
+	LinesBefore int // 追加前目标文件的条目行数（仅 CountLines 时统计）
+	LinesAfter  int // 追加后目标文件的条目行数（仅 CountLines 时统计）
+}
+
+// excludedCode 判断编码是否匹配任一排除正则
+func excludedCode(code string, patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(code) {
+			return true
+		}
+	}
+	return false
+}
+
 // AppendToDictFile 将源文件内容追加到目标字典文件
 // sourceFile: 源文件路径
 // targetFile: 目标字典文件路径
 // needSort: 是否需要排序（编码升序，重码组内按词频降序）
 // removeFreq: 是否需要删除词频列
-func AppendToDictFile(sourceFile, targetFile string, needSort, removeFreq bool) error {
-	var sourceContent string
-	var err error
-	
+// opts: 追加选项
+func AppendToDictFile(sourceFile, targetFile string, needSort, removeFreq bool, opts DictAppendOptions) (*DictAppendResult, error) {
+	var lines []string
+	result := &DictAppendResult{}
+	if opts.CountLines {
+		entries, err := readDictFile(targetFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取目标文件失败: %w", err)
+		}
+		result.LinesBefore = len(entries)
+	}
+
 	if needSort {
 		// 如果需要排序，使用readSourceFile读取完整的DictEntry列表
 		entries, err := readSourceFile(sourceFile, !removeFreq) // 保留词频用于排序
 		if err != nil {
-			return fmt.Errorf("读取源文件失败: %w", err)
+			return nil, fmt.Errorf("读取源文件失败: %w", err)
 		}
-		
+		entries = append(entries, opts.ExtraEntries...)
+
 		// 排序
-		sortDictEntries(entries)
-		
-		// 对LL.chars.full.dict.yaml进行特殊处理：简码汉字下移
-		if strings.Contains(targetFile, "LL.chars.full.dict.yaml") {
-			entries = processSimpleCharsInFullDict(entries)
-		}
-		
-		// 构建排序后的内容
-		var result strings.Builder
+		if opts.SuffixOrder != nil {
+			sortDictEntriesBySuffix(entries, opts.SuffixOrder)
+		} else if opts.PlaceholderAware {
+			sortDictEntriesPlaceholderAware(entries, opts.Placeholders)
+		} else {
+			sortDictEntries(entries)
+		}
+
+		// 对LL.chars.full.dict.yaml进行特殊处理：简码汉字下移（未给出简码表时跳过）
+		if strings.Contains(targetFile, "LL.chars.full.dict.yaml") && opts.SimpleCharsFile != "" {
+			entries = processSimpleCharsInFullDict(entries, opts.SimpleCharsFile, opts.Rules)
+		}
+
 		for _, entry := range entries {
-			result.WriteString(fmt.Sprintf("%s\t%s\n", entry.Text, entry.Code))
+			lines = append(lines, fmt.Sprintf("%s\t%s", entry.Text, entry.Code))
 		}
-		sourceContent = result.String()
 	} else {
 		// 如果不需要排序，直接读取内容
-		sourceContent, err = readSourceFileContent(sourceFile, removeFreq)
+		sourceContent, err := readSourceFileContent(sourceFile, removeFreq)
 		if err != nil {
-			return fmt.Errorf("读取源文件失败: %w", err)
+			return nil, fmt.Errorf("读取源文件失败: %w", err)
+		}
+		lines = strings.Split(strings.TrimSuffix(sourceContent, "\n"), "\n")
+		for _, entry := range opts.ExtraEntries {
+			lines = append(lines, fmt.Sprintf("%s\t%s", entry.Text, entry.Code))
 		}
 	}
-	
-	// 简单的追加操作：在目标文件末尾添加源文件内容
-	err = appendToFile(targetFile, sourceContent)
-	if err != nil {
-		return fmt.Errorf("追加到目标文件失败: %w", err)
-	}
-	
-	return nil
-}
 
-// readSourceFileContent 读取源文件内容并处理词频列
-func readSourceFileContent(filepath string, removeFreq bool) (string, error) {
-	file, err := os.Open(filepath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-	
+	// 构建要写入的内容，跳过编码被排除的条目
 	var content strings.Builder
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
+	for _, line := range lines {
+		if line == "" {
 			continue
 		}
-		
-		fields := strings.Split(line, "\t")
-		if len(fields) < 2 {
+		if excludedCode(dictLineCode(line), opts.ExcludeCodes) {
+			result.Excluded++
 			continue
 		}
-		
-		// 如果需要删除词频，只保留前两列
-		if removeFreq && len(fields) >= 3 {
-			content.WriteString(fmt.Sprintf("%s\t%s\n", fields[0], fields[1]))
-		} else {
-			content.WriteString(line + "\n")
+		content.WriteString(line + "\n")
+		result.Written++
+	}
+	sourceContent := content.String()
+	var err error
+
+	if opts.HeaderPreserve {
+		// 重写头部与旧数据后原子替换目标文件
+		err = rewriteDictFile(targetFile, sourceContent)
+		if err != nil {
+			return nil, fmt.Errorf("重写目标文件失败: %w", err)
+		}
+	} else {
+		// 简单的追加操作：在目标文件末尾添加源文件内容
+		err = appendToFile(targetFile, sourceContent)
+		if err != nil {
+			return nil, fmt.Errorf("追加到目标文件失败: %w", err)
 		}
 	}
-	
-	if err := scanner.Err(); err != nil {
-		return "", err
+
+	if opts.CountLines {
+		entries, err := readDictFile(targetFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取目标文件失败: %w", err)
+		}
+		result.LinesAfter = len(entries)
 	}
-	
-	return content.String(), nil
+
+	return result, nil
 }
 
-// sortSourceContent 对源文件内容进行排序
-func sortSourceContent(content string) string {
-	lines := strings.Split(strings.TrimSpace(content), "\n")
-	
-	// 解析为DictEntry列表进行排序
-	var entries []*DictEntry
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+// rewriteDictFile 以"头部 + 排序后的旧数据 + 新数据"重写字典文件
+// 先写入同目录下的临时文件，成功后原子重命名覆盖原文件
+func rewriteDictFile(targetFile, newContent string) error {
+	originalContent, err := readDictFileContent(targetFile)
+	if err != nil {
+		return err
+	}
+
+	header, oldLines := splitDictFileContent(originalContent)
+	if header == "" {
+		header = getDefaultHeader(targetFile)
+	}
+
+	// 旧数据按编码稳定排序，同码条目保持原有顺序
+	sort.SliceStable(oldLines, func(i, j int) bool {
+		return dictLineCode(oldLines[i]) < dictLineCode(oldLines[j])
+	})
+
+	var content strings.Builder
+	content.WriteString(header)
+	for _, line := range oldLines {
+		content.WriteString(line + "\n")
+	}
+	content.WriteString(newContent)
+
+	return writeFileAtomic(targetFile, []byte(content.String()))
+}
+
+// writeFileAtomic 先写入同目录下的临时文件，成功后原子重命名覆盖目标文件
+// 目标文件已存在时沿用其权限
+func writeFileAtomic(targetFile string, content []byte) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(targetFile), filepath.Base(targetFile)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+	defer os.Remove(tempPath) // 重命名成功后为空操作
+
+	// 沿用原文件权限，CreateTemp 默认仅属主可读写
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(targetFile); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := tempFile.Chmod(mode); err != nil {
+		tempFile.Close()
+		return err
+	}
+
+	if _, err := tempFile.Write(content); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempPath, targetFile)
+}
+
+// splitDictFileContent 拆分字典文件内容为头部与数据行
+// 文件不以换行结尾时，最后一行视为上次中断写入的残行并丢弃
+func splitDictFileContent(content string) (string, []string) {
+	if content == "" {
+		return "", nil
+	}
+
+	dataStart := findDataSectionStart(content)
+	if dataStart < 0 {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
 		}
-		fields := strings.Split(line, "\t")
-		if len(fields) >= 2 {
-			entry := &DictEntry{
-				Text: fields[0],
-				Code: fields[1],
-			}
-			// 如果有词频信息，解析词频
-			if len(fields) >= 3 {
-				freq, err := strconv.ParseInt(fields[2], 10, 64)
-				if err == nil {
-					entry.Freq = freq
-				} else {
-					// 如果解析失败，设置默认词频为0
-					entry.Freq = 0
-				}
-			} else {
-				// 如果没有词频信息，设置默认词频为0
-				entry.Freq = 0
-			}
-			entries = append(entries, entry)
+		return content, nil
+	}
+
+	header := content[:dataStart]
+	data := content[dataStart:]
+	if idx := strings.LastIndex(data, "\n"); idx >= 0 {
+		data = data[:idx]
+	} else {
+		data = ""
+	}
+
+	var lines []string
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
 		}
+		lines = append(lines, line)
 	}
-	
-	// 排序
-	sortDictEntries(entries)
-	
-	// 重新构建内容
-	var result strings.Builder
-	for _, entry := range entries {
-		result.WriteString(fmt.Sprintf("%s\t%s\n", entry.Text, entry.Code))
+
+	return header, lines
+}
+
+// dictLineCode 提取字典数据行的编码列
+func dictLineCode(line string) string {
+	fields := strings.Split(line, "\t")
+	if len(fields) < 2 {
+		return ""
 	}
-	
-	return result.String()
+	return fields[1]
+}
+
+// readSourceFileContent 读取源文件内容并处理词频列
+func readSourceFileContent(filepath string, removeFreq bool) (string, error) {
+	var content strings.Builder
+	err := tabfile.ScanFile(filepath, tabfile.Options{TrimSpace: true, MinColumns: 2}, func(row *tabfile.Row) error {
+		// 如果需要删除词频，只保留前两列
+		if removeFreq && row.Len() >= 3 {
+			content.WriteString(fmt.Sprintf("%s\t%s\n", row.Column(0), row.Column(1)))
+		} else {
+			content.WriteString(row.Text + "\n")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return content.String(), nil
 }
 
 // appendToFile 将内容追加到文件末尾
@@ -1078,105 +1601,72 @@ func appendToFile(filepath, content string) error {
 		return err
 	}
 	defer file.Close()
-	
+
 	_, err = file.WriteString(content)
 	return err
 }
 
 // readSourceFile 读取源文件并解析为DictEntry列表
 func readSourceFile(filepath string, removeFreq bool) ([]*DictEntry, error) {
-	file, err := os.Open(filepath)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-	
 	var entries []*DictEntry
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		
-		fields := strings.Split(line, "\t")
-		if len(fields) < 2 {
-			continue
-		}
-		
+	err := tabfile.ScanFile(filepath, tabfile.Options{TrimSpace: true, MinColumns: 2}, func(row *tabfile.Row) error {
 		entry := &DictEntry{
-			Text: fields[0],
-			Code: fields[1],
+			Text: row.Column(0),
+			Code: row.Column(1),
 		}
-		
+
 		// 如果有第三列且不需要删除词频，解析词频
-		if len(fields) >= 3 && !removeFreq {
-			freq, err := strconv.ParseInt(fields[2], 10, 64)
-			if err == nil {
+		if !removeFreq {
+			if freq, ok := row.Int64(2); ok {
 				entry.Freq = freq
 			}
 		}
-		
+
 		entries = append(entries, entry)
-	}
-	
-	if err := scanner.Err(); err != nil {
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-	
+
 	return entries, nil
 }
 
 // readDictFile 读取字典文件并解析为DictEntry列表
 func readDictFile(filepath string) ([]*DictEntry, error) {
-	file, err := os.Open(filepath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// 文件不存在，返回空列表
-			return []*DictEntry{}, nil
-		}
-		return nil, err
-	}
-	defer file.Close()
-	
 	var entries []*DictEntry
-	scanner := bufio.NewScanner(file)
-	
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		
-		// 跳过注释和元数据
-		if strings.HasPrefix(line, "#") || line == "---" || line == "..." {
-			continue
+	err := tabfile.ScanFile(filepath, tabfile.Options{TrimSpace: true}, func(row *tabfile.Row) error {
+		line := row.Text
+
+		// 跳过元数据
+		if line == "---" || line == "..." {
+			return nil
 		}
-		
+
 		// 检查是否进入数据部分
 		if strings.HasPrefix(line, "name:") || strings.HasPrefix(line, "version:") ||
-		   strings.HasPrefix(line, "sort:") || strings.HasPrefix(line, "columns:") ||
-		   strings.HasPrefix(line, "encoder:") {
-			continue
-		}
-		
-		// 跳过空行
-		if line == "" {
-			continue
+			strings.HasPrefix(line, "sort:") || strings.HasPrefix(line, "columns:") ||
+			strings.HasPrefix(line, "encoder:") {
+			return nil
 		}
-		
+
 		// 解析数据行
-		fields := strings.Split(line, "\t")
-		if len(fields) >= 2 {
-			entry := &DictEntry{
-				Text: fields[0],
-				Code: fields[1],
-			}
-			entries = append(entries, entry)
+		if row.Len() >= 2 {
+			entries = append(entries, &DictEntry{
+				Text: row.Column(0),
+				Code: row.Column(1),
+			})
 		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		// 文件不存在，返回空列表
+		return []*DictEntry{}, nil
 	}
-	
-	if err := scanner.Err(); err != nil {
+	if err != nil {
 		return nil, err
 	}
-	
+
 	return entries, nil
 }
 
@@ -1186,73 +1676,95 @@ func sortDictEntries(entries []*DictEntry) {
 	// 使用sort.SliceStable进行稳定排序，确保词频相同时保持原始顺序
 	sort.SliceStable(entries, func(i, j int) bool {
 		a, b := entries[i], entries[j]
-		
+
 		// 首先按编码升序排列
 		if a.Code != b.Code {
 			return a.Code < b.Code
 		}
-		
+
 		// 编码相同，按词频降序排列
 		return a.Freq > b.Freq
 	})
 }
 
-// processSimpleCharsInFullDict 对LL.chars.full.dict.yaml中的简码汉字进行特殊处理
-func processSimpleCharsInFullDict(entries []*DictEntry) []*DictEntry {
+// sortDictEntriesPlaceholderAware 按多字词简码的规则排序字典条目，词频即权重
+// 避免词频同为 0 时占位符排到真实词前面
+// 字典源文件没有来源标记，占位符由 placeholders（PlaceholderSet）给出，不看字符本身
+func sortDictEntriesPlaceholderAware(entries []*DictEntry, placeholders map[string]bool) {
+	isPlaceholder := func(entry *DictEntry) bool { return placeholders[PlaceholderKey(entry.Text, entry.Code)] }
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		return wordSimpleCodeLess(a.Code, a.Text, isPlaceholder(a), a.Freq, b.Code, b.Text, isPlaceholder(b), b.Freq)
+	})
+}
+
+// sortDictEntriesBySuffix 与 sortDictEntries 相同，但同前缀、末码属于 suffixes 的编码排在该前缀其它编码之前，并按 suffixes 的顺序排列
+// 与 lua 候选面板按末码固定位置展示的顺序一致
+func sortDictEntriesBySuffix(entries []*DictEntry, suffixes []string) {
+	sortKeys := make(map[*DictEntry]string, len(entries))
+	for _, entry := range entries {
+		sortKeys[entry] = suffixSortKey(entry.Code, suffixes)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if sortKeys[a] != sortKeys[b] {
+			return sortKeys[a] < sortKeys[b]
+		}
+		return a.Freq > b.Freq
+	})
+}
+
+// suffixSortKey 把编码中属于 suffixes 的末码换成按顺序递增、小于任何键位字符的字节，其余编码保持原样
+func suffixSortKey(code string, suffixes []string) string {
+	if len(code) == 0 {
+		return code
+	}
+	last := code[len(code)-1:]
+	for rank, suffix := range suffixes {
+		if last == suffix {
+			return code[:len(code)-1] + string(rune(1+rank))
+		}
+	}
+	return code
+}
+
+// processSimpleCharsInFullDict 对LL.chars.full.dict.yaml中的简码汉字进行特殊处理，并应用 chars_full 体验规则
+func processSimpleCharsInFullDict(entries []*DictEntry, simpleFile string, rules *ExperienceRules) []*DictEntry {
 	// 读取简码文件，构建简码汉字映射
-	simpleChars := loadSimpleChars()
-	
+	simpleChars := loadSimpleChars(simpleFile)
+	if rules == nil {
+		rules = DefaultExperienceRules()
+	}
+
 	// 按编码分组处理
 	groupedEntries := groupEntriesByCode(entries)
-	
+
 	// 对每个编码组进行特殊处理，然后重新组装
 	result := make([]*DictEntry, 0, len(entries))
 	for _, group := range groupedEntries {
-		processedGroup := processCodeGroup(group, simpleChars)
+		processedGroup := processCodeGroup(group, simpleChars, rules)
 		result = append(result, processedGroup...)
 	}
-	
+
 	return result
 }
 
 // loadSimpleChars 从code_chars_simp.txt加载简码汉字信息
-func loadSimpleChars() map[string]int {
+func loadSimpleChars(simpleFile string) map[string]int {
 	simpleChars := make(map[string]int)
-	
-	// 简码文件路径，这里假设在deploy/tmp目录下
-	simpleFile := "../deploy/tmp/code_chars_simp.txt"
-	file, err := os.Open(simpleFile)
-	if err != nil {
-		// 如果文件不存在，返回空映射
-		return simpleChars
-	}
-	defer file.Close()
-	
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		
-		fields := strings.Split(line, "\t")
-		if len(fields) < 2 {
-			continue
-		}
-		
-		char := fields[0]
-		code := fields[1]
-		
+
+	// 文件不存在或读取出错时返回已读到的部分（不存在时为空映射）
+	tabfile.ScanFile(simpleFile, tabfile.Options{TrimSpace: true, MinColumns: 2}, func(row *tabfile.Row) error {
+		char := row.Column(0)
+		code := row.Column(1)
+
 		// 根据编码长度判断是一简还是二简
-		// 一简：编码长度为1或2（一简+补码）
-		// 二简：编码长度为2或3（二简+补码）
-		if len(code) == 1 || len(code) == 2 {
-			simpleChars[char] = 1 // 一简（包括一简+补码）
-		} else if len(code) == 3 {
-			simpleChars[char] = 2 // 二简（包括二简+补码）
+		if level := simpleCharLevel(code); level > 0 {
+			simpleChars[char] = level
 		}
-	}
-	
+		return nil
+	})
+
 	return simpleChars
 }
 
@@ -1260,51 +1772,50 @@ func loadSimpleChars() map[string]int {
 func groupEntriesByCode(entries []*DictEntry) [][]*DictEntry {
 	groups := make(map[string][]*DictEntry)
 	codeOrder := make([]string, 0)
-	
+
 	for _, entry := range entries {
 		if _, exists := groups[entry.Code]; !exists {
 			codeOrder = append(codeOrder, entry.Code)
 		}
 		groups[entry.Code] = append(groups[entry.Code], entry)
 	}
-	
+
 	// 按原有编码顺序转换为切片
 	result := make([][]*DictEntry, 0, len(groups))
 	for _, code := range codeOrder {
 		result = append(result, groups[code])
 	}
-	
+
 	return result
 }
 
-// processCodeGroup 处理单个编码组的简码汉字特殊排序
-func processCodeGroup(group []*DictEntry, simpleChars map[string]int) []*DictEntry {
-	if len(group) < 3 {
-		// 如果重码组内候选不足三个，不应用特殊规则
-		return group
+// processCodeGroup 处理单个编码组的简码汉字特殊排序，再应用 chars_full 体验规则
+func processCodeGroup(group []*DictEntry, simpleChars map[string]int, rules *ExperienceRules) []*DictEntry {
+	result := group
+	// 重码组内候选不足三个时不下移简码汉字
+	if len(group) >= 3 {
+		// 创建副本进行处理，避免影响原始数据
+		result = make([]*DictEntry, len(group))
+		copy(result, group)
+
+		// 第一步：处理一简汉字，下移2行
+		result = moveSimpleChars(result, simpleChars, 1, 2)
+
+		// 第二步：处理二简汉字，下移2行
+		result = moveSimpleChars(result, simpleChars, 2, 2)
 	}
-	
-	// 创建副本进行处理，避免影响原始数据
-	result := make([]*DictEntry, len(group))
-	copy(result, group)
-	
-	// 第一步：处理一简汉字，下移2行
-	result = moveSimpleChars(result, simpleChars, 1, 2)
-	
-	// 第二步：处理二简汉字，下移2行
-	result = moveSimpleChars(result, simpleChars, 2, 2)
-	
-	// 第三步：处理"的"、"了"二字，下移2位
-	result = moveSpecialChars(result)
-	
-	return result
+
+	// 第三步：按体验规则调整（默认规则为"的"、"了"二字下移2位）
+	return applyOrderRules(rules, RuleScopeCharsFull, group[0].Code, result, func(entry *DictEntry) string {
+		return entry.Text
+	})
 }
 
 // moveSimpleChars 移动简码汉字
 func moveSimpleChars(group []*DictEntry, simpleChars map[string]int, simpleType int, moveCount int) []*DictEntry {
 	result := make([]*DictEntry, len(group))
 	copy(result, group)
-	
+
 	// 找到所有指定类型的简码汉字
 	simpleIndices := make([]int, 0)
 	for i, entry := range result {
@@ -1312,7 +1823,7 @@ func moveSimpleChars(group []*DictEntry, simpleChars map[string]int, simpleType
 			simpleIndices = append(simpleIndices, i)
 		}
 	}
-	
+
 	// 对每个简码汉字进行移动（从后往前处理，避免索引变化）
 	for i := len(simpleIndices) - 1; i >= 0; i-- {
 		idx := simpleIndices[i]
@@ -1325,35 +1836,7 @@ func moveSimpleChars(group []*DictEntry, simpleChars map[string]int, simpleType
 			result[idx+moveCount] = temp
 		}
 	}
-	
-	return result
-}
 
-// moveSpecialChars 移动特殊字符"的"和"了"
-func moveSpecialChars(group []*DictEntry) []*DictEntry {
-	result := make([]*DictEntry, len(group))
-	copy(result, group)
-	
-	specialChars := map[string]bool{
-		"的": true,
-		"了": true,
-	}
-	
-	// 找到特殊字符的位置
-	for i, entry := range result {
-		if specialChars[entry.Text] {
-			// 下移2位
-			if i+2 < len(result) {
-				temp := result[i]
-				for j := i; j < i+2; j++ {
-					result[j] = result[j+1]
-				}
-				result[i+2] = temp
-			}
-			break // 每次只处理一个特殊字符
-		}
-	}
-	
 	return result
 }
 
@@ -1364,18 +1847,18 @@ func mergeDictEntries(existing, new []*DictEntry) []*DictEntry {
 	for _, entry := range existing {
 		existingMap[entry.Text] = entry.Code
 	}
-	
+
 	// 创建结果列表，先包含现有条目
 	result := make([]*DictEntry, len(existing))
 	copy(result, existing)
-	
+
 	// 添加新条目，避免重复
 	for _, entry := range new {
 		if _, exists := existingMap[entry.Text]; !exists {
 			result = append(result, entry)
 		}
 	}
-	
+
 	return result
 }
 
@@ -1386,15 +1869,15 @@ func writeDictFile(filepath string, entries []*DictEntry) error {
 	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
-	
+
 	file, err := os.Create(filepath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	
+
 	writer := bufio.NewWriter(file)
-	
+
 	// 写入原始头部信息
 	if originalContent != "" {
 		// 找到数据部分的开始位置
@@ -1410,7 +1893,7 @@ func writeDictFile(filepath string, entries []*DictEntry) error {
 		// 文件不存在，写入默认头部
 		writer.WriteString(getDefaultHeader(filepath))
 	}
-	
+
 	// 写入数据条目
 	for _, entry := range entries {
 		line := fmt.Sprintf("%s\t%s\n", entry.Text, entry.Code)
@@ -1418,10 +1901,10 @@ func writeDictFile(filepath string, entries []*DictEntry) error {
 			return err
 		}
 	}
-	
+
 	// 写入尾部信息
 	writer.WriteString("...\n")
-	
+
 	return writer.Flush()
 }
 
@@ -1435,34 +1918,34 @@ func readDictFileContent(filepath string) (string, error) {
 		return "", err
 	}
 	defer file.Close()
-	
+
 	content, err := os.ReadFile(filepath)
 	if err != nil {
 		return "", err
 	}
-	
+
 	return string(content), nil
 }
 
 // findDataSectionStart 找到数据部分的开始位置
 func findDataSectionStart(content string) int {
 	lines := strings.Split(content, "\n")
-	
+
 	for i, line := range lines {
 		trimmed := strings.TrimSpace(line)
 		// 数据行以非注释、非元数据的制表符分隔内容开始
 		if trimmed != "" &&
-		   !strings.HasPrefix(trimmed, "#") &&
-		   !strings.HasPrefix(trimmed, "---") &&
-		   !strings.HasPrefix(trimmed, "...") &&
-		   !strings.HasPrefix(trimmed, "name:") &&
-		   !strings.HasPrefix(trimmed, "version:") &&
-		   !strings.HasPrefix(trimmed, "sort:") &&
-		   !strings.HasPrefix(trimmed, "columns:") &&
-		   !strings.HasPrefix(trimmed, "encoder:") &&
-		   !strings.HasPrefix(trimmed, "exclude_patterns:") &&
-		   !strings.HasPrefix(trimmed, "rules:") &&
-		   strings.Contains(trimmed, "\t") {
+			!strings.HasPrefix(trimmed, "#") &&
+			!strings.HasPrefix(trimmed, "---") &&
+			!strings.HasPrefix(trimmed, "...") &&
+			!strings.HasPrefix(trimmed, "name:") &&
+			!strings.HasPrefix(trimmed, "version:") &&
+			!strings.HasPrefix(trimmed, "sort:") &&
+			!strings.HasPrefix(trimmed, "columns:") &&
+			!strings.HasPrefix(trimmed, "encoder:") &&
+			!strings.HasPrefix(trimmed, "exclude_patterns:") &&
+			!strings.HasPrefix(trimmed, "rules:") &&
+			strings.Contains(trimmed, "\t") {
 			// 返回这个数据行之前的所有内容
 			pos := 0
 			for j := 0; j < i; j++ {
@@ -1471,17 +1954,17 @@ func findDataSectionStart(content string) int {
 			return pos
 		}
 	}
-	
+
 	return -1
 }
 
 // getDefaultHeader 根据文件名返回默认头部信息
 func getDefaultHeader(filePath string) string {
 	filename := filepath.Base(filePath)
-	
+
 	var name string
 	var description string
-	
+
 	switch filename {
 	case "LL.chars.quick.dict.yaml":
 		name = "LL.chars.quick"
@@ -1502,7 +1985,7 @@ func getDefaultHeader(filePath string) string {
 		name = "default"
 		description = "离乱字典文件"
 	}
-	
+
 	return fmt.Sprintf(`# encoding: utf-8
 #
 # %s
@@ -1541,41 +2024,69 @@ func LoadFullDictMap(dictFilePath string) (map[string][]string, error) {
 	defer file.Close()
 
 	codeCharMap := make(map[string][]string)
-	scanner := bufio.NewScanner(file)
-	
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		// 跳过注释和元数据行
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "---") ||
-		   strings.HasPrefix(line, "...") || strings.HasPrefix(line, "name:") ||
-		   strings.HasPrefix(line, "version:") || strings.HasPrefix(line, "sort:") ||
-		   strings.HasPrefix(line, "columns:") || strings.HasPrefix(line, "encoder:") ||
-		   strings.HasPrefix(line, "  - ") || strings.HasPrefix(line, "  exclude_patterns:") ||
-		   strings.HasPrefix(line, "  rules:") {
-			continue
+	err = tabfile.Scan(file, dictFilePath, tabfile.Options{TrimSpace: true}, func(row *tabfile.Row) error {
+		line := row.Text
+		// 跳过元数据行
+		if strings.HasPrefix(line, "---") ||
+			strings.HasPrefix(line, "...") || strings.HasPrefix(line, "name:") ||
+			strings.HasPrefix(line, "version:") || strings.HasPrefix(line, "sort:") ||
+			strings.HasPrefix(line, "columns:") || strings.HasPrefix(line, "encoder:") ||
+			strings.HasPrefix(line, "  - ") || strings.HasPrefix(line, "  exclude_patterns:") ||
+			strings.HasPrefix(line, "  rules:") {
+			return nil
 		}
-		
+
 		// 解析数据行：字符\t编码
-		fields := strings.Split(line, "\t")
-		if len(fields) >= 2 {
-			char := fields[0]
-			code := fields[1]
+		if row.Len() >= 2 {
+			char := row.Column(0)
+			code := row.Column(1)
 			codeCharMap[code] = append(codeCharMap[code], char)
 		}
-	}
-	
-	if err := scanner.Err(); err != nil {
+		return nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("读取码表文件失败: %w", err)
 	}
-	
+
 	return codeCharMap, nil
 }
 
+// 预置数据缺失后缀的补位策略
+const (
+	PresetPadPlaceholder = "placeholder" // 使用①②③④占位
+	PresetPadFullCode    = "full-code"   // 使用全码表中可达的最高频字符
+)
+
+// PresetDataOptions preset_data.txt 生成选项
+type PresetDataOptions struct {
+	PadMissingSuffixes string           // 缺失后缀的补位策略：placeholder 或 full-code
+	Display            *DisplayReplacer // 候选字符的显示替换，nil 表示不替换
+	FullDictFile       string           // 已生成的LL.chars.full.dict.yaml路径，读取失败时回退到全码表
+	Charset            map[string]bool  // 只为字集中的字符生成预设条目，nil 表示不过滤
+	SuffixOrder        []string         // 候选的末码顺序，nil 表示 w r u o
+}
+
+// filterCharMetaByCharset 返回只含字集中字符的新列表，原切片不修改
+func filterCharMetaByCharset(charMetaList []*types.CharMeta, charset map[string]bool) []*types.CharMeta {
+	filtered := make([]*types.CharMeta, 0, len(charMetaList))
+	for _, charMeta := range charMetaList {
+		if charset[charMeta.Char] {
+			filtered = append(filtered, charMeta)
+		}
+	}
+	return filtered
+}
+
 // BuildPresetData 根据单字简码表和全码表生成 preset_data.txt
-func BuildPresetData(simpleCodeList []*types.CharMeta, fullCodeMetaList []*types.CharMeta) ([]string, error) {
-	// 尝试从deploy/tmp/LL.chars.full.dict.yaml码表文件加载字符映射
-	fullDictPath := "../deploy/tmp/LL.chars.full.dict.yaml"
-	codeCharMap, err := LoadFullDictMap(fullDictPath)
+func BuildPresetData(simpleCodeList []*types.CharMeta, fullCodeMetaList []*types.CharMeta, opts PresetDataOptions) ([]string, error) {
+	switch opts.PadMissingSuffixes {
+	case "", PresetPadPlaceholder, PresetPadFullCode:
+	default:
+		return nil, fmt.Errorf("未知的补位策略: %s", opts.PadMissingSuffixes)
+	}
+
+	// 尝试从已生成的LL.chars.full.dict.yaml码表文件加载字符映射
+	codeCharMap, err := LoadFullDictMap(opts.FullDictFile)
 	if err != nil {
 		// 如果码表文件不存在，回退到使用fullCodeMetaList
 		codeCharMap = make(map[string][]string)
@@ -1583,33 +2094,65 @@ func BuildPresetData(simpleCodeList []*types.CharMeta, fullCodeMetaList []*types
 			codeCharMap[charMeta.Code] = append(codeCharMap[charMeta.Code], charMeta.Char)
 		}
 	}
-	
+
+	// 指定字集时，简码分组、三码组合与补位都只取字集中的字符
+	if opts.Charset != nil {
+		simpleCodeList = filterCharMetaByCharset(simpleCodeList, opts.Charset)
+		fullCodeMetaList = filterCharMetaByCharset(fullCodeMetaList, opts.Charset)
+		for code, chars := range codeCharMap {
+			kept := make([]string, 0, len(chars))
+			for _, char := range chars {
+				if opts.Charset[char] {
+					kept = append(kept, char)
+				}
+			}
+			codeCharMap[code] = kept
+		}
+	}
+
+	// full-code 模式下建立"全码前缀 -> 最高频字符"索引，按"前缀+末码"查找，用于补齐缺失的后缀
+	var fullCodePrefixIndex map[string]string
+	if opts.PadMissingSuffixes == PresetPadFullCode {
+		fullCodePrefixIndex = buildFullCodePrefixIndex(fullCodeMetaList)
+	}
+	padMissing := func(code, placeholder string) string {
+		if char, exists := fullCodePrefixIndex[code]; exists {
+			return char
+		}
+		return placeholder
+	}
+
 	// 按前缀分组（使用简码表）
 	prefixGroups := make(map[string][]*types.CharMeta)
-	
+
 	for _, charMeta := range simpleCodeList {
 		code := charMeta.Code
 		// 只有当编码长度大于1时才有前缀
 		if len(code) > 1 {
-			prefix := code[:len(code)-1]  // 去掉最后一个字符作为前缀
+			prefix := code[:len(code)-1] // 去掉最后一个字符作为前缀
 			prefixGroups[prefix] = append(prefixGroups[prefix], charMeta)
 		}
 	}
-	
+
+	suffixOrder := opts.SuffixOrder
+	if suffixOrder == nil {
+		suffixOrder = suffixKeys
+	}
+
 	// 生成输出行
 	outputLines := make([]string, 0, len(prefixGroups))
-	
+
 	for prefix, chars := range prefixGroups {
 		// 按照末码类型将字符分类
 		wChars := make([]string, 0)
 		rChars := make([]string, 0)
 		uChars := make([]string, 0)
 		oChars := make([]string, 0)
-		
+
 		for _, charMeta := range chars {
 			code := charMeta.Code
 			lastChar := string(code[len(code)-1])
-			
+
 			switch lastChar {
 			case "w":
 				wChars = append(wChars, charMeta.Char)
@@ -1621,10 +2164,10 @@ func BuildPresetData(simpleCodeList []*types.CharMeta, fullCodeMetaList []*types
 				oChars = append(oChars, charMeta.Char)
 			}
 		}
-		
-		// 固定的后缀顺序：w, r, u, o
-		suffixes := []string{"w", "r", "u", "o"}
-		
+
+		// 后缀顺序默认为 w, r, u, o，可由 opts.SuffixOrder 调整
+		suffixes := suffixOrder
+
 		// 构建候选项
 		candidates := make([]string, 0, 4)
 		for _, suffix := range suffixes {
@@ -1634,41 +2177,51 @@ func BuildPresetData(simpleCodeList []*types.CharMeta, fullCodeMetaList []*types
 				if len(wChars) > 0 {
 					candidate = suffix + wChars[0]
 				} else {
-					candidate = suffix + "①"
+					candidate = suffix + padMissing(prefix+suffix, "①")
 				}
 			case "r":
 				if len(rChars) > 0 {
 					candidate = suffix + rChars[0]
 				} else {
-					candidate = suffix + "②"
+					candidate = suffix + padMissing(prefix+suffix, "②")
 				}
 			case "u":
 				if len(uChars) > 0 {
 					candidate = suffix + uChars[0]
 				} else {
-					candidate = suffix + "③"
+					candidate = suffix + padMissing(prefix+suffix, "③")
 				}
 			case "o":
 				if len(oChars) > 0 {
 					candidate = suffix + oChars[0]
 				} else {
-					candidate = suffix + "④"
+					candidate = suffix + padMissing(prefix+suffix, "④")
 				}
 			}
 			candidates = append(candidates, candidate)
 		}
-		
+
 		// 将四个候选项用空格连接
 		candidateStr := strings.Join(candidates, " ")
 		outputLine := candidateStr + "\t" + prefix
 		outputLines = append(outputLines, outputLine)
 	}
-	
+
 	// 添加三码组合（",,,~zzz"）的13824个组合
-	outputLines = append(outputLines, generateThreeCodeCombinations(codeCharMap)...)
-	
+	outputLines = append(outputLines, generateThreeCodeCombinations(codeCharMap, suffixOrder)...)
+
+	// 候选部分应用显示替换，编码部分保持原样
+	if opts.Display != nil {
+		for i, line := range outputLines {
+			parts := strings.SplitN(line, "\t", 2)
+			if len(parts) == 2 {
+				outputLines[i] = opts.Display.Replace(parts[0]) + "\t" + parts[1]
+			}
+		}
+	}
+
 	// 按编码（code）升序排列
-	sort.Slice(outputLines, func(i, j int) bool {
+	sortSlice(outputLines, func(i, j int) bool {
 		// 提取每行的编码部分（制表符后的内容）
 		partsI := strings.Split(outputLines[i], "\t")
 		partsJ := strings.Split(outputLines[j], "\t")
@@ -1681,55 +2234,56 @@ func BuildPresetData(simpleCodeList []*types.CharMeta, fullCodeMetaList []*types
 	return outputLines, nil
 }
 
+// buildFullCodePrefixIndex 构建"全码前缀"到最高频字符的索引，按"简码前缀+末码"查找即得全码以此开头的最高频字符
+// 每个全码的两码及以上的各个前缀（含全码本身）都是键，全码在该前缀之后还有更多码的字也能查到
+// fullCodeMetaList 已按词频降序排列，因此每个键首次出现的字符即为最高频字符
+func buildFullCodePrefixIndex(fullCodeMetaList []*types.CharMeta) map[string]string {
+	index := make(map[string]string)
+	for _, charMeta := range fullCodeMetaList {
+		code := charMeta.Code
+		for i := 2; i <= len(code); i++ {
+			if _, exists := index[code[:i]]; !exists {
+				index[code[:i]] = charMeta.Char
+			}
+		}
+	}
+	return index
+}
+
+// suffixPlaceholders 各末码缺少候选时使用的占位符
+var suffixPlaceholders = map[string]string{"w": "①", "r": "②", "u": "③", "o": "④"}
+
 // generateThreeCodeCombinations 生成三码组合的数据，使用实际字符或占位符
-func generateThreeCodeCombinations(codeCharMap map[string][]string) []string {
+// 候选按 suffixes 的顺序排列
+func generateThreeCodeCombinations(codeCharMap map[string][]string, suffixes []string) []string {
 	// 24个键：qtypasdfghjkl;zxcvbnm,./
 	keys := []string{"q", "t", "y", "p", "a", "s", "d", "f", "g", "h", "j", "k", "l", ";", "z", "x", "c", "v", "b", "n", "m", ",", ".", "/"}
-	
+
 	outputLines := make([]string, 0, 24*24*24) // 13824个组合
-	
+
 	// 生成所有三码组合
 	for _, first := range keys {
 		for _, second := range keys {
 			for _, third := range keys {
 				prefix := first + second + third
-				
-				// 查找对应四个后缀的实际字符
-				wChar := findCharForCodeFromDict(codeCharMap, prefix+"w")
-				rChar := findCharForCodeFromDict(codeCharMap, prefix+"r")
-				uChar := findCharForCodeFromDict(codeCharMap, prefix+"u")
-				oChar := findCharForCodeFromDict(codeCharMap, prefix+"o")
-				
-				// 构建候选项
-				candidates := make([]string, 0, 4)
-				if wChar != "" {
-					candidates = append(candidates, "w"+wChar)
-				} else {
-					candidates = append(candidates, "w①")
-				}
-				if rChar != "" {
-					candidates = append(candidates, "r"+rChar)
-				} else {
-					candidates = append(candidates, "r②")
-				}
-				if uChar != "" {
-					candidates = append(candidates, "u"+uChar)
-				} else {
-					candidates = append(candidates, "u③")
-				}
-				if oChar != "" {
-					candidates = append(candidates, "o"+oChar)
-				} else {
-					candidates = append(candidates, "o④")
+
+				// 查找各后缀对应的实际字符，构建候选项
+				candidates := make([]string, 0, len(suffixes))
+				for _, suffix := range suffixes {
+					if char := findCharForCodeFromDict(codeCharMap, prefix+suffix); char != "" {
+						candidates = append(candidates, suffix+char)
+					} else {
+						candidates = append(candidates, suffix+suffixPlaceholders[suffix])
+					}
 				}
-				
+
 				candidateStr := strings.Join(candidates, " ")
 				outputLine := candidateStr + "\t" + prefix
 				outputLines = append(outputLines, outputLine)
 			}
 		}
 	}
-	
+
 	return outputLines
 }
 
@@ -1742,38 +2296,78 @@ func findCharForCodeFromDict(codeCharMap map[string][]string, code string) strin
 	return ""
 }
 
+// 字根说明的输出方式
+const (
+	RootsNoteNone   = "none"   // 忽略字根说明
+	RootsNoteInline = "inline" // 拼入条目文本，如"⿰氵〔三点水〕"
+	RootsNoteFile   = "file"   // 输出到单独的注释文件
+)
+
+// RootsDictOptions 字根码表生成选项
+type RootsDictOptions struct {
+	NoteMode   string           // 字根说明的输出方式：none、inline 或 file
+	NoteFile   string           // NoteMode 为 file 时的注释文件路径
+	Display    *DisplayReplacer // 字根文本的显示替换，nil 表示不替换
+	SkipNonCJK bool             // 跳过首字符不是中日韩汉字（含扩展区与部首）的字根，私有区部件保留
+	CodePrefix string           // 字根编码的引导前缀，为空时使用 DefaultRootsCodePrefix
+}
+
+// isCJKRoot 判断字根首字符是否为中日韩汉字、扩展区汉字或部首，私有区部件视为汉字部件
+func isCJKRoot(root string) bool {
+	r, _ := utf8.DecodeRuneInString(root)
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Co, r)
+}
+
 // GenerateRootsDict 根据ll_map.txt生成字根码表并追加到LL.roots.dict.yaml
-// llMapFile: ll_map.txt文件路径，格式为"字根编码\t字根"
+// llMapFile: ll_map.txt文件路径，格式为"字根编码\t字根"，可选第三列为字根说明
 // rootsDictFile: LL.roots.dict.yaml文件路径
-func GenerateRootsDict(llMapFile, rootsDictFile string) error {
-	// 读取ll_map.txt文件
-	file, err := os.Open(llMapFile)
+func GenerateRootsDict(llMapFile, rootsDictFile string, opts RootsDictOptions) error {
+	switch opts.NoteMode {
+	case "", RootsNoteNone, RootsNoteInline:
+	case RootsNoteFile:
+		if opts.NoteFile == "" {
+			return fmt.Errorf("未指定字根说明注释文件")
+		}
+	default:
+		return fmt.Errorf("未知的字根说明输出方式: %s", opts.NoteMode)
+	}
+
+	// 读取ll_map.txt文件，与构建编码共用同一份映射表索引
+	index, err := ReadCompMapIndexed(llMapFile)
 	if err != nil {
 		return fmt.Errorf("读取ll_map.txt文件失败: %w", err)
 	}
-	defer file.Close()
+
+	codePrefix := opts.CodePrefix
+	if codePrefix == "" {
+		codePrefix = DefaultRootsCodePrefix
+	}
 
 	// 解析ll_map.txt内容
 	var rootsEntries []*DictEntry
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
+	var notes strings.Builder
+	// 格式为"字根编码\t字根"或"字根编码\t字根\t字根说明"
+	for _, mapEntry := range index.Entries {
+		code := mapEntry.Code
+		root := mapEntry.Comp
+		if opts.SkipNonCJK && !isCJKRoot(root) {
 			continue
 		}
+		note := mapEntry.Note
 
-		// 格式为"字根编码\t字根"
-		fields := strings.Split(line, "\t")
-		if len(fields) < 2 {
-			continue
-		}
+		// 转换为"字根\t引导前缀+字根编码"格式
+		transformedCode := codePrefix + code
+		root = opts.Display.Replace(root)
 
-		code := fields[0]
-		root := fields[1]
+		if note != "" {
+			switch opts.NoteMode {
+			case RootsNoteInline:
+				root += "〔" + note + "〕"
+			case RootsNoteFile:
+				notes.WriteString(fmt.Sprintf("%s\t%s\t%s\n", root, transformedCode, note))
+			}
+		}
 
-		// 转换为"字根\t\]字根编码"格式
-		transformedCode := "]" + code
-		
 		rootsEntries = append(rootsEntries, &DictEntry{
 			Text: root,
 			Code: transformedCode,
@@ -1781,10 +2375,6 @@ func GenerateRootsDict(llMapFile, rootsDictFile string) error {
 		})
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("扫描ll_map.txt文件失败: %w", err)
-	}
-
 	// 构建要追加的内容，保持ll_map.txt的原始顺序
 	var contentToAppend strings.Builder
 	for _, entry := range rootsEntries {
@@ -1797,6 +2387,12 @@ func GenerateRootsDict(llMapFile, rootsDictFile string) error {
 		return fmt.Errorf("追加到LL.roots.dict.yaml失败: %w", err)
 	}
 
+	if opts.NoteMode == RootsNoteFile {
+		if err := os.WriteFile(opts.NoteFile, []byte(notes.String()), 0o644); err != nil {
+			return fmt.Errorf("写入字根说明文件失败: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -1808,7 +2404,7 @@ func generatePlaceholders(startIndex, count, maxLimit int) []string {
 	if count <= 0 || startIndex > maxLimit {
 		return nil
 	}
-	
+
 	// 根据最大限制数确定占位符字符集
 	var placeholders []string
 	switch maxLimit {
@@ -1839,21 +2435,21 @@ func generatePlaceholders(startIndex, count, maxLimit int) []string {
 			placeholders[i] = fmt.Sprintf("(%d)", i+1)
 		}
 	}
-	
+
 	// 从startIndex开始取count个占位符
 	if startIndex > len(placeholders) {
 		return nil
 	}
-	
+
 	endIndex := startIndex + count - 1
 	if endIndex > len(placeholders) {
 		endIndex = len(placeholders)
 		count = endIndex - startIndex + 1
 	}
-	
+
 	if count <= 0 {
 		return nil
 	}
-	
+
 	return placeholders[startIndex-1 : startIndex-1+count]
 }
@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gen_ll/tabfile"
+	"gen_ll/types"
+)
+
+// 编码变化原因分类
+const (
+	ChangeReasonDivision = "拆分变"
+	ChangeReasonRootKey  = "字根移键"
+	ChangeReasonSimple   = "简码调整"
+	ChangeReasonAdded    = "新增"
+	ChangeReasonOther    = "其他"
+)
+
+// CodeTable 某一版本的单字码表：字符 -> 编码列表（按文件中的出现顺序）
+type CodeTable struct {
+	Full   map[string][]string
+	Simple map[string][]string
+}
+
+// ReadCodeTable 读取一版 code_full.txt 与 code_simp.txt（格式均为"字\t编码\t词频"）
+func ReadCodeTable(fullFile, simpFile string) (*CodeTable, error) {
+	full, err := readCharCodes(fullFile)
+	if err != nil {
+		return nil, err
+	}
+	simple, err := readCharCodes(simpFile)
+	if err != nil {
+		return nil, err
+	}
+	return &CodeTable{Full: full, Simple: simple}, nil
+}
+
+// readCharCodes 读取"字\t编码"格式的码表，同字多码按出现顺序保留并去重
+func readCharCodes(filepath string) (map[string][]string, error) {
+	codes := make(map[string][]string)
+	err := tabfile.ScanFile(filepath, tabfile.Options{}, func(row *tabfile.Row) error {
+		if row.Len() < 2 {
+			return row.Errorf("格式错误，应为字\\t编码\\t词频")
+		}
+		codes[row.Column(0)] = appendUnique(codes[row.Column(0)], row.Column(1))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// appendUnique 追加不重复的字符串
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// CodeChange 单字在两个版本间的编码变化
+type CodeChange struct {
+	Char      string
+	Freq      int64
+	OldFull   []string
+	NewFull   []string
+	OldSimple []string
+	NewSimple []string
+	Reasons   []string
+}
+
+// ChangelogOptions 编码变更公告选项
+type ChangelogOptions struct {
+	Chars       map[string]bool              // 关注的字符，nil 时按字频取前 Top 个
+	Top         int                          // 未指定字符清单时按字频取的字数
+	OldDivision map[string][]*types.Division // 上一版拆分表，nil 时不判断拆分变化
+	OldCompMap  map[string]string            // 上一版映射表，nil 时不判断字根移键
+}
+
+// BuildCodeChangelog 比较上一版码表与本次生成结果，列出关注字符中编码有变化的字
+// 全码变化的原因通过比较主拆分与映射表推断，无法推断时归为"其他"；结果按字频降序
+func BuildCodeChangelog(old *CodeTable, fullCodeMetaList, simpleCodeList []*types.CharMeta, compMap map[string]string, opts ChangelogOptions) []*CodeChange {
+	newFull := make(map[string][]string)
+	mainDivisions := make(map[string]*types.Division)
+	freqs := make(map[string]int64)
+	chars := make([]string, 0)
+	for _, charMeta := range fullCodeMetaList {
+		if _, exists := newFull[charMeta.Char]; !exists {
+			chars = append(chars, charMeta.Char)
+		}
+		newFull[charMeta.Char] = appendUnique(newFull[charMeta.Char], charMeta.Code)
+		if charMeta.MDiv {
+			mainDivisions[charMeta.Char] = charMeta.Division
+			freqs[charMeta.Char] = charMeta.Freq
+		}
+	}
+	newSimple := make(map[string][]string)
+	for _, charMeta := range SortedCharMetaView(simpleCodeList, CharMetaByCodeFreq) {
+		newSimple[charMeta.Char] = appendUnique(newSimple[charMeta.Char], charMeta.Code)
+	}
+
+	// 按字频降序，未指定清单时取前 Top 个
+	sort.SliceStable(chars, func(i, j int) bool {
+		return freqs[chars[i]] > freqs[chars[j]]
+	})
+	if opts.Chars != nil {
+		selected := make([]string, 0, len(opts.Chars))
+		for _, char := range chars {
+			if opts.Chars[char] {
+				selected = append(selected, char)
+			}
+		}
+		chars = selected
+	} else if opts.Top > 0 && len(chars) > opts.Top {
+		chars = chars[:opts.Top]
+	}
+
+	changes := make([]*CodeChange, 0)
+	for _, char := range chars {
+		oldFull, existed := old.Full[char]
+		change := &CodeChange{
+			Char:      char,
+			Freq:      freqs[char],
+			OldFull:   oldFull,
+			NewFull:   newFull[char],
+			OldSimple: old.Simple[char],
+			NewSimple: newSimple[char],
+		}
+
+		if !existed {
+			change.Reasons = append(change.Reasons, ChangeReasonAdded)
+			changes = append(changes, change)
+			continue
+		}
+		fullChanged := strings.Join(change.OldFull, " ") != strings.Join(change.NewFull, " ")
+		simpleChanged := strings.Join(change.OldSimple, " ") != strings.Join(change.NewSimple, " ")
+		if !fullChanged && !simpleChanged {
+			continue
+		}
+
+		if fullChanged {
+			change.Reasons = append(change.Reasons, inferFullCodeChange(char, mainDivisions[char], compMap, opts)...)
+		}
+		if simpleChanged {
+			change.Reasons = append(change.Reasons, ChangeReasonSimple)
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// inferFullCodeChange 推断全码变化的原因：主拆分部件不同为拆分变，部件的映射编码不同为字根移键
+func inferFullCodeChange(char string, division *types.Division, compMap map[string]string, opts ChangelogOptions) []string {
+	var reasons []string
+	if division == nil {
+		return []string{ChangeReasonOther}
+	}
+
+	if opts.OldDivision != nil {
+		if oldDivs := opts.OldDivision[char]; len(oldDivs) > 0 && strings.Join(oldDivs[0].Divs, " ") != strings.Join(division.Divs, " ") {
+			reasons = append(reasons, ChangeReasonDivision)
+		}
+	}
+	if opts.OldCompMap != nil {
+		for _, root := range division.Divs {
+			if oldCode, exists := opts.OldCompMap[root]; exists && oldCode != compMap[root] {
+				reasons = append(reasons, ChangeReasonRootKey)
+				break
+			}
+		}
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, ChangeReasonOther)
+	}
+	return reasons
+}
+
+// WriteCodeChangelog 以 Markdown 写出面向用户的编码变更公告
+func WriteCodeChangelog(filepath string, changes []*CodeChange) error {
+	counts := make(map[string]int)
+	for _, change := range changes {
+		for _, reason := range change.Reasons {
+			counts[reason]++
+		}
+	}
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString("# 编码变更公告\n\n")
+	if len(changes) == 0 {
+		buffer.WriteString("常用字编码均无变化。\n")
+		return os.WriteFile(filepath, buffer.Bytes(), 0o644)
+	}
+
+	buffer.WriteString(fmt.Sprintf("共 %d 字编码有变化", len(changes)))
+	summary := make([]string, 0, len(counts))
+	for _, reason := range []string{ChangeReasonDivision, ChangeReasonRootKey, ChangeReasonSimple, ChangeReasonAdded, ChangeReasonOther} {
+		if counts[reason] > 0 {
+			summary = append(summary, fmt.Sprintf("%s %d", reason, counts[reason]))
+		}
+	}
+	buffer.WriteString("（" + strings.Join(summary, "，") + "）。\n\n")
+
+	buffer.WriteString("| 字 | 旧码 | 新码 | 原因 |\n")
+	buffer.WriteString("| --- | --- | --- | --- |\n")
+	for _, change := range changes {
+		buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+			change.Char,
+			formatChangelogCodes(change.OldSimple, change.OldFull),
+			formatChangelogCodes(change.NewSimple, change.NewFull),
+			strings.Join(change.Reasons, "、"),
+		))
+	}
+
+	return os.WriteFile(filepath, buffer.Bytes(), 0o644)
+}
+
+// formatChangelogCodes 格式化一个版本的编码："简码 / 全码"，无简码时只列全码
+func formatChangelogCodes(simple, full []string) string {
+	if len(full) == 0 {
+		return "—"
+	}
+	codes := "`" + strings.Join(full, "` `") + "`"
+	if len(simple) > 0 {
+		codes = "`" + strings.Join(simple, "` `") + "` / " + codes
+	}
+	return codes
+}
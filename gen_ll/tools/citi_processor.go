@@ -3,70 +3,199 @@ package tools
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"sort"
-	"strconv"
 	"strings"
+
+	"gen_ll/tabfile"
 )
 
 // CitiEntry 表示一个编码条目
 type CitiEntry struct {
-	Text     string // 字或词
-	Code     string // 编码
-	Freq     int64  // 词频
-	Source   string // 来源文件标识
+	Text   string // 字或词
+	Code   string // 编码
+	Freq   int64  // 词频
+	Source string // 来源文件标识
+}
+
+// CitiOptions 跟打词提处理选项
+type CitiOptions struct {
+	CodeMaxLength          int               // 编码最大长度，超过的条目视为数据错误，0 表示不限制
+	Strict                 bool              // 严格模式：数据错误直接返回错误而不是跳过
+	SectionPreview         io.Writer         // 非 nil 时在合并前输出每个来源的前若干条，用于排查合并顺序
+	CandidateBaseLengthMin int               // 重码组编码短于该长度时不加候选后缀，只保留首选，0 或 1 表示不限制
+	SimpleChars            map[string]int    // 各字的简码级别（见 SimpleCharLevels），非 nil 时出简让全直接使用，不再读取简码文件
+	SourceSort             map[string]string // 各来源合并前的排序方式（键为来源标识），未列出的来源保持原顺序
+	KeyRemap               KeyRemap          // 写出 genda_citi.txt 时的编码键位重映射，在加补码后缀与排序之后进行，为空不重映射
+	Rules                  *ExperienceRules  // 单字全码出简让全时应用的 citi 体验规则，nil 时使用默认规则集
+	SaimaFile              string            // 非空时另外生成极速赛码表（"字词\t编码"），重码组第 2、3、4… 候选在编码后追加数字选重键，不含 ll_citi_pre 与键位重映射
+	SaimaStartKey          byte              // 极速赛码表第 2 候选的选重键（'1'~'9'），0 同 '2'
+	OutputCodeMaxLength    int               // 写出 genda_citi.txt 的编码最大长度（含补码后缀，不计键位重映射），0 表示不限制；大竹词提随之受限，不影响 dict.yaml
+	OutputCodeOverflow     string            // 重码组补码后缀超出 OutputCodeMaxLength 时的处理方式：drop（默认，丢弃超长候选）或 numbered（整组改为数字选重）
+	ReservedPrefix         string            // 字根反查引导前缀，以此开头的条目视为数据错误，为空不校验
+	WordSource             string            // 词语来源：linglong（默认，玲珑词简码与全码）或 words（多字词简码与全码，旧版四文件流程）
+	Placeholders           map[string]bool   // 词语来源简码表中的占位符条目（PlaceholderSet），读入后去掉；nil 时不去掉任何条目
+}
+
+// 跟打词提的词语来源
+const (
+	CitiWordSourceLinglong = "linglong" // 玲珑词简码、全码
+	CitiWordSourceWords    = "words"    // 多字词简码、全码
+)
+
+// citiWordSections 各词语来源的简码、全码来源标识
+var citiWordSections = map[string][2]string{
+	CitiWordSourceLinglong: {"LL_linglong.quick", "LL_linglong.full"},
+	CitiWordSourceWords:    {"words_simp", "words_full"},
+}
+
+// 超长编码的处理方式
+const (
+	OverflowDrop     = "drop"     // 丢弃超长的候选
+	OverflowNumbered = "numbered" // 重码组改为数字选重（首选原编码，其后候选追加 2~9），仍超长或超过 9 的候选丢弃
+)
+
+// CitiResult 跟打词提处理结果
+type CitiResult struct {
+	LineErrors  []*LineError // 按 opts 跳过的不合法条目
+	CodeTooLong int          // 受 OutputCodeMaxLength 限制未写出的条目数（含 numbered 方式超过 9 的候选）
+}
+
+// 跟打词提来源排序方式
+const (
+	CitiSortKeep = "keep" // 保持来源原顺序
+	CitiSortFreq = "freq" // 按词频降序
+	CitiSortCode = "code" // 按编码升序
+)
+
+// citiSources 跟打词提按合并顺序排列的来源标识，词语来源两种二选一
+var citiSources = []string{"citi_pre", "chars_simp", "chars_full", "LL_linglong.quick", "LL_linglong.full", "words_simp", "words_full"}
+
+// ParseCitiSourceSort 解析来源排序设置，格式：chars_simp:freq,LL_linglong.full:code
+func ParseCitiSourceSort(sortStr string) (map[string]string, error) {
+	sourceSort := make(map[string]string)
+	if strings.TrimSpace(sortStr) == "" {
+		return sourceSort, nil
+	}
+
+	for _, pair := range strings.Split(sortStr, ",") {
+		parts := strings.Split(pair, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("来源排序格式应为 来源:方式: %s", pair)
+		}
+		source, mode := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		knownSource := false
+		for _, citiSource := range citiSources {
+			knownSource = knownSource || source == citiSource
+		}
+		if !knownSource {
+			return nil, fmt.Errorf("未知的跟打词提来源: %s（可选 %s）", source, strings.Join(citiSources, "、"))
+		}
+		switch mode {
+		case CitiSortKeep, CitiSortFreq, CitiSortCode:
+		default:
+			return nil, fmt.Errorf("未知的跟打词提来源排序方式: %s（可选 keep、freq、code）", mode)
+		}
+		sourceSort[source] = mode
+	}
+
+	return sourceSort, nil
+}
+
+// sortCitiSection 按来源的排序方式重排该来源的条目，在补码后缀添加之后进行：
+// 后缀按重码组内的候选顺序分配，重排只改变条目在词提中的位置，不改变各条目得到的编码
+// 排序稳定，同词频或同编码的条目保持原相对顺序
+func sortCitiSection(entries []*CitiEntry, mode string) {
+	switch mode {
+	case CitiSortFreq:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Freq > entries[j].Freq
+		})
+	case CitiSortCode:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Code < entries[j].Code
+		})
+	}
+}
+
+// 来源预览输出的条目数
+const sectionPreviewSize = 10
+
+// previewSection 输出某一来源合并前的前若干条目
+func previewSection(writer io.Writer, section string, entries []*CitiEntry) {
+	if writer == nil {
+		return
+	}
+	fmt.Fprintf(writer, "[%s] 共 %d 条\n", section, len(entries))
+	for i, entry := range entries {
+		if i >= sectionPreviewSize {
+			break
+		}
+		fmt.Fprintf(writer, "  %s\t%s\t%d\n", entry.Text, entry.Code, entry.Freq)
+	}
 }
 
 // ReadCitiFile 读取编码文件并解析为CitiEntry列表
 func ReadCitiFile(filepath string, source string) ([]*CitiEntry, error) {
+	entries, _, err := ReadCitiFileWithOptions(filepath, source, CitiOptions{})
+	return entries, err
+}
+
+// ReadCitiFileWithOptions 读取编码文件并按选项校验条目
+// 不合法的条目默认跳过并以 LineError 返回，严格模式下直接返回错误
+func ReadCitiFileWithOptions(filepath string, source string, opts CitiOptions) ([]*CitiEntry, []*LineError, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
-		return nil, fmt.Errorf("无法打开文件 %s: %w", filepath, err)
+		return nil, nil, fmt.Errorf("无法打开文件 %s: %w", filepath, err)
 	}
 	defer file.Close()
 
 	var entries []*CitiEntry
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+	var lineErrors []*LineError
+	err = tabfile.Scan(file, filepath, tabfile.Options{TrimSpace: true, MinColumns: 2}, func(row *tabfile.Row) error {
+		entry := &CitiEntry{
+			Text:   row.Column(0),
+			Code:   row.Column(1),
+			Source: source,
 		}
 
-		fields := strings.Split(line, "\t")
-		if len(fields) < 2 {
-			continue
+		// 校验编码长度
+		if opts.CodeMaxLength > 0 && len(entry.Code) > opts.CodeMaxLength {
+			lineErr := row.Errorf("编码 %s 长度 %d 超过上限 %d", entry.Code, len(entry.Code), opts.CodeMaxLength)
+			if opts.Strict {
+				return lineErr
+			}
+			lineErrors = append(lineErrors, lineErr)
+			return nil
 		}
 
-		entry := &CitiEntry{
-			Text:   fields[0],
-			Code:   fields[1],
-			Source: source,
+		// 以字根反查引导前缀开头的编码属于字根码表，不能出现在跟打词提中
+		if opts.ReservedPrefix != "" && strings.HasPrefix(entry.Code, opts.ReservedPrefix) {
+			lineErr := row.Errorf("编码 %s 落入字根反查引导前缀 %s 的专用编码空间", entry.Code, opts.ReservedPrefix)
+			if opts.Strict {
+				return lineErr
+			}
+			lineErrors = append(lineErrors, lineErr)
+			return nil
 		}
 
 		// 如果有第三列，解析词频
-		if len(fields) >= 3 {
-			freq, err := strconv.ParseInt(fields[2], 10, 64)
-			if err == nil {
-				entry.Freq = freq
-			}
+		if freq, ok := row.Int64(2); ok {
+			entry.Freq = freq
 		}
 
 		entries = append(entries, entry)
+		return nil
+	})
+	if lineErr, ok := err.(*LineError); ok {
+		return nil, nil, lineErr
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("读取文件 %s 时出错: %w", filepath, err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件 %s 时出错: %w", filepath, err)
 	}
 
-	return entries, nil
-}
-
-// SortByFreq 按词频降序排序
-func SortByFreq(entries []*CitiEntry) {
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Freq > entries[j].Freq
-	})
+	return entries, lineErrors, nil
 }
 
 // WriteCitiFile 将CitiEntry列表写入文件
@@ -92,134 +221,16 @@ func WriteCitiFile(filepath string, entries []*CitiEntry) error {
 	return nil
 }
 
-// ProcessCitiFiles 处理四个编码文件：按词频重排
-func ProcessCitiFiles(charsSimpFile, charsFullFile, wordsSimpFile, wordsFullFile string) error {
-	// 读取四个文件
-	charsSimpEntries, err := ReadCitiFile(charsSimpFile, "chars_simp")
-	if err != nil {
-		return fmt.Errorf("读取单字简码文件失败: %w", err)
-	}
-
-	charsFullEntries, err := ReadCitiFile(charsFullFile, "chars_full")
-	if err != nil {
-		return fmt.Errorf("读取单字全码文件失败: %w", err)
-	}
-
-	wordsSimpEntries, err := ReadCitiFile(wordsSimpFile, "words_simp")
-	if err != nil {
-		return fmt.Errorf("读取多字词简码文件失败: %w", err)
-	}
-
-	wordsFullEntries, err := ReadCitiFile(wordsFullFile, "words_full")
-	if err != nil {
-		return fmt.Errorf("读取多字词全码文件失败: %w", err)
-	}
-
-	// 按词频重排
-	SortByFreq(charsSimpEntries)
-	SortByFreq(charsFullEntries)
-	SortByFreq(wordsSimpEntries)
-	SortByFreq(wordsFullEntries)
-
-	// 写回原文件
-	if err := WriteCitiFile(charsSimpFile, charsSimpEntries); err != nil {
-		return fmt.Errorf("写入单字简码文件失败: %w", err)
-	}
-
-	if err := WriteCitiFile(charsFullFile, charsFullEntries); err != nil {
-		return fmt.Errorf("写入单字全码文件失败: %w", err)
-	}
-
-	if err := WriteCitiFile(wordsSimpFile, wordsSimpEntries); err != nil {
-		return fmt.Errorf("写入多字词简码文件失败: %w", err)
-	}
-
-	if err := WriteCitiFile(wordsFullFile, wordsFullEntries); err != nil {
-		return fmt.Errorf("写入多字词全码文件失败: %w", err)
-	}
-
-	return nil
-}
-
-// CombineCitiFiles 将四个文件按照指定顺序拼接在一起
-func CombineCitiFiles(charsSimpFile, charsFullFile, wordsSimpFile, wordsFullFile string) ([]*CitiEntry, error) {
-	var allEntries []*CitiEntry
-
-	// 按照指定顺序读取四个文件
-	files := []struct {
-		path   string
-		source string
-	}{
-		{charsSimpFile, "chars_simp"},
-		{charsFullFile, "chars_full"},
-		{wordsSimpFile, "words_simp"},
-		{wordsFullFile, "words_full"},
-	}
-
-	for _, file := range files {
-		entries, err := ReadCitiFile(file.path, file.source)
-		if err != nil {
-			return nil, fmt.Errorf("读取文件 %s 失败: %w", file.path, err)
-		}
-		allEntries = append(allEntries, entries...)
-	}
-
-	return allEntries, nil
-}
-
-// CombineAllCitiFiles 按照指定顺序组合所有文件：ll_citi_pre + 四个编码文件
-func CombineAllCitiFiles(citiPreFile, charsSimpFile, charsFullFile, wordsSimpFile, wordsFullFile string) ([]*CitiEntry, error) {
-	var allEntries []*CitiEntry
-
-	// 1. 首先读取现有的ll_citi_pre.txt内容
-	existingEntries, err := ReadCitiFile(citiPreFile, "citi_pre")
-	if err != nil && !os.IsNotExist(err) {
-		return nil, fmt.Errorf("读取现有文件失败: %w", err)
-	}
-	allEntries = append(allEntries, existingEntries...)
-
-	// 2. 然后按照指定顺序读取四个编码文件
-	files := []struct {
-		path   string
-		source string
-	}{
-		{charsSimpFile, "chars_simp"},
-		{charsFullFile, "chars_full"},
-		{wordsSimpFile, "words_simp"},
-		{wordsFullFile, "words_full"},
-	}
-
-	for _, file := range files {
-		entries, err := ReadCitiFile(file.path, file.source)
-		if err != nil {
-			return nil, fmt.Errorf("读取文件 %s 失败: %w", file.path, err)
-		}
-		allEntries = append(allEntries, entries...)
-	}
-
-	return allEntries, nil
-}
-
-// AppendToCitiPre 将合并的条目追加到ll_citi_pre.txt
-func AppendToCitiPre(entries []*CitiEntry, citiPreFile string) error {
-	// 读取现有的ll_citi_pre.txt内容
-	existingEntries, err := ReadCitiFile(citiPreFile, "existing")
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("读取现有文件失败: %w", err)
-	}
-
-	// 合并现有条目和新条目
-	allEntries := append(existingEntries, entries...)
-
-	// 写入文件
-	file, err := os.Create(citiPreFile)
+// CreateGendaCiti 创建genda_citi.txt并删除词频
+func CreateGendaCiti(entries []*CitiEntry, gendaCitiFile string) error {
+	file, err := os.Create(gendaCitiFile)
 	if err != nil {
 		return fmt.Errorf("创建文件失败: %w", err)
 	}
 	defer file.Close()
 
 	writer := bufio.NewWriter(file)
-	for _, entry := range allEntries {
+	for _, entry := range entries {
 		line := fmt.Sprintf("%s\t%s\n", entry.Text, entry.Code)
 		if _, err := writer.WriteString(line); err != nil {
 			return fmt.Errorf("写入文件失败: %w", err)
@@ -233,45 +244,102 @@ func AppendToCitiPre(entries []*CitiEntry, citiPreFile string) error {
 	return nil
 }
 
-// CreateGendaCiti 创建genda_citi.txt并删除词频
-func CreateGendaCiti(entries []*CitiEntry, gendaCitiFile string) error {
-	file, err := os.Create(gendaCitiFile)
-	if err != nil {
-		return fmt.Errorf("创建文件失败: %w", err)
+// candidateSuffixes 重码候选的补码后缀，第 11 个起在后缀前加"="翻页
+var candidateSuffixes = []string{"_", "e", "i", "[", "2", "3", "7", "8", "9", "0"}
+
+// NumberedCandidateOptions 数字选重（赛码表）候选标注选项
+type NumberedCandidateOptions struct {
+	StartKey  byte // 第 2 候选追加的数字键，其后候选依次加一，超过 9 的候选无法选重而丢弃；0 同 '2'
+	KeepOrder bool // 组内按当前顺序编号（单字全码已应用出简让全时使用），否则按词频降序
+}
+
+// AddNumberedCandidateCodes 为重复编码按数字选重标注候选：首选使用原编码，第 2、3、4… 候选在编码后追加 StartKey、StartKey+1…
+// 与跟打词提的补码后缀共用分组逻辑，保持原始文件顺序
+func AddNumberedCandidateCodes(entries []*CitiEntry, opts NumberedCandidateOptions) []*CitiEntry {
+	candidateCode := func(code string, rank, size int) (string, bool) {
+		return numberedCandidateCode(code, rank, opts.StartKey)
 	}
-	defer file.Close()
+	if opts.KeepOrder {
+		return addCandidateCodesInOrder(entries, 0, candidateCode)
+	}
+	return addCandidateCodesByFreq(entries, 0, candidateCode)
+}
 
-	writer := bufio.NewWriter(file)
-	for _, entry := range entries {
-		line := fmt.Sprintf("%s\t%s\n", entry.Text, entry.Code)
-		if _, err := writer.WriteString(line); err != nil {
-			return fmt.Errorf("写入文件失败: %w", err)
-		}
+// numberedCandidateCode 数字选重方案：首选使用原编码，第 rank+1 候选追加 startKey+rank-1，超过 9 的候选无法选重；startKey 为 0 同 '2'
+func numberedCandidateCode(code string, rank int, startKey byte) (string, bool) {
+	if startKey == 0 {
+		startKey = '2'
+	}
+	if rank == 0 {
+		return code, true
+	}
+	key := int(startKey) + rank - 1
+	if key > '9' {
+		return "", false
 	}
+	return code + string(rune(key)), true
+}
 
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("刷新文件失败: %w", err)
+// limitedCandidateCode 返回受 opts.OutputCodeMaxLength 约束的补码方案，不限制时即 gendaCandidateCode
+// 组内末位候选的补码后缀超长时，numbered 方式整组改为数字选重；仍超长的候选丢弃，tooLong 非 nil 时计数
+func limitedCandidateCode(opts CitiOptions, tooLong *int) func(code string, rank, size int) (string, bool) {
+	maxLength := opts.OutputCodeMaxLength
+	return func(code string, rank, size int) (string, bool) {
+		newCode, ok := gendaCandidateCode(code, rank)
+		if maxLength <= 0 {
+			return newCode, ok
+		}
+		if opts.OutputCodeOverflow == OverflowNumbered {
+			if last, _ := gendaCandidateCode(code, size-1); len(last) > maxLength {
+				newCode, ok = numberedCandidateCode(code, rank, '2')
+			}
+		}
+		if ok && len(newCode) > maxLength {
+			ok = false
+		}
+		if !ok && tooLong != nil {
+			*tooLong++
+		}
+		return newCode, ok
 	}
+}
 
-	return nil
+// gendaCandidateCode 跟打词提的补码后缀方案：四码首选不加后缀，前 10 个候选使用单字符后缀，其后翻页
+func gendaCandidateCode(code string, rank int) (string, bool) {
+	if rank == 0 && len(code) == 4 {
+		// 4码位词组首选使用原编码，不添加后缀
+		return code, true
+	}
+	if rank < 10 {
+		// 前10个候选使用单字符后缀
+		return code + candidateSuffixes[rank], true
+	}
+	// 第11个及以后的候选使用翻页格式
+	page := (rank - 10) / 10
+	posInPage := (rank - 10) % 10
+	// 第1页：=_, =e, =i, =[, =2, =3, =7, =8, =9, =0
+	// 第2页：==_, ==e, ==i, ==[, ==2, ==3, ==7, ==8, ==9, ==0
+	// 第3页：===_, ===e, 以此类推...
+	equals := strings.Repeat("=", page+1)
+	return fmt.Sprintf("%s%s%s", code, equals, candidateSuffixes[posInPage]), true
 }
 
-// AddCandidateCodes 为重复编码添加候选码，保持原始文件顺序
-func AddCandidateCodes(entries []*CitiEntry) []*CitiEntry {
+// addCandidateCodesByFreq 重码组内按词频降序排名，用 candidateCode 得到各候选的编码，结果保持原始文件顺序
+// 编码短于 baseLengthMin 的重码组只保留词频最高的一条；candidateCode 返回 false 的候选丢弃
+func addCandidateCodesByFreq(entries []*CitiEntry, baseLengthMin int, candidateCode func(code string, rank, size int) (string, bool)) []*CitiEntry {
 	// 按编码分组，但记录每个条目的原始位置
 	type entryWithIndex struct {
 		entry *CitiEntry
 		index int
 	}
 	codeGroups := make(map[string][]*entryWithIndex)
-	
+
 	for i, entry := range entries {
 		codeGroups[entry.Code] = append(codeGroups[entry.Code], &entryWithIndex{entry, i})
 	}
 
 	// 创建结果数组，保持原始顺序
 	result := make([]*CitiEntry, len(entries))
-	candidateSuffixes := []string{"_", "e", "i", "[", "2", "3", "7", "8", "9", "0"}
 
 	// 处理每个编码的重码情况
 	for code, group := range codeGroups {
@@ -282,30 +350,22 @@ func AddCandidateCodes(entries []*CitiEntry) []*CitiEntry {
 		}
 
 		// 有重码，按词频排序（保持词频排序）
-		sort.Slice(group, func(i, j int) bool {
+		sortSlice(group, func(i, j int) bool {
 			return group[i].entry.Freq > group[j].entry.Freq
 		})
 
+		if len(code) < baseLengthMin {
+			// 短码加后缀不便输入，只保留首选
+			result[group[0].index] = group[0].entry
+			continue
+		}
+
 		// 为每个候选添加后缀，保持原始位置
 		for i, ew := range group {
-			var newCode string
-			if i == 0 && len(code) == 4 {
-				// 4码位词组首选使用原编码，不添加后缀
-				newCode = code
-			} else if i < 10 {
-				// 前10个候选使用单字符后缀
-				newCode = code + candidateSuffixes[i]
-			} else {
-				// 第11个及以后的候选使用翻页格式
-				page := (i - 10) / 10
-				posInPage := (i - 10) % 10
-				// 第1页：=_, =e, =i, =[, =2, =3, =7, =8, =9, =0
-				// 第2页：==_, ==e, ==i, ==[, ==2, ==3, ==7, ==8, ==9, ==0
-				// 第3页：===_, ===e, 以此类推...
-				equals := strings.Repeat("=", page+1)
-				newCode = fmt.Sprintf("%s%s%s", code, equals, candidateSuffixes[posInPage])
+			newCode, ok := candidateCode(code, i, len(group))
+			if !ok {
+				continue
 			}
-
 			newEntry := &CitiEntry{
 				Text:   ew.entry.Text,
 				Code:   newCode,
@@ -316,7 +376,7 @@ func AddCandidateCodes(entries []*CitiEntry) []*CitiEntry {
 		}
 	}
 
-	// 移除可能为nil的条目（理论上不应该有）
+	// 移除为nil的条目（被丢弃的候选）
 	finalResult := make([]*CitiEntry, 0, len(entries))
 	for _, entry := range result {
 		if entry != nil {
@@ -327,47 +387,40 @@ func AddCandidateCodes(entries []*CitiEntry) []*CitiEntry {
 	return finalResult
 }
 
-// AddCandidateCodesWithSimpleSorting 为重复编码添加候选码，在应用出简让全逻辑后添加补码后缀
-func AddCandidateCodesWithSimpleSorting(entries []*CitiEntry) []*CitiEntry {
+// addCandidateCodesInOrder 重码组内按当前顺序排名，用 candidateCode 得到各候选的编码，结果按重码组依次排列
+// 编码短于 baseLengthMin 的重码组只保留排在首位的一条；candidateCode 返回 false 的候选丢弃
+func addCandidateCodesInOrder(entries []*CitiEntry, baseLengthMin int, candidateCode func(code string, rank, size int) (string, bool)) []*CitiEntry {
 	// 按编码分组
 	codeGroups := make(map[string][]*CitiEntry)
-	
+
 	for _, entry := range entries {
 		codeGroups[entry.Code] = append(codeGroups[entry.Code], entry)
 	}
 
 	// 创建结果数组
 	result := make([]*CitiEntry, 0, len(entries))
-	candidateSuffixes := []string{"_", "e", "i", "[", "2", "3", "7", "8", "9", "0"}
 
 	// 处理每个编码的重码情况
-	for code, group := range codeGroups {
+	for _, code := range codeGroupOrder(entries, codeGroups) {
+		group := codeGroups[code]
 		if len(group) == 1 {
 			// 没有重码，直接使用原编码
 			result = append(result, group[0])
 			continue
 		}
 
+		if len(code) < baseLengthMin {
+			// 短码加后缀不便输入，只保留首选
+			result = append(result, group[0])
+			continue
+		}
+
 		// 有重码，按当前顺序（已经应用了出简让全逻辑）添加后缀
 		for i, entry := range group {
-			var newCode string
-			if i == 0 && len(code) == 4 {
-				// 4码位词组首选使用原编码，不添加后缀
-				newCode = code
-			} else if i < 10 {
-				// 前10个候选使用单字符后缀
-				newCode = code + candidateSuffixes[i]
-			} else {
-				// 第11个及以后的候选使用翻页格式
-				page := (i - 10) / 10
-				posInPage := (i - 10) % 10
-				// 第1页：=_, =e, =i, =[, =2, =3, =7, =8, =9, =0
-				// 第2页：==_, ==e, ==i, ==[, ==2, ==3, ==7, ==8, ==9, ==0
-				// 第3页：===_, ===e, 以此类推...
-				equals := strings.Repeat("=", page+1)
-				newCode = fmt.Sprintf("%s%s%s", code, equals, candidateSuffixes[posInPage])
+			newCode, ok := candidateCode(code, i, len(group))
+			if !ok {
+				continue
 			}
-
 			newEntry := &CitiEntry{
 				Text:   entry.Text,
 				Code:   newCode,
@@ -381,122 +434,230 @@ func AddCandidateCodesWithSimpleSorting(entries []*CitiEntry) []*CitiEntry {
 	return result
 }
 
-// ProcessCitiFilesComplete 完整的citi文件处理流程
-func ProcessCitiFilesComplete(charsSimpFile, charsFullFile, wordsSimpFile, wordsFullFile, citiPreFile, gendaCitiFile string) error {
+// codeGroupOrder 返回重码组的遍历顺序：稳定模式下按编码首次出现的顺序，否则按映射遍历顺序
+func codeGroupOrder(entries []*CitiEntry, codeGroups map[string][]*CitiEntry) []string {
+	codes := make([]string, 0, len(codeGroups))
+	if stableSort {
+		seen := make(map[string]bool, len(codeGroups))
+		for _, entry := range entries {
+			if !seen[entry.Code] {
+				seen[entry.Code] = true
+				codes = append(codes, entry.Code)
+			}
+		}
+		return codes
+	}
+	for code := range codeGroups {
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// ProcessCitiFiles 完整的citi文件处理流程：ll_citi_pre、单字简码、单字全码，再接词语来源的简码与全码
+// 词语来源由 opts.WordSource 选择玲珑词或多字词，两者共用补码后缀与出简让全逻辑，多字词简码中的占位符不进入词提
+// 不合法的条目按 opts 跳过并以 LineError 返回；opts.SourceSort 指定的来源在加补码后缀之后、合并之前重排
+// charsSimpFile、wordsQuickFile 为空时跳过对应的简码来源（纯全码版本），单字全码也不做出简让全
+// wordsFullFile 为空时跳过词语全码来源（词语没有结果）
+// opts.OutputCodeMaxLength 非 0 时超长的条目不写出，数量记入 CitiResult.CodeTooLong；极速赛码表不受限制
+func ProcessCitiFiles(charsSimpFile, charsFullFile, wordsQuickFile, wordsFullFile, citiPreFile, gendaCitiFile string, opts CitiOptions) (*CitiResult, error) {
 	// 按照指定顺序分别处理每个来源，保持各自原始排序
 	var allEntries []*CitiEntry
+	result := &CitiResult{}
+	if opts.SaimaStartKey != 0 && (opts.SaimaStartKey < '1' || opts.SaimaStartKey > '9') {
+		return result, fmt.Errorf("极速赛码表选重起始键须为 1~9: %c", opts.SaimaStartKey)
+	}
+	switch opts.OutputCodeOverflow {
+	case "", OverflowDrop, OverflowNumbered:
+	default:
+		return result, fmt.Errorf("未知的超长编码处理方式: %s", opts.OutputCodeOverflow)
+	}
+	wordSource := opts.WordSource
+	if wordSource == "" {
+		wordSource = CitiWordSourceLinglong
+	}
+	wordSections, ok := citiWordSections[wordSource]
+	if !ok {
+		return result, fmt.Errorf("未知的跟打词提词语来源: %s", opts.WordSource)
+	}
+	candidateCode := limitedCandidateCode(opts, &result.CodeTooLong)
+	// dropTooLong 丢弃已带补码后缀的来源中超长的条目
+	dropTooLong := func(entries []*CitiEntry) []*CitiEntry {
+		if opts.OutputCodeMaxLength <= 0 {
+			return entries
+		}
+		kept := entries[:0:0]
+		for _, entry := range entries {
+			if len(entry.Code) > opts.OutputCodeMaxLength {
+				result.CodeTooLong++
+				continue
+			}
+			kept = append(kept, entry)
+		}
+		return kept
+	}
+	// 极速赛码表使用各来源未加补码后缀的条目，按数字选重另行标注
+	var saimaEntries []*CitiEntry
+	addSaimaSection := func(entries []*CitiEntry, source string, keepOrder bool) {
+		if opts.SaimaFile == "" {
+			return
+		}
+		numbered := AddNumberedCandidateCodes(entries, NumberedCandidateOptions{StartKey: opts.SaimaStartKey, KeepOrder: keepOrder})
+		sortCitiSection(numbered, opts.SourceSort[source])
+		saimaEntries = append(saimaEntries, numbered...)
+	}
+	readCiti := func(filepath, source string) ([]*CitiEntry, error) {
+		entries, errs, err := ReadCitiFileWithOptions(filepath, source, opts)
+		result.LineErrors = append(result.LineErrors, errs...)
+		return entries, err
+	}
 
 	// 1. 首先处理ll_citi_pre.txt - 不进行重码处理，保持原有顺序
-	citiPreEntries, err := ReadCitiFile(citiPreFile, "citi_pre")
+	citiPreEntries, err := readCiti(citiPreFile, "citi_pre")
 	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("读取ll_citi_pre.txt失败: %w", err)
+		return result, fmt.Errorf("读取ll_citi_pre.txt失败: %w", err)
 	}
 	// ll_citi_pre.txt已经包含候选编码补码，直接使用
+	citiPreEntries = dropTooLong(citiPreEntries)
+	sortCitiSection(citiPreEntries, opts.SourceSort["citi_pre"])
+	previewSection(opts.SectionPreview, "ll_citi_pre", citiPreEntries)
 	allEntries = append(allEntries, citiPreEntries...)
 
 	// 2. 然后处理code_chars_simp.txt - 不需要运用补码规则，直接使用
-	charsSimpEntries, err := ReadCitiFile(charsSimpFile, "chars_simp")
-	if err != nil {
-		return fmt.Errorf("读取code_chars_simp.txt失败: %w", err)
+	if charsSimpFile != "" {
+		charsSimpEntries, err := readCiti(charsSimpFile, "chars_simp")
+		if err != nil {
+			return result, fmt.Errorf("读取code_chars_simp.txt失败: %w", err)
+		}
+		sortCitiSection(charsSimpEntries, opts.SourceSort["chars_simp"])
+		saimaEntries = append(saimaEntries, charsSimpEntries...)
+		charsSimpEntries = dropTooLong(charsSimpEntries)
+		previewSection(opts.SectionPreview, "chars_simp", charsSimpEntries)
+		allEntries = append(allEntries, charsSimpEntries...)
 	}
-	allEntries = append(allEntries, charsSimpEntries...)
 
 	// 3. 接着处理code_chars_full.txt - 需要运用补码规则，并应用出简让全逻辑
-	charsFullEntries, err := ReadCitiFile(charsFullFile, "chars_full")
+	charsFullEntries, err := readCiti(charsFullFile, "chars_full")
 	if err != nil {
-		return fmt.Errorf("读取code_chars_full.txt失败: %w", err)
+		return result, fmt.Errorf("读取code_chars_full.txt失败: %w", err)
 	}
-	
-	// 对单字全码应用出简让全逻辑，然后添加补码后缀
-	charsFullEntries = applySimpleCharsSortingToCiti(charsFullEntries)
-	charsFullWithCandidates := AddCandidateCodesWithSimpleSorting(charsFullEntries)
+
+	// 对单字全码应用出简让全逻辑，然后添加补码后缀；没有简码来源时直接跳过出简让全
+	if charsSimpFile != "" {
+		charsFullEntries = applySimpleCharsSortingToCiti(charsFullEntries, charsSimpFile, opts.SimpleChars, opts.Rules)
+	}
+	// 没有重码的条目不经补码方案，同样按最大长度丢弃
+	charsFullWithCandidates := dropTooLong(addCandidateCodesInOrder(charsFullEntries, opts.CandidateBaseLengthMin, candidateCode))
+	addSaimaSection(charsFullEntries, "chars_full", true)
+	sortCitiSection(charsFullWithCandidates, opts.SourceSort["chars_full"])
+	previewSection(opts.SectionPreview, "chars_full", charsFullWithCandidates)
 	allEntries = append(allEntries, charsFullWithCandidates...)
 
-	// 4. 然后处理code_words_simp.txt - 需要运用补码规则
-	wordsSimpEntries, err := ReadCitiFile(wordsSimpFile, "words_simp")
-	if err != nil {
-		return fmt.Errorf("读取code_words_simp.txt失败: %w", err)
+	// 4、5. 然后处理词语来源的简码与全码 - 需要运用补码规则
+	for i, wordsFile := range []string{wordsQuickFile, wordsFullFile} {
+		if wordsFile == "" {
+			continue
+		}
+		section := wordSections[i]
+		wordsEntries, err := readCiti(wordsFile, section)
+		if err != nil {
+			return result, fmt.Errorf("读取%s失败: %w", wordsFile, err)
+		}
+		wordsEntries = dropPlaceholders(wordsEntries, opts.Placeholders)
+		wordsWithCandidates := dropTooLong(addCandidateCodesByFreq(wordsEntries, opts.CandidateBaseLengthMin, candidateCode))
+		addSaimaSection(wordsEntries, section, false)
+		sortCitiSection(wordsWithCandidates, opts.SourceSort[section])
+		previewSection(opts.SectionPreview, section, wordsWithCandidates)
+		allEntries = append(allEntries, wordsWithCandidates...)
+	}
+
+	// 极速赛码表在键位重映射之前写出：数字选重键可能与重映射的替换字符冲突
+	if opts.SaimaFile != "" {
+		if err := CreateGendaCiti(saimaEntries, opts.SaimaFile); err != nil {
+			return result, fmt.Errorf("创建极速赛码表失败: %w", err)
+		}
 	}
-	wordsSimpWithCandidates := AddCandidateCodes(wordsSimpEntries)
-	allEntries = append(allEntries, wordsSimpWithCandidates...)
 
-	// 5. 最后处理code_words_full.txt - 需要运用补码规则
-	wordsFullEntries, err := ReadCitiFile(wordsFullFile, "words_full")
-	if err != nil {
-		return fmt.Errorf("读取code_words_full.txt失败: %w", err)
+	// 键位重映射只作用于写出的编码，补码分组与排序仍按原编码进行
+	for _, entry := range allEntries {
+		entry.Code = opts.KeyRemap.Apply(entry.Code)
 	}
-	wordsFullWithCandidates := AddCandidateCodes(wordsFullEntries)
-	allEntries = append(allEntries, wordsFullWithCandidates...)
 
 	// 创建genda_citi.txt并删除词频
 	if err := CreateGendaCiti(allEntries, gendaCitiFile); err != nil {
-		return fmt.Errorf("创建genda_citi.txt失败: %w", err)
+		return result, fmt.Errorf("创建genda_citi.txt失败: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
 
-// ProcessCitiFilesWithLinglong 使用玲珑词库的完整citi文件处理流程
-func ProcessCitiFilesWithLinglong(charsSimpFile, charsFullFile, linglongQuickFile, linglongFullFile, citiPreFile, gendaCitiFile string) error {
-	// 按照指定顺序分别处理每个来源，保持各自原始排序
-	var allEntries []*CitiEntry
-
-	// 1. 首先处理ll_citi_pre.txt - 不进行重码处理，保持原有顺序
-	citiPreEntries, err := ReadCitiFile(citiPreFile, "citi_pre")
-	if err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("读取ll_citi_pre.txt失败: %w", err)
+// dropPlaceholders 去掉多字词简码中的占位符条目，占位符只用于输入法中占住空码位
+// 按生成时的来源标记判断，与占位符同字的真实词照常保留
+func dropPlaceholders(entries []*CitiEntry, placeholders map[string]bool) []*CitiEntry {
+	kept := entries[:0:0]
+	for _, entry := range entries {
+		if !placeholders[PlaceholderKey(entry.Text, entry.Code)] {
+			kept = append(kept, entry)
+		}
 	}
-	// ll_citi_pre.txt已经包含候选编码补码，直接使用
-	allEntries = append(allEntries, citiPreEntries...)
+	return kept
+}
 
-	// 2. 然后处理code_chars_simp.txt - 不需要运用补码规则，直接使用
-	charsSimpEntries, err := ReadCitiFile(charsSimpFile, "chars_simp")
-	if err != nil {
-		return fmt.Errorf("读取code_chars_simp.txt失败: %w", err)
-	}
-	allEntries = append(allEntries, charsSimpEntries...)
+// 大竹词提排序方式
+const (
+	DazhuSortNone     = "none"      // 保持跟打词提顺序
+	DazhuSortFirstCol = "first-col" // 按第一列排序（默认为编码，反向输出时为字词）
+)
 
-	// 3. 接着处理code_chars_full.txt - 需要运用补码规则，并应用出简让全逻辑
-	charsFullEntries, err := ReadCitiFile(charsFullFile, "chars_full")
-	if err != nil {
-		return fmt.Errorf("读取code_chars_full.txt失败: %w", err)
-	}
-	
-	// 对单字全码应用出简让全逻辑，然后添加补码后缀
-	charsFullEntries = applySimpleCharsSortingToCiti(charsFullEntries)
-	charsFullWithCandidates := AddCandidateCodesWithSimpleSorting(charsFullEntries)
-	allEntries = append(allEntries, charsFullWithCandidates...)
+// DazhuOptions 大竹词提生成选项
+type DazhuOptions struct {
+	Reverse  bool     // 输出"字词\t编码"，供按字词反查编码
+	SortBy   string   // 排序方式：none 或 first-col
+	KeyRemap KeyRemap // 编码键位重映射，在截取与排序之前进行；跟打词提已重映射时再次应用不改变编码
+}
 
-	// 4. 然后处理LL_linglong.quick.dict.yaml - 需要运用补码规则
-	linglongQuickEntries, err := ReadCitiFile(linglongQuickFile, "LL_linglong.quick")
-	if err != nil {
-		return fmt.Errorf("读取LL_linglong.quick.dict.yaml失败: %w", err)
+// CreateDazhuCode 根据genda_citi.txt生成dazhu_code.txt，格式为"编码\t字词"
+// 先按跟打词提顺序截取不超过 maxSizeMB 的条目，再按需排序
+func CreateDazhuCode(gendaCitiFile, dazhuCodeFile string, maxSizeMB int, opts DazhuOptions) error {
+	switch opts.SortBy {
+	case "", DazhuSortNone, DazhuSortFirstCol:
+	default:
+		return fmt.Errorf("未知的大竹词提排序方式: %s", opts.SortBy)
 	}
-	linglongQuickWithCandidates := AddCandidateCodes(linglongQuickEntries)
-	allEntries = append(allEntries, linglongQuickWithCandidates...)
 
-	// 5. 最后处理LL_linglong.full.dict.yaml - 需要运用补码规则
-	linglongFullEntries, err := ReadCitiFile(linglongFullFile, "LL_linglong.full")
+	// 读取genda_citi.txt文件
+	entries, err := ReadCitiFile(gendaCitiFile, "genda_citi")
 	if err != nil {
-		return fmt.Errorf("读取LL_linglong.full.dict.yaml失败: %w", err)
+		return fmt.Errorf("读取genda_citi.txt失败: %w", err)
 	}
-	linglongFullWithCandidates := AddCandidateCodes(linglongFullEntries)
-	allEntries = append(allEntries, linglongFullWithCandidates...)
 
-	// 创建genda_citi.txt并删除词频
-	if err := CreateGendaCiti(allEntries, gendaCitiFile); err != nil {
-		return fmt.Errorf("创建genda_citi.txt失败: %w", err)
+	maxSizeBytes := maxSizeMB * 1024 * 1024
+	currentSize := 0
+
+	// 按"编码\t字词"（反向时"字词\t编码"）格式生成，并控制文件大小
+	type dazhuLine struct {
+		first, second string
 	}
+	var lines []dazhuLine
+	for _, entry := range entries {
+		code := opts.KeyRemap.Apply(entry.Code)
+		line := dazhuLine{first: code, second: entry.Text}
+		if opts.Reverse {
+			line = dazhuLine{first: entry.Text, second: code}
+		}
+		lineSize := len(line.first) + len(line.second) + 2
 
-	return nil
-}
+		// 检查是否超过最大文件大小
+		if currentSize+lineSize > maxSizeBytes {
+			break
+		}
+		lines = append(lines, line)
+		currentSize += lineSize
+	}
 
-// CreateDazhuCode 根据genda_citi.txt生成dazhu_code.txt，格式为"编码\t字词"
-func CreateDazhuCode(gendaCitiFile, dazhuCodeFile string, maxSizeMB int) error {
-	// 读取genda_citi.txt文件
-	entries, err := ReadCitiFile(gendaCitiFile, "genda_citi")
-	if err != nil {
-		return fmt.Errorf("读取genda_citi.txt失败: %w", err)
+	if opts.SortBy == DazhuSortFirstCol {
+		sort.SliceStable(lines, func(i, j int) bool {
+			return lines[i].first < lines[j].first
+		})
 	}
 
 	// 创建输出文件
@@ -507,23 +668,10 @@ func CreateDazhuCode(gendaCitiFile, dazhuCodeFile string, maxSizeMB int) error {
 	defer file.Close()
 
 	writer := bufio.NewWriter(file)
-	maxSizeBytes := maxSizeMB * 1024 * 1024
-	currentSize := 0
-
-	// 按"编码\t字词"格式写入，并控制文件大小
-	for _, entry := range entries {
-		line := fmt.Sprintf("%s\t%s\n", entry.Code, entry.Text)
-		lineSize := len([]byte(line))
-		
-		// 检查是否超过最大文件大小
-		if currentSize+lineSize > maxSizeBytes {
-			break
-		}
-		
-		if _, err := writer.WriteString(line); err != nil {
+	for _, line := range lines {
+		if _, err := writer.WriteString(line.first + "\t" + line.second + "\n"); err != nil {
 			return fmt.Errorf("写入文件失败: %w", err)
 		}
-		currentSize += lineSize
 	}
 
 	if err := writer.Flush(); err != nil {
@@ -534,101 +682,64 @@ func CreateDazhuCode(gendaCitiFile, dazhuCodeFile string, maxSizeMB int) error {
 }
 
 // applySimpleCharsSortingToCiti 对CitiEntry列表应用出简让全排序逻辑
-func applySimpleCharsSortingToCiti(entries []*CitiEntry) []*CitiEntry {
+func applySimpleCharsSortingToCiti(entries []*CitiEntry, simpleFile string, simpleChars map[string]int, rules *ExperienceRules) []*CitiEntry {
+	// 未直接给出简码信息时从简码文件读取
+	if simpleChars == nil {
+		simpleChars = loadSimpleChars(simpleFile)
+	}
+	if rules == nil {
+		rules = DefaultExperienceRules()
+	}
+
 	// 按编码分组
 	groups := make(map[string][]*CitiEntry)
 	codeOrder := make([]string, 0)
-	
+
 	for _, entry := range entries {
 		if _, exists := groups[entry.Code]; !exists {
 			codeOrder = append(codeOrder, entry.Code)
 		}
 		groups[entry.Code] = append(groups[entry.Code], entry)
 	}
-	
+
 	// 对每个编码组进行特殊处理
 	result := make([]*CitiEntry, 0, len(entries))
 	for _, code := range codeOrder {
 		group := groups[code]
-		processedGroup := processCitiCodeGroup(group)
+		processedGroup := processCitiCodeGroup(group, simpleChars, rules)
 		result = append(result, processedGroup...)
 	}
-	
-	return result
-}
 
-// processCitiCodeGroup 处理单个编码组的简码汉字特殊排序
-func processCitiCodeGroup(group []*CitiEntry) []*CitiEntry {
-	if len(group) < 3 {
-		// 如果重码组内候选不足三个，不应用特殊规则
-		return group
-	}
-	
-	// 读取简码信息
-	simpleChars := loadSimpleCharsForCiti()
-	
-	// 创建副本进行处理，避免影响原始数据
-	result := make([]*CitiEntry, len(group))
-	copy(result, group)
-	
-	// 第一步：处理一简汉字，下移2行
-	result = moveSimpleCharsInCiti(result, simpleChars, 1, 2)
-	
-	// 第二步：处理二简汉字，下移2行
-	result = moveSimpleCharsInCiti(result, simpleChars, 2, 2)
-	
-	// 第三步：处理"的"、"了"二字，下移2位
-	result = moveSpecialCharsInCiti(result)
-	
 	return result
 }
 
-// loadSimpleCharsForCiti 从code_chars_simp.txt加载简码汉字信息
-func loadSimpleCharsForCiti() map[string]int {
-	simpleChars := make(map[string]int)
-	
-	// 简码文件路径，这里假设在deploy/tmp目录下
-	simpleFile := "../deploy/tmp/code_chars_simp.txt"
-	file, err := os.Open(simpleFile)
-	if err != nil {
-		// 如果文件不存在，返回空映射
-		return simpleChars
+// processCitiCodeGroup 处理单个编码组的简码汉字特殊排序，再应用 citi 体验规则
+func processCitiCodeGroup(group []*CitiEntry, simpleChars map[string]int, rules *ExperienceRules) []*CitiEntry {
+	result := group
+	// 重码组内候选不足三个时不下移简码汉字
+	if len(group) >= 3 {
+		// 创建副本进行处理，避免影响原始数据
+		result = make([]*CitiEntry, len(group))
+		copy(result, group)
+
+		// 第一步：处理一简汉字，下移2行
+		result = moveSimpleCharsInCiti(result, simpleChars, 1, 2)
+
+		// 第二步：处理二简汉字，下移2行
+		result = moveSimpleCharsInCiti(result, simpleChars, 2, 2)
 	}
-	defer file.Close()
-	
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		
-		fields := strings.Split(line, "\t")
-		if len(fields) < 2 {
-			continue
-		}
-		
-		char := fields[0]
-		code := fields[1]
-		
-		// 根据编码长度判断是一简还是二简
-		// 一简：编码长度为1或2（一简+补码）
-		// 二简：编码长度为2或3（二简+补码）
-		if len(code) == 1 || len(code) == 2 {
-			simpleChars[char] = 1 // 一简（包括一简+补码）
-		} else if len(code) == 3 {
-			simpleChars[char] = 2 // 二简（包括二简+补码）
-		}
-	}
-	
-	return simpleChars
+
+	// 第三步：按体验规则调整（默认规则为"的"、"了"二字下移2位）
+	return applyOrderRules(rules, RuleScopeCiti, group[0].Code, result, func(entry *CitiEntry) string {
+		return entry.Text
+	})
 }
 
 // moveSimpleCharsInCiti 在CitiEntry列表中移动简码汉字
 func moveSimpleCharsInCiti(group []*CitiEntry, simpleChars map[string]int, simpleType int, moveCount int) []*CitiEntry {
 	result := make([]*CitiEntry, len(group))
 	copy(result, group)
-	
+
 	// 找到所有指定类型的简码汉字
 	simpleIndices := make([]int, 0)
 	for i, entry := range result {
@@ -636,7 +747,7 @@ func moveSimpleCharsInCiti(group []*CitiEntry, simpleChars map[string]int, simpl
 			simpleIndices = append(simpleIndices, i)
 		}
 	}
-	
+
 	// 对每个简码汉字进行移动（从后往前处理，避免索引变化）
 	for i := len(simpleIndices) - 1; i >= 0; i-- {
 		idx := simpleIndices[i]
@@ -649,34 +760,6 @@ func moveSimpleCharsInCiti(group []*CitiEntry, simpleChars map[string]int, simpl
 			result[idx+moveCount] = temp
 		}
 	}
-	
-	return result
-}
 
-// moveSpecialCharsInCiti 在CitiEntry列表中移动特殊字符"的"和"了"
-func moveSpecialCharsInCiti(group []*CitiEntry) []*CitiEntry {
-	result := make([]*CitiEntry, len(group))
-	copy(result, group)
-	
-	specialChars := map[string]bool{
-		"的": true,
-		"了": true,
-	}
-	
-	// 找到特殊字符的位置
-	for i, entry := range result {
-		if specialChars[entry.Text] {
-			// 下移2位
-			if i+2 < len(result) {
-				temp := result[i]
-				for j := i; j < i+2; j++ {
-					result[j] = result[j+1]
-				}
-				result[i+2] = temp
-			}
-			break // 每次只处理一个特殊字符
-		}
-	}
-	
 	return result
-}
\ No newline at end of file
+}
@@ -0,0 +1,73 @@
+package tools
+
+// codeKey 不超过 4 字节的编码逐字节打包成的整数，作为编码计数表、集合的键
+// 大词库下几十万个短编码以字符串为键时哈希与分配开销可观，打包后只需对整数哈希
+// 编码不含 NUL 字节，不同长度的编码打包后也互不相同；大写、数字与标点键都按原字节打包，不依赖键位表
+type codeKey uint32
+
+// packCode 打包编码，编码超过 4 字节或含 NUL 字节时返回 false
+func packCode(code string) (codeKey, bool) {
+	if len(code) > 4 {
+		return 0, false
+	}
+	var key codeKey
+	for i := 0; i < len(code); i++ {
+		if code[i] == 0 {
+			return 0, false
+		}
+		key = key<<8 | codeKey(code[i])
+	}
+	return key, true
+}
+
+// String 还原为编码
+func (key codeKey) String() string {
+	var buffer [4]byte
+	n := len(buffer)
+	for ; key != 0; key >>= 8 {
+		n--
+		buffer[n] = byte(key)
+	}
+	return string(buffer[n:])
+}
+
+// codeTable 以编码为键的表，行为与 map[string]V 相同
+// 能打包的编码以 codeKey 为键，其余（超过 4 字节或含 NUL 的编码）退回字符串键
+type codeTable[V any] struct {
+	packed map[codeKey]V
+	other  map[string]V
+}
+
+// newCodeTable 创建空的编码表
+func newCodeTable[V any]() *codeTable[V] {
+	return &codeTable[V]{packed: make(map[codeKey]V), other: make(map[string]V)}
+}
+
+// get 返回编码对应的值，不存在时返回零值
+func (table *codeTable[V]) get(code string) V {
+	if key, ok := packCode(code); ok {
+		return table.packed[key]
+	}
+	return table.other[code]
+}
+
+// set 设置编码对应的值
+func (table *codeTable[V]) set(code string, value V) {
+	if key, ok := packCode(code); ok {
+		table.packed[key] = value
+		return
+	}
+	table.other[code] = value
+}
+
+// codes 返回表中的全部编码，顺序不固定
+func (table *codeTable[V]) codes() []string {
+	codes := make([]string, 0, len(table.packed)+len(table.other))
+	for key := range table.packed {
+		codes = append(codes, key.String())
+	}
+	for code := range table.other {
+		codes = append(codes, code)
+	}
+	return codes
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 码表列名，与 Rime 字典头部 columns 的写法一致
+const (
+	ColumnText   = "text"   // 字词
+	ColumnCode   = "code"   // 编码
+	ColumnWeight = "weight" // 词频或权重
+	ColumnSimp   = "simp"   // 该字的简码（只用于单字全码表）
+)
+
+// 可配置列的码表输出名
+const (
+	OutputCharsFull    = "chars_full"
+	OutputCharsSimp    = "chars_simp"
+	OutputWordsFull    = "words_full"
+	OutputWordsSimp    = "words_simp"
+	OutputLinglongFull = "linglong_full"
+	OutputLinglongSimp = "linglong_simp"
+)
+
+var columnOutputs = []string{OutputCharsFull, OutputCharsSimp, OutputWordsFull, OutputWordsSimp, OutputLinglongFull, OutputLinglongSimp}
+
+// ColumnLayout 单个码表的输出列，按顺序写出
+type ColumnLayout []string
+
+// OutputColumns 各码表的输出列，未配置的码表用 DefaultColumnLayout
+type OutputColumns map[string]ColumnLayout
+
+// DefaultColumnLayout 各码表的默认列：都是字词、编码、权重三列，词码表缺权重的条目只写两列
+func DefaultColumnLayout() ColumnLayout {
+	return ColumnLayout{ColumnText, ColumnCode, ColumnWeight}
+}
+
+// ParseOutputColumns 解析各码表的输出列，格式："words_full=text,code chars_simp=text,code,weight"，各项以空白分隔
+// 列须以 text,code 开头，之后可加 weight；chars_full 还可在最后加 simp。字典追加与跟打词提按前两列读取码表，因此不能调换顺序
+func ParseOutputColumns(columnsStr string) (OutputColumns, error) {
+	columns := make(OutputColumns)
+	for _, item := range strings.Fields(columnsStr) {
+		output, list, ok := strings.Cut(item, "=")
+		if !ok || list == "" {
+			return nil, fmt.Errorf("输出列格式应为 码表=列,列: %s", item)
+		}
+		knownOutput := false
+		for _, columnOutput := range columnOutputs {
+			knownOutput = knownOutput || output == columnOutput
+		}
+		if !knownOutput {
+			return nil, fmt.Errorf("未知的码表: %s（可选 %s）", output, strings.Join(columnOutputs, "、"))
+		}
+		if _, exists := columns[output]; exists {
+			return nil, fmt.Errorf("码表 %s 的输出列重复配置", output)
+		}
+		layout := ColumnLayout(strings.Split(list, ","))
+		if err := checkColumnLayout(output, layout); err != nil {
+			return nil, err
+		}
+		columns[output] = layout
+	}
+	return columns, nil
+}
+
+// checkColumnLayout 校验列顺序：text、code、[weight]、[simp]，simp 只用于单字全码表
+func checkColumnLayout(output string, layout ColumnLayout) error {
+	if len(layout) < 2 || layout[0] != ColumnText || layout[1] != ColumnCode {
+		return fmt.Errorf("码表 %s 的输出列须以 text,code 开头: %s", output, strings.Join(layout, ","))
+	}
+	rest := layout[2:]
+	if len(rest) > 0 && rest[0] == ColumnWeight {
+		rest = rest[1:]
+	}
+	if len(rest) > 0 && rest[0] == ColumnSimp && output == OutputCharsFull {
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("码表 %s 的输出列不支持: %s（text,code 之后可加 weight，chars_full 还可加 simp）", output, rest[0])
+	}
+	return nil
+}
+
+// Layout 码表的输出列，未配置时为默认列
+func (columns OutputColumns) Layout(output string) ColumnLayout {
+	if layout, exists := columns[output]; exists {
+		return layout
+	}
+	return DefaultColumnLayout()
+}
+
+// Has 是否包含某列
+func (layout ColumnLayout) Has(column string) bool {
+	for _, name := range layout {
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
+
+// Row 按列渲染一行（含换行），weight 为最后一列且为空时省略，与默认输出中缺权重的词条一致
+func (layout ColumnLayout) Row(text, code, weight, simp string) string {
+	var row strings.Builder
+	for i, column := range layout {
+		var value string
+		switch column {
+		case ColumnText:
+			value = text
+		case ColumnCode:
+			value = code
+		case ColumnWeight:
+			if weight == "" && i == len(layout)-1 {
+				continue
+			}
+			value = weight
+		case ColumnSimp:
+			value = simp
+		}
+		if i > 0 {
+			row.WriteByte('\t')
+		}
+		row.WriteString(value)
+	}
+	row.WriteByte('\n')
+	return row.String()
+}
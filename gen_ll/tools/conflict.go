@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"gen_ll/types"
+)
+
+// SimpleCodeConflict 单字简码与词简码完全同码的一对条目
+type SimpleCodeConflict struct {
+	Code     string
+	Char     string
+	CharFreq int64
+	Word     string
+	Weight   int64
+	Source   string  // words_simp 或 linglong_simp
+	Score    float64 // 字频与词权重的乘积，越大越影响输入体验
+}
+
+// BuildSimpleCodeConflicts 找出单字简码与多字词、玲珑词简码完全同码的条目，按字频与权重的乘积降序排列
+// 占位符不算冲突；只读分析，不改变分配结果
+func BuildSimpleCodeConflicts(simpleCodeList []*types.CharMeta, wordSimpleCodes, linglongSimpleCodes []*types.WordSimpleCode) []*SimpleCodeConflict {
+	charsByCode := make(map[string][]*types.CharMeta)
+	for _, charMeta := range simpleCodeList {
+		charsByCode[charMeta.Code] = append(charsByCode[charMeta.Code], charMeta)
+	}
+
+	var conflicts []*SimpleCodeConflict
+	collect := func(wordSimpleCodes []*types.WordSimpleCode, source string) {
+		for _, wordSimpleCode := range wordSimpleCodes {
+			if wordSimpleCode.IsPlaceholder {
+				continue
+			}
+			weight := parseWeight(wordSimpleCode.Weight)
+			for _, charMeta := range charsByCode[wordSimpleCode.Code] {
+				conflicts = append(conflicts, &SimpleCodeConflict{
+					Code:     wordSimpleCode.Code,
+					Char:     charMeta.Char,
+					CharFreq: charMeta.Freq,
+					Word:     wordSimpleCode.Word,
+					Weight:   weight,
+					Source:   source,
+					Score:    float64(charMeta.Freq) * float64(weight),
+				})
+			}
+		}
+	}
+	collect(wordSimpleCodes, "words_simp")
+	collect(linglongSimpleCodes, "linglong_simp")
+
+	sort.SliceStable(conflicts, func(i, j int) bool {
+		if conflicts[i].Score != conflicts[j].Score {
+			return conflicts[i].Score > conflicts[j].Score
+		}
+		if conflicts[i].Code != conflicts[j].Code {
+			return conflicts[i].Code < conflicts[j].Code
+		}
+		return conflicts[i].Word < conflicts[j].Word
+	})
+	return conflicts
+}
+
+// WriteSimpleCodeConflicts 以 tsv 写出简码冲突报告，每行格式为"编码\t单字\t字频\t词\t权重\t来源\t乘积"
+func WriteSimpleCodeConflicts(filepath string, conflicts []*SimpleCodeConflict) error {
+	buffer := bytes.Buffer{}
+	buffer.WriteString("编码\t单字\t字频\t词\t权重\t来源\t乘积\n")
+	for _, conflict := range conflicts {
+		buffer.WriteString(fmt.Sprintf("%s\t%s\t%d\t%s\t%d\t%s\t%.0f\n",
+			conflict.Code, conflict.Char, conflict.CharFreq, conflict.Word, conflict.Weight, conflict.Source, conflict.Score))
+	}
+	return os.WriteFile(filepath, buffer.Bytes(), 0o644)
+}
+
+// WordCodeDuplicate 多字词与玲珑词中字词、编码完全相同的一条，两个字典都收录时 Rime 中出现重复候选
+type WordCodeDuplicate struct {
+	Word           string
+	Code           string
+	WordsWeight    string // 多字词表中的权重（可能为空）
+	LinglongWeight string // 玲珑词表中的权重（可能为空）
+}
+
+// FindWordCodeDuplicates 找出多字词全码与玲珑词全码中字词、编码完全相同的条目，按多字词表顺序排列
+// 同一来源内部的重复不计，同一对只列一次；只读分析
+func FindWordCodeDuplicates(wordCodes, linglongCodes []*types.WordCode) []*WordCodeDuplicate {
+	linglongWeights := make(map[string]string, len(linglongCodes))
+	for _, wordCode := range linglongCodes {
+		key := wordCode.Word + "\t" + wordCode.Code
+		if _, exists := linglongWeights[key]; !exists {
+			linglongWeights[key] = wordCode.Weight
+		}
+	}
+
+	var duplicates []*WordCodeDuplicate
+	seen := make(map[string]bool)
+	for _, wordCode := range wordCodes {
+		key := wordCode.Word + "\t" + wordCode.Code
+		linglongWeight, exists := linglongWeights[key]
+		if !exists || seen[key] {
+			continue
+		}
+		seen[key] = true
+		duplicates = append(duplicates, &WordCodeDuplicate{
+			Word:           wordCode.Word,
+			Code:           wordCode.Code,
+			WordsWeight:    wordCode.Weight,
+			LinglongWeight: linglongWeight,
+		})
+	}
+	return duplicates
+}
+
+// RemoveWordCodeDuplicates 玲珑优先去重：返回去掉 duplicates 中各对的多字词全码，保持原顺序，不修改 wordCodes
+func RemoveWordCodeDuplicates(wordCodes []*types.WordCode, duplicates []*WordCodeDuplicate) []*types.WordCode {
+	duplicated := make(map[string]bool, len(duplicates))
+	for _, duplicate := range duplicates {
+		duplicated[duplicate.Word+"\t"+duplicate.Code] = true
+	}
+	kept := make([]*types.WordCode, 0, len(wordCodes))
+	for _, wordCode := range wordCodes {
+		if !duplicated[wordCode.Word+"\t"+wordCode.Code] {
+			kept = append(kept, wordCode)
+		}
+	}
+	return kept
+}
+
+// WriteWordCodeDuplicates 以 tsv 写出词条重复编码清单，每行格式为"词\t编码\t多字词权重\t玲珑权重"
+func WriteWordCodeDuplicates(filepath string, duplicates []*WordCodeDuplicate) error {
+	buffer := bytes.Buffer{}
+	buffer.WriteString("词\t编码\t多字词权重\t玲珑权重\n")
+	for _, duplicate := range duplicates {
+		buffer.WriteString(fmt.Sprintf("%s\t%s\t%s\t%s\n", duplicate.Word, duplicate.Code, duplicate.WordsWeight, duplicate.LinglongWeight))
+	}
+	return os.WriteFile(filepath, buffer.Bytes(), 0o644)
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DeployFile 一个待部署的产物
+type DeployFile struct {
+	Source string // 生成的文件
+	Target string // 部署位置
+}
+
+// DeployOptions 部署选项
+type DeployOptions struct {
+	DryRun bool // 只返回部署计划，不写任何文件
+	Backup bool // 替换前把已有的目标文件复制为"目标文件.bak"
+}
+
+// RimeDeployFiles 按 Rime 用户目录约定列出产物的部署位置
+// 字典文件放在目录根下，preset_data.txt 放在 lua/chars_cand/ 下
+func RimeDeployFiles(deployDir string, dictFiles []string, presetData string) []DeployFile {
+	files := make([]DeployFile, 0, len(dictFiles)+1)
+	for _, dictFile := range dictFiles {
+		files = append(files, DeployFile{
+			Source: dictFile,
+			Target: filepath.Join(deployDir, filepath.Base(dictFile)),
+		})
+	}
+	if presetData != "" {
+		files = append(files, DeployFile{
+			Source: presetData,
+			Target: filepath.Join(deployDir, "lua", "chars_cand", "preset_data.txt"),
+		})
+	}
+	return files
+}
+
+// DeployFiles 把产物复制到部署位置，已有文件按原子替换处理
+func DeployFiles(files []DeployFile, opts DeployOptions) error {
+	if opts.DryRun {
+		return nil
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file.Source)
+		if err != nil {
+			return fmt.Errorf("读取产物 %s 失败: %w", file.Source, err)
+		}
+		if err := writeDeployContent(file.Target, content, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDeployContent 把内容原子写入部署位置，按需先备份已有文件
+func writeDeployContent(target string, content []byte, opts DeployOptions) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("创建部署目录失败: %w", err)
+	}
+
+	if opts.Backup {
+		if previous, err := os.ReadFile(target); err == nil {
+			if err := writeFileAtomic(target+".bak", previous); err != nil {
+				return fmt.Errorf("备份 %s 失败: %w", target, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("读取已部署文件 %s 失败: %w", target, err)
+		}
+	}
+
+	if err := writeFileAtomic(target, content); err != nil {
+		return fmt.Errorf("部署 %s 失败: %w", target, err)
+	}
+	return nil
+}
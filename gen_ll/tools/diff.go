@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gen_ll/tabfile"
+	"gen_ll/types"
+)
+
+// CodeTableChange 某个字词在两版码表间的编码差异：Old 为空是新增，New 为空是删除，都不为空是修改
+type CodeTableChange struct {
+	Text string
+	Old  []string // 上一版的编码，去重后按字母序
+	New  []string // 本次的编码，去重后按字母序
+}
+
+// CodeTableDiff 两版码表的差异
+type CodeTableDiff struct {
+	Changes  []*CodeTableChange // 先按本次码表中首次出现的顺序，删除的字词随后按上一版的顺序
+	Added    int
+	Removed  int
+	Modified int
+}
+
+// DiffCodeTables 按字词比较两版码表：同一字词的编码集合不同即为修改，不比较条目顺序与词频
+func DiffCodeTables(oldList, newList []*types.CharMeta) *CodeTableDiff {
+	oldCodes, oldOrder := groupCodes(oldList)
+	newCodes, newOrder := groupCodes(newList)
+
+	diff := &CodeTableDiff{}
+	for _, text := range newOrder {
+		old, existed := oldCodes[text]
+		switch {
+		case !existed:
+			diff.Added++
+		case !equalStrings(old, newCodes[text]):
+			diff.Modified++
+		default:
+			continue
+		}
+		diff.Changes = append(diff.Changes, &CodeTableChange{Text: text, Old: old, New: newCodes[text]})
+	}
+	for _, text := range oldOrder {
+		if _, exists := newCodes[text]; !exists {
+			diff.Removed++
+			diff.Changes = append(diff.Changes, &CodeTableChange{Text: text, Old: oldCodes[text]})
+		}
+	}
+	return diff
+}
+
+// groupCodes 按字词归组编码，返回各字词去重排序后的编码与首次出现的顺序
+// 占位符一个字就有上万个编码，按"字词\t编码"去重而不是在各字词的编码列表中查找
+func groupCodes(list []*types.CharMeta) (map[string][]string, []string) {
+	codes := make(map[string][]string)
+	seen := make(map[string]bool, len(list))
+	var order []string
+	for _, charMeta := range list {
+		if _, exists := codes[charMeta.Char]; !exists {
+			order = append(order, charMeta.Char)
+		}
+		key := charMeta.Char + "\t" + charMeta.Code
+		if !seen[key] {
+			seen[key] = true
+			codes[charMeta.Char] = append(codes[charMeta.Char], charMeta.Code)
+		}
+	}
+	for _, textCodes := range codes {
+		sort.Strings(textCodes)
+	}
+	return codes, order
+}
+
+// equalStrings 两个字符串列表是否逐项相同
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Format 渲染差异：每个字词先以 "-" 行列出旧编码、再以 "+" 行列出新编码，同一字词的多个编码以空格分隔
+func (diff *CodeTableDiff) Format() []byte {
+	buffer := bytes.Buffer{}
+	for _, change := range diff.Changes {
+		if len(change.Old) > 0 {
+			buffer.WriteString(fmt.Sprintf("-%s\t%s\n", change.Text, strings.Join(change.Old, " ")))
+		}
+		if len(change.New) > 0 {
+			buffer.WriteString(fmt.Sprintf("+%s\t%s\n", change.Text, strings.Join(change.New, " ")))
+		}
+	}
+	return buffer.Bytes()
+}
+
+// ReadCodeTableEntries 读取已写出的码表，只取前两列（字词、编码），供与本次结果比较
+// 词码表中缺权重的条目只有两列，自定义输出列时 text,code 也总在最前，因此都能读取
+func ReadCodeTableEntries(filepath string) ([]*types.CharMeta, error) {
+	var entries []*types.CharMeta
+	err := tabfile.ScanFile(filepath, tabfile.Options{}, func(row *tabfile.Row) error {
+		if row.Len() < 2 || row.Column(0) == "" || row.Column(1) == "" {
+			return row.Errorf("格式错误，应为字词\\t编码[\\t词频]")
+		}
+		entries = append(entries, &types.CharMeta{Char: row.Column(0), Code: row.Column(1)})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// WordCodeMetas 把多字词全码转为只有字词与编码的字元，供 DiffCodeTables 比较
+func WordCodeMetas(wordCodes []*types.WordCode) []*types.CharMeta {
+	list := make([]*types.CharMeta, 0, len(wordCodes))
+	for _, wordCode := range wordCodes {
+		list = append(list, &types.CharMeta{Char: wordCode.Word, Code: wordCode.Code})
+	}
+	return list
+}
+
+// WordSimpleCodeMetas 把多字词简码（含占位符）转为只有字词与编码的字元，供 DiffCodeTables 比较
+func WordSimpleCodeMetas(wordSimpleCodes []*types.WordSimpleCode) []*types.CharMeta {
+	list := make([]*types.CharMeta, 0, len(wordSimpleCodes))
+	for _, wordSimpleCode := range wordSimpleCodes {
+		list = append(list, &types.CharMeta{Char: wordSimpleCode.Word, Code: wordSimpleCode.Code})
+	}
+	return list
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+
+	"gen_ll/types"
+)
+
+// TestDiffCodeTables 按字词比较编码集合：新增、删除、修改分别计数，编码去重排序后比较，不看条目顺序与词频
+// 变更先按本次码表中的顺序，删除的字词随后按上一版的顺序
+func TestDiffCodeTables(t *testing.T) {
+	oldList := []*types.CharMeta{
+		{Char: "的", Code: "dea", Freq: 9},
+		{Char: "一", Code: "yi"},
+		{Char: "一", Code: "yia"},
+		{Char: "了", Code: "le"},
+		{Char: "是", Code: "shi"},
+		{Char: "不", Code: "bu"},
+	}
+	newList := []*types.CharMeta{
+		{Char: "在", Code: "zai"},
+		{Char: "一", Code: "yia"},
+		{Char: "一", Code: "yi"},
+		{Char: "一", Code: "yi"},
+		{Char: "的", Code: "de"},
+		{Char: "是", Code: "shi", Freq: 3},
+	}
+
+	diff := DiffCodeTables(oldList, newList)
+	want := []*CodeTableChange{
+		{Text: "在", New: []string{"zai"}},
+		{Text: "的", Old: []string{"dea"}, New: []string{"de"}},
+		{Text: "了", Old: []string{"le"}},
+		{Text: "不", Old: []string{"bu"}},
+	}
+	if !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("变更 %+v，期望 %+v", diff.Changes, want)
+	}
+	if diff.Added != 1 || diff.Removed != 2 || diff.Modified != 1 {
+		t.Errorf("新增 %d，删除 %d，修改 %d，期望 1、2、1", diff.Added, diff.Removed, diff.Modified)
+	}
+	if got, want := string(diff.Format()), "+在\tzai\n-的\tdea\n+的\tde\n-了\tle\n-不\tbu\n"; got != want {
+		t.Errorf("Format() = %q，期望 %q", got, want)
+	}
+
+	if same := DiffCodeTables(newList, newList); len(same.Changes) != 0 || len(same.Format()) != 0 {
+		t.Errorf("相同码表的差异应为空: %+v", same.Changes)
+	}
+}
@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"gen_ll/tabfile"
+)
+
+// DisplayReplacer 展示性输出的字符替换器
+// 用于把私有区（PUA）部件替换为可显示的近似字符串，编码计算仍使用原始部件
+type DisplayReplacer struct {
+	mappings map[rune]string
+	mutex    sync.Mutex
+	hits     int
+	missing  map[rune]int
+}
+
+// ReadDisplayMap 读取显示替换表，格式为"原字符\t替换字符串"
+func ReadDisplayMap(filepath string) (map[string]string, error) {
+	mappings := map[string]string{}
+	err := forEachRow(filepath, tabfile.Options{}, func(row *tabfile.Row) error {
+		if row.Len() < 2 || utf8.RuneCountInString(row.Column(0)) != 1 {
+			return row.Errorf("格式错误，应为单个字符\\t替换字符串")
+		}
+		mappings[row.Column(0)] = row.Column(1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mappings, nil
+}
+
+// NewDisplayReplacer 根据显示替换表创建替换器
+func NewDisplayReplacer(mappings map[string]string) *DisplayReplacer {
+	replacer := &DisplayReplacer{
+		mappings: make(map[rune]string, len(mappings)),
+		missing:  make(map[rune]int),
+	}
+	for char, display := range mappings {
+		r, _ := utf8.DecodeRuneInString(char)
+		replacer.mappings[r] = display
+	}
+	return replacer
+}
+
+// Replace 替换文本中的字符，并记录命中次数与未覆盖的私有区字符
+// 替换器为 nil 时原样返回，可在未启用替换时直接调用
+func (replacer *DisplayReplacer) Replace(text string) string {
+	if replacer == nil {
+		return text
+	}
+
+	replacer.mutex.Lock()
+	defer replacer.mutex.Unlock()
+
+	var result strings.Builder
+	for _, r := range text {
+		if display, exists := replacer.mappings[r]; exists {
+			result.WriteString(display)
+			replacer.hits++
+			continue
+		}
+		if unicode.Is(unicode.Co, r) {
+			replacer.missing[r]++
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// Hits 返回替换命中次数
+func (replacer *DisplayReplacer) Hits() int {
+	if replacer == nil {
+		return 0
+	}
+	replacer.mutex.Lock()
+	defer replacer.mutex.Unlock()
+	return replacer.hits
+}
+
+// Missing 返回替换表未覆盖的私有区字符，按码位升序，格式如"U+E000"
+func (replacer *DisplayReplacer) Missing() []string {
+	if replacer == nil {
+		return nil
+	}
+	replacer.mutex.Lock()
+	defer replacer.mutex.Unlock()
+
+	runes := make([]rune, 0, len(replacer.missing))
+	for r := range replacer.missing {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool {
+		return runes[i] < runes[j]
+	})
+
+	result := make([]string, 0, len(runes))
+	for _, r := range runes {
+		result = append(result, fmt.Sprintf("U+%04X", r))
+	}
+	return result
+}
@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"sort"
+	"sync"
+)
+
+// 空跑：照常构建，码表输出只计数不写文件
+var dryRun bool
+
+// SetDryRun 设置是否空跑
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
+// DryRunOutput 空跑时一份码表本应写出的条目数
+type DryRunOutput struct {
+	Path  string
+	Lines int
+}
+
+var (
+	dryRunMutex   sync.Mutex
+	dryRunOutputs []DryRunOutput
+)
+
+// recordDryRunOutput 记录一份空跑码表，码表由多个协程并行写出
+func recordDryRunOutput(path string, lines int) {
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	dryRunOutputs = append(dryRunOutputs, DryRunOutput{Path: path, Lines: lines})
+}
+
+// DryRunOutputs 返回空跑中各码表本应写出的条目数，按路径排序
+func DryRunOutputs() []DryRunOutput {
+	dryRunMutex.Lock()
+	defer dryRunMutex.Unlock()
+	outputs := append([]DryRunOutput(nil), dryRunOutputs...)
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].Path < outputs[j].Path
+	})
+	return outputs
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gen_ll/tabfile"
+	"gen_ll/types"
+)
+
+// keyFingers 标准指法下各键对应的手指：0-3 为左手小指到食指，4-7 为右手食指到小指
+var keyFingers = map[byte]int{
+	'q': 0, 'a': 0, 'z': 0,
+	'w': 1, 's': 1, 'x': 1,
+	'e': 2, 'd': 2, 'c': 2,
+	'r': 3, 'f': 3, 'v': 3, 't': 3, 'g': 3, 'b': 3,
+	'y': 4, 'h': 4, 'n': 4, 'u': 4, 'j': 4, 'm': 4,
+	'i': 5, 'k': 5, ',': 5,
+	'o': 6, 'l': 6, '.': 6,
+	'p': 7, ';': 7, '/': 7,
+}
+
+// isPinky 判断手指是否为小指
+func isPinky(finger int) bool {
+	return finger == 0 || finger == 7
+}
+
+// EquivStats 按字频加权的手感指标
+type EquivStats struct {
+	Equivalence    float64  `json:"equivalence"`      // 平均每个两键组合的当量
+	SameFingerRate float64  `json:"same_finger_rate"` // 同指（不同键）组合占全部两键组合的比例
+	PinkyLoad      float64  `json:"pinky_load"`       // 小指击键占全部击键的比例
+	Pairs          int      `json:"pairs"`            // 参与统计的两键组合种数
+	MissingPairs   []string `json:"missing_pairs"`    // 当量表缺失、使用默认代价的组合
+}
+
+// ReadEquivTable 读取按键当量表，格式为"两键组合\t代价"
+func ReadEquivTable(filepath string) (map[string]float64, error) {
+	costs := map[string]float64{}
+	err := forEachRow(filepath, tabfile.Options{}, func(row *tabfile.Row) error {
+		if row.Len() < 2 || len(row.Column(0)) != 2 {
+			return row.Errorf("格式错误，应为两键组合\\t代价")
+		}
+		cost, err := strconv.ParseFloat(strings.TrimSpace(row.Column(1)), 64)
+		if err != nil {
+			return row.Errorf("代价不是数字: %s", row.Column(1))
+		}
+		costs[row.Column(0)] = cost
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return costs, nil
+}
+
+// EvaluateEquivalence 按字频加权计算整体当量、同指率与小指负担
+// 每个字按实际输入时使用的编码计算：有简码用简码，否则用主拆分全码
+// 当量表缺失的组合使用 defaultCost，并记录在 MissingPairs 中
+func EvaluateEquivalence(simpleCodeList, fullCodeMetaList []*types.CharMeta, costs map[string]float64, defaultCost float64) *EquivStats {
+	codes := make(map[string]string)
+	freqs := make(map[string]int64)
+	for _, charMeta := range fullCodeMetaList {
+		if charMeta.MDiv {
+			codes[charMeta.Char] = charMeta.Code
+			freqs[charMeta.Char] = charMeta.Freq
+		}
+	}
+	for _, charMeta := range simpleCodeList {
+		if current, exists := codes[charMeta.Char]; !exists || len(charMeta.Code) < len(current) {
+			codes[charMeta.Char] = charMeta.Code
+			freqs[charMeta.Char] = charMeta.Freq
+		}
+	}
+
+	var totalCost, pairWeight, sameFingerWeight, strokeWeight, pinkyWeight float64
+	seenPairs := make(map[string]bool)
+	missing := make(map[string]bool)
+	for char, code := range codes {
+		weight := float64(freqs[char])
+		if weight <= 0 {
+			continue
+		}
+		for i := 0; i < len(code); i++ {
+			strokeWeight += weight
+			if finger, exists := keyFingers[code[i]]; exists && isPinky(finger) {
+				pinkyWeight += weight
+			}
+			if i == 0 {
+				continue
+			}
+
+			pair := code[i-1 : i+1]
+			seenPairs[pair] = true
+			cost, exists := costs[pair]
+			if !exists {
+				cost = defaultCost
+				missing[pair] = true
+			}
+			totalCost += cost * weight
+			pairWeight += weight
+
+			first, firstExists := keyFingers[code[i-1]]
+			second, secondExists := keyFingers[code[i]]
+			if firstExists && secondExists && first == second && code[i-1] != code[i] {
+				sameFingerWeight += weight
+			}
+		}
+	}
+
+	stats := &EquivStats{Pairs: len(seenPairs), MissingPairs: []string{}}
+	if pairWeight > 0 {
+		stats.Equivalence = totalCost / pairWeight
+		stats.SameFingerRate = sameFingerWeight / pairWeight
+	}
+	if strokeWeight > 0 {
+		stats.PinkyLoad = pinkyWeight / strokeWeight
+	}
+	for pair := range missing {
+		stats.MissingPairs = append(stats.MissingPairs, pair)
+	}
+	sort.Strings(stats.MissingPairs)
+
+	return stats
+}
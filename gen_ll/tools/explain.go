@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"gen_ll/tabfile"
+)
+
+// ExplainSource 参与编码归属查询的一个产物文件
+type ExplainSource struct {
+	Name       string // 产物名，如 chars_full、citi_pre
+	File       string
+	CodePrefix string // 该产物编码的固定前缀（字根码表为引导前缀，默认"]"），匹配前去除
+}
+
+// ExplainEntry 编码在某个产物中的一个条目
+type ExplainEntry struct {
+	Source   string `json:"source"`           // 产物名
+	File     string `json:"file"`             // 来源文件
+	Line     int    `json:"line"`             // 文件中的行号
+	Text     string `json:"text"`             // 字词或字根
+	Code     string `json:"code"`             // 条目在文件中的实际编码
+	Suffix   string `json:"suffix,omitempty"` // 派生编码的候选后缀，直接命中时为空
+	Position int    `json:"position"`         // 同一文件同码条目中的排序位置，从 1 开始
+}
+
+// CodeExplanation 一个编码在全部产物中的归属
+type CodeExplanation struct {
+	Code    string          `json:"code"`
+	Entries []*ExplainEntry `json:"entries"`           // 编码完全相同的条目
+	Derived []*ExplainEntry `json:"derived"`           // 编码加候选后缀得到的派生条目
+	Missing []string        `json:"missing,omitempty"` // 不存在而跳过的产物文件
+}
+
+// ExplainCode 按产物顺序逐个扫描文件，列出编码及其候选派生编码的全部条目
+// dict.yaml 只扫描"..."之后的数据段；不存在的文件记入 Missing，不视为错误
+func ExplainCode(code string, sources []ExplainSource) (*CodeExplanation, error) {
+	explanation := &CodeExplanation{Code: code, Entries: []*ExplainEntry{}, Derived: []*ExplainEntry{}}
+	for _, source := range sources {
+		if _, err := os.Stat(source.File); os.IsNotExist(err) {
+			explanation.Missing = append(explanation.Missing, source.File)
+			continue
+		}
+
+		inData := !strings.HasSuffix(source.File, ".yaml")
+		positions := make(map[string]int)
+		err := forEachRow(source.File, tabfile.Options{}, func(row *tabfile.Row) error {
+			if !inData {
+				inData = strings.TrimSpace(row.Text) == "..."
+				return nil
+			}
+			if row.Len() < 2 {
+				return nil
+			}
+			entryCode := strings.TrimPrefix(row.Column(1), source.CodePrefix)
+			if !strings.HasPrefix(entryCode, code) {
+				return nil
+			}
+			suffix := entryCode[len(code):]
+			if suffix != "" && !isCandidateSuffix(suffix) {
+				return nil
+			}
+
+			positions[entryCode]++
+			entry := &ExplainEntry{
+				Source:   source.Name,
+				File:     source.File,
+				Line:     row.Line,
+				Text:     row.Column(0),
+				Code:     row.Column(1),
+				Suffix:   suffix,
+				Position: positions[entryCode],
+			}
+			if suffix == "" {
+				explanation.Entries = append(explanation.Entries, entry)
+			} else {
+				explanation.Derived = append(explanation.Derived, entry)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return explanation, nil
+}
+
+// isCandidateSuffix 判断是否为重码候选补码后缀：可选的若干"="翻页加一个候选后缀
+func isCandidateSuffix(suffix string) bool {
+	suffix = strings.TrimLeft(suffix, "=")
+	for _, candidateSuffix := range candidateSuffixes {
+		if suffix == candidateSuffix {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatCodeExplanation 格式化为人类可读文本：每个条目一行"产物\t字词\t编码\t第N位\t文件:行号"
+func FormatCodeExplanation(explanation *CodeExplanation) []byte {
+	buffer := bytes.Buffer{}
+	writeEntries := func(title string, entries []*ExplainEntry) {
+		buffer.WriteString(fmt.Sprintf("\n[%s] %d 条\n", title, len(entries)))
+		for _, entry := range entries {
+			buffer.WriteString(fmt.Sprintf("%s\t%s\t%s\t第%d位\t%s:%d\n", entry.Source, entry.Text, entry.Code, entry.Position, entry.File, entry.Line))
+		}
+	}
+
+	buffer.WriteString(fmt.Sprintf("编码: %s\n", explanation.Code))
+	writeEntries("同码条目", explanation.Entries)
+	writeEntries("候选派生编码", explanation.Derived)
+	if len(explanation.Missing) > 0 {
+		buffer.WriteString(fmt.Sprintf("\n未找到的产物: %s\n", strings.Join(explanation.Missing, " ")))
+	}
+	return buffer.Bytes()
+}
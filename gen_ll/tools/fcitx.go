@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// fcitx5 码表头部的功能键：拼音反查、提示与组词前缀，不能与编码字符重复
+const (
+	fcitx5PinyinKey          = "@"
+	fcitx5PromptKey          = "&"
+	fcitx5ConstructPhraseKey = "^"
+)
+
+// fcitx5PhraseRules 词码规则，与多字词全码的取码规则一致：二字词各取前两码，三字词前两字各取首码、末字取前两码，四字及以上取前三字与末字首码
+var fcitx5PhraseRules = []string{
+	"e2=p11+p12+p21+p22",
+	"e3=p11+p21+p31+p32",
+	"a4=p11+p21+p31+n11",
+}
+
+// Fcitx5Options fcitx5 码表导出选项
+type Fcitx5Options struct {
+	IncludeWords bool // 包含多字词全码与简码（不含占位符）
+	PhraseRules  bool // 写出 [Rule] 段，供 fcitx5 按规则组词；词码不按默认规则取码时（如按部首取码）应关闭
+}
+
+// Fcitx5Result fcitx5 码表导出结果
+type Fcitx5Result struct {
+	KeyCode string // 头部 KeyCode：数据中出现的全部编码字符
+	Length  int    // 头部 Length：最长编码长度
+	Entries int    // [Data] 段条目数
+}
+
+// fcitx5Entry [Data] 段的一个条目
+type fcitx5Entry struct {
+	code, text string
+	simple     bool // 简码条目，同码时排在全码之前
+	freq       int64
+}
+
+// WriteFcitx5Table 按 fcitx5（libime）码表文本格式导出构建结果：KeyCode/Length/Pinyin 等头部字段、可选的 [Rule] 段与 [Data] 段
+// 单字简码与全码合并为一张表，按编码升序排列，同码时简码条目在前、再按字频降序；同字同码只保留一条
+// KeyCode 按键位顺序列出数据中实际出现的编码字符，Length 取最长编码长度
+func WriteFcitx5Table(path string, result *Result, opts Fcitx5Options) (*Fcitx5Result, error) {
+	var entries []*fcitx5Entry
+	seen := make(map[string]bool)
+	add := func(code, text string, simple bool, freq int64) {
+		if code == "" || text == "" || seen[text+"\t"+code] {
+			return
+		}
+		seen[text+"\t"+code] = true
+		entries = append(entries, &fcitx5Entry{code: code, text: text, simple: simple, freq: freq})
+	}
+
+	for charMeta := range result.RangeSimpleChars() {
+		add(charMeta.Code, charMeta.Char, true, charMeta.Freq)
+	}
+	for charMeta := range result.RangeChars() {
+		add(charMeta.Code, charMeta.Char, false, charMeta.Freq)
+	}
+	if opts.IncludeWords {
+		for wordSimpleCode := range result.RangeWordSimpleCodes() {
+			if !wordSimpleCode.IsPlaceholder {
+				add(wordSimpleCode.Code, wordSimpleCode.Word, true, parseWeight(wordSimpleCode.Weight))
+			}
+		}
+		for wordCode := range result.RangeWords() {
+			add(wordCode.Code, wordCode.Word, false, parseWeight(wordCode.Weight))
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].code != entries[j].code {
+			return entries[i].code < entries[j].code
+		}
+		if entries[i].simple != entries[j].simple {
+			return entries[i].simple
+		}
+		return entries[i].freq > entries[j].freq
+	})
+
+	report := &Fcitx5Result{Entries: len(entries)}
+	keys := make(map[rune]bool)
+	for _, entry := range entries {
+		if strings.ContainsAny(entry.code, " \t") {
+			return nil, fmt.Errorf("编码含空白，无法写入 fcitx5 码表: %s %s", entry.code, entry.text)
+		}
+		report.Length = max(report.Length, len([]rune(entry.code)))
+		for _, key := range entry.code {
+			keys[key] = true
+		}
+	}
+	report.KeyCode = fcitx5KeyCode(keys)
+	if strings.ContainsAny(report.KeyCode, fcitx5PinyinKey+fcitx5PromptKey+fcitx5ConstructPhraseKey) {
+		return nil, fmt.Errorf("编码字符与 fcitx5 功能键冲突: %s", report.KeyCode)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建文件 %s: %w", path, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	fmt.Fprintf(writer, "KeyCode=%s\nLength=%d\nPinyin=%s\nPrompt=%s\nConstructPhrase=%s\n", report.KeyCode, report.Length, fcitx5PinyinKey, fcitx5PromptKey, fcitx5ConstructPhraseKey)
+	if opts.PhraseRules {
+		writer.WriteString("[Rule]\n")
+		for _, rule := range fcitx5PhraseRules {
+			writer.WriteString(rule + "\n")
+		}
+	}
+	writer.WriteString("[Data]\n")
+	for _, entry := range entries {
+		writer.WriteString(entry.code + " " + entry.text + "\n")
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("写入文件 %s 时出错: %w", path, err)
+	}
+
+	return report, nil
+}
+
+// fcitx5KeyCode 按键位顺序（24键、末码键）排列出现过的编码字符，其余字符按码位追加在后
+func fcitx5KeyCode(keys map[rune]bool) string {
+	builder := strings.Builder{}
+	for _, key := range append(append([]string{}, codeKeys...), suffixKeys...) {
+		for _, char := range key {
+			if keys[char] {
+				builder.WriteRune(char)
+				delete(keys, char)
+			}
+		}
+	}
+	extra := make([]rune, 0, len(keys))
+	for char := range keys {
+		extra = append(extra, char)
+	}
+	sort.Slice(extra, func(i, j int) bool {
+		return extra[i] < extra[j]
+	})
+	builder.WriteString(string(extra))
+	return builder.String()
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gen_ll/types"
+)
+
+// fcitx5Result 单字全码、简码与多字词各几条，含标点键编码与占位符
+func fcitx5Result() *Result {
+	return &Result{
+		FullCodeMetaList: []*types.CharMeta{
+			{Char: "甲", Code: "abcw", Freq: 100},
+			{Char: "乙", Code: "abcr", Freq: 50},
+			{Char: "丙", Code: "a;cw", Freq: 30},
+		},
+		SimpleCodeList: []*types.CharMeta{
+			{Char: "甲", Code: "aw", Freq: 100},
+			{Char: "乙", Code: "abr", Freq: 50},
+		},
+		WordCodes: []*types.WordCode{{Word: "甲乙", Code: "abab", Weight: "20"}},
+		WordSimpleCodes: []*types.WordSimpleCode{
+			{Word: "甲乙", Code: "aa", Weight: "20"},
+			{Word: "①", Code: "ab", Weight: "0", IsPlaceholder: true, BaseCode: "ab"},
+		},
+	}
+}
+
+func TestWriteFcitx5Table(t *testing.T) {
+	const header = "KeyCode=a;cbwr\nLength=4\nPinyin=@\nPrompt=&\nConstructPhrase=^\n"
+	tests := []struct {
+		name    string
+		opts    Fcitx5Options
+		want    string
+		entries int
+	}{
+		{
+			name:    "单字",
+			want:    header + "[Data]\na;cw 丙\nabcr 乙\nabcw 甲\nabr 乙\naw 甲\n",
+			entries: 5,
+		},
+		{
+			name:    "含词条与组词规则",
+			opts:    Fcitx5Options{IncludeWords: true, PhraseRules: true},
+			want:    header + "[Rule]\ne2=p11+p12+p21+p22\ne3=p11+p21+p31+p32\na4=p11+p21+p31+n11\n[Data]\na;cw 丙\naa 甲乙\nabab 甲乙\nabcr 乙\nabcw 甲\nabr 乙\naw 甲\n",
+			entries: 7,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ll.txt")
+			report, err := WriteFcitx5Table(path, fcitx5Result(), test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.want {
+				t.Errorf("码表内容:\n%s\n期望:\n%s", content, test.want)
+			}
+			if report.KeyCode != "a;cbwr" || report.Length != 4 || report.Entries != test.entries {
+				t.Errorf("导出结果 %+v", report)
+			}
+		})
+	}
+}
+
+func TestWriteFcitx5TableKeyConflict(t *testing.T) {
+	result := &Result{FullCodeMetaList: []*types.CharMeta{{Char: "甲", Code: "a@cw", Freq: 1}}}
+	if _, err := WriteFcitx5Table(filepath.Join(t.TempDir(), "ll.txt"), result, Fcitx5Options{}); err == nil {
+		t.Error("编码含拼音反查键 @ 时应返回错误")
+	}
+}
@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// 码表清洗排序方式
+const (
+	FormatSortKeep = "keep" // 保持文件原顺序
+	FormatSortCode = "code" // 编码升序，同码按词频降序（与字典追加的排序一致）
+	FormatSortFreq = "freq" // 词频降序
+)
+
+// FormatOptions 码表清洗选项
+type FormatOptions struct {
+	SortBy        string // 排序方式：keep、code 或 freq，空同 keep
+	Dedupe        bool   // 去除字词与编码都相同的重复条目，保留首次出现的一条
+	StripFreq     bool   // 输出"字词\t编码"，不带词频列
+	AddCandidates bool   // 为重码添加候选后缀（同 AddCandidateCodes，组内按词频排序）
+	CSV           bool   // 按 CSV（RFC 4180）写出，含逗号、引号的字段（如编码中的","键）加引号转义
+	CodeMaxLength int    // 添加候选后缀后的编码最大长度，同 CitiOptions.OutputCodeMaxLength，0 表示不限制
+	CodeOverflow  string // 候选后缀超长时的处理方式，同 CitiOptions.OutputCodeOverflow
+}
+
+// FormatResult 码表清洗结果
+type FormatResult struct {
+	Read        int // 读入的条目数
+	Duplicates  int // 去重丢弃的条目数
+	Written     int // 写出的条目数
+	CodeTooLong int // 添加候选后缀后编码超长而丢弃的条目数
+}
+
+// FormatCodeTable 读取"字词\t编码[\t词频]"码表，按选项清洗后写出，不需要拆分表与映射表
+// 处理顺序：去重、添加候选后缀、排序；排序在加后缀之后，只改变条目位置，不改变各条目得到的编码
+// 未去掉词频时输出三列，缺词频的条目写为 0
+func FormatCodeTable(inPath, outPath string, opts FormatOptions) (*FormatResult, error) {
+	switch opts.SortBy {
+	case "", FormatSortKeep, FormatSortCode, FormatSortFreq:
+	default:
+		return nil, fmt.Errorf("未知的码表排序方式: %s", opts.SortBy)
+	}
+	switch opts.CodeOverflow {
+	case "", OverflowDrop, OverflowNumbered:
+	default:
+		return nil, fmt.Errorf("未知的超长编码处理方式: %s", opts.CodeOverflow)
+	}
+
+	entries, err := ReadCitiFile(inPath, "fmt")
+	if err != nil {
+		return nil, err
+	}
+	result := &FormatResult{Read: len(entries)}
+
+	if opts.Dedupe {
+		seen := make(map[string]bool, len(entries))
+		deduped := make([]*CitiEntry, 0, len(entries))
+		for _, entry := range entries {
+			key := entry.Text + "\t" + entry.Code
+			if seen[key] {
+				result.Duplicates++
+				continue
+			}
+			seen[key] = true
+			deduped = append(deduped, entry)
+		}
+		entries = deduped
+	}
+
+	if opts.AddCandidates {
+		limit := CitiOptions{OutputCodeMaxLength: opts.CodeMaxLength, OutputCodeOverflow: opts.CodeOverflow}
+		entries = addCandidateCodesByFreq(entries, 0, limitedCandidateCode(limit, &result.CodeTooLong))
+	}
+
+	switch opts.SortBy {
+	case FormatSortCode:
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].Code != entries[j].Code {
+				return entries[i].Code < entries[j].Code
+			}
+			return entries[i].Freq > entries[j].Freq
+		})
+	case FormatSortFreq:
+		sortCitiSection(entries, CitiSortFreq)
+	}
+
+	if opts.CSV {
+		if err := writeCodeTableCSV(outPath, entries, opts.StripFreq); err != nil {
+			return nil, err
+		}
+		result.Written = len(entries)
+		return result, nil
+	}
+
+	if !opts.StripFreq {
+		if err := WriteCitiFile(outPath, entries); err != nil {
+			return nil, err
+		}
+		result.Written = len(entries)
+		return result, nil
+	}
+
+	file, err := os.Create(outPath)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建文件 %s: %w", outPath, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, entry := range entries {
+		if _, err := writer.WriteString(entry.Text + "\t" + entry.Code + "\n"); err != nil {
+			return nil, fmt.Errorf("写入文件 %s 时出错: %w", outPath, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("刷新文件 %s 时出错: %w", outPath, err)
+	}
+	result.Written = len(entries)
+
+	return result, nil
+}
+
+// writeCodeTableCSV 按 CSV 写出码表，列为字词、编码[、词频]，不写表头
+func writeCodeTableCSV(outPath string, entries []*CitiEntry, stripFreq bool) error {
+	file, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("无法创建文件 %s: %w", outPath, err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	for _, entry := range entries {
+		record := []string{entry.Text, entry.Code}
+		if !stripFreq {
+			record = append(record, strconv.FormatInt(entry.Freq, 10))
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("写入文件 %s 时出错: %w", outPath, err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("刷新文件 %s 时出错: %w", outPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,6 @@
+//go:build !update_golden
+
+package tools
+
+// updateGolden 为 true 时 TestWordsFullPipeline 重新生成 expected/ 而不比较，见 golden_update_test.go
+const updateGolden = false
@@ -0,0 +1,6 @@
+//go:build update_golden
+
+package tools
+
+// updateGolden 行为有意变更后以 go test -tags update_golden 重新生成 expected/
+const updateGolden = true
@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gen_ll/types"
+)
+
+// 部件图导出格式
+const (
+	RootGraphJSON = "json"
+	RootGraphDOT  = "dot"
+)
+
+// RootGraphNode 部件节点：主拆分中出现过的部件及其键位
+type RootGraphNode struct {
+	Root   string `json:"root"`   // 部件
+	Code   string `json:"code"`   // 映射表中的字根编码，未收录时为空
+	Key    string `json:"key"`    // 字根大码所在键，未收录时为空
+	Count  int    `json:"count"`  // 在主拆分中的出现次数
+	Weight int64  `json:"weight"` // 按字频累计的出现次数
+}
+
+// RootGraphEdge 部件相邻边：From 紧接在 To 之前出现在某字的主拆分中
+type RootGraphEdge struct {
+	From   string `json:"from"`   // 前一个部件
+	To     string `json:"to"`     // 后一个部件
+	Count  int    `json:"count"`  // 相邻出现的次数
+	Weight int64  `json:"weight"` // 按字频累计的相邻次数
+}
+
+// RootGraph 字-拆分-编码图：节点为部件，边为部件在拆分中相邻出现
+type RootGraph struct {
+	Nodes      []*RootGraphNode `json:"nodes"`       // 按权重降序，同权重按部件排序
+	Edges      []*RootGraphEdge `json:"edges"`       // 按权重降序，同权重按次数降序、再按部件排序
+	TotalEdges int              `json:"total_edges"` // 截取前的边数
+}
+
+// BuildRootGraph 由拆分表与字频构建部件图，只统计各字的主拆分（拆分表中的第一个拆分），字频缺失按 0 计
+// topEdges 大于 0 时只保留权重前 N 的边，节点随之只保留这些边的端点
+func BuildRootGraph(divTable map[string][]*types.Division, freqSet map[string]int64, compMap map[string]string, topEdges int) *RootGraph {
+	nodeIndex := make(map[string]*RootGraphNode)
+	edgeIndex := make(map[[2]string]*RootGraphEdge)
+	for char, divisions := range divTable {
+		if len(divisions) == 0 {
+			continue
+		}
+		freq := freqSet[char]
+		divs := divisions[0].Divs
+		for i, root := range divs {
+			node, exists := nodeIndex[root]
+			if !exists {
+				node = &RootGraphNode{Root: root, Code: compMap[root]}
+				if node.Code != "" {
+					node.Key = node.Code[:1]
+				}
+				nodeIndex[root] = node
+			}
+			node.Count++
+			node.Weight += freq
+			if i == 0 {
+				continue
+			}
+			pair := [2]string{divs[i-1], root}
+			edge, exists := edgeIndex[pair]
+			if !exists {
+				edge = &RootGraphEdge{From: pair[0], To: pair[1]}
+				edgeIndex[pair] = edge
+			}
+			edge.Count++
+			edge.Weight += freq
+		}
+	}
+
+	graph := &RootGraph{Edges: make([]*RootGraphEdge, 0, len(edgeIndex)), TotalEdges: len(edgeIndex)}
+	for _, edge := range edgeIndex {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+
+	if topEdges > 0 && topEdges < len(graph.Edges) {
+		graph.Edges = graph.Edges[:topEdges]
+		kept := make(map[string]*RootGraphNode)
+		for _, edge := range graph.Edges {
+			kept[edge.From] = nodeIndex[edge.From]
+			kept[edge.To] = nodeIndex[edge.To]
+		}
+		nodeIndex = kept
+	}
+	graph.Nodes = make([]*RootGraphNode, 0, len(nodeIndex))
+	for _, node := range nodeIndex {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].Weight != graph.Nodes[j].Weight {
+			return graph.Nodes[i].Weight > graph.Nodes[j].Weight
+		}
+		return graph.Nodes[i].Root < graph.Nodes[j].Root
+	})
+
+	return graph
+}
+
+// WriteRootGraph 按格式写出部件图：json 为 {nodes, edges, total_edges}，dot 为 Graphviz 有向图
+func WriteRootGraph(path string, graph *RootGraph, format string) error {
+	var content []byte
+	switch format {
+	case RootGraphJSON:
+		data, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化部件图失败: %w", err)
+		}
+		content = append(data, '\n')
+	case RootGraphDOT:
+		buffer := bytes.Buffer{}
+		buffer.WriteString("digraph roots {\n")
+		for _, node := range graph.Nodes {
+			buffer.WriteString(fmt.Sprintf("  %s [label=%s, key=%s, code=%s, count=%d, weight=%d];\n",
+				dotQuote(node.Root), dotQuote(node.Root+"\n"+node.Key), dotQuote(node.Key), dotQuote(node.Code), node.Count, node.Weight))
+		}
+		for _, edge := range graph.Edges {
+			buffer.WriteString(fmt.Sprintf("  %s -> %s [count=%d, weight=%d];\n", dotQuote(edge.From), dotQuote(edge.To), edge.Count, edge.Weight))
+		}
+		buffer.WriteString("}\n")
+		content = buffer.Bytes()
+	default:
+		return fmt.Errorf("未知的部件图格式: %s", format)
+	}
+
+	return os.WriteFile(path, content, 0o644)
+}
+
+// dotQuote 转为 DOT 的双引号字符串，换行写作 \n 以便在标签中换行
+func dotQuote(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gen_ll/types"
+)
+
+// wordsPipelineFixture 多字词流程回归检查的输入表与期望输出
+var wordsPipelineFixture = filepath.Join("testdata", "words_pipeline")
+
+// wordsPipelineCharCodeMap 按默认参数从拆分表、映射表与字频表构建多字词取字用的单字全码映射
+func wordsPipelineCharCodeMap(t *testing.T) map[string]string {
+	t.Helper()
+	divTable, _, _, err := ReadDivisionTable([]string{filepath.Join(wordsPipelineFixture, "ll_div.txt")}, DivisionTableOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compIndex, err := ReadCompMapIndexed(filepath.Join(wordsPipelineFixture, "ll_map.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	charFreq, err := ReadCharFreq(filepath.Join(wordsPipelineFixture, "freq.txt"), CharFreqOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullCodeMetaList, skipped := BuildFullCodeMetaList(divTable, compIndex.CompCode, charFreq.Chars)
+	for _, err := range skipped {
+		t.Logf("跳过无法构造的全码条目: %v", err)
+	}
+	fullCodeMetaList, _ = DedupFullCodeMetaList(fullCodeMetaList)
+	charCodeMap, err := NewCharCodeMaps(fullCodeMetaList).Get(CharCodeStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return charCodeMap
+}
+
+// wordsPipelineCodes 读取词表并生成全码，没有任何全码时测试失败
+func wordsPipelineCodes(t *testing.T, name string, charCodeMap map[string]string) []*types.WordCode {
+	t.Helper()
+	entries, _, err := ReadWordsFile(filepath.Join(wordsPipelineFixture, name), WordsFileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wordCodes, _ := BuildWordsFullCode(entries, charCodeMap, WordsFullCodeOptions{})
+	if len(wordCodes) == 0 {
+		t.Fatalf("%s 没有生成任何全码", name)
+	}
+	return wordCodes
+}
+
+// wordsPipelineLimit 解析简码长度限制
+func wordsPipelineLimit(t *testing.T, limitStr string) map[int]int {
+	t.Helper()
+	limit, err := ParseLenCodeLimit(limitStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return limit
+}
+
+// renderWordCodes 按默认列写出全码表，保持词表原始顺序
+func renderWordCodes(wordCodes []*types.WordCode) string {
+	var output strings.Builder
+	layout := DefaultColumnLayout()
+	for _, wordCode := range wordCodes {
+		output.WriteString(layout.Row(wordCode.Word, wordCode.Code, wordCode.Weight, ""))
+	}
+	return output.String()
+}
+
+// renderWordSimpleCodes 按默认列写出简码表，先按编码升序、同码按权重降序
+func renderWordSimpleCodes(wordSimpleCodes []*types.WordSimpleCode) string {
+	var output strings.Builder
+	layout := DefaultColumnLayout()
+	for _, wordSimpleCode := range SortedWordSimpleCodeView(wordSimpleCodes) {
+		output.WriteString(layout.Row(wordSimpleCode.Word, wordSimpleCode.Code, wordSimpleCode.Weight, ""))
+	}
+	return output.String()
+}
+
+// checkGolden 比较输出与 expected/ 中的期望输出；以 -tags update_golden 运行时改为重新生成
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join(wordsPipelineFixture, "expected", name)
+	if updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("已更新 %s", path)
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s 与期望输出不一致:\n期望:\n%s\n实际:\n%s", name, want, got)
+	}
+}
+
+// TestWordsFullPipeline 多字词流程回归检查：ReadWordsFile → BuildWordsFullCode → BuildWordsSimpleCode → SortWordSimpleCodes
+// 玲珑词库单独使用 linglong.txt 与不同的简码长度限制（含四码），检查两条路径互不串扰
+func TestWordsFullPipeline(t *testing.T) {
+	charCodeMap := wordsPipelineCharCodeMap(t)
+
+	wordCodes := wordsPipelineCodes(t, "ll_words.txt", charCodeMap)
+	wordSimpleCodes := BuildWordsSimpleCode(wordCodes, wordsPipelineLimit(t, "1:1,2:1,3:0,4:0"), WordsSimpleCodeOptions{})
+	checkGolden(t, "code_words_full.txt", renderWordCodes(wordCodes))
+	checkGolden(t, "code_words_simp.txt", renderWordSimpleCodes(wordSimpleCodes))
+
+	linglongCodes := wordsPipelineCodes(t, "linglong.txt", charCodeMap)
+	linglongSimpleCodes := BuildLinglongSimpleCode(linglongCodes, wordsPipelineLimit(t, "1:1,2:1,3:1,4:1"))
+	checkGolden(t, "linglong_full.txt", renderWordCodes(linglongCodes))
+	checkGolden(t, "linglong_simp.txt", renderWordSimpleCodes(linglongSimpleCodes))
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"gen_ll/tabfile"
+)
+
+// 不可见字符的处理方式
+const (
+	InvisibleStrict  = "strict"  // 含不可见字符的行直接报错
+	InvisibleLenient = "lenient" // 剥离后照常读入，并以 LineError 返回供调用方警告
+)
+
+// 不可见字符的类别，按类别配置检测与剥离的范围
+const (
+	InvisibleZeroWidth        = "zero-width"        // 零宽空格、零宽非连字、零宽连字、词连接符
+	InvisibleBOM              = "bom"               // 字节序标记（零宽不换行空格）
+	InvisibleBidi             = "bidi"              // 方向控制符
+	InvisibleIdeographicSpace = "ideographic-space" // 全角空格
+)
+
+// 各类别包含的字符；组合附加符与异体字选择符是字形的一部分，不算不可见字符
+var invisibleCategories = map[string][]rune{
+	InvisibleZeroWidth:        {0x200B, 0x200C, 0x200D, 0x2060},
+	InvisibleBOM:              {0xFEFF},
+	InvisibleBidi:             {0x061C, 0x200E, 0x200F, 0x202A, 0x202B, 0x202C, 0x202D, 0x202E, 0x2066, 0x2067, 0x2068, 0x2069},
+	InvisibleIdeographicSpace: {0x3000},
+}
+
+// InvisibleOptions 不可见字符检测选项
+type InvisibleOptions struct {
+	Mode  string        // strict 或 lenient，空同 lenient
+	Chars map[rune]bool // 检测的字符，nil 表示不检测
+}
+
+// ParseInvisibleChars 解析逗号分隔的不可见字符类别，空串表示不检测
+func ParseInvisibleChars(spec string) (map[rune]bool, error) {
+	var chars map[rune]bool
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		runes, exists := invisibleCategories[name]
+		if !exists {
+			return nil, fmt.Errorf("未知的不可见字符类别: %s（可选 %s、%s、%s、%s）", name, InvisibleZeroWidth, InvisibleBOM, InvisibleBidi, InvisibleIdeographicSpace)
+		}
+		if chars == nil {
+			chars = make(map[rune]bool)
+		}
+		for _, r := range runes {
+			chars[r] = true
+		}
+	}
+	return chars, nil
+}
+
+// ValidateInvisibleMode 校验不可见字符的处理方式
+func ValidateInvisibleMode(mode string) error {
+	switch mode {
+	case "", InvisibleStrict, InvisibleLenient:
+		return nil
+	}
+	return fmt.Errorf("未知的不可见字符处理方式: %s（可选 %s、%s）", mode, InvisibleStrict, InvisibleLenient)
+}
+
+// stripInvisible 去掉行中要检测的不可见字符，返回剥离后的行与找到的字符（按出现顺序去重）
+func (opts InvisibleOptions) stripInvisible(text string) (string, []rune) {
+	if opts.Chars == nil {
+		return text, nil
+	}
+	var found []rune
+	stripped := strings.Map(func(r rune) rune {
+		if !opts.Chars[r] {
+			return r
+		}
+		for _, seen := range found {
+			if seen == r {
+				return -1
+			}
+		}
+		found = append(found, r)
+		return -1
+	}, text)
+	return stripped, found
+}
+
+// checkRow 检查一行中的不可见字符：没有时原样返回；strict 时返回错误；lenient 时返回剥离后按 parseOpts 重新分列的行与警告
+// 剥离后为空行时返回的行为 nil，调用方跳过
+func (opts InvisibleOptions) checkRow(row *tabfile.Row, parseOpts tabfile.Options) (*tabfile.Row, *LineError, error) {
+	stripped, found := opts.stripInvisible(row.Text)
+	if len(found) == 0 {
+		return row, nil, nil
+	}
+	if opts.Mode == InvisibleStrict {
+		return nil, nil, row.Errorf("含不可见字符 %s", formatRunes(found))
+	}
+	return row.Reparse(stripped, parseOpts), row.Errorf("含不可见字符 %s，已剥离", formatRunes(found)), nil
+}
+
+// formatRunes 把字符列为 U+XXXX，以顿号分隔
+func formatRunes(runes []rune) string {
+	codes := make([]string, len(runes))
+	for i, r := range runes {
+		codes[i] = fmt.Sprintf("U+%04X", r)
+	}
+	return strings.Join(codes, "、")
+}
@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gen_ll/types"
+)
+
+func TestParseInvisibleChars(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []rune
+		wantErr bool
+	}{
+		{spec: ""},
+		{spec: "bom", want: []rune{0xFEFF}},
+		{spec: " ideographic-space , bom ", want: []rune{0x3000, 0xFEFF}},
+		{spec: "zero-width", want: []rune{0x200B, 0x200C, 0x200D, 0x2060}},
+		{spec: "bom,unknown", wantErr: true},
+	}
+	for _, test := range tests {
+		chars, err := ParseInvisibleChars(test.spec)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParseInvisibleChars(%q) 错误: %v", test.spec, err)
+			continue
+		}
+		if len(chars) != len(test.want) {
+			t.Errorf("ParseInvisibleChars(%q) = %v，期望 %U", test.spec, chars, test.want)
+		}
+		for _, r := range test.want {
+			if !chars[r] {
+				t.Errorf("ParseInvisibleChars(%q) 缺少 %U", test.spec, r)
+			}
+		}
+	}
+	if chars, _ := ParseInvisibleChars(""); chars != nil {
+		t.Errorf("空串应表示不检测，得到 %v", chars)
+	}
+}
+
+// invisibleWords 词表中混入零宽空格、BOM、方向控制符与全角空格；全角空格未剥离时会把词条截断为两列
+const invisibleWords = "我\u200B们\t10\n\uFEFF你好\t5\n他们\u3000美好\t3\n\u202E正常\u202C\t1\n\u200B\n"
+
+func TestReadWordsFileInvisible(t *testing.T) {
+	path := writeInput(t, t.TempDir(), "ll_words.txt", invisibleWords)
+	allChars, err := ParseInvisibleChars("zero-width,bom,bidi,ideographic-space")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spaceOnly, err := ParseInvisibleChars("ideographic-space")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		opts      InvisibleOptions
+		want      []*types.WordEntry
+		warnings  int
+		wantError string
+	}{
+		{
+			name: "不检测",
+			want: []*types.WordEntry{{Word: "我\u200B们", Weight: "10"}, {Word: "\uFEFF你好", Weight: "5"}, {Word: "他们", Weight: "美好"}, {Word: "\u202E正常\u202C", Weight: "1"}, {Word: "\u200B"}},
+		},
+		{
+			name:     "lenient 剥离全部类别",
+			opts:     InvisibleOptions{Mode: InvisibleLenient, Chars: allChars},
+			want:     []*types.WordEntry{{Word: "我们", Weight: "10"}, {Word: "你好", Weight: "5"}, {Word: "他们美好", Weight: "3"}, {Word: "正常", Weight: "1"}},
+			warnings: 5,
+		},
+		{
+			name:     "只检测全角空格",
+			opts:     InvisibleOptions{Chars: spaceOnly},
+			want:     []*types.WordEntry{{Word: "我\u200B们", Weight: "10"}, {Word: "\uFEFF你好", Weight: "5"}, {Word: "他们美好", Weight: "3"}, {Word: "\u202E正常\u202C", Weight: "1"}, {Word: "\u200B"}},
+			warnings: 1,
+		},
+		{
+			name:      "strict 报错",
+			opts:      InvisibleOptions{Mode: InvisibleStrict, Chars: allChars},
+			wantError: "U+200B",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ResetRunState()
+			entries, report, err := ReadWordsFile(path, WordsFileOptions{Invisible: test.opts})
+			if test.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantError) {
+					t.Fatalf("错误 %v，期望含 %s", err, test.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(entries, test.want) {
+				t.Errorf("词表 %s，期望 %s", quoteWordEntries(entries), quoteWordEntries(test.want))
+			}
+			if len(report.InvisibleLines) != test.warnings {
+				t.Errorf("警告 %d 行，期望 %d 行: %v", len(report.InvisibleLines), test.warnings, report.InvisibleLines)
+			}
+		})
+	}
+}
+
+// quoteWordEntries 把词条列为带引号的 词/权重，不可见字符以转义形式显示
+func quoteWordEntries(entries []*types.WordEntry) string {
+	quoted := make([]string, len(entries))
+	for i, entry := range entries {
+		quoted[i] = fmt.Sprintf("%+q/%q", entry.Word, entry.Weight)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func TestReadDivisionTableInvisible(t *testing.T) {
+	path := writeInput(t, t.TempDir(), "ll_div.txt", "的\u200D\t[白勹丶,de,CJK,U+7684]\n了\t[乛亅,le,CJK,U+4E86]\n")
+	chars, err := ParseInvisibleChars("zero-width")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, _, issues, err := ReadDivisionTable([]string{path}, DivisionTableOptions{Invisible: InvisibleOptions{Mode: InvisibleLenient, Chars: chars}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := table["的"]; !exists || len(table) != 2 {
+		t.Errorf("剥离后应读到 的 与 了: %v", table)
+	}
+	if len(issues) != 1 || !strings.Contains(issues[0].Error(), "U+200D") {
+		t.Errorf("警告 %v，期望一条 U+200D", issues)
+	}
+
+	ResetRunState()
+	if _, _, _, err := ReadDivisionTable([]string{path}, DivisionTableOptions{Invisible: InvisibleOptions{Mode: InvisibleStrict, Chars: chars}}); err == nil || !strings.Contains(err.Error(), "U+200D") {
+		t.Errorf("strict 时应报错，得到 %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteJSONL 把每个条目编码为一行 JSON 写到 path（JSON Lines），字段名与结构体字段名一致，已有文件覆盖
+func WriteJSONL[T any](path string, items []T) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	// 码表中的 < > & 原样输出，不转义为 \u003c 等
+	encoder.SetEscapeHTML(false)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			file.Close()
+			return fmt.Errorf("写入 %s 失败: %w", path, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("写入 %s 失败: %w", path, err)
+	}
+	return file.Close()
+}
+
+// WriteJSONLTables 把各码表以 JSON Lines 写到 dir 下的"表名.jsonl"，返回写出的文件
+// 单字码表每行为 types.CharMeta（含拆分信息），多字词与玲珑词码表每行为 types.WordCode 或 types.WordSimpleCode
+// 条目顺序与对应的 TSV 码表一致；纯全码版本没有单字简码、多字词与玲珑词没有结果时，对应的表不写出
+func WriteJSONLTables(dir string, result *Result) ([]string, error) {
+	tables := []struct {
+		name    string
+		enabled bool
+		write   func(path string) error
+	}{
+		{OutputCharsFull, true, func(path string) error {
+			return WriteJSONL(path, result.FullCodeMetaList)
+		}},
+		{OutputCharsSimp, result.SimpleCodeList != nil, func(path string) error {
+			return WriteJSONL(path, SortedCharMetaView(result.SimpleCodeList, CharMetaByCodeFreq))
+		}},
+		{OutputWordsFull, result.WordCodes != nil, func(path string) error {
+			return WriteJSONL(path, result.WordCodes)
+		}},
+		{OutputWordsSimp, result.WordSimpleCodes != nil, func(path string) error {
+			return WriteJSONL(path, SortedWordSimpleCodeView(result.WordSimpleCodes))
+		}},
+		{OutputLinglongFull, result.LinglongCodes != nil, func(path string) error {
+			return WriteJSONL(path, result.LinglongCodes)
+		}},
+		{OutputLinglongSimp, result.LinglongSimpleCodes != nil, func(path string) error {
+			return WriteJSONL(path, SortedWordSimpleCodeView(result.LinglongSimpleCodes))
+		}},
+	}
+	var files []string
+	for _, table := range tables {
+		if !table.enabled {
+			continue
+		}
+		path := filepath.Join(dir, table.name+".jsonl")
+		if err := table.write(path); err != nil {
+			return files, err
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyRemap 编码键位重映射：把编码中的某些键（通常是 ; , . / 等标点键）逐字符替换为其他字符，
+// 供不能正确处理标点编码的下游（旧版跟打器等）使用；键为单个键位，值为替换字符
+type KeyRemap map[byte]byte
+
+// ParseKeyRemap 解析键位重映射，格式：";=1 ,=4 .=5 /=6"，各项以空白分隔
+// 被替换的须是编码键位；替换字符须为单个可见 ASCII 字符，且不能是键位、候选后缀、翻页符"="、大写字母或"#"，
+// 各项的替换字符互不相同，保证重映射后的编码与原编码一一对应，不会与其他编码撞码
+func ParseKeyRemap(remapStr string) (KeyRemap, error) {
+	remap := make(KeyRemap)
+	used := make(map[byte]byte)
+	for _, item := range strings.Fields(remapStr) {
+		from, to, ok := strings.Cut(item, "=")
+		if !ok || len(from) != 1 || len(to) != 1 {
+			return nil, fmt.Errorf("键位重映射格式错误: %s", item)
+		}
+		if !isCodeKey(from) {
+			return nil, fmt.Errorf("不是编码键位: %s", from)
+		}
+		if !isRemapTarget(to[0]) {
+			return nil, fmt.Errorf("替换字符不可用（与编码字符冲突或不可见）: %s", item)
+		}
+		if _, exists := remap[from[0]]; exists {
+			return nil, fmt.Errorf("键位重复映射: %s", from)
+		}
+		if other, exists := used[to[0]]; exists {
+			return nil, fmt.Errorf("键位 %c 与 %s 映射到同一字符 %s", other, from, to)
+		}
+		remap[from[0]] = to[0]
+		used[to[0]] = from[0]
+	}
+	return remap, nil
+}
+
+// isCodeKey 判断是否为编码键位（24键或末码键）
+func isCodeKey(key string) bool {
+	for _, keys := range [][]string{codeKeys, suffixKeys} {
+		for _, codeKey := range keys {
+			if codeKey == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRemapTarget 判断字符能否作为重映射的替换字符：不会出现在任何原编码中，写出后也不会被当作注释
+func isRemapTarget(char byte) bool {
+	if char <= ' ' || char > '~' || char == '=' || char == '#' || (char >= 'A' && char <= 'Z') {
+		return false
+	}
+	if isCodeKey(string(char)) {
+		return false
+	}
+	for _, suffix := range candidateSuffixes {
+		if suffix == string(char) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply 返回重映射后的编码，未列出的字符保持不变；映射为空时原样返回
+func (remap KeyRemap) Apply(code string) string {
+	if len(remap) == 0 {
+		return code
+	}
+	mapped := []byte(code)
+	for i := range mapped {
+		if to, ok := remap[mapped[i]]; ok {
+			mapped[i] = to
+		}
+	}
+	return string(mapped)
+}
@@ -0,0 +1,158 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gen_ll/tabfile"
+)
+
+// LintInputs 只读校验拆分表、映射表与词表，返回发现的全部问题，不写出任何文件
+// 校验项：行格式、Unicode 码位、重复定义、拆分部件映射、词表字符覆盖；多个拆分表逐个校验，重复拆分只在同一文件内检查
+func LintInputs(divFiles []string, mapFile, wordsFile string) ([]*LineError, error) {
+	var issues []*LineError
+
+	compMap, mapIssues, err := lintCompMap(mapFile)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, mapIssues...)
+
+	divChars := map[string]bool{}
+	for _, divFile := range divFiles {
+		chars, divIssues, err := lintDivisionTable(divFile, compMap)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, divIssues...)
+		for char := range chars {
+			divChars[char] = true
+		}
+	}
+
+	if wordsFile != "" {
+		wordsIssues, err := lintWordsFile(wordsFile, divChars)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, wordsIssues...)
+	}
+
+	return issues, nil
+}
+
+// lintCompMap 校验映射表：列数与部件重复定义
+func lintCompMap(filepath string) (map[string]string, []*LineError, error) {
+	var issues []*LineError
+	mappings := map[string]string{}
+	definedAt := map[string]int{}
+	err := forEachRow(filepath, tabfile.Options{StripComment: true}, func(row *tabfile.Row) error {
+		if row.Len() < 2 || row.Column(0) == "" {
+			issues = append(issues, row.Errorf("格式错误，应为编码\\t字根[\\t字根说明]"))
+			return nil
+		}
+		comp := row.Column(1)
+		if previous, exists := definedAt[comp]; exists {
+			issues = append(issues, row.Errorf("字根 %s 重复定义（首次定义于第 %d 行）", comp, previous))
+			return nil
+		}
+		definedAt[comp] = row.Line
+		mappings[comp] = row.Column(0)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return mappings, issues, nil
+}
+
+// lintDivisionTable 校验拆分表：格式、Unicode 码位、重复拆分与部件映射
+// 返回拆分表中出现的全部字符，供词表覆盖检查使用
+func lintDivisionTable(filepath string, compMap map[string]string) (map[string]bool, []*LineError, error) {
+	var issues []*LineError
+	chars := map[string]bool{}
+	seen := map[string]int{}
+	err := forEachRow(filepath, tabfile.Options{StripComment: true}, func(row *tabfile.Row) error {
+		addIssue := func(msg string) {
+			issues = append(issues, row.Errorf("%s", msg))
+		}
+
+		if row.Len() < 2 {
+			addIssue("格式错误，应为字符\\t[拆分,拼音,字集,码位]")
+			return nil
+		}
+		char := row.Column(0)
+		meta := strings.Split(strings.Trim(row.Column(1), "[]"), ",")
+		if len(meta) < 4 {
+			addIssue("格式错误，拆分信息应包含拆分、拼音、字集、码位四项")
+			return nil
+		}
+		chars[char] = true
+
+		if r, size := utf8.DecodeRuneInString(char); size == len(char) && r != utf8.RuneError {
+			if expected := fmt.Sprintf("U+%04X", r); meta[3] != expected {
+				addIssue(fmt.Sprintf("码位不符: %s 应为 %s，实际为 %s", char, expected, meta[3]))
+			}
+		} else {
+			addIssue(fmt.Sprintf("字符 %s 不是单个 Unicode 字符", char))
+		}
+
+		key := char + "\t" + meta[0]
+		if previous, exists := seen[key]; exists {
+			addIssue(fmt.Sprintf("重复拆分: %s [%s]（首次出现于第 %d 行）", char, meta[0], previous))
+			return nil
+		}
+		seen[key] = row.Line
+
+		components := componentMatcher.FindAllString(meta[0], -1)
+		if len(components) == 0 {
+			addIssue(fmt.Sprintf("字符 %s 缺少拆分", char))
+			return nil
+		}
+		for _, component := range components {
+			if _, exists := compMap[component]; !exists {
+				addIssue(fmt.Sprintf("非法部件: %s（字符: %s）", component, char))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return chars, issues, nil
+}
+
+// lintWordsFile 校验词表：词条重复、汉字是否都在拆分表中
+func lintWordsFile(filepath string, divChars map[string]bool) ([]*LineError, error) {
+	var issues []*LineError
+	seen := map[string]int{}
+	err := forEachRow(filepath, tabfile.Options{TrimSpace: true, SplitSpace: true, MinColumns: 1, StripComment: true}, func(row *tabfile.Row) error {
+		word := row.Column(0)
+
+		if previous, exists := seen[word]; exists {
+			issues = append(issues, row.Errorf("词条 %s 重复（首次出现于第 %d 行）", word, previous))
+			return nil
+		}
+		seen[word] = row.Line
+
+		var uncovered []string
+		for _, r := range word {
+			if unicode.Is(unicode.Han, r) && !divChars[string(r)] {
+				uncovered = append(uncovered, string(r))
+			}
+		}
+		if len(uncovered) > 0 {
+			issues = append(issues, row.Errorf("词条 %s 含拆分表未收录的字: %s", word, strings.Join(uncovered, " ")))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 码表清单路径：码表及其压缩副本写出后记录路径、字节数与 SHA-256，为空不记录
+var outputManifest string
+
+// SetOutputManifest 设置码表清单路径，为空不记录
+func SetOutputManifest(path string) {
+	outputManifest = path
+}
+
+// ManifestEntry 清单中的一份输出文件
+type ManifestEntry struct {
+	Path   string
+	Size   int64
+	SHA256 string
+}
+
+var (
+	manifestMutex   sync.Mutex
+	manifestEntries = map[string]ManifestEntry{}
+)
+
+// outputDigest 边写边计算输出文件的字节数与 SHA-256
+type outputDigest struct {
+	hash hash.Hash
+	size int64
+}
+
+func newOutputDigest() *outputDigest {
+	return &outputDigest{hash: sha256.New()}
+}
+
+func (digest *outputDigest) Write(p []byte) (int, error) {
+	digest.size += int64(len(p))
+	return digest.hash.Write(p)
+}
+
+// entry 以 path 为路径生成清单条目
+func (digest *outputDigest) entry(path string) ManifestEntry {
+	return ManifestEntry{Path: path, Size: digest.size, SHA256: hex.EncodeToString(digest.hash.Sum(nil))}
+}
+
+// recordOutputManifest 记录写出的文件并整份重写清单，每行"路径\t字节数\tSHA-256"，按路径排序
+// 码表由多个协程并行写出；同一路径再次写出（监视模式重新构建）时替换原条目
+func recordOutputManifest(entries ...ManifestEntry) error {
+	manifestMutex.Lock()
+	defer manifestMutex.Unlock()
+	for _, entry := range entries {
+		manifestEntries[entry.Path] = entry
+	}
+	paths := make([]string, 0, len(manifestEntries))
+	for path := range manifestEntries {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var builder strings.Builder
+	for _, path := range paths {
+		entry := manifestEntries[path]
+		builder.WriteString(fmt.Sprintf("%s\t%d\t%s\n", entry.Path, entry.Size, entry.SHA256))
+	}
+	if err := os.WriteFile(outputManifest, []byte(builder.String()), 0o644); err != nil {
+		return fmt.Errorf("写入码表清单失败: %w", err)
+	}
+	return nil
+}
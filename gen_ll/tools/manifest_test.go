@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// manifestLine 按磁盘上的文件生成清单行
+func manifestLine(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	return fmt.Sprintf("%s\t%d\t%s\n", path, len(content), hex.EncodeToString(sum[:]))
+}
+
+// TestOutputFileManifest Close 把码表与压缩副本记入清单，字节数与 SHA-256 与磁盘上的文件一致
+// 一次写出与流式写出都覆盖，清单按路径排序；同一路径再次写出时替换原条目
+func TestOutputFileManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.tsv")
+	defer SetOutputManifest("")
+	defer SetCompressOutputs(false)
+	defer SetStreamWrite(false)
+	defer ResetRunState()
+	SetOutputManifest(manifest)
+	SetCompressOutputs(true)
+
+	write := func(path, content string) {
+		t.Helper()
+		output := CreateOutputFile(path)
+		output.WriteString(content)
+		if err := output.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buffered := filepath.Join(dir, "b_full.txt")
+	streamed := filepath.Join(dir, "a_simp.txt")
+	write(buffered, "旧\tabc\n")
+	write(buffered, "的\tde\n一\tyi\n")
+	SetStreamWrite(true)
+	write(streamed, "了\tle\n")
+
+	want := manifestLine(t, streamed) + manifestLine(t, streamed+".gz") + manifestLine(t, buffered) + manifestLine(t, buffered+".gz")
+	content, err := os.ReadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != want {
+		t.Errorf("清单:\n%s\n期望:\n%s", content, want)
+	}
+
+	gzFile, err := os.Open(buffered + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gzFile.Close()
+	reader, err := gzip.NewReader(gzFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decompressed, err := io.ReadAll(reader); err != nil || string(decompressed) != "的\tde\n一\tyi\n" {
+		t.Errorf("压缩副本内容 %q: %v", decompressed, err)
+	}
+}
+
+// TestOutputFileManifestDryRun 空跑不写出清单
+func TestOutputFileManifestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "manifest.tsv")
+	defer SetOutputManifest("")
+	defer SetDryRun(false)
+	defer ResetRunState()
+	SetOutputManifest(manifest)
+	SetDryRun(true)
+
+	output := CreateOutputFile(filepath.Join(dir, "full.txt"))
+	output.WriteString("的\tde\n")
+	if err := output.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(manifest); !os.IsNotExist(err) {
+		t.Errorf("空跑不应写出清单: %v", err)
+	}
+}
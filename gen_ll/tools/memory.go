@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// 并发构建的工作协程数，0 表示按 CPU 核心数
+var concurrencyLimit int
+
+// SetConcurrency 设置并发构建的工作协程数，0 表示按 CPU 核心数；内存紧张时设为 1 降低峰值
+func SetConcurrency(workers int) {
+	concurrencyLimit = workers
+}
+
+// concurrency 返回并发构建的工作协程数
+func concurrency() int {
+	if concurrencyLimit > 0 {
+		return concurrencyLimit
+	}
+	return runtime.NumCPU()
+}
+
+// MemoryInUse 返回进程向系统申请且尚未归还的内存（字节），作为内存水位
+func MemoryInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.Sys - stats.HeapReleased
+}
+
+// 码表是否流式写出：边写边落盘，不在内存中拼出整份内容
+var streamWrite bool
+
+// SetStreamWrite 设置码表是否流式写出
+func SetStreamWrite(enabled bool) {
+	streamWrite = enabled
+}
+
+// 码表是否同时写出 gzip 压缩副本（原路径加 .gz）
+var compressOutputs bool
+
+// SetCompressOutputs 设置码表是否同时写出 gzip 压缩副本
+func SetCompressOutputs(enabled bool) {
+	compressOutputs = enabled
+}
+
+// OutputFile 码表输出：默认在内存中拼好整份内容后一次写出，流式写出时经缓冲直接写入文件，空跑时只数行
+// 需要压缩副本时内容同时送入 gzip 压缩流，不重读写出的文件；记录码表清单时写出的内容与压缩副本同样边写边计算摘要
+// 创建或写入失败时记录第一个错误，由 Close 返回
+type OutputFile struct {
+	path     string
+	buffer   bytes.Buffer
+	file     *os.File
+	writer   *bufio.Writer
+	gzFile   *os.File
+	gz       *gzip.Writer
+	digest   *outputDigest
+	gzDigest *outputDigest
+	err      error
+	dryRun   bool
+	lines    int
+}
+
+// CreateOutputFile 按当前写出方式创建码表输出
+func CreateOutputFile(path string) *OutputFile {
+	output := &OutputFile{path: path, dryRun: dryRun}
+	if output.dryRun {
+		return output
+	}
+	if outputManifest != "" {
+		output.digest = newOutputDigest()
+	}
+	if streamWrite {
+		output.file, output.err = os.Create(path)
+		if output.err == nil {
+			output.writer = bufio.NewWriter(output.file)
+		}
+	}
+	if compressOutputs && output.err == nil {
+		output.gzFile, output.err = os.Create(path + ".gz")
+		if output.err == nil {
+			var gzTarget io.Writer = output.gzFile
+			if output.digest != nil {
+				output.gzDigest = newOutputDigest()
+				gzTarget = io.MultiWriter(output.gzFile, output.gzDigest)
+			}
+			output.gz = gzip.NewWriter(gzTarget)
+		}
+	}
+	return output
+}
+
+// WriteString 写入一段内容
+func (output *OutputFile) WriteString(s string) {
+	switch {
+	case output.err != nil:
+	case output.dryRun:
+		output.lines += strings.Count(s, "\n")
+	case output.writer != nil:
+		_, output.err = output.writer.WriteString(s)
+		if output.err == nil && output.gz != nil {
+			_, output.err = output.gz.Write([]byte(s))
+		}
+		if output.digest != nil {
+			output.digest.Write([]byte(s))
+		}
+	default:
+		output.buffer.WriteString(s)
+	}
+}
+
+// Close 写出全部内容并关闭文件（含压缩副本），都成功时把码表与压缩副本记入码表清单，返回过程中的第一个错误
+func (output *OutputFile) Close() error {
+	if output.dryRun {
+		recordDryRunOutput(output.path, output.lines)
+		if compressOutputs {
+			recordDryRunOutput(output.path+".gz", output.lines)
+		}
+		return nil
+	}
+	if output.file == nil {
+		if output.err == nil {
+			output.err = os.WriteFile(output.path, output.buffer.Bytes(), 0o644)
+		}
+		if output.err == nil && output.gz != nil {
+			_, output.err = output.gz.Write(output.buffer.Bytes())
+		}
+		if output.digest != nil {
+			output.digest.Write(output.buffer.Bytes())
+		}
+	} else {
+		if output.err == nil {
+			output.err = output.writer.Flush()
+		}
+		if err := output.file.Close(); output.err == nil {
+			output.err = err
+		}
+	}
+	if output.gz != nil {
+		if err := output.gz.Close(); output.err == nil {
+			output.err = err
+		}
+	}
+	if output.gzFile != nil {
+		if err := output.gzFile.Close(); output.err == nil {
+			output.err = err
+		}
+	}
+	if output.err == nil && output.digest != nil {
+		entries := []ManifestEntry{output.digest.entry(output.path)}
+		if output.gzDigest != nil {
+			entries = append(entries, output.gzDigest.entry(output.path+".gz"))
+		}
+		output.err = recordOutputManifest(entries...)
+	}
+	return output.err
+}
@@ -0,0 +1,130 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// OutputDirOptions 输出目录检查选项
+type OutputDirOptions struct {
+	NoCreate bool // 缺少的目录直接报错，不自动创建
+	DryRun   bool // 只检查，能创建的缺少目录也不创建
+}
+
+// OutputDirError 输出目录检查失败：列出每个无法使用的目录及原因，以及运行的用户
+type OutputDirError struct {
+	User     string
+	Problems []string // "目录: 原因"，按目录排序
+	Missing  bool     // 是否有因不自动创建而报错的目录
+}
+
+func (e *OutputDirError) Error() string {
+	return fmt.Sprintf("以下 %d 个输出目录无法使用（当前用户 %s）:\n  %s", len(e.Problems), e.User, strings.Join(e.Problems, "\n  "))
+}
+
+// PrepareOutputDirs 在构建前检查全部输出目录：已存在的须是当前用户可写的目录，缺少的按需创建
+// 先检查完所有目录，全部可用才创建缺少的目录，任一目录有问题时不创建任何目录，问题汇总在一个 *OutputDirError 中返回
+func PrepareOutputDirs(dirs []string, opts OutputDirOptions) error {
+	unique := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		if dir != "" && dir != "." {
+			unique[filepath.Clean(dir)] = true
+		}
+	}
+	sorted := make([]string, 0, len(unique))
+	for dir := range unique {
+		sorted = append(sorted, dir)
+	}
+	sort.Strings(sorted)
+
+	outputErr := &OutputDirError{}
+	var missing []string
+	for _, dir := range sorted {
+		info, err := os.Stat(dir)
+		switch {
+		case err == nil && !info.IsDir():
+			outputErr.Problems = append(outputErr.Problems, dir+": 已存在同名文件，不是目录")
+		case err == nil:
+			if reason := checkWritableDir(dir); reason != "" {
+				outputErr.Problems = append(outputErr.Problems, dir+": "+reason)
+			}
+		case !os.IsNotExist(err):
+			outputErr.Problems = append(outputErr.Problems, fmt.Sprintf("%s: 无法访问: %v", dir, err))
+		case opts.NoCreate:
+			outputErr.Problems = append(outputErr.Problems, dir+": 目录不存在")
+			outputErr.Missing = true
+		default:
+			if reason := checkCreatableDir(dir); reason != "" {
+				outputErr.Problems = append(outputErr.Problems, dir+": 目录不存在，"+reason)
+			} else {
+				missing = append(missing, dir)
+			}
+		}
+	}
+	if len(outputErr.Problems) > 0 {
+		outputErr.User = currentUserName()
+		return outputErr
+	}
+	if opts.DryRun {
+		return nil
+	}
+
+	for _, dir := range missing {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("无法创建目录 %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// checkWritableDir 在目录中试建临时文件判断能否写入，可写时返回空串
+// 直接试写而不看权限位，root、ACL 与只读挂载都能得到实际结果
+func checkWritableDir(dir string) string {
+	probe, err := os.CreateTemp(dir, ".gen_ll-probe*")
+	if err != nil {
+		if os.IsPermission(err) {
+			return "当前用户没有写权限"
+		}
+		return fmt.Sprintf("无法写入: %v", err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return ""
+}
+
+// checkCreatableDir 找到最近的已存在上级目录，判断能否在其下创建缺少的目录，可以时返回空串
+func checkCreatableDir(dir string) string {
+	parent := filepath.Dir(dir)
+	for {
+		info, err := os.Stat(parent)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Sprintf("上级路径 %s 不是目录", parent)
+			}
+			if reason := checkWritableDir(parent); reason != "" {
+				return fmt.Sprintf("且无法在 %s 下创建: %s", parent, reason)
+			}
+			return ""
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Sprintf("无法访问上级目录 %s: %v", parent, err)
+		}
+		next := filepath.Dir(parent)
+		if next == parent {
+			return ""
+		}
+		parent = next
+	}
+}
+
+// currentUserName 当前用户名，取不到时退回 uid
+func currentUserName() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return fmt.Sprintf("uid %d", os.Getuid())
+}
@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gen_ll/types"
+)
+
+// benchmarkWordCodes 按固定种子生成 n 条四码词码，权重随序号递减
+func benchmarkWordCodes(n int) []*types.WordCode {
+	random := rand.New(rand.NewSource(1))
+	wordCodes := make([]*types.WordCode, 0, n)
+	for i := 0; i < n; i++ {
+		code := make([]byte, 0, 4)
+		for j := 0; j < 4; j++ {
+			code = append(code, codeKeys[random.Intn(len(codeKeys))][0])
+		}
+		wordCodes = append(wordCodes, &types.WordCode{Word: fmt.Sprintf("词%d", i), Code: string(code), Weight: fmt.Sprint(n - i)})
+	}
+	return wordCodes
+}
+
+// BenchmarkWordsSimpleCodePlaceholders 多字词简码连同占位符阶段的分配：三码全空间 13824 个码位都要补占位符
+// 全空间按长度只生成一次，占位条目从每个长度一块预分配的存储中取
+func BenchmarkWordsSimpleCodePlaceholders(b *testing.B) {
+	wordCodes := benchmarkWordCodes(300)
+	lenCodeLimit := map[int]int{1: 1, 2: 1, 3: 1}
+	for _, space := range []string{PlaceholderSpaceAll, PlaceholderSpaceUsed} {
+		b.Run(space, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				BuildWordsSimpleCode(wordCodes, lenCodeLimit, WordsSimpleCodeOptions{PlaceholderSpace: space})
+			}
+		})
+	}
+}
+
+// BenchmarkForEachBaseCode 遍历三码全空间：缓存生成后不再分配
+func BenchmarkForEachBaseCode(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		count := 0
+		forEachBaseCode(3, func(string) { count++ })
+		if count != len(codeKeys)*len(codeKeys)*len(codeKeys) {
+			b.Fatalf("三码全空间 %d 个码位", count)
+		}
+	}
+}
+
+// placeholderTestWordCodes 大写编码的词码，规则算出的码位都落在 24 键全空间之外
+// 一简各词取首码；二简只给二字词，取"首码+第三码"：丙丁、戊己的一简 A 已满，分别落到 AY、AW
+func placeholderTestWordCodes() []*types.WordCode {
+	return []*types.WordCode{
+		{Word: "甲乙", Code: "ABCD", Weight: "50"},
+		{Word: "丙丁", Code: "AXYZ", Weight: "40"},
+		{Word: "戊己", Code: "AVWU", Weight: "30"},
+		{Word: "甲乙丙", Code: "QRST", Weight: "20"},
+		{Word: "一二三四", Code: "KLMN", Weight: "10"},
+	}
+}
+
+// simpleCodeSet 按简码长度收集简码中出现的码位
+func simpleCodeSet(wordSimpleCodes []*types.WordSimpleCode) map[int]map[string]bool {
+	codes := map[int]map[string]bool{}
+	for _, item := range wordSimpleCodes {
+		if codes[len(item.Code)] == nil {
+			codes[len(item.Code)] = map[string]bool{}
+		}
+		codes[len(item.Code)][item.Code] = true
+	}
+	return codes
+}
+
+// TestWordsPlaceholderSpaceUsed used 只补规则算出的码位：一简码位恰为各词全码首码，二简码位只来自二字词的"首码+第三码"
+func TestWordsPlaceholderSpaceUsed(t *testing.T) {
+	lenCodeLimit := map[int]int{1: 1, 2: 2}
+	result := BuildWordsSimpleCode(placeholderTestWordCodes(), lenCodeLimit, WordsSimpleCodeOptions{PlaceholderSpace: PlaceholderSpaceUsed})
+
+	want := map[int]map[string]bool{
+		1: {"A": true, "Q": true, "K": true},
+		2: {"AY": true, "AW": true},
+	}
+	if got := simpleCodeSet(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("码位 %v，期望 %v", got, want)
+	}
+
+	var placeholders []string
+	for _, item := range result {
+		if item.IsPlaceholder {
+			placeholders = append(placeholders, item.BaseCode)
+		}
+	}
+	if want := []string{"AW", "AY"}; !reflect.DeepEqual(placeholders, want) {
+		t.Errorf("占位符码位 %v，期望 %v", placeholders, want)
+	}
+}
+
+// TestWordsPlaceholderSpaceAll all 在规则算出的码位之外补满 24 键全空间（一简 24、二简 576 个码位），不多补其他码位
+func TestWordsPlaceholderSpaceAll(t *testing.T) {
+	lenCodeLimit := map[int]int{1: 1, 2: 2}
+	used := simpleCodeSet(BuildWordsSimpleCode(placeholderTestWordCodes(), lenCodeLimit, WordsSimpleCodeOptions{PlaceholderSpace: PlaceholderSpaceUsed}))
+	all := simpleCodeSet(BuildWordsSimpleCode(placeholderTestWordCodes(), lenCodeLimit, WordsSimpleCodeOptions{}))
+
+	keys := strings.Join(codeKeys, "")
+	for length, wantGeneric := range map[int]int{1: 24, 2: 576} {
+		generic := 0
+		for code := range all[length] {
+			switch {
+			case strings.Trim(code, keys) == "":
+				generic++
+			case !used[length][code]:
+				t.Errorf("补位码位 %q 不在 24 键全空间，也不是规则算出的", code)
+			}
+		}
+		if generic != wantGeneric {
+			t.Errorf("%d 简 24 键全空间补了 %d 个码位，期望 %d", length, generic, wantGeneric)
+		}
+		for code := range used[length] {
+			if !all[length][code] {
+				t.Errorf("规则算出的码位 %q 缺失", code)
+			}
+		}
+	}
+	if len(all) != 2 {
+		t.Errorf("码位长度 %v，期望只有一简、二简", all)
+	}
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"sort"
+	"strings"
+)
+
+// PrefixIndex 编码前缀索引：编码升序排列的切片加编码到占用者的映射
+// 前缀查询二分定位起点后顺序取出，复杂度为 O(len(prefix)·log n + k)，k 为命中的编码数
+// 通过 NewPrefixIndex 一次性构建，构建后只读，可在多个 goroutine 间共享
+type PrefixIndex struct {
+	codes     []string
+	occupants map[string][]*CodeOccupant
+}
+
+// PrefixIndexEntry 构建前缀索引的一个条目
+type PrefixIndexEntry struct {
+	Code   string
+	Text   string
+	Source string
+}
+
+// NewPrefixIndex 由条目构建前缀索引，同一编码的占用者保持条目顺序
+func NewPrefixIndex(entries []PrefixIndexEntry) *PrefixIndex {
+	index := &PrefixIndex{occupants: make(map[string][]*CodeOccupant)}
+	for _, entry := range entries {
+		if _, exists := index.occupants[entry.Code]; !exists {
+			index.codes = append(index.codes, entry.Code)
+		}
+		index.occupants[entry.Code] = append(index.occupants[entry.Code], &CodeOccupant{Text: entry.Text, Source: entry.Source})
+	}
+	sort.Strings(index.codes)
+	return index
+}
+
+// WithPrefix 返回以 prefix 开头的所有编码（升序），返回的切片为只读视图
+func (index *PrefixIndex) WithPrefix(prefix string) []string {
+	start := sort.SearchStrings(index.codes, prefix)
+	end := start
+	for end < len(index.codes) && strings.HasPrefix(index.codes[end], prefix) {
+		end++
+	}
+	return index.codes[start:end]
+}
+
+// Occupants 返回编码的占用者，编码未被占用时返回 nil
+func (index *PrefixIndex) Occupants(code string) []*CodeOccupant {
+	return index.occupants[code]
+}
+
+// Lookup 返回以 prefix 开头的所有编码及其占用者（按编码升序）
+func (index *PrefixIndex) Lookup(prefix string) []*CodeUsage {
+	codes := index.WithPrefix(prefix)
+	usages := make([]*CodeUsage, 0, len(codes))
+	for _, code := range codes {
+		usages = append(usages, &CodeUsage{Code: code, Occupants: index.occupants[code]})
+	}
+	return usages
+}
+
+// Len 返回索引中不同编码的数量
+func (index *PrefixIndex) Len() int {
+	return len(index.codes)
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// prefixIndexEntries 以基准测试的全码为条目
+func prefixIndexEntries(n int) []PrefixIndexEntry {
+	entries := make([]PrefixIndexEntry, 0, n)
+	for _, charMeta := range benchmarkFullCodes(n) {
+		entries = append(entries, PrefixIndexEntry{Code: charMeta.Code, Text: charMeta.Char, Source: "chars_full"})
+	}
+	return entries
+}
+
+// scanPrefix 全表扫描找出以 prefix 开头的编码（去重、升序），即前缀索引之前各处的做法
+func scanPrefix(entries []PrefixIndexEntry, prefix string) []string {
+	seen := make(map[string]bool)
+	var codes []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Code, prefix) && !seen[entry.Code] {
+			seen[entry.Code] = true
+			codes = append(codes, entry.Code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func TestPrefixIndexWithPrefix(t *testing.T) {
+	entries := prefixIndexEntries(2000)
+	index := NewPrefixIndex(entries)
+	for _, prefix := range []string{"", "a", "ab", "abc", "abcw", "zz", "zzzzz"} {
+		got, want := index.WithPrefix(prefix), scanPrefix(entries, prefix)
+		if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Errorf("WithPrefix(%q) 得到 %d 个编码，全表扫描 %d 个", prefix, len(got), len(want))
+		}
+	}
+}
+
+// BenchmarkPrefixLookup 前缀索引与全表扫描的查询耗时：命中数相近时索引随表大小按对数增长，扫描按线性增长
+func BenchmarkPrefixLookup(b *testing.B) {
+	prefixes := []string{"abc", "xyz", "qstw", "mnp"}
+	for _, n := range []int{10000, 100000} {
+		entries := prefixIndexEntries(n)
+		index := NewPrefixIndex(entries)
+		b.Run(fmt.Sprintf("index/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				index.Lookup(prefixes[i%len(prefixes)])
+			}
+		})
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				scanPrefix(entries, prefixes[i%len(prefixes)])
+			}
+		})
+	}
+}
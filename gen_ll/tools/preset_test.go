@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"testing"
+
+	"gen_ll/types"
+)
+
+// presetFullCodes 全码表按词频降序：甲与丁的全码为四码，比"简码前缀+末码"多一码
+func presetFullCodes() []*types.CharMeta {
+	return []*types.CharMeta{
+		{Char: "甲", Code: "abrd", Freq: 100},
+		{Char: "乙", Code: "abr", Freq: 50},
+		{Char: "丙", Code: "abwq", Freq: 40},
+		{Char: "丁", Code: "abuo", Freq: 10},
+	}
+}
+
+func TestBuildFullCodePrefixIndex(t *testing.T) {
+	index := buildFullCodePrefixIndex(presetFullCodes())
+	tests := []struct {
+		key  string
+		char string
+	}{
+		{"ab", "甲"},
+		{"abr", "甲"}, // 四码全码 abrd 以 abr 开头，且比全码恰为 abr 的乙字频高
+		{"abrd", "甲"},
+		{"abu", "丁"}, // 只有四码全码 abuo 以 abu 开头
+		{"abuo", "丁"},
+		{"abw", "丙"},
+		{"abo", ""},
+		{"a", ""},
+	}
+	for _, test := range tests {
+		if char := index[test.key]; char != test.char {
+			t.Errorf("index[%q] = %q, want %q", test.key, char, test.char)
+		}
+	}
+}
+
+func TestBuildPresetDataPadFullCode(t *testing.T) {
+	simpleCodeList := []*types.CharMeta{{Char: "丙", Code: "abw", Freq: 40}}
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{PresetPadPlaceholder, "w丙 r② u③ o④\tab"},
+		{PresetPadFullCode, "w丙 r甲 u丁 o④\tab"},
+	}
+	for _, test := range tests {
+		lines, err := BuildPresetData(simpleCodeList, presetFullCodes(), PresetDataOptions{PadMissingSuffixes: test.mode})
+		if err != nil {
+			t.Fatalf("%s: BuildPresetData: %v", test.mode, err)
+		}
+		found := false
+		for _, line := range lines {
+			if line == test.want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("%s: preset_data 中没有 %q", test.mode, test.want)
+		}
+	}
+}
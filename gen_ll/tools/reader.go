@@ -1,44 +1,256 @@
 package tools
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"unicode"
+	"unicode/utf8"
 
+	"gen_ll/tabfile"
 	"gen_ll/types"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 var (
 	// 文件内容缓存
 	fileCache     = make(map[string][]byte)
 	fileCacheLock sync.RWMutex
+
+	// 映射表索引缓存，按路径共享
+	compMapCache     = make(map[string]*CompMapIndex)
+	compMapCacheLock sync.Mutex
 )
 
-// 读取文件内容，带缓存功能
+// ResetRunState 清空文件内容缓存、映射表索引缓存与空跑记录，供同一进程内重新构建（监视模式）前调用
+func ResetRunState() {
+	fileCacheLock.Lock()
+	fileCache = make(map[string][]byte)
+	fileCacheLock.Unlock()
+
+	compMapCacheLock.Lock()
+	compMapCache = make(map[string]*CompMapIndex)
+	compMapCacheLock.Unlock()
+
+	dryRunMutex.Lock()
+	dryRunOutputs = nil
+	dryRunMutex.Unlock()
+
+	manifestMutex.Lock()
+	manifestEntries = map[string]ManifestEntry{}
+	manifestMutex.Unlock()
+}
+
+// LineError 输入文件中某一行的数据错误
+type LineError = tabfile.LineError
+
+// StdinPath 输入参数取该值时从标准输入读取
+const StdinPath = "-"
+
+// gzipMagic gzip 流的起始字节
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// isGzip 按扩展名 .gz 或起始字节判断输入是否为 gzip 压缩
+func isGzip(filepath string, head []byte) bool {
+	return strings.HasSuffix(filepath, ".gz") || bytes.HasPrefix(head, gzipMagic)
+}
+
+// inputName 错误信息中的输入名，标准输入写作"标准输入"
+func inputName(filepath string) string {
+	if filepath == StdinPath {
+		return "标准输入"
+	}
+	return filepath
+}
+
+// gunzipContent 内容为 gzip 压缩时整体解压，否则原样返回；流损坏时返回带文件名的错误
+func gunzipContent(filepath string, content []byte) ([]byte, error) {
+	if !isGzip(filepath, content) {
+		return content, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("解压 %s 失败: %w", inputName(filepath), err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("解压 %s 失败: %w", inputName(filepath), err)
+	}
+	return data, nil
+}
+
+// 读取文件内容，带缓存功能；标准输入只能读取一次，不进入缓存
+// gzip 压缩的文件（.gz 或起始字节为 gzip 魔数）透明解压，缓存中存放解压后的内容，键仍为路径
 func readFileWithCache(filepath string) ([]byte, error) {
+	if filepath == StdinPath {
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return gunzipContent(filepath, content)
+	}
+
 	fileCacheLock.RLock()
 	content, exists := fileCache[filepath]
 	fileCacheLock.RUnlock()
-	
+
 	if exists {
 		return content, nil
 	}
-	
+
 	content, err := os.ReadFile(filepath)
 	if err != nil {
 		return nil, err
 	}
-	
+	content, err = gunzipContent(filepath, content)
+	if err != nil {
+		return nil, err
+	}
+
 	fileCacheLock.Lock()
 	fileCache[filepath] = content
 	fileCacheLock.Unlock()
-	
+
 	return content, nil
 }
 
+// 不低于该大小的文件按行流式解析，不经缓存；小文件仍整体读入并缓存
+var streamReadThreshold int64 = 64 << 20
+
+// SetStreamReadThreshold 设置流式解析的文件大小阈值（字节），0 表示所有文件都流式解析
+func SetStreamReadThreshold(threshold int64) {
+	streamReadThreshold = threshold
+}
+
+// 流式解析时单行的最大长度
+const maxStreamLineSize = 16 << 20
+
+// forEachLine 逐行处理文件，行尾的 \r 已去除
+// 大文件用 bufio.Scanner 按行读取以避免整份内容与切分结果同时驻留内存，两条路径得到的行完全一致
+// gzip 压缩的输入两条路径都透明解压，是否流式解析按压缩后的文件大小判断
+func forEachLine(filepath string, handle func(line string)) error {
+	if filepath == StdinPath {
+		return scanInput(filepath, os.Stdin, handle)
+	}
+
+	info, err := os.Stat(filepath)
+	if err != nil {
+		return err
+	}
+
+	if info.Size() < streamReadThreshold {
+		buffer, err := readFileWithCache(filepath)
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(string(buffer), "\n")
+		// 与 bufio.ScanLines 一致：文件末尾的换行不产生额外的空行
+		if len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for _, line := range lines {
+			handle(strings.TrimSuffix(line, "\r"))
+		}
+		return nil
+	}
+
+	file, err := os.Open(filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return scanInput(filepath, file, handle)
+}
+
+// scanInput 按行读取文件或标准输入，gzip 压缩时边解压边读取，流损坏时返回带文件名的错误
+func scanInput(filepath string, reader io.Reader, handle func(line string)) error {
+	buffered := bufio.NewReader(reader)
+	head, _ := buffered.Peek(len(gzipMagic))
+	if !isGzip(filepath, head) {
+		return scanLines(buffered, handle)
+	}
+	gzipReader, err := gzip.NewReader(buffered)
+	if err != nil {
+		return fmt.Errorf("解压 %s 失败: %w", inputName(filepath), err)
+	}
+	defer gzipReader.Close()
+	if err := scanLines(gzipReader, handle); err != nil {
+		return fmt.Errorf("读取 gzip 文件 %s 失败: %w", inputName(filepath), err)
+	}
+	return nil
+}
+
+// scanLines 用 bufio.Scanner 按行读取，供大文件与标准输入使用
+func scanLines(reader io.Reader, handle func(line string)) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		handle(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// forEachRow 逐行解析文件为表格行，读取路径（缓存、标准输入、流式）同 forEachLine
+// handle 返回错误时忽略其余行并返回该错误，返回 tabfile.Stop 时只停止不报错
+func forEachRow(filepath string, opts tabfile.Options, handle func(row *tabfile.Row) error) error {
+	parser := tabfile.NewParser(filepath, opts)
+	var handleErr error
+	err := forEachLine(filepath, func(line string) {
+		if handleErr != nil {
+			return
+		}
+		if row := parser.Parse(line); row != nil {
+			handleErr = handle(row)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if handleErr == tabfile.Stop {
+		return nil
+	}
+	return handleErr
+}
+
+// ValidatePrefixFree 校验映射表的互斥前缀约束：任何字根编码都不能是另一个不同编码的前缀，同码字根不算违规
+// 编码排序后以某编码为前缀的编码紧随其后，顺序向后扫描即可列出全部违规对
+func ValidatePrefixFree(compMap map[string]string) error {
+	rootsByCode := make(map[string][]string)
+	for root, code := range compMap {
+		rootsByCode[code] = append(rootsByCode[code], root)
+	}
+	codes := make([]string, 0, len(rootsByCode))
+	for code, roots := range rootsByCode {
+		sort.Strings(roots)
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var errorMessages []string
+	for i, code := range codes {
+		for j := i + 1; j < len(codes) && strings.HasPrefix(codes[j], code); j++ {
+			errorMessages = append(errorMessages, fmt.Sprintf("编码 %s（字根 %s）是编码 %s（字根 %s）的前缀",
+				code, strings.Join(rootsByCode[code], " "), codes[j], strings.Join(rootsByCode[codes[j]], " ")))
+		}
+	}
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("发现 %d 对编码违反互斥前缀约束:\n%s", len(errorMessages), strings.Join(errorMessages, "\n"))
+	}
+
+	return nil
+}
+
 // ValidateDivisionComponents 验证拆分部件是否在映射表中定义
 func ValidateDivisionComponents(divTable map[string][]*types.Division, compMap map[string]string) error {
 	invalidComponents := make(map[string][]string) // 部件 -> [位置信息]
@@ -57,8 +269,16 @@ func ValidateDivisionComponents(divTable map[string][]*types.Division, compMap m
 	}
 
 	if len(invalidComponents) > 0 {
+		// 按部件排序，保证每次输出顺序一致
+		components := make([]string, 0, len(invalidComponents))
+		for component := range invalidComponents {
+			components = append(components, component)
+		}
+		sort.Strings(components)
+
 		var errorMessages []string
-		for component, positions := range invalidComponents {
+		for _, component := range components {
+			positions := invalidComponents[component]
 			// 只显示前3个位置，避免输出过长
 			displayPositions := positions
 			if len(positions) > 3 {
@@ -73,118 +293,477 @@ func ValidateDivisionComponents(divTable map[string][]*types.Division, compMap m
 	return nil
 }
 
-func ReadDivisionTable(filepath string) (table map[string][]*types.Division, err error) {
-	buffer, err := readFileWithCache(filepath)
-	if err != nil {
-		return
+// componentMatcher 匹配拆分中的单个部件：{...} 形式的组合部件或单个字符
+var componentMatcher = regexp.MustCompile("{.*?}|.")
+
+// DivisionTableOptions 拆分表读取选项
+type DivisionTableOptions struct {
+	InferUnicode     bool             // 码位缺失或与字符不符时按字符实际码位填写，允许省略码位列
+	CharLimit        int              // 最多读取的字符数，读到第 CharLimit+1 个不同字符时停止，多个文件按合并后的字数计，0 表示不限制
+	ValidateEncoding bool             // 校验每行字符为合法 UTF-8 且恰好是一个字素簇（基字加组合附加符）
+	Strict           bool             // 严格模式：格式错误的行直接返回错误而不是跳过
+	Invisible        InvisibleOptions // 不可见字符（零宽字符、BOM、方向控制符、全角空格）的检测与剥离
+}
+
+// ReadDivisionTable 读取拆分表；格式错误的行（缺少制表符、拆分信息字段不足、拆分为空）默认跳过并以 LineError 返回，严格模式下直接返回错误
+// 含不可见字符的行按 opts.Invisible 报错，或剥离后照常读入并同样以 LineError 返回
+// 多个文件按顺序合并：后面的文件中出现的字整体替换前面文件中该字的全部拆分，而不是追加为次拆分，被替换的字按字排序后以 overridden 返回
+func ReadDivisionTable(filepaths []string, opts DivisionTableOptions) (table map[string][]*types.Division, overridden []string, issues []*LineError, err error) {
+	table = map[string][]*types.Division{}
+	for _, filepath := range filepaths {
+		fileTable, fileIssues, err := readDivisionFile(filepath, opts, table)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		issues = append(issues, fileIssues...)
+		for char, divs := range fileTable {
+			if _, exists := table[char]; exists {
+				overridden = append(overridden, char)
+			}
+			table[char] = divs
+		}
 	}
+	sort.Strings(overridden)
+	return
+}
 
-	matcher := regexp.MustCompile("{.*?}|.")
+// readDivisionFile 读取单个拆分表文件；同一文件中一个字的多行都保留，依次为首要拆分与次拆分
+// merged 为前面文件已合并的结果，只用于 CharLimit 计数：替换已有的字不占名额，名额用完后首个文件停止读取，后面的文件只跳过新字、继续读取替换
+func readDivisionFile(filepath string, opts DivisionTableOptions, merged map[string][]*types.Division) (table map[string][]*types.Division, issues []*LineError, err error) {
 	table = map[string][]*types.Division{}
-	for _, line := range strings.Split(string(buffer), "\n") {
-		if len(line) == 0 || strings.HasPrefix(line, "#") {
-			continue
+	newChars := 0
+	var encodingErrors []string
+	skip := func(lineErr *LineError) error {
+		if opts.Strict {
+			return lineErr
 		}
-		// 的\t[白勹丶,de_dī_dí_dì,CJK,U+7684]
-		line := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
-		if len(line) < 2 {
-			continue
+		issues = append(issues, lineErr)
+		return nil
+	}
+	parseOpts := tabfile.Options{StripComment: true}
+	// 的\t[白勹丶,de_dī_dí_dì,CJK,U+7684]
+	err = forEachRow(filepath, parseOpts, func(row *tabfile.Row) error {
+		row, warning, err := opts.Invisible.checkRow(row, parseOpts)
+		if err != nil {
+			return err
+		}
+		if warning != nil {
+			issues = append(issues, warning)
+		}
+		if row == nil {
+			return nil
+		}
+		if row.Len() < 2 {
+			return skip(row.Errorf("格式错误，缺少制表符"))
+		}
+		char := norm.NFC.String(row.Column(0))
+		if opts.ValidateEncoding {
+			if msg := validateGrapheme(char); msg != "" {
+				encodingErrors = append(encodingErrors, row.Errorf("%s", msg).Error())
+				return nil
+			}
 		}
 		// [白勹丶,de_dī_dí_dì,CJK,U+7684]
-		meta := strings.Split(strings.Trim(line[1], "[]"), ",")
+		meta := strings.Split(strings.Trim(norm.NFC.String(row.Column(1)), "[]"), ",")
+		if opts.InferUnicode && len(meta) == 3 {
+			meta = append(meta, "")
+		}
 		if len(meta) < 4 {
-			continue
+			return skip(row.Errorf("格式错误，缺少字段"))
+		}
+		if opts.InferUnicode {
+			meta[3] = inferUnicode(char, meta[3])
 		}
 		div := types.Division{
-			Char: line[0],
-			Divs: matcher.FindAllString(meta[0], -1),
-			Pin:  meta[1],
-			Set:  meta[2],
+			Char:    char,
+			Divs:    componentMatcher.FindAllString(meta[0], -1),
+			Pin:     meta[1],
+			Set:     meta[2],
 			Unicode: meta[3],
 		}
 		if len(div.Divs) == 0 {
-			continue
+			return skip(row.Errorf("格式错误，缺少拆分"))
+		}
+		if _, exists := table[div.Char]; !exists && opts.CharLimit > 0 {
+			if _, exists := merged[div.Char]; !exists {
+				if len(merged)+newChars >= opts.CharLimit {
+					if len(merged) == 0 {
+						return tabfile.Stop
+					}
+					return nil
+				}
+				newChars++
+			}
 		}
 		table[div.Char] = append(table[div.Char], &div)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(encodingErrors) > 0 {
+		return nil, nil, fmt.Errorf("拆分表字符编码校验失败:\n%s", strings.Join(encodingErrors, "\n"))
 	}
 
 	return
 }
 
+// validateGrapheme 检查字符串是否恰好为一个字素簇，不是时返回原因
+// 只按基字后接组合附加符（Mn、Mc、Me，含异体字选择符）判断；调用方先按 NFC 规范化，分解形式的谚文音节等由此合成为一个码位
+func validateGrapheme(char string) string {
+	if !utf8.ValidString(char) {
+		return "字符不是合法的 UTF-8"
+	}
+	runes := []rune(char)
+	if len(runes) == 0 {
+		return "字符为空"
+	}
+	if unicode.Is(unicode.M, runes[0]) {
+		return fmt.Sprintf("字符以组合附加符 U+%04X 开头", runes[0])
+	}
+	for _, r := range runes[1:] {
+		if unicode.Is(unicode.M, r) {
+			continue
+		}
+		return fmt.Sprintf("字符 %q 包含 %d 个码位，不是单个字素簇", char, len(runes))
+	}
+	return ""
+}
+
+// inferUnicode 按字符的首个码位生成 U+XXXX 形式的码位，空字符保持原值
+func inferUnicode(char, unicode string) string {
+	runes := []rune(char)
+	if len(runes) == 0 {
+		return unicode
+	}
+	return fmt.Sprintf("U+%04X", runes[0])
+}
 
-func ReadCompMap(filepath string) (mappings map[string]string, err error) {
-	buffer, err := readFileWithCache(filepath)
+// ReadCompMap 读取字根映射表，返回字根到编码的映射，以及按字根排序的字根列表
+// 映射的遍历顺序不固定，需要稳定输出（报告、调试日志）时按 keys 遍历；结果与 ReadCompMapIndexed 共享，调用方不得修改
+func ReadCompMap(filepath string) (mappings map[string]string, keys []string, err error) {
+	index, err := ReadCompMapIndexed(filepath)
 	if err != nil {
-		return
+		return nil, nil, err
 	}
+	return index.CompCode, index.Comps, nil
+}
 
-	mappings = map[string]string{}
-	for _, line := range strings.Split(string(buffer), "\n") {
-		if len(line) == 0 || strings.HasPrefix(line, "#") {
-			continue
+// CompMapEntry 映射表中的一行
+type CompMapEntry struct {
+	Code string // 原始编码（未替换"_"）
+	Comp string // 字根
+	Note string // 字根说明（第三列），只用于字根码表
+	Line int    // 行号（从1开始）
+}
+
+// CompMapDuplicate 映射表中重复定义的字根，同一字根只记一次
+type CompMapDuplicate struct {
+	File  string
+	Comp  string
+	Lines []int    // 各次定义的行号
+	Codes []string // 各次定义的原始编码，与 Lines 一一对应
+}
+
+// Conflicting 各次定义的编码是否不全相同
+func (dup *CompMapDuplicate) Conflicting() bool {
+	for _, code := range dup.Codes[1:] {
+		if code != dup.Codes[0] {
+			return true
 		}
-		line := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
-		code, comp := strings.ReplaceAll(line[0], "_", "1"), line[1]
-		mappings[comp] = code
 	}
+	return false
+}
 
-	return
+func (dup *CompMapDuplicate) String() string {
+	definitions := make([]string, len(dup.Lines))
+	for i, line := range dup.Lines {
+		definitions[i] = fmt.Sprintf("第 %d 行 %s", line, dup.Codes[i])
+	}
+	return fmt.Sprintf("%s: 字根 %s 重复定义: %s", dup.File, dup.Comp, strings.Join(definitions, "，"))
+}
+
+// CompMapIndex 字根映射表的正反索引，只读
+type CompMapIndex struct {
+	Entries    []*CompMapEntry     // 按映射表顺序的全部行
+	CompCode   map[string]string   // 字根→编码（"_"替换为"1"），同一字根重复定义时以最后一行为准
+	KeyComps   map[string][]string // 编码首键→字根，按编码升序，同码按映射表中首次出现的顺序
+	Comps      []string            // 按字根排序的字根列表
+	Duplicates []*CompMapDuplicate // 重复定义的字根，按首次定义的顺序
 }
 
-func ReadCharFreq(filepath string) (freqSet map[string]int64, err error) {
-	buffer, err := readFileWithCache(filepath)
+// ConflictError 有字根重复定义且编码不同时返回列出全部冲突的错误；编码相同的重复定义不算冲突
+func (index *CompMapIndex) ConflictError() error {
+	var conflicts []string
+	for _, dup := range index.Duplicates {
+		if dup.Conflicting() {
+			conflicts = append(conflicts, dup.String())
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+	return fmt.Errorf("映射表中 %d 个字根重复定义且编码不同:\n%s", len(conflicts), strings.Join(conflicts, "\n"))
+}
+
+// ReadCompMapIndexed 读取字根映射表并构建正反索引；同一路径在一次运行内只读取一次，各处共享同一索引
+func ReadCompMapIndexed(filepath string) (*CompMapIndex, error) {
+	compMapCacheLock.Lock()
+	defer compMapCacheLock.Unlock()
+	if index, exists := compMapCache[filepath]; exists {
+		return index, nil
+	}
+
+	index := &CompMapIndex{CompCode: map[string]string{}, KeyComps: map[string][]string{}}
+	// 编码\t字根[\t字根说明]
+	err := forEachRow(filepath, tabfile.Options{TrimSpace: true, MinColumns: 2, StripComment: true}, func(row *tabfile.Row) error {
+		index.Entries = append(index.Entries, &CompMapEntry{Code: row.Column(0), Comp: norm.NFC.String(row.Column(1)), Note: strings.TrimSpace(row.Column(2)), Line: row.Line})
+		return nil
+	})
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	freqSet = map[string]int64{}
-	for _, line := range strings.Split(string(buffer), "\n") {
-		if len(line) == 0 || strings.HasPrefix(line, "#") {
+	firstEntry := map[string]*CompMapEntry{}
+	duplicates := map[string]*CompMapDuplicate{}
+	for _, entry := range index.Entries {
+		if first, exists := firstEntry[entry.Comp]; !exists {
+			index.Comps = append(index.Comps, entry.Comp)
+			firstEntry[entry.Comp] = entry
+		} else {
+			dup, recorded := duplicates[entry.Comp]
+			if !recorded {
+				dup = &CompMapDuplicate{File: filepath, Comp: entry.Comp, Lines: []int{first.Line}, Codes: []string{first.Code}}
+				duplicates[entry.Comp] = dup
+				index.Duplicates = append(index.Duplicates, dup)
+			}
+			dup.Lines = append(dup.Lines, entry.Line)
+			dup.Codes = append(dup.Codes, entry.Code)
+		}
+		index.CompCode[entry.Comp] = strings.ReplaceAll(entry.Code, "_", "1")
+	}
+	// 此时 Comps 为首次出现的顺序，按首键归组后稳定排序，同码字根保持映射表中的先后
+	for _, comp := range index.Comps {
+		code := index.CompCode[comp]
+		if code == "" {
 			continue
 		}
-		line := strings.Split(strings.TrimRight(line, "\r\n"), "\t")
-		char, freqStr := line[0], line[1]
-		freq, _ := strconv.ParseFloat(freqStr, 64)
-		freqSet[char] = int64(freq)
+		index.KeyComps[code[:1]] = append(index.KeyComps[code[:1]], comp)
+	}
+	for _, comps := range index.KeyComps {
+		sort.SliceStable(comps, func(i, j int) bool {
+			return index.CompCode[comps[i]] < index.CompCode[comps[j]]
+		})
 	}
+	sort.Strings(index.Comps)
 
-	return
+	compMapCache[filepath] = index
+	return index, nil
+}
+
+// CharFreqOptions 频率表读取选项
+type CharFreqOptions struct {
+	KeepWords bool // 多字条目另存到 CharFreq.Words，供多字词缺权重时引用（默认只计数后丢弃）
+}
+
+// CharFreq 频率表读取结果
+type CharFreq struct {
+	Chars     map[string]int64 // 单字频率
+	Words     map[string]int64 // 多字条目频率，仅 KeepWords 时收集
+	WordLines int              // 多字条目数
 }
 
+// ReadCharFreq 读取字词混合的频率表，只有单字（一个字素簇，即一个码位加可选的组合附加符）计入字频
+func ReadCharFreq(filepath string, opts CharFreqOptions) (*CharFreq, error) {
+	charFreq := &CharFreq{Chars: map[string]int64{}}
+	if opts.KeepWords {
+		charFreq.Words = map[string]int64{}
+	}
+	err := forEachRow(filepath, tabfile.Options{MinColumns: 2, StripComment: true}, func(row *tabfile.Row) error {
+		char := norm.NFC.String(row.Column(0))
+		freq, _ := strconv.ParseFloat(row.Column(1), 64)
+		if validateGrapheme(char) != "" {
+			charFreq.WordLines++
+			if opts.KeepWords {
+				charFreq.Words[char] = int64(freq)
+			}
+			return nil
+		}
+		charFreq.Chars[char] = int64(freq)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
+	return charFreq, nil
+}
 
+// ReadWordFreq 读取词频表，格式为"词\t频率"（也可用空格分隔），频率允许为小数，取整数部分
+func ReadWordFreq(filepath string) (map[string]int64, error) {
+	wordFreq := map[string]int64{}
+	err := forEachRow(filepath, tabfile.Options{TrimSpace: true, SplitSpace: true}, func(row *tabfile.Row) error {
+		if row.Len() < 2 {
+			return row.Errorf("格式错误，应为词\\t频率")
+		}
+		freq, err := strconv.ParseFloat(row.Column(1), 64)
+		if err != nil {
+			return row.Errorf("频率不是数字: %s", row.Column(1))
+		}
+		wordFreq[row.Column(0)] = int64(freq)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-// ReadWordsFile 读取多字词文件
-func ReadWordsFile(filepath string) ([]*types.WordEntry, error) {
-	buffer, err := readFileWithCache(filepath)
+	return wordFreq, nil
+}
+
+// ReadCharset 读取字集文件，每行一个字符，取每行第一列
+func ReadCharset(filepath string) (map[string]bool, error) {
+	charset := map[string]bool{}
+	err := forEachRow(filepath, tabfile.Options{}, func(row *tabfile.Row) error {
+		char := strings.TrimSpace(row.Column(0))
+		if len(char) == 0 || strings.HasPrefix(char, "#") {
+			return nil
+		}
+		charset[char] = true
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	wordEntries := make([]*types.WordEntry, 0)
-	for _, line := range strings.Split(string(buffer), "\n") {
-		line = strings.TrimSpace(line)
-		if len(line) == 0 || strings.HasPrefix(line, "#") {
-			continue
+	return charset, nil
+}
+
+// ReadRadicalMap 读取部首编码表，格式为"部首或字符集名\t编码"
+func ReadRadicalMap(filepath string) (map[string]string, error) {
+	radicalMap := map[string]string{}
+	err := forEachRow(filepath, tabfile.Options{}, func(row *tabfile.Row) error {
+		if row.Len() < 2 || row.Column(0) == "" || row.Column(1) == "" {
+			return row.Errorf("格式错误，应为部首或字符集名\\t编码")
 		}
+		radicalMap[row.Column(0)] = row.Column(1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		// 使用制表符或空格分割
-		fields := strings.Fields(line)
-		if len(fields) == 0 {
-			continue
+	return radicalMap, nil
+}
+
+// ReadCharCodeList 读取已生成的单字全码表（code_full.txt，格式为"字\t编码\t词频"，可带第四列简码），作为单字全码列表直接使用
+// 条目保持文件顺序，没有拆分信息；同字多个编码时以首次出现的一条作为主拆分，与 CreateCharCodeMap 取主拆分编码对应
+// 任何一行格式不对或文件中没有条目时返回错误
+func ReadCharCodeList(filepath string) ([]*types.CharMeta, error) {
+	var charMetaList []*types.CharMeta
+	seen := make(map[string]bool)
+	err := forEachRow(filepath, tabfile.Options{}, func(row *tabfile.Row) error {
+		if row.Len() < 3 || row.Len() > 4 || row.Column(0) == "" || row.Column(1) == "" {
+			return row.Errorf("格式错误，应为字\\t编码\\t词频")
 		}
+		freq, err := strconv.ParseInt(row.Column(2), 10, 64)
+		if err != nil {
+			return row.Errorf("词频不是整数: %s", row.Column(2))
+		}
+		char := row.Column(0)
+		charMetaList = append(charMetaList, &types.CharMeta{
+			Char: char,
+			Code: row.Column(1),
+			Freq: freq,
+			MDiv: !seen[char],
+		})
+		seen[char] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(charMetaList) == 0 {
+		return nil, fmt.Errorf("%s 中没有任何单字编码", filepath)
+	}
 
-		word := fields[0]
+	return charMetaList, nil
+}
+
+// WordsFileOptions 多字词文件读取选项
+type WordsFileOptions struct {
+	SortByWeight    bool             // 按权重降序返回（默认保持文件原始顺序）
+	FallbackWeights map[string]int64 // 词条缺权重时查此表补全，在排序之前进行，nil 时不补
+	DefaultWeight   string           // 查表后仍缺权重的词条使用的权重，为空时保持缺省（按 0 处理）
+	Invisible       InvisibleOptions // 不可见字符（零宽字符、BOM、方向控制符、全角空格）的检测与剥离，在分列之前进行
+}
+
+// WordWeightReport 词条权重补全统计
+type WordWeightReport struct {
+	Missing          int          // 文件中缺权重的词条数
+	Filled           int          // 其中查表补全的词条数
+	PlaceholderWords []*LineError // 与多字词简码占位符（①至⑩）相同的词条，照常读入，由调用方决定警告或拒绝
+	InvisibleLines   []*LineError // 剥离了不可见字符的行（lenient），由调用方警告
+}
+
+// HitRate 查表命中率（百分比），没有缺权重的词条时为 0
+func (report *WordWeightReport) HitRate() float64 {
+	if report.Missing == 0 {
+		return 0
+	}
+	return float64(report.Filled) * 100 / float64(report.Missing)
+}
+
+// ReadWordsFile 读取多字词文件，缺权重的词条按选项查表补全并返回补全统计
+func ReadWordsFile(filepath string, opts WordsFileOptions) ([]*types.WordEntry, *WordWeightReport, error) {
+	wordEntries := make([]*types.WordEntry, 0)
+	report := &WordWeightReport{}
+	// 使用制表符或空格分割；全角空格也是空白，须在分列前检测，否则会把词条截断
+	parseOpts := tabfile.Options{TrimSpace: true, SplitSpace: true, MinColumns: 1, StripComment: true}
+	err := forEachRow(filepath, parseOpts, func(row *tabfile.Row) error {
+		row, warning, err := opts.Invisible.checkRow(row, parseOpts)
+		if err != nil {
+			return err
+		}
+		if warning != nil {
+			report.InvisibleLines = append(report.InvisibleLines, warning)
+		}
+		if row == nil {
+			return nil
+		}
+		word := row.Column(0)
+		if IsPlaceholder(word) {
+			report.PlaceholderWords = append(report.PlaceholderWords, row.Errorf("词条 %s 与多字词简码占位符相同", word))
+		}
 		weight := ""
-		if len(fields) >= 2 {
-			weight = fields[1]
+		if row.Len() >= 2 {
+			weight = row.Column(1)
+		} else {
+			report.Missing++
+			if fallback, exists := opts.FallbackWeights[word]; exists {
+				weight = strconv.FormatInt(fallback, 10)
+				report.Filled++
+			} else {
+				weight = opts.DefaultWeight
+			}
 		}
 
 		wordEntries = append(wordEntries, &types.WordEntry{
 			Word:   word,
 			Weight: weight,
 		})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.SortByWeight {
+		// 稳定排序，权重相同的词条保持文件顺序
+		sort.SliceStable(wordEntries, func(i, j int) bool {
+			return parseWeight(wordEntries[i].Weight) > parseWeight(wordEntries[j].Weight)
+		})
 	}
 
-	return wordEntries, nil
+	return wordEntries, report, nil
 }
@@ -0,0 +1,267 @@
+package tools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gen_ll/types"
+)
+
+// writeInput 在 dir 下写出输入表
+func writeInput(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// withStreamReadThreshold 以给定的流式解析阈值执行 read，执行完恢复原阈值并清空文件缓存
+func withStreamReadThreshold(threshold int64, read func()) {
+	defer SetStreamReadThreshold(streamReadThreshold)
+	defer ResetRunState()
+	SetStreamReadThreshold(threshold)
+	read()
+}
+
+// TestStreamReadMatchesCached 流式解析（阈值 0，所有文件都按行读取）与缓存读取的解析结果完全一致
+// 除回归数据外还覆盖 CRLF 换行、空行、缺末尾换行与行尾注释
+func TestStreamReadMatchesCached(t *testing.T) {
+	dir := t.TempDir()
+	edgeWords := writeInput(t, dir, "words.txt", "一个\t100\r\n\r\n之后\r\n# 注释行\n不过\t30 # 行尾注释\n一个\t5")
+	edgeFreq := writeInput(t, dir, "freq.txt", "一\t10\r\n不\t8\n\n一个\t7\n个\t3")
+
+	wordsFiles := []string{
+		filepath.Join(wordsPipelineFixture, "ll_words.txt"),
+		filepath.Join(wordsPipelineFixture, "linglong.txt"),
+		edgeWords,
+	}
+	freqFiles := []string{filepath.Join(wordsPipelineFixture, "freq.txt"), edgeFreq}
+	wordsOpts := WordsFileOptions{SortByWeight: true, FallbackWeights: map[string]int64{"之后": 42}}
+
+	for _, path := range wordsFiles {
+		type wordsResult struct {
+			Entries []*types.WordEntry
+			Report  *WordWeightReport
+		}
+		var cached, streamed wordsResult
+		for _, run := range []struct {
+			threshold int64
+			result    *wordsResult
+		}{{1 << 40, &cached}, {0, &streamed}} {
+			withStreamReadThreshold(run.threshold, func() {
+				entries, report, err := ReadWordsFile(path, wordsOpts)
+				if err != nil {
+					t.Fatalf("ReadWordsFile(%s): %v", path, err)
+				}
+				*run.result = wordsResult{entries, report}
+			})
+		}
+		if len(cached.Entries) == 0 {
+			t.Errorf("%s 没有读到词条", path)
+		}
+		if !reflect.DeepEqual(cached, streamed) {
+			t.Errorf("%s 流式解析与缓存读取的词表不一致:\n缓存: %+v\n流式: %+v", path, cached, streamed)
+		}
+	}
+
+	for _, path := range freqFiles {
+		var cached, streamed *CharFreq
+		for _, run := range []struct {
+			threshold int64
+			result    **CharFreq
+		}{{1 << 40, &cached}, {0, &streamed}} {
+			withStreamReadThreshold(run.threshold, func() {
+				charFreq, err := ReadCharFreq(path, CharFreqOptions{KeepWords: true})
+				if err != nil {
+					t.Fatalf("ReadCharFreq(%s): %v", path, err)
+				}
+				*run.result = charFreq
+			})
+		}
+		if len(cached.Chars) == 0 {
+			t.Errorf("%s 没有读到字频", path)
+		}
+		if !reflect.DeepEqual(cached, streamed) {
+			t.Errorf("%s 流式解析与缓存读取的频率表不一致:\n缓存: %+v\n流式: %+v", path, cached, streamed)
+		}
+	}
+}
+
+// TestReadCompMapIndexedKeyCompsOrder 同一首键的字根按编码升序，同码保持映射表中首次出现的先后；重复读取顺序不变
+func TestReadCompMapIndexedKeyCompsOrder(t *testing.T) {
+	path := writeInput(t, t.TempDir(), "ll_map.txt", "zpo\t丶\nakw\t口\nzpo\t乙\nzaa\t木\nzpo\t十\nab_\t日\nzbb\t丶\n")
+	want := map[string][]string{
+		"z": {"木", "丶", "乙", "十"}, // 丶重复定义为 zbb，以最后一行为准，位置按首次出现
+		"a": {"日", "口"},           // ab_ 的 "_" 按 "1" 参与排序
+	}
+	for i := 0; i < 3; i++ {
+		ResetRunState()
+		index, err := ReadCompMapIndexed(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(index.KeyComps, want) {
+			t.Fatalf("第 %d 次读取 KeyComps = %v，期望 %v", i+1, index.KeyComps, want)
+		}
+		if cached, err := ReadCompMapIndexed(path); err != nil || cached != index {
+			t.Errorf("同一运行内再次读取应返回缓存的索引: %v", err)
+		}
+	}
+}
+
+// TestReadersStripTrailingComment 四种输入表都去掉行尾注释：前面是空白（含制表符）的 # 或全角 ＃，注释中可含制表符
+// 紧跟在内容后的 # 不是注释
+func TestReadersStripTrailingComment(t *testing.T) {
+	dir := t.TempDir()
+
+	divPath := writeInput(t, dir, "ll_div.txt", "# 整行注释\n的\t[白勹丶,de,CJK,U+7684]\t# 常用\t字\n了\t[乛亅,le,CJK,U+4E86] ＃全角\t注释\n")
+	divTable, _, issues, err := ReadDivisionTable([]string{divPath}, DivisionTableOptions{})
+	if err != nil || len(issues) > 0 {
+		t.Fatalf("ReadDivisionTable: %v %v", err, issues)
+	}
+	for char, want := range map[string]types.Division{
+		"的": {Char: "的", Divs: []string{"白", "勹", "丶"}, Pin: "de", Set: "CJK", Unicode: "U+7684"},
+		"了": {Char: "了", Divs: []string{"乛", "亅"}, Pin: "le", Set: "CJK", Unicode: "U+4E86"},
+	} {
+		if divisions := divTable[char]; len(divisions) != 1 || !reflect.DeepEqual(*divisions[0], want) {
+			t.Errorf("拆分表 %s: %+v，期望 %+v", char, divisions, want)
+		}
+	}
+
+	mapPath := writeInput(t, dir, "ll_map.txt", "zpo\t丶\t# 注释\t含制表符\nxpo\t口 ＃ 全角\nabc\tC#\n")
+	compMap, _, err := ReadCompMap(mapPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"丶": "zpo", "口": "xpo", "C#": "abc"}; !reflect.DeepEqual(compMap, want) {
+		t.Errorf("映射表 %v，期望 %v", compMap, want)
+	}
+
+	freqPath := writeInput(t, dir, "freq.txt", "的\t100\t# 注\t释\n了\t50 ＃全角\n")
+	charFreq, err := ReadCharFreq(freqPath, CharFreqOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int64{"的": 100, "了": 50}; !reflect.DeepEqual(charFreq.Chars, want) {
+		t.Errorf("字频 %v，期望 %v", charFreq.Chars, want)
+	}
+
+	wordsPath := writeInput(t, dir, "ll_words.txt", "的了\t30\t# 注\t释\n了的 ＃ 无权重\nC#语言\t5\n")
+	entries, _, err := ReadWordsFile(wordsPath, WordsFileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*types.WordEntry{{Word: "的了", Weight: "30"}, {Word: "了的"}, {Word: "C#语言", Weight: "5"}}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("词表 %+v，期望 %+v", entries, want)
+	}
+}
+
+// TestReadersNormalizeNFC 拆分表、映射表与频率表读入时规范化为 NFC：分解形式的字与合成形式的字根、字频对得上
+// 分解形式的谚文音节由三个字母组成，规范化后才能通过字素簇校验
+func TestReadersNormalizeNFC(t *testing.T) {
+	dir := t.TempDir()
+	divPath := writeInput(t, dir, "ll_div.txt", "e\u0301\t[e\u0301,e,Latin,U+00E9]\n\u1112\u1161\u11ab\t[\u1112\u1161\u11ab,han,Hangul,U+D55C]\n")
+	divTable, _, issues, err := ReadDivisionTable([]string{divPath}, DivisionTableOptions{ValidateEncoding: true})
+	if err != nil || len(issues) > 0 {
+		t.Fatalf("ReadDivisionTable: %v %v", err, issues)
+	}
+	for char, want := range map[string]types.Division{
+		"\u00e9": {Char: "\u00e9", Divs: []string{"\u00e9"}, Pin: "e", Set: "Latin", Unicode: "U+00E9"},
+		"\ud55c": {Char: "\ud55c", Divs: []string{"\ud55c"}, Pin: "han", Set: "Hangul", Unicode: "U+D55C"},
+	} {
+		if divisions := divTable[char]; len(divisions) != 1 || !reflect.DeepEqual(*divisions[0], want) {
+			t.Errorf("拆分表 %+q: %+v，期望 %+v", char, divisions, want)
+		}
+	}
+
+	compMap, _, err := ReadCompMap(writeInput(t, dir, "ll_map.txt", "abc\te\u0301\nhan\t\u1112\u1161\u11ab\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"\u00e9": "abc", "\ud55c": "han"}; !reflect.DeepEqual(compMap, want) {
+		t.Errorf("映射表 %+q，期望 %+q", compMap, want)
+	}
+
+	charFreq, err := ReadCharFreq(writeInput(t, dir, "freq.txt", "e\u0301\t7\n\u1112\u1161\u11ab\t3\n"), CharFreqOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int64{"\u00e9": 7, "\ud55c": 3}; !reflect.DeepEqual(charFreq.Chars, want) {
+		t.Errorf("字频 %v，期望 %v", charFreq.Chars, want)
+	}
+
+	charMetaList, skipped := BuildFullCodeMetaList(divTable, compMap, charFreq.Chars)
+	if len(skipped) > 0 || len(charMetaList) != 2 {
+		t.Fatalf("全码 %+v，跳过 %v", charMetaList, skipped)
+	}
+	for _, charMeta := range charMetaList {
+		if charMeta.Freq != charFreq.Chars[charMeta.Char] || charMeta.Code == "" {
+			t.Errorf("%+q 的全码 %q、字频 %d 未按规范化后的字根与字频取得", charMeta.Char, charMeta.Code, charMeta.Freq)
+		}
+	}
+}
+
+// TestReadDivisionTableMalformedLines 格式错误的行（缺少制表符、拆分信息字段不足、拆分为空）默认跳过，并按文件行号返回
+// 严格模式下第一处格式错误即返回错误
+func TestReadDivisionTableMalformedLines(t *testing.T) {
+	path := writeInput(t, t.TempDir(), "ll_div.txt", "的\t[白勹丶,de,CJK,U+7684]\n# 注释\n㊣\n㊣\t[㊣,zheng]\n了\t[,le,CJK,U+4E86]\n")
+
+	table, _, issues, err := ReadDivisionTable([]string{path}, DivisionTableOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table) != 1 || table["的"] == nil {
+		t.Errorf("只应读入 的: %v", table)
+	}
+	want := []*LineError{
+		{File: path, Line: 3, Msg: "格式错误，缺少制表符"},
+		{File: path, Line: 4, Msg: "格式错误，缺少字段"},
+		{File: path, Line: 5, Msg: "格式错误，缺少拆分"},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("格式错误 %v，期望 %v", issues, want)
+	}
+
+	_, _, _, err = ReadDivisionTable([]string{path}, DivisionTableOptions{Strict: true})
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || *lineErr != *want[0] {
+		t.Errorf("严格模式应在第 3 行报错，得到 %v", err)
+	}
+}
+
+// TestReadCompMapIndexedDuplicates 重复定义的字根按首次定义的顺序各记一次，列出各次定义的行号与编码，编码以最后一行为准
+// 只有编码不同的重复定义算冲突
+func TestReadCompMapIndexedDuplicates(t *testing.T) {
+	path := writeInput(t, t.TempDir(), "ll_map.txt", "zpo\t丶\nakw\t口\nzpo\t乙\nxpo\t丶\nakw\t口\nzzz\t丶\n")
+	index, err := ReadCompMapIndexed(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*CompMapDuplicate{
+		{File: path, Comp: "丶", Lines: []int{1, 4, 6}, Codes: []string{"zpo", "xpo", "zzz"}},
+		{File: path, Comp: "口", Lines: []int{2, 5}, Codes: []string{"akw", "akw"}},
+	}
+	if !reflect.DeepEqual(index.Duplicates, want) {
+		t.Fatalf("重复定义 %+v，期望 %+v", index.Duplicates, want)
+	}
+	if !index.Duplicates[0].Conflicting() || index.Duplicates[1].Conflicting() {
+		t.Error("只有 丶 的重复定义编码不同")
+	}
+	if got, want := index.Duplicates[0].String(), path+": 字根 丶 重复定义: 第 1 行 zpo，第 4 行 xpo，第 6 行 zzz"; got != want {
+		t.Errorf("String() = %q，期望 %q", got, want)
+	}
+	if index.CompCode["丶"] != "zzz" {
+		t.Errorf("丶 的编码 %q，应以最后一行 zzz 为准", index.CompCode["丶"])
+	}
+	err = index.ConflictError()
+	if err == nil || !strings.HasPrefix(err.Error(), "映射表中 1 个字根重复定义且编码不同:\n") || strings.Contains(err.Error(), "口") {
+		t.Errorf("ConflictError() = %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultRootsCodePrefix 字根码表编码的默认引导前缀，以此开头的编码为字根反查专用
+const DefaultRootsCodePrefix = "]"
+
+// reservedPrefixShown 违规编码最多列出的条目数，避免输出过长
+const reservedPrefixShown = 20
+
+// ValidateReservedPrefix 校验单字、多字词与玲珑词的全码、简码都不以字根反查引导前缀开头
+// 以引导前缀开头的编码是字根码表的专用编码空间，普通编码落入时 Rime 中会与字根反查冲突；多字词简码占位符同样参与校验
+func ValidateReservedPrefix(result *Result, prefix string) error {
+	if prefix == "" {
+		return nil
+	}
+
+	var violations []string
+	count := 0
+	check := func(kind, text, code string) {
+		if !strings.HasPrefix(code, prefix) {
+			return
+		}
+		count++
+		if len(violations) < reservedPrefixShown {
+			violations = append(violations, fmt.Sprintf("%s %s\t%s", kind, text, code))
+		}
+	}
+	for _, charMeta := range result.FullCodeMetaList {
+		check("单字全码", charMeta.Char, charMeta.Code)
+	}
+	for _, charMeta := range result.SimpleCodeList {
+		check("单字简码", charMeta.Char, charMeta.Code)
+	}
+	for _, wordCode := range result.WordCodes {
+		check("多字词全码", wordCode.Word, wordCode.Code)
+	}
+	for _, wordSimpleCode := range result.WordSimpleCodes {
+		check("多字词简码", wordSimpleCode.Word, wordSimpleCode.Code)
+	}
+	for _, wordCode := range result.LinglongCodes {
+		check("玲珑词全码", wordCode.Word, wordCode.Code)
+	}
+	for _, wordSimpleCode := range result.LinglongSimpleCodes {
+		check("玲珑词简码", wordSimpleCode.Word, wordSimpleCode.Code)
+	}
+
+	if count > 0 {
+		if count > len(violations) {
+			violations = append(violations, fmt.Sprintf("……（只列出前 %d 项）", len(violations)))
+		}
+		return fmt.Errorf("发现 %d 个编码落入字根反查引导前缀 %s 的专用编码空间:\n%s", count, prefix, strings.Join(violations, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"sort"
+	"sync"
+
+	"gen_ll/types"
+)
+
+// 编码最大长度
+const MaxCodeLength = 4
+
+// codeKeys 编码键位（24键），前三码均取自这些键
+var codeKeys = []string{"q", "t", "y", "p", "a", "s", "d", "f", "g", "h", "j", "k", "l", ";", "z", "x", "c", "v", "b", "n", "m", ",", ".", "/"}
+
+// suffixKeys 单字全码末码（小码）与单字简码末码使用的键
+var suffixKeys = []string{"w", "r", "u", "o"}
+
+// Result 一次构建得到的全部编码数据
+type Result struct {
+	FullCodeMetaList    []*types.CharMeta       // 单字全码
+	SimpleCodeList      []*types.CharMeta       // 单字简码
+	WordCodes           []*types.WordCode       // 多字词全码
+	WordSimpleCodes     []*types.WordSimpleCode // 多字词简码（含占位符）
+	LinglongCodes       []*types.WordCode       // 玲珑多字词全码
+	LinglongSimpleCodes []*types.WordSimpleCode // 玲珑多字词简码
+	CompMap             map[string]string       // 字根到编码的映射
+	Rules               *ExperienceRules        // 构建使用的体验规则，出简让全、跟打词提阶段继续使用
+
+	indexOnce sync.Once
+	index     *PrefixIndex
+}
+
+// CodeOccupant 编码的占用者
+type CodeOccupant struct {
+	Text   string // 字或词
+	Source string // 来源：chars_full、chars_simp、words_full、words_simp、linglong_full、linglong_simp
+}
+
+// CodeUsage 单个编码的占用情况
+type CodeUsage struct {
+	Code      string
+	Occupants []*CodeOccupant
+}
+
+// LengthUsage 某一编码长度下的占用情况
+type LengthUsage struct {
+	Length   int          // 编码长度
+	Occupied []*CodeUsage // 已占用的编码
+	Free     []string     // 剩余空位
+}
+
+// PrefixUsageReport 某一前缀下各长度编码的占用情况
+type PrefixUsageReport struct {
+	Prefix  string
+	Lengths []*LengthUsage
+}
+
+// PrefixIndex 返回构建结果全部编码的前缀索引，首次调用时构建，之后各处共享
+// 多字词简码中的占位符只用于固定候选位置，不算占用
+func (result *Result) PrefixIndex() *PrefixIndex {
+	result.indexOnce.Do(func() {
+		var entries []PrefixIndexEntry
+		for charMeta := range result.RangeChars() {
+			entries = append(entries, PrefixIndexEntry{Code: charMeta.Code, Text: charMeta.Char, Source: "chars_full"})
+		}
+		for charMeta := range result.RangeSimpleChars() {
+			entries = append(entries, PrefixIndexEntry{Code: charMeta.Code, Text: charMeta.Char, Source: "chars_simp"})
+		}
+		for wordCode := range result.RangeWords() {
+			entries = append(entries, PrefixIndexEntry{Code: wordCode.Code, Text: wordCode.Word, Source: "words_full"})
+		}
+		for wordSimpleCode := range result.RangeWordSimpleCodes() {
+			if !wordSimpleCode.IsPlaceholder {
+				entries = append(entries, PrefixIndexEntry{Code: wordSimpleCode.Code, Text: wordSimpleCode.Word, Source: "words_simp"})
+			}
+		}
+		for wordCode := range result.RangeLinglongWords() {
+			entries = append(entries, PrefixIndexEntry{Code: wordCode.Code, Text: wordCode.Word, Source: "linglong_full"})
+		}
+		for wordSimpleCode := range result.RangeLinglongSimpleCodes() {
+			if !wordSimpleCode.IsPlaceholder {
+				entries = append(entries, PrefixIndexEntry{Code: wordSimpleCode.Code, Text: wordSimpleCode.Word, Source: "linglong_simp"})
+			}
+		}
+		result.index = NewPrefixIndex(entries)
+	})
+	return result.index
+}
+
+// PrefixUsage 查询以 prefix 开头的各长度编码的占用者与剩余空位
+// 空位按编码规则枚举：中间各码取自24键，末码取自24键或 w r u o
+func (result *Result) PrefixUsage(prefix string) *PrefixUsageReport {
+	report := &PrefixUsageReport{Prefix: prefix}
+	index := result.PrefixIndex()
+
+	occupiedByLength := make(map[int][]*CodeUsage)
+	for _, usage := range index.Lookup(prefix) {
+		occupiedByLength[len(usage.Code)] = append(occupiedByLength[len(usage.Code)], usage)
+	}
+
+	minLength := len(prefix)
+	if minLength == 0 {
+		minLength = 1
+	}
+	for length := minLength; length <= MaxCodeLength; length++ {
+		usage := &LengthUsage{Length: length, Occupied: occupiedByLength[length]}
+		for _, code := range enumerateCodes(prefix, length) {
+			if index.Occupants(code) == nil {
+				usage.Free = append(usage.Free, code)
+			}
+		}
+		report.Lengths = append(report.Lengths, usage)
+	}
+
+	return report
+}
+
+// enumerateCodes 枚举以 prefix 开头、长度为 length 的全部合法编码
+func enumerateCodes(prefix string, length int) []string {
+	if length < len(prefix) {
+		return nil
+	}
+	if length == len(prefix) {
+		return []string{prefix}
+	}
+
+	lastKeys := append(append([]string{}, codeKeys...), suffixKeys...)
+	codes := []string{prefix}
+	for pos := len(prefix); pos < length; pos++ {
+		keys := codeKeys
+		if pos == length-1 {
+			keys = lastKeys
+		}
+		next := make([]string, 0, len(codes)*len(keys))
+		for _, code := range codes {
+			for _, key := range keys {
+				next = append(next, code+key)
+			}
+		}
+		codes = next
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gen_ll/types"
+)
+
+// ReverseDictOptions 反查注释词典生成选项
+type ReverseDictOptions struct {
+	Display *DisplayReplacer // 拆分部件的显示替换，nil 时原样输出
+}
+
+// reverseDictEntry 反查注释：全码、回填的简码（没有时省略括号）与拆分部件，格式为"全码(简码)[拆分]"
+func reverseDictEntry(charMeta *types.CharMeta, display *DisplayReplacer) string {
+	entry := charMeta.Code
+	if charMeta.SimpCode != "" {
+		entry += "(" + charMeta.SimpCode + ")"
+	}
+	if charMeta.Division != nil {
+		entry += "[" + display.Replace(strings.Join(charMeta.Division.Divs, "")) + "]"
+	}
+	return entry
+}
+
+// WriteReverseDict 生成供 Rime 反查注释使用的词典（如 LL_reverse.dict.yaml），每行"字\t全码(简码)[拆分]"，返回条目数
+// 字典名取自文件名；条目按字符 Unicode 顺序排列，同字多个拆分各占一行，简码只回填在主拆分条目上
+func WriteReverseDict(path string, fullCodeMetaList []*types.CharMeta, opts ReverseDictOptions) (int, error) {
+	name := strings.TrimSuffix(filepath.Base(path), ".dict.yaml")
+
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("无法创建文件 %s: %w", path, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	fmt.Fprintf(writer, "# encoding: utf-8\n#\n# 离乱反查注释：全码(简码)[拆分]\n#\n\n---\nname: \"%s\"\nversion: release\nsort: original\ncolumns:\n  - text\n  - code\n...\n\n", name)
+	count := 0
+	for _, charMeta := range SortedCharMetaView(fullCodeMetaList, CharMetaByChar) {
+		if charMeta.Code == "" {
+			continue
+		}
+		writer.WriteString(charMeta.Char + "\t" + reverseDictEntry(charMeta, opts.Display) + "\n")
+		count++
+	}
+	if err := writer.Flush(); err != nil {
+		return 0, fmt.Errorf("写入文件 %s 时出错: %w", path, err)
+	}
+
+	return count, nil
+}
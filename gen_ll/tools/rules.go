@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gen_ll/tabfile"
+)
+
+// 体验规则作用域
+const (
+	RuleScopeCharsSimp = "chars_simp" // 单字简码生成
+	RuleScopeCharsFull = "chars_full" // LL.chars.full.dict.yaml 同码组排序（与出简让全同一阶段）
+	RuleScopeCiti      = "citi"       // 跟打词提单字全码同码组排序（与出简让全同一阶段）
+)
+
+// 体验规则动作
+const (
+	RuleActionNoSimp = "no-simp" // 不出简，只用于 chars_simp
+	RuleActionDemote = "demote"  // demote:N 在同码组内下移 N 位，组内其后不足 N 位时不移动
+	RuleActionPin    = "pin"     // pin:P 移到同码组第 P 位（从 1 开始），组不足 P 位时移到末位
+)
+
+// DefaultExperienceRulesText 默认规则集，即原先写死在生成流程中的规则："的""了"不出简，并在单字全码与跟打词提中下移两位
+const DefaultExperienceRulesText = `# 字词	作用域	动作
+的	chars_simp	no-simp
+了	chars_simp	no-simp
+的	chars_full	demote:2
+了	chars_full	demote:2
+的	citi	demote:2
+了	citi	demote:2
+`
+
+// ExperienceRule 一条体验规则
+type ExperienceRule struct {
+	Text   string // 字或词
+	Scope  string // 作用域：chars_simp、chars_full 或 citi
+	Action string // 动作：no-simp、demote 或 pin
+	Arg    int    // demote 的下移位数或 pin 的目标位置
+	Line   int    // 规则文件中的行号
+}
+
+func (rule *ExperienceRule) String() string {
+	if rule.Action == RuleActionNoSimp {
+		return fmt.Sprintf("%s\t%s\t%s", rule.Text, rule.Scope, rule.Action)
+	}
+	return fmt.Sprintf("%s\t%s\t%s:%d", rule.Text, rule.Scope, rule.Action, rule.Arg)
+}
+
+// RuleHit 一次排序规则的实际生效：条目在同码组内从 From 位移到 To 位（从 1 开始）
+type RuleHit struct {
+	Rule *ExperienceRule
+	Code string
+	From int
+	To   int
+}
+
+// ExperienceRules 体验规则集，排序规则按文件顺序依次作用于每个同码组
+// 生效记录可并发追加，供调试时核对规则是否按预期作用
+type ExperienceRules struct {
+	Rules []*ExperienceRule
+
+	mu   sync.Mutex
+	hits []RuleHit
+}
+
+// DefaultExperienceRules 返回默认规则集
+func DefaultExperienceRules() *ExperienceRules {
+	rules, err := parseExperienceRules(strings.NewReader(DefaultExperienceRulesText), "默认规则")
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// ReadExperienceRules 读取规则文件，每行"字词\t作用域\t动作"，# 开头为注释
+// 规则文件替换整个默认规则集，需要保留默认规则时把 DefaultExperienceRulesText 的内容一并写入
+func ReadExperienceRules(filepath string) (*ExperienceRules, error) {
+	rules := &ExperienceRules{}
+	if err := forEachRow(filepath, experienceRulesFormat, rules.addRow); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// parseExperienceRules 从 reader 解析规则集，file 只用于错误定位
+func parseExperienceRules(reader io.Reader, file string) (*ExperienceRules, error) {
+	rules := &ExperienceRules{}
+	if err := tabfile.Scan(reader, file, experienceRulesFormat, rules.addRow); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// experienceRulesFormat 规则文件按任意空白分列
+var experienceRulesFormat = tabfile.Options{TrimSpace: true, SplitSpace: true}
+
+// addRow 解析一行规则并加入规则集
+func (rules *ExperienceRules) addRow(row *tabfile.Row) error {
+	rule, err := parseExperienceRule(row)
+	if err != nil {
+		return err
+	}
+	rules.Rules = append(rules.Rules, rule)
+	return nil
+}
+
+// parseExperienceRule 解析一行规则并检查作用域与动作的搭配
+func parseExperienceRule(row *tabfile.Row) (*ExperienceRule, error) {
+	if row.Len() != 3 {
+		return nil, row.Errorf("格式错误，应为 字词\\t作用域\\t动作")
+	}
+	rule := &ExperienceRule{Text: row.Column(0), Scope: row.Column(1), Line: row.Line}
+	action, arg, hasArg := strings.Cut(row.Column(2), ":")
+	rule.Action = action
+
+	switch rule.Scope {
+	case RuleScopeCharsSimp:
+		if action != RuleActionNoSimp || hasArg {
+			return nil, row.Errorf("chars_simp 只支持 no-simp: %s", row.Column(2))
+		}
+	case RuleScopeCharsFull, RuleScopeCiti:
+		if action != RuleActionDemote && action != RuleActionPin {
+			return nil, row.Errorf("%s 只支持 demote:N 与 pin:P: %s", rule.Scope, row.Column(2))
+		}
+		value, err := strconv.Atoi(arg)
+		if !hasArg || err != nil || value < 1 {
+			return nil, row.Errorf("%s 需要正整数参数: %s", action, row.Column(2))
+		}
+		rule.Arg = value
+	default:
+		return nil, row.Errorf("未知的作用域: %s", rule.Scope)
+	}
+	return rule, nil
+}
+
+// NoSimpChars 返回 chars_simp 作用域中不出简的字
+func (rules *ExperienceRules) NoSimpChars() []string {
+	var chars []string
+	for _, rule := range rules.Rules {
+		if rule.Scope == RuleScopeCharsSimp && rule.Action == RuleActionNoSimp {
+			chars = append(chars, rule.Text)
+		}
+	}
+	return chars
+}
+
+// Hits 返回排序规则的生效记录，按生效顺序排列
+func (rules *ExperienceRules) Hits() []RuleHit {
+	rules.mu.Lock()
+	defer rules.mu.Unlock()
+	return append([]RuleHit(nil), rules.hits...)
+}
+
+// applyOrderRules 按作用域内的排序规则依次调整编码为 code 的同码组，有调整时返回副本，原切片不修改
+// 每条规则只作用于组内第一个匹配的条目
+func applyOrderRules[T any](rules *ExperienceRules, scope, code string, group []T, text func(T) string) []T {
+	result := group
+	copied := false
+	for _, rule := range rules.Rules {
+		if rule.Scope != scope {
+			continue
+		}
+		from := -1
+		for i, entry := range result {
+			if text(entry) == rule.Text {
+				from = i
+				break
+			}
+		}
+		if from < 0 {
+			continue
+		}
+
+		to := from
+		switch rule.Action {
+		case RuleActionDemote:
+			if from+rule.Arg < len(result) {
+				to = from + rule.Arg
+			}
+		case RuleActionPin:
+			to = min(rule.Arg, len(result)) - 1
+		}
+		if to == from {
+			continue
+		}
+
+		if !copied {
+			result = append([]T(nil), result...)
+			copied = true
+		}
+		moved := result[from]
+		if to > from {
+			copy(result[from:to], result[from+1:to+1])
+		} else {
+			copy(result[to+1:from+1], result[to:from])
+		}
+		result[to] = moved
+		rules.recordHit(RuleHit{Rule: rule, Code: code, From: from + 1, To: to + 1})
+	}
+	return result
+}
+
+// recordHit 记录一次规则生效
+func (rules *ExperienceRules) recordHit(hit RuleHit) {
+	rules.mu.Lock()
+	defer rules.mu.Unlock()
+	rules.hits = append(rules.hits, hit)
+}
@@ -0,0 +1,192 @@
+package tools
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"gen_ll/types"
+)
+
+// benchmarkFullCodes 按固定种子生成 n 个字的全码：三码字根加一码末码，字频随序号递减
+func benchmarkFullCodes(n int) []*types.CharMeta {
+	const rootKeys = "abcdefghijklmnpqstvxyz"
+	random := rand.New(rand.NewSource(1))
+	fullCodeList := make([]*types.CharMeta, 0, n)
+	for i := 0; i < n; i++ {
+		code := make([]byte, 0, 4)
+		for j := 0; j < 3; j++ {
+			code = append(code, rootKeys[random.Intn(len(rootKeys))])
+		}
+		code = append(code, suffixKeys[random.Intn(len(suffixKeys))][0])
+		fullCodeList = append(fullCodeList, &types.CharMeta{Char: fmt.Sprintf("字%d", i), Code: string(code), Freq: int64(n - i)})
+	}
+	return fullCodeList
+}
+
+// TestBuildSimpleCodeListShortFullCode 全码只有 2、3 码的字：候选不短于全码时不出简，也不占码位、不计入同前缀数量
+func TestBuildSimpleCodeListShortFullCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		fullCodes    []*types.CharMeta
+		lenCodeLimit map[int]int
+		want         map[string]string
+	}{
+		{
+			name:         "二码全码的1简候选与全码相同",
+			fullCodes:    []*types.CharMeta{{Char: "甲", Code: "ab", Freq: 100}, {Char: "乙", Code: "acdb", Freq: 50}},
+			lenCodeLimit: map[int]int{1: 1},
+			want:         map[string]string{"乙": "ab"},
+		},
+		{
+			name:         "二码全码的2简候选长于全码",
+			fullCodes:    []*types.CharMeta{{Char: "甲", Code: "ab", Freq: 100}, {Char: "乙", Code: "abcb", Freq: 50}},
+			lenCodeLimit: map[int]int{2: 1},
+			want:         map[string]string{"乙": "abb"},
+		},
+		{
+			name:         "三码全码可出1简",
+			fullCodes:    []*types.CharMeta{{Char: "甲", Code: "abc", Freq: 100}},
+			lenCodeLimit: map[int]int{1: 1, 2: 1},
+			want:         map[string]string{"甲": "ac"},
+		},
+		{
+			name:         "三码全码的2简候选与全码相同",
+			fullCodes:    []*types.CharMeta{{Char: "甲", Code: "abc", Freq: 100}, {Char: "乙", Code: "abdc", Freq: 50}},
+			lenCodeLimit: map[int]int{2: 1},
+			want:         map[string]string{"乙": "abc"},
+		},
+		{
+			name:         "三码全码的3简候选与全码相同",
+			fullCodes:    []*types.CharMeta{{Char: "甲", Code: "abc", Freq: 100}, {Char: "乙", Code: "abcw", Freq: 50}},
+			lenCodeLimit: map[int]int{3: 1},
+			want:         map[string]string{"乙": "abc"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			simpleCodeList := BuildSimpleCodeList(test.fullCodes, test.lenCodeLimit, nil)
+			got := make(map[string]string, len(simpleCodeList))
+			for _, charMeta := range simpleCodeList {
+				got[charMeta.Char] = charMeta.Code
+			}
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("简码 %v，期望 %v", got, test.want)
+			}
+		})
+	}
+}
+
+// buildSimpleCodeListScan 按前缀计数之前的实现：每试一级前缀都扫描一遍已出的简码统计同前缀数量，仅作对照
+func buildSimpleCodeListScan(fullCodeList []*types.CharMeta, lenCodeLimit map[int]int, noSimplifyChars []string) []*types.CharMeta {
+	sortedList := make([]*types.CharMeta, len(fullCodeList))
+	copy(sortedList, fullCodeList)
+	sortSlice(sortedList, func(i, j int) bool {
+		return sortedList[i].Freq > sortedList[j].Freq
+	})
+
+	resultData := make([]*types.CharMeta, 0)
+	usedCodes := newCodeTable[bool]()
+	noSimplifySet := make(map[string]bool)
+	for _, char := range noSimplifyChars {
+		noSimplifySet[char] = true
+	}
+
+	for _, charMeta := range sortedList {
+		code := charMeta.Code
+		if noSimplifySet[charMeta.Char] || len(code) == 0 {
+			continue
+		}
+
+		fullCodeLastChar := string(code[len(code)-1])
+		var simplified string
+		for i := 0; i < len(code); i++ {
+			limit := lenCodeLimit[i+1]
+			if limit == 0 {
+				continue
+			}
+
+			currentPrefix := code[:i+1]
+			targetLength := i + 1
+			if i+1 <= 2 {
+				targetLength = i + 2
+			}
+
+			samePrefixCount := 0
+			for _, res := range resultData {
+				if len(res.Code) == targetLength && strings.HasPrefix(res.Code, currentPrefix) {
+					samePrefixCount++
+				}
+			}
+			if samePrefixCount >= limit {
+				continue
+			}
+
+			candidate := currentPrefix
+			if i+1 <= 2 {
+				candidate = currentPrefix + fullCodeLastChar
+			}
+			if len(candidate) >= len(code) {
+				continue
+			}
+			if !usedCodes.get(candidate) {
+				simplified = candidate
+				usedCodes.set(simplified, true)
+				break
+			}
+		}
+
+		if simplified != "" {
+			if newCharMeta, err := types.NewSimpCharMeta(charMeta.Char, simplified, charMeta.Freq); err == nil {
+				resultData = append(resultData, newCharMeta)
+			}
+		}
+	}
+
+	sortCharMetaByFreq(resultData)
+	return resultData
+}
+
+// TestBuildSimpleCodeListMatchesScan 按前缀计数的结果与逐条扫描的旧实现一致，含 2、3 码全码与不出简字
+func TestBuildSimpleCodeListMatchesScan(t *testing.T) {
+	fullCodeList := benchmarkFullCodes(3000)
+	for i, charMeta := range benchmarkFullCodes(600) {
+		fullCodeList = append(fullCodeList, &types.CharMeta{Char: fmt.Sprintf("短%d", i), Code: charMeta.Code[:2+i%2], Freq: charMeta.Freq})
+	}
+	noSimplifyChars := []string{"字0", "字5", "短3"}
+
+	for _, lenCodeLimit := range []map[int]int{
+		{1: 4, 2: 4, 3: 4},
+		{1: 1, 2: 2},
+		{2: 10, 3: 6},
+		{3: 1},
+		{1: 24, 2: 24, 3: 24},
+	} {
+		t.Run(fmt.Sprint(lenCodeLimit), func(t *testing.T) {
+			got := BuildSimpleCodeList(fullCodeList, lenCodeLimit, noSimplifyChars)
+			want := buildSimpleCodeListScan(fullCodeList, lenCodeLimit, noSimplifyChars)
+			if len(got) != len(want) {
+				t.Fatalf("简码数量 %d，旧实现 %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i].Char != want[i].Char || got[i].Code != want[i].Code || got[i].Freq != want[i].Freq {
+					t.Fatalf("第 %d 条为 %s %s %d，旧实现为 %s %s %d", i, got[i].Char, got[i].Code, got[i].Freq, want[i].Char, want[i].Code, want[i].Freq)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBuildSimpleCodeList(b *testing.B) {
+	lenCodeLimit := map[int]int{1: 4, 2: 4, 3: 4}
+	for _, n := range []int{1000, 10000, 50000} {
+		fullCodeList := benchmarkFullCodes(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				BuildSimpleCodeList(fullCodeList, lenCodeLimit, nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package tools
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gen_ll/types"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema 导出数据库的表结构；码表按编码建索引，便于按编码前缀查询
+const sqliteSchema = `
+CREATE TABLE chars_full (text TEXT NOT NULL, code TEXT NOT NULL, freq INTEGER);
+CREATE TABLE chars_simp (text TEXT NOT NULL, code TEXT NOT NULL, freq INTEGER);
+CREATE TABLE words_full (text TEXT NOT NULL, code TEXT NOT NULL, freq INTEGER);
+CREATE TABLE words_simp (text TEXT NOT NULL, code TEXT NOT NULL, freq INTEGER);
+CREATE TABLE linglong_full (text TEXT NOT NULL, code TEXT NOT NULL, freq INTEGER);
+CREATE TABLE linglong_simp (text TEXT NOT NULL, code TEXT NOT NULL, freq INTEGER);
+CREATE TABLE divisions (char TEXT NOT NULL, components TEXT NOT NULL, pin TEXT, "set" TEXT, unicode TEXT);
+CREATE INDEX chars_full_code ON chars_full (code);
+CREATE INDEX chars_simp_code ON chars_simp (code);
+CREATE INDEX words_full_code ON words_full (code);
+CREATE INDEX words_simp_code ON words_simp (code);
+CREATE INDEX linglong_full_code ON linglong_full (code);
+CREATE INDEX linglong_simp_code ON linglong_simp (code);
+CREATE INDEX divisions_char ON divisions (char);
+`
+
+// SQLiteResult SQLite 导出结果：各表写入的行数，按表名
+type SQLiteResult struct {
+	Rows map[string]int
+}
+
+// sqliteRow 码表中的一行，freq 为 nil 时写入 NULL（词条缺权重）
+type sqliteRow struct {
+	text, code string
+	freq       interface{}
+}
+
+// WriteSQLiteDB 把构建结果写入一个新的 SQLite 数据库，已有文件先删除
+// 码表与写出的码表文件条目一致（多字词简码含占位符），freq 为字频或词权重，词条缺权重或权重不是整数时为 NULL
+// divisions 为单字全码中的全部拆分（含次拆分），components 为部件原样拼接，不做显示替换
+func WriteSQLiteDB(path string, result *Result) (*SQLiteResult, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("删除已有数据库 %s 失败: %w", path, err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库 %s 失败: %w", path, err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	// 提交后回滚为空操作
+	defer tx.Rollback()
+	if _, err := tx.Exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("创建数据表失败: %w", err)
+	}
+
+	tables := []struct {
+		name string
+		rows []sqliteRow
+	}{
+		{"chars_full", charMetaRows(result.FullCodeMetaList)},
+		{"chars_simp", charMetaRows(result.SimpleCodeList)},
+		{"words_full", wordCodeRows(result.WordCodes)},
+		{"words_simp", wordSimpleCodeRows(result.WordSimpleCodes)},
+		{"linglong_full", wordCodeRows(result.LinglongCodes)},
+		{"linglong_simp", wordSimpleCodeRows(result.LinglongSimpleCodes)},
+	}
+	sqliteResult := &SQLiteResult{Rows: make(map[string]int)}
+	for _, table := range tables {
+		stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (text, code, freq) VALUES (?, ?, ?)", table.name))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range table.rows {
+			if _, err := stmt.Exec(row.text, row.code, row.freq); err != nil {
+				stmt.Close()
+				return nil, fmt.Errorf("写入 %s 失败: %w", table.name, err)
+			}
+		}
+		stmt.Close()
+		sqliteResult.Rows[table.name] = len(table.rows)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO divisions (char, components, pin, "set", unicode) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	for _, charMeta := range result.FullCodeMetaList {
+		division := charMeta.Division
+		if division == nil {
+			continue
+		}
+		if _, err := stmt.Exec(division.Char, strings.Join(division.Divs, ""), division.Pin, division.Set, division.Unicode); err != nil {
+			return nil, fmt.Errorf("写入 divisions 失败: %w", err)
+		}
+		sqliteResult.Rows["divisions"]++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("写入数据库 %s 失败: %w", path, err)
+	}
+	return sqliteResult, nil
+}
+
+// charMetaRows 单字码表的行，freq 为字频
+func charMetaRows(charMetas []*types.CharMeta) []sqliteRow {
+	rows := make([]sqliteRow, len(charMetas))
+	for i, charMeta := range charMetas {
+		rows[i] = sqliteRow{charMeta.Char, charMeta.Code, charMeta.Freq}
+	}
+	return rows
+}
+
+// wordCodeRows 多字词全码表的行
+func wordCodeRows(wordCodes []*types.WordCode) []sqliteRow {
+	rows := make([]sqliteRow, len(wordCodes))
+	for i, wordCode := range wordCodes {
+		rows[i] = sqliteRow{wordCode.Word, wordCode.Code, sqliteWeight(wordCode.Weight)}
+	}
+	return rows
+}
+
+// wordSimpleCodeRows 多字词简码表的行，占位符条目照常写入
+func wordSimpleCodeRows(wordSimpleCodes []*types.WordSimpleCode) []sqliteRow {
+	rows := make([]sqliteRow, len(wordSimpleCodes))
+	for i, wordSimpleCode := range wordSimpleCodes {
+		rows[i] = sqliteRow{wordSimpleCode.Word, wordSimpleCode.Code, sqliteWeight(wordSimpleCode.Weight)}
+	}
+	return rows
+}
+
+// sqliteWeight 词权重转为 freq 列的值；与 parseWeight 不同，缺权重或不是整数时为 NULL 而不是 0
+func sqliteWeight(weight string) interface{} {
+	value, err := strconv.ParseInt(weight, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return value
+}
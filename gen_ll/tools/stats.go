@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"gen_ll/types"
+)
+
+// RootUsage 字根在主拆分中的使用统计
+type RootUsage struct {
+	Root         string // 字根
+	Key          string // 字根大码所在键
+	Count        int    // 出现次数
+	WeightedFreq int64  // 按字频加权的出现次数
+}
+
+// KeyLoad 键位负担统计
+type KeyLoad struct {
+	Key          string  // 键
+	Count        int     // 该键上字根的出现次数
+	WeightedFreq int64   // 该键上字根的字频加权次数
+	Share        float64 // 加权次数占全部字根的比例
+}
+
+// BuildRootFrequency 统计各字根在主拆分中的出现次数与字频加权次数，并按字根大码所在键汇总负担
+// 字根按加权次数降序排列，键按负担占比降序排列；各键的字根取自映射表索引的反向索引
+func BuildRootFrequency(fullCodeMetaList []*types.CharMeta, index *CompMapIndex) ([]*RootUsage, []*KeyLoad) {
+	rootIndex := make(map[string]*RootUsage)
+	for _, charMeta := range fullCodeMetaList {
+		if !charMeta.MDiv || charMeta.Division == nil {
+			continue
+		}
+		for _, root := range charMeta.Division.Divs {
+			compCode := index.CompCode[root]
+			if compCode == "" {
+				continue
+			}
+			usage, exists := rootIndex[root]
+			if !exists {
+				usage = &RootUsage{Root: root, Key: compCode[:1]}
+				rootIndex[root] = usage
+			}
+			usage.Count++
+			usage.WeightedFreq += charMeta.Freq
+		}
+	}
+
+	roots := make([]*RootUsage, 0, len(rootIndex))
+	for _, usage := range rootIndex {
+		roots = append(roots, usage)
+	}
+	keys := make([]*KeyLoad, 0, len(index.KeyComps))
+	var totalFreq int64
+	for key, comps := range index.KeyComps {
+		load := &KeyLoad{Key: key}
+		for _, comp := range comps {
+			if usage, exists := rootIndex[comp]; exists {
+				load.Count += usage.Count
+				load.WeightedFreq += usage.WeightedFreq
+			}
+		}
+		if load.Count == 0 {
+			// 键上的字根都没有出现在主拆分中
+			continue
+		}
+		keys = append(keys, load)
+		totalFreq += load.WeightedFreq
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		if roots[i].WeightedFreq != roots[j].WeightedFreq {
+			return roots[i].WeightedFreq > roots[j].WeightedFreq
+		}
+		return roots[i].Root < roots[j].Root
+	})
+
+	for _, load := range keys {
+		if totalFreq > 0 {
+			load.Share = float64(load.WeightedFreq) / float64(totalFreq)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].WeightedFreq != keys[j].WeightedFreq {
+			return keys[i].WeightedFreq > keys[j].WeightedFreq
+		}
+		return keys[i].Key < keys[j].Key
+	})
+
+	return roots, keys
+}
+
+// WriteRootFrequency 以 tsv 写出字根频率与键位负担
+// 每行格式为"类型\t名称\t键\t次数\t加权次数\t占比"，类型为 root 或 key
+func WriteRootFrequency(filepath string, roots []*RootUsage, keys []*KeyLoad) error {
+	var totalFreq int64
+	for _, load := range keys {
+		totalFreq += load.WeightedFreq
+	}
+
+	buffer := bytes.Buffer{}
+	buffer.WriteString("类型\t名称\t键\t次数\t加权次数\t占比\n")
+	for _, usage := range roots {
+		share := 0.0
+		if totalFreq > 0 {
+			share = float64(usage.WeightedFreq) / float64(totalFreq)
+		}
+		buffer.WriteString(fmt.Sprintf("root\t%s\t%s\t%d\t%d\t%.6f\n", usage.Root, usage.Key, usage.Count, usage.WeightedFreq, share))
+	}
+	for _, load := range keys {
+		buffer.WriteString(fmt.Sprintf("key\t%s\t%s\t%d\t%d\t%.6f\n", load.Key, load.Key, load.Count, load.WeightedFreq, load.Share))
+	}
+
+	return os.WriteFile(filepath, buffer.Bytes(), 0o644)
+}
@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gen_ll/types"
+)
+
+// SimpleCodeCandidate 某一简码前缀下按字频排列的一个候选字
+type SimpleCodeCandidate struct {
+	Char     string
+	Code     string // 以该前缀开头的全码
+	Freq     int64
+	SimpCode string // 当前配置下该字实际拿到的简码，没有简码时为空
+}
+
+// SimpleCodeSuggestion 一个简码前缀的推荐对照：字频最高的候选字与当前实际拿到该级简码的字
+type SimpleCodeSuggestion struct {
+	Prefix     string
+	Holders    []*types.CharMeta      // 当前拿到该前缀该级简码的字，按简码升序
+	Candidates []*SimpleCodeCandidate // 全码以该前缀开头的字，按字频降序，最多 top 个
+}
+
+// SuggestSimpleCodes 列出各简码前缀下全码以该前缀开头、字频最高的 top 个字，以及当前配置下实际拿到该级简码的字
+// level 为 1 时前缀为全码首码，为 2 时为全码前两码；只列出有候选字或有简码的前缀，按前缀升序
+// 同一字的多个拆分以该前缀开头时只列一次
+func (result *Result) SuggestSimpleCodes(level, top int) ([]*SimpleCodeSuggestion, error) {
+	if level != 1 && level != 2 {
+		return nil, fmt.Errorf("简码级别只能为 1 或 2: %d", level)
+	}
+	if top <= 0 {
+		return nil, fmt.Errorf("候选字数须为正数: %d", top)
+	}
+
+	suggestions := make(map[string]*SimpleCodeSuggestion)
+	suggestion := func(prefix string) *SimpleCodeSuggestion {
+		if _, ok := suggestions[prefix]; !ok {
+			suggestions[prefix] = &SimpleCodeSuggestion{Prefix: prefix}
+		}
+		return suggestions[prefix]
+	}
+
+	// 同字有多个简码时取最短的，与 FillSimpCodes 一致
+	simpCodes := make(map[string]string)
+	for _, charMeta := range result.SimpleCodeList {
+		if current, exists := simpCodes[charMeta.Char]; !exists || len(charMeta.Code) < len(current) {
+			simpCodes[charMeta.Char] = charMeta.Code
+		}
+		if simpleCharLevel(charMeta.Code) == level && len(charMeta.Code) > level {
+			holder := suggestion(charMeta.Code[:level])
+			holder.Holders = append(holder.Holders, charMeta)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, charMeta := range result.FullCodeMetaList {
+		if len(charMeta.Code) <= level {
+			continue
+		}
+		prefix := charMeta.Code[:level]
+		if seen[prefix+"\t"+charMeta.Char] {
+			continue
+		}
+		seen[prefix+"\t"+charMeta.Char] = true
+		candidate := suggestion(prefix)
+		candidate.Candidates = append(candidate.Candidates, &SimpleCodeCandidate{
+			Char:     charMeta.Char,
+			Code:     charMeta.Code,
+			Freq:     charMeta.Freq,
+			SimpCode: simpCodes[charMeta.Char],
+		})
+	}
+
+	prefixes := make([]string, 0, len(suggestions))
+	for prefix := range suggestions {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	list := make([]*SimpleCodeSuggestion, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		item := suggestions[prefix]
+		sort.SliceStable(item.Holders, func(i, j int) bool {
+			return item.Holders[i].Code < item.Holders[j].Code
+		})
+		sort.SliceStable(item.Candidates, func(i, j int) bool {
+			if item.Candidates[i].Freq != item.Candidates[j].Freq {
+				return item.Candidates[i].Freq > item.Candidates[j].Freq
+			}
+			return item.Candidates[i].Char < item.Candidates[j].Char
+		})
+		if len(item.Candidates) > top {
+			item.Candidates = item.Candidates[:top]
+		}
+		list = append(list, item)
+	}
+	return list, nil
+}
+
+// FormatSimpleCodeSuggestions 把简码推荐对照格式化为文本：每个前缀先列当前简码，再逐行列候选字
+// 候选字行为"名次\t字\t字频\t全码\t当前简码"，没有简码时最后一列为"-"
+func FormatSimpleCodeSuggestions(suggestions []*SimpleCodeSuggestion, level int) []byte {
+	levelName := map[int]string{1: "一简", 2: "二简"}[level]
+	buffer := bytes.Buffer{}
+	for i, suggestion := range suggestions {
+		if i > 0 {
+			buffer.WriteString("\n")
+		}
+		holders := make([]string, 0, len(suggestion.Holders))
+		for _, holder := range suggestion.Holders {
+			holders = append(holders, fmt.Sprintf("%s(%s)", holder.Char, holder.Code))
+		}
+		if len(holders) == 0 {
+			holders = append(holders, "无")
+		}
+		buffer.WriteString(fmt.Sprintf("[%s] 当前%s: %s\n", suggestion.Prefix, levelName, strings.Join(holders, " ")))
+		for rank, candidate := range suggestion.Candidates {
+			simpCode := candidate.SimpCode
+			if simpCode == "" {
+				simpCode = "-"
+			}
+			buffer.WriteString(fmt.Sprintf("%d\t%s\t%d\t%s\t%s\n", rank+1, candidate.Char, candidate.Freq, candidate.Code, simpCode))
+		}
+	}
+	return buffer.Bytes()
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gen_ll/types"
+)
+
+// TemplateSpec 一个自定义模板输出："名称=模板文件:输出路径"
+type TemplateSpec struct {
+	Name     string
+	Template string
+	Output   string
+}
+
+// ParseTemplateSpec 解析"名称=模板文件:输出路径"
+// 路径中 Windows 盘符后的冒号（如 C:\ 或 C:/）不视为分隔符
+func ParseTemplateSpec(spec string) (*TemplateSpec, error) {
+	name, rest, found := strings.Cut(spec, "=")
+	if !found || name == "" {
+		return nil, fmt.Errorf("模板参数格式应为 名称=模板文件:输出路径: %s", spec)
+	}
+	for i := 0; i < len(rest); i++ {
+		if rest[i] != ':' || isDriveColon(rest, i) {
+			continue
+		}
+		templateFile, output := rest[:i], rest[i+1:]
+		if templateFile == "" || output == "" {
+			break
+		}
+		return &TemplateSpec{Name: name, Template: templateFile, Output: output}, nil
+	}
+	return nil, fmt.Errorf("模板参数格式应为 名称=模板文件:输出路径: %s", spec)
+}
+
+// isDriveColon 判断 path[i] 处的冒号是否为盘符冒号：前面是单个字母，后面是路径分隔符
+func isDriveColon(path string, i int) bool {
+	if i+1 >= len(path) || (path[i+1] != '\\' && path[i+1] != '/') {
+		return false
+	}
+	if i < 1 || (i >= 2 && path[i-2] != ':') {
+		return false
+	}
+	letter := path[i-1]
+	return ('a' <= letter && letter <= 'z') || ('A' <= letter && letter <= 'Z')
+}
+
+// TemplateRoot 字根及其编码
+type TemplateRoot struct {
+	Root string
+	Code string
+}
+
+// TemplateContext 模板渲染上下文，集合均为只读
+type TemplateContext struct {
+	Chars         []*types.CharMeta       // 单字全码（含次拆分，按字频降序）
+	SimpChars     []*types.CharMeta       // 单字简码
+	Words         []*types.WordCode       // 多字词全码
+	WordSimps     []*types.WordSimpleCode // 多字词简码（含占位符）
+	LinglongWords []*types.WordCode       // 玲珑多字词全码
+	LinglongSimps []*types.WordSimpleCode // 玲珑多字词简码
+	Roots         []*TemplateRoot         // 字根，按字根排序
+}
+
+// NewTemplateContext 由构建结果生成模板渲染上下文
+func NewTemplateContext(result *Result) *TemplateContext {
+	roots := make([]*TemplateRoot, 0, len(result.CompMap))
+	for root, code := range result.CompMap {
+		roots = append(roots, &TemplateRoot{Root: root, Code: code})
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].Root < roots[j].Root
+	})
+
+	return &TemplateContext{
+		Chars:         result.FullCodeMetaList,
+		SimpChars:     result.SimpleCodeList,
+		Words:         result.WordCodes,
+		WordSimps:     result.WordSimpleCodes,
+		LinglongWords: result.LinglongCodes,
+		LinglongSimps: result.LinglongSimpleCodes,
+		Roots:         roots,
+	}
+}
+
+// templateFuncs 模板可用的辅助函数
+// sortByCode、sortByFreq 返回排序后的副本，不修改上下文中的集合
+var templateFuncs = template.FuncMap{
+	"sortByCode": templateSortByCode,
+	"sortByFreq": templateSortByFreq,
+	"join":       strings.Join,
+}
+
+// templateSortByCode 按编码升序排列，同码按字频或权重降序
+func templateSortByCode(list interface{}) (interface{}, error) {
+	switch list := list.(type) {
+	case []*types.CharMeta:
+		return SortedCharMetaView(list, CharMetaByCodeFreq), nil
+	case []*types.WordCode:
+		view := append([]*types.WordCode{}, list...)
+		sort.SliceStable(view, func(i, j int) bool {
+			if view[i].Code != view[j].Code {
+				return view[i].Code < view[j].Code
+			}
+			return parseWeight(view[i].Weight) > parseWeight(view[j].Weight)
+		})
+		return view, nil
+	case []*types.WordSimpleCode:
+		return SortedWordSimpleCodeView(list), nil
+	case []*TemplateRoot:
+		view := append([]*TemplateRoot{}, list...)
+		sort.SliceStable(view, func(i, j int) bool {
+			return view[i].Code < view[j].Code
+		})
+		return view, nil
+	}
+	return nil, fmt.Errorf("sortByCode 不支持的类型 %T", list)
+}
+
+// templateSortByFreq 按字频或权重降序排列
+func templateSortByFreq(list interface{}) (interface{}, error) {
+	switch list := list.(type) {
+	case []*types.CharMeta:
+		return SortedCharMetaView(list, func(a, b *types.CharMeta) bool {
+			if a.Freq != b.Freq {
+				return a.Freq > b.Freq
+			}
+			return CharMetaByCodeFreq(a, b)
+		}), nil
+	case []*types.WordCode:
+		view := append([]*types.WordCode{}, list...)
+		sort.SliceStable(view, func(i, j int) bool {
+			return parseWeight(view[i].Weight) > parseWeight(view[j].Weight)
+		})
+		return view, nil
+	case []*types.WordSimpleCode:
+		view := append([]*types.WordSimpleCode{}, list...)
+		sort.SliceStable(view, func(i, j int) bool {
+			return parseWeight(view[i].Weight) > parseWeight(view[j].Weight)
+		})
+		return view, nil
+	}
+	return nil, fmt.Errorf("sortByFreq 不支持的类型 %T", list)
+}
+
+// RenderTemplate 用 text/template 渲染一个模板并写出
+// 解析与执行错误由 text/template 给出，形如"template: 模板文件名:行号: ..."
+func RenderTemplate(spec *TemplateSpec, ctx *TemplateContext) error {
+	content, err := os.ReadFile(spec.Template)
+	if err != nil {
+		return fmt.Errorf("读取模板 %s 失败: %w", spec.Name, err)
+	}
+
+	tmpl, err := template.New(filepath.Base(spec.Template)).Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		return fmt.Errorf("解析模板 %s 失败: %w", spec.Name, err)
+	}
+
+	buffer := bytes.Buffer{}
+	if err := tmpl.Execute(&buffer, ctx); err != nil {
+		return fmt.Errorf("渲染模板 %s 失败: %w", spec.Name, err)
+	}
+
+	return os.WriteFile(spec.Output, buffer.Bytes(), 0o644)
+}
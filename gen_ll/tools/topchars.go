@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+
+	"gen_ll/types"
+)
+
+// 含被截掉字的词的处理方式
+const (
+	TopCharsWordsSkip = "skip" // 跳过含被截掉字的词
+	TopCharsWordsKeep = "keep" // 保留，编码仍按全量单字计算
+)
+
+// TopCharsOptions 按字频截取精简码表的选项
+type TopCharsOptions struct {
+	N     int    // 只保留字频排名前 N 的字，0 表示不截取
+	Words string // 多字词、玲珑词中含被截掉字时的处理方式：skip 或 keep，空同 skip
+}
+
+// TopCharsReport 截取统计
+type TopCharsReport struct {
+	Chars        int // 截取前的字数（同字多个拆分只计一次）
+	KeptChars    int // 保留的字数
+	FullEntries  int // 保留的全码条目数（含次拆分）
+	SimpEntries  int // 保留的简码条目数
+	SkippedWords int // 跳过的多字词与玲珑词条目数（全码与简码分别计数）
+}
+
+// TruncateTopChars 返回只含字频排名前 N 的字的构建结果，不修改 result
+// 字频相同按字符 Unicode 顺序排名；简码沿用全量构建时分配的编码，保证与完整码表的码位一致
+// 多字词按字符逐个判断，多字词简码中的占位符总是保留
+func TruncateTopChars(result *Result, opts TopCharsOptions) (*Result, *TopCharsReport, error) {
+	switch opts.Words {
+	case "", TopCharsWordsSkip, TopCharsWordsKeep:
+	default:
+		return nil, nil, fmt.Errorf("未知的被截掉字所在词的处理方式: %s", opts.Words)
+	}
+	if opts.N < 0 {
+		return nil, nil, fmt.Errorf("截取字数不能为负数: %d", opts.N)
+	}
+
+	charFreq := make(map[string]int64)
+	for _, charMeta := range result.FullCodeMetaList {
+		if freq, exists := charFreq[charMeta.Char]; !exists || charMeta.Freq > freq {
+			charFreq[charMeta.Char] = charMeta.Freq
+		}
+	}
+	chars := make([]string, 0, len(charFreq))
+	for char := range charFreq {
+		chars = append(chars, char)
+	}
+	sort.Slice(chars, func(i, j int) bool {
+		if charFreq[chars[i]] != charFreq[chars[j]] {
+			return charFreq[chars[i]] > charFreq[chars[j]]
+		}
+		return chars[i] < chars[j]
+	})
+	report := &TopCharsReport{Chars: len(chars), KeptChars: len(chars)}
+	if opts.N == 0 || opts.N >= len(chars) {
+		report.FullEntries = len(result.FullCodeMetaList)
+		report.SimpEntries = len(result.SimpleCodeList)
+		return result, report, nil
+	}
+
+	cut := make(map[string]bool, len(chars)-opts.N)
+	for _, char := range chars[opts.N:] {
+		cut[char] = true
+	}
+	report.KeptChars = opts.N
+
+	keepChar := func(charMeta *types.CharMeta) bool { return !cut[charMeta.Char] }
+	// keepWord 判断词是否保留，跳过时计数
+	keepWord := func(word string) bool {
+		if opts.Words == TopCharsWordsKeep {
+			return true
+		}
+		for _, char := range word {
+			if cut[string(char)] {
+				report.SkippedWords++
+				return false
+			}
+		}
+		return true
+	}
+	keepWordCode := func(wordCode *types.WordCode) bool { return keepWord(wordCode.Word) }
+	keepWordSimpleCode := func(wordSimpleCode *types.WordSimpleCode) bool {
+		return wordSimpleCode.IsPlaceholder || keepWord(wordSimpleCode.Word)
+	}
+
+	truncated := &Result{
+		FullCodeMetaList:    filterList(result.FullCodeMetaList, keepChar),
+		SimpleCodeList:      filterList(result.SimpleCodeList, keepChar),
+		WordCodes:           filterList(result.WordCodes, keepWordCode),
+		WordSimpleCodes:     filterList(result.WordSimpleCodes, keepWordSimpleCode),
+		LinglongCodes:       filterList(result.LinglongCodes, keepWordCode),
+		LinglongSimpleCodes: filterList(result.LinglongSimpleCodes, keepWordSimpleCode),
+		CompMap:             result.CompMap,
+		Rules:               result.Rules,
+	}
+	report.FullEntries = len(truncated.FullCodeMetaList)
+	report.SimpEntries = len(truncated.SimpleCodeList)
+	return truncated, report, nil
+}
+
+// filterList 返回 keep 为真的元素组成的新切片，保持原顺序；nil 仍返回 nil（表示该部分未生成）
+func filterList[T any](list []T, keep func(T) bool) []T {
+	if list == nil {
+		return nil
+	}
+	kept := make([]T, 0, len(list))
+	for _, item := range list {
+		if keep(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
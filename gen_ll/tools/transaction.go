@@ -0,0 +1,210 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DictTransactionOptions 事务式字典追加选项
+type DictTransactionOptions struct {
+	Backup     bool   // 提交替换前把原字典保存为"字典.bak"
+	PreviewDir string // 预览：提交时把校验通过的临时副本写到该目录下的同名文件，不改动目标字典
+}
+
+// stagedDict 一个目标字典的临时副本
+type stagedDict struct {
+	target  string // 目标字典
+	staged  string // 临时副本，与目标同名，位于目标目录下的暂存目录中
+	existed bool   // 事务开始前目标字典是否存在
+}
+
+// DictTransaction 事务式字典追加：每次追加都作用在目标字典的临时副本上，
+// Commit 时全部校验通过才统一替换生效，任何一次追加或校验失败都不改动已有字典
+type DictTransaction struct {
+	opts       DictTransactionOptions
+	dicts      map[string]*stagedDict
+	order      []string          // 目标字典首次追加的顺序，提交按此顺序替换
+	stagingDir map[string]string // 目标目录到暂存目录，暂存目录与目标同在一个文件系统，保证重命名原子
+	failed     []string          // 追加失败的目标字典
+}
+
+// NewDictTransaction 创建字典追加事务，使用完毕须调用 Commit 或 Rollback 清理暂存目录
+func NewDictTransaction(opts DictTransactionOptions) *DictTransaction {
+	return &DictTransaction{
+		opts:       opts,
+		dicts:      make(map[string]*stagedDict),
+		stagingDir: make(map[string]string),
+	}
+}
+
+// Append 同 AppendToDictFile，但追加到目标字典的临时副本；同一字典多次追加共用一个副本
+// 副本与目标同名，按文件名区分的特殊处理（如 LL.chars.full 的出简让全、默认头部）不受影响
+func (tx *DictTransaction) Append(sourceFile, targetFile string, needSort, removeFreq bool, opts DictAppendOptions) (*DictAppendResult, error) {
+	dict, err := tx.stage(targetFile)
+	if err != nil {
+		tx.failed = append(tx.failed, targetFile)
+		return nil, err
+	}
+
+	result, err := AppendToDictFile(sourceFile, dict.staged, needSort, removeFreq, opts)
+	if err != nil {
+		tx.failed = append(tx.failed, targetFile)
+		return nil, err
+	}
+	return result, nil
+}
+
+// stage 返回目标字典的临时副本，首次追加时复制目标字典的内容与权限
+func (tx *DictTransaction) stage(targetFile string) (*stagedDict, error) {
+	if dict, ok := tx.dicts[targetFile]; ok {
+		return dict, nil
+	}
+
+	targetDir := filepath.Dir(targetFile)
+	stagingDir, ok := tx.stagingDir[targetDir]
+	if !ok {
+		var err error
+		stagingDir, err = os.MkdirTemp(targetDir, ".gen_ll-staging*")
+		if err != nil {
+			return nil, fmt.Errorf("创建暂存目录失败: %w", err)
+		}
+		tx.stagingDir[targetDir] = stagingDir
+	}
+
+	dict := &stagedDict{target: targetFile, staged: filepath.Join(stagingDir, filepath.Base(targetFile))}
+	content, err := os.ReadFile(targetFile)
+	if err == nil {
+		dict.existed = true
+		mode := os.FileMode(0o644)
+		if info, err := os.Stat(targetFile); err == nil {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(dict.staged, content, mode); err != nil {
+			return nil, fmt.Errorf("复制 %s 到暂存目录失败: %w", targetFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取目标字典 %s 失败: %w", targetFile, err)
+	}
+
+	tx.dicts[targetFile] = dict
+	tx.order = append(tx.order, targetFile)
+	return dict, nil
+}
+
+// validateStagedDict 校验临时副本：以换行结尾（没有中断残行），数据段每行都有非空的字词与编码
+func validateStagedDict(stagedFile string) error {
+	content, err := os.ReadFile(stagedFile)
+	if err != nil {
+		return err
+	}
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		return fmt.Errorf("文件末行不完整")
+	}
+	_, lines := splitDictFileContent(string(content))
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			return fmt.Errorf("数据行格式错误: %q", line)
+		}
+	}
+	return nil
+}
+
+// Commit 校验全部临时副本并统一替换目标字典
+// 有追加失败或校验失败时不替换任何字典；替换中途失败时把已替换的字典恢复原状
+func (tx *DictTransaction) Commit() error {
+	defer tx.Rollback()
+
+	if len(tx.failed) > 0 {
+		return fmt.Errorf("%d 个字典追加失败（%s），未改动任何字典", len(tx.failed), strings.Join(tx.failed, "、"))
+	}
+	for _, target := range tx.order {
+		if err := validateStagedDict(tx.dicts[target].staged); err != nil {
+			return fmt.Errorf("校验 %s 失败，未改动任何字典: %w", target, err)
+		}
+	}
+	if tx.opts.PreviewDir != "" {
+		for _, target := range tx.order {
+			if err := CopyPreviewFile(tx.dicts[target].staged, PreviewPath(tx.opts.PreviewDir, target)); err != nil {
+				return fmt.Errorf("写出 %s 的预览失败: %w", target, err)
+			}
+		}
+		return nil
+	}
+
+	// 原字典先移到暂存目录留底，全部替换成功后再按需转为备份
+	var replaced []*stagedDict
+	restore := func() {
+		for i := len(replaced) - 1; i >= 0; i-- {
+			dict := replaced[i]
+			if dict.existed {
+				os.Rename(dict.staged+".orig", dict.target)
+			} else {
+				os.Remove(dict.target)
+			}
+		}
+	}
+	for _, target := range tx.order {
+		dict := tx.dicts[target]
+		if dict.existed {
+			if err := os.Rename(dict.target, dict.staged+".orig"); err != nil {
+				restore()
+				return fmt.Errorf("替换 %s 失败，已恢复全部字典: %w", dict.target, err)
+			}
+		}
+		if err := os.Rename(dict.staged, dict.target); err != nil {
+			if dict.existed {
+				os.Rename(dict.staged+".orig", dict.target)
+			}
+			restore()
+			return fmt.Errorf("替换 %s 失败，已恢复全部字典: %w", dict.target, err)
+		}
+		replaced = append(replaced, dict)
+	}
+
+	if tx.opts.Backup {
+		for _, dict := range replaced {
+			if !dict.existed {
+				continue
+			}
+			if err := os.Rename(dict.staged+".orig", dict.target+".bak"); err != nil {
+				return fmt.Errorf("备份 %s 失败: %w", dict.target, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PreviewPath 目标文件在预览目录下的同名文件
+func PreviewPath(previewDir, targetFile string) string {
+	return filepath.Join(previewDir, filepath.Base(targetFile))
+}
+
+// CopyPreviewFile 把文件当前的内容复制为预览文件，覆盖上次的预览；源文件不存在时删除已有的预览文件，之后的追加从空文件开始
+func CopyPreviewFile(sourceFile, previewFile string) error {
+	content, err := os.ReadFile(sourceFile)
+	if os.IsNotExist(err) {
+		if err := os.Remove(previewFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(previewFile, content, 0o644)
+}
+
+// Rollback 丢弃全部临时副本，不改动目标字典；Commit 之后调用为空操作
+func (tx *DictTransaction) Rollback() {
+	for _, stagingDir := range tx.stagingDir {
+		os.RemoveAll(stagingDir)
+	}
+	tx.stagingDir = make(map[string]string)
+}
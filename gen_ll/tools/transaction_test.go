@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// transactionDict 事务测试用的已有字典内容
+const transactionDict = "---\nname: test\n...\n\n甲\tab\n"
+
+// writeTransactionFile 写出测试文件
+func writeTransactionFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkTransactionFile 文件内容应与 want 一致
+func checkTransactionFile(t *testing.T, path, want string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != want {
+		t.Errorf("%s 内容 %q，期望 %q", path, content, want)
+	}
+}
+
+// checkNoStaging 提交或回滚后目录中不应留下暂存目录
+func checkNoStaging(t *testing.T, dir string) {
+	t.Helper()
+	staging, err := filepath.Glob(filepath.Join(dir, ".gen_ll-staging*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(staging) > 0 {
+		t.Errorf("暂存目录未清理: %v", staging)
+	}
+}
+
+// TestDictTransactionCommitRefusesAfterFailedAppend 有一次追加失败时，Commit 不替换任何字典，包括追加成功的
+func TestDictTransactionCommitRefusesAfterFailedAppend(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.txt")
+	writeTransactionFile(t, source, "乙\tcd\t10\n")
+	target := filepath.Join(dir, "a.dict.yaml")
+	writeTransactionFile(t, target, transactionDict)
+
+	tx := NewDictTransaction(DictTransactionOptions{})
+	if _, err := tx.Append(source, target, false, true, DictAppendOptions{}); err != nil {
+		t.Fatalf("追加 %s: %v", target, err)
+	}
+	if _, err := tx.Append(filepath.Join(dir, "missing.txt"), filepath.Join(dir, "b.dict.yaml"), false, true, DictAppendOptions{}); err == nil {
+		t.Fatal("源文件不存在时追加应失败")
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("有追加失败时 Commit 应返回错误")
+	}
+
+	checkTransactionFile(t, target, transactionDict)
+	if _, err := os.Stat(filepath.Join(dir, "b.dict.yaml")); !os.IsNotExist(err) {
+		t.Errorf("追加失败的字典不应被创建: %v", err)
+	}
+	checkNoStaging(t, dir)
+}
+
+// TestDictTransactionCommitRestoresOnRenameFailure 替换中途重命名失败时，已替换的字典恢复原状
+func TestDictTransactionCommitRestoresOnRenameFailure(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.txt")
+	writeTransactionFile(t, source, "乙\tcd\t10\n")
+	existing := filepath.Join(dir, "a.dict.yaml")
+	writeTransactionFile(t, existing, transactionDict)
+	created := filepath.Join(dir, "b.dict.yaml")
+
+	tx := NewDictTransaction(DictTransactionOptions{Backup: true})
+	for _, target := range []string{existing, created} {
+		if _, err := tx.Append(source, target, false, true, DictAppendOptions{}); err != nil {
+			t.Fatalf("追加 %s: %v", target, err)
+		}
+	}
+	// 第二个字典的位置被非空目录占住，第一个字典替换成功后第二个重命名失败
+	if err := os.MkdirAll(filepath.Join(created, "occupied"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("重命名失败时 Commit 应返回错误")
+	}
+
+	checkTransactionFile(t, existing, transactionDict)
+	if info, err := os.Stat(created); err != nil || !info.IsDir() {
+		t.Errorf("占位目录 %s 应保持不变: %v", created, err)
+	}
+	if _, err := os.Stat(existing + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("替换失败时不应写出备份: %v", err)
+	}
+	checkNoStaging(t, dir)
+}
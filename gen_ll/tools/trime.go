@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 同文输入法导出的方案与主词典名，与桌面版的 LL 方案并存互不覆盖
+const trimeSchemaID = "LL_trime"
+
+// trimeImportTables 同文主词典按此顺序导入的子词典，与桌面版 LL.dict.yaml 一致；未生成的子词典跳过
+var trimeImportTables = []string{"LL.chars.quick", "LL.chars.full", "LL.words.quick", "LL.words.full", "LL.roots"}
+
+// TrimeExportFiles 列出导出到同文目录的词典文件：全部放在目录根下（同文的 rime 用户目录）
+// 同文不加载桌面版的 lua 脚本，preset_data 不导出
+func TrimeExportFiles(exportDir string, dictFiles []string) []DeployFile {
+	return RimeDeployFiles(exportDir, dictFiles, "")
+}
+
+// TrimeConfigFiles 生成同文所需的最小配置骨架：主词典（只导入子词典）、方案与 default.custom.yaml 方案列表补丁
+// 返回 文件名 到内容的映射，dictFiles 为本次导出的词典文件，只导入其中存在的子词典
+func TrimeConfigFiles(dictFiles []string) map[string][]byte {
+	exported := make(map[string]bool)
+	for _, dictFile := range dictFiles {
+		exported[strings.TrimSuffix(filepath.Base(dictFile), ".dict.yaml")] = true
+	}
+
+	dict := bytes.Buffer{}
+	dict.WriteString("# encoding: utf-8\n#\n# 离乱（同文输入法），由 gen_ll -trime-out 生成，只导入子词典\n#\n\n---\n")
+	dict.WriteString(fmt.Sprintf("name: %s\nversion: release\nsort: by_weight\nimport_tables:\n", trimeSchemaID))
+	for _, table := range trimeImportTables {
+		if exported[table] {
+			dict.WriteString(fmt.Sprintf("  - %s\n", table))
+		}
+	}
+	dict.WriteString("columns:\n  - text\n  - code\n  - weight\n...\n")
+
+	schema := bytes.Buffer{}
+	schema.WriteString("# Rime schema\n# encoding: utf-8\n# 离乱（同文输入法）最小方案骨架，由 gen_ll -trime-out 生成，不含 lua 组件与主题\n\n")
+	schema.WriteString(fmt.Sprintf("schema:\n  schema_id: %s\n  name: 离乱（同文）\n  version: release\n\n", trimeSchemaID))
+	schema.WriteString(`engine:
+  processors:
+    - ascii_composer
+    - recognizer
+    - key_binder
+    - speller
+    - punctuator
+    - selector
+    - navigator
+    - express_editor
+  segmentors:
+    - ascii_segmentor
+    - matcher
+    - abc_segmentor
+    - punct_segmentor
+    - fallback_segmentor
+  translators:
+    - punct_translator
+    - table_translator
+
+speller:
+  alphabet: qwrtyuopasdfghjkl;'zxcvbnm,./]
+  auto_select: true
+
+`)
+	schema.WriteString(fmt.Sprintf(`translator:
+  dictionary: %s
+  prism: %s
+  enable_completion: false
+  enable_sentence: false
+  enable_user_dict: false
+  enable_encoder: false
+
+punctuator:
+  import_preset: default
+`, trimeSchemaID, trimeSchemaID))
+
+	defaultCustom := fmt.Sprintf("# encoding: utf-8\n# 由 gen_ll -trime-out 生成：把同文方案加入方案列表\n\npatch:\n  schema_list:\n    - schema: %s\n", trimeSchemaID)
+
+	return map[string][]byte{
+		trimeSchemaID + ".dict.yaml":   dict.Bytes(),
+		trimeSchemaID + ".schema.yaml": schema.Bytes(),
+		"default.custom.yaml":          []byte(defaultCustom),
+	}
+}
+
+// WriteTrimeConfig 把配置骨架写入导出目录，已有文件原子替换，返回写出的文件路径（按文件名排序）
+func WriteTrimeConfig(exportDir string, dictFiles []string, opts DeployOptions) ([]string, error) {
+	configFiles := TrimeConfigFiles(dictFiles)
+	names := make([]string, 0, len(configFiles))
+	for name := range configFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(exportDir, name)
+		paths = append(paths, path)
+		if opts.DryRun {
+			continue
+		}
+		if err := writeDeployContent(path, configFiles[name], opts); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
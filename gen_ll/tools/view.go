@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"iter"
+
+	"gen_ll/types"
+)
+
+// 构建完成后的编码列表在写出阶段被多个 goroutine 共享同一批指针，约定一律只读：
+// 不修改元素字段，也不对原切片排序；需要不同顺序时通过下面的函数取排序视图。
+
+// SortedCharMetaView 返回按 less 排序的单字列表副本，原切片与元素均不修改
+func SortedCharMetaView(charMetaList []*types.CharMeta, less func(a, b *types.CharMeta) bool) []*types.CharMeta {
+	view := make([]*types.CharMeta, len(charMetaList))
+	copy(view, charMetaList)
+	sortSlice(view, func(i, j int) bool {
+		return less(view[i], view[j])
+	})
+	return view
+}
+
+// SortedWordSimpleCodeView 返回按 SortWordSimpleCodes 规则排序的多字词简码副本
+func SortedWordSimpleCodeView(wordSimpleCodes []*types.WordSimpleCode) []*types.WordSimpleCode {
+	view := make([]*types.WordSimpleCode, len(wordSimpleCodes))
+	copy(view, wordSimpleCodes)
+	SortWordSimpleCodes(view)
+	return view
+}
+
+// CharMetaByChar 按字符升序
+func CharMetaByChar(a, b *types.CharMeta) bool {
+	return a.Char < b.Char
+}
+
+// CharMetaByCodeFreq 按编码升序，同码按字频降序，再按字符升序
+func CharMetaByCodeFreq(a, b *types.CharMeta) bool {
+	if a.Code != b.Code {
+		return a.Code < b.Code
+	}
+	if a.Freq != b.Freq {
+		return a.Freq > b.Freq
+	}
+	return a.Char < b.Char
+}
+
+// 下面的迭代器供库使用方逐条消费构建结果，不必一次拿到整个切片；
+// 目前基于构建好的切片实现，顺序与对应字段相同，调用方同样只读，之后改为流式构建时接口不变
+
+// RangeChars 按全码表顺序迭代单字全码条目（含次拆分）
+func (result *Result) RangeChars() iter.Seq[*types.CharMeta] {
+	return rangeSlice(result.FullCodeMetaList)
+}
+
+// RangeSimpleChars 迭代单字简码条目，纯全码版本为空
+func (result *Result) RangeSimpleChars() iter.Seq[*types.CharMeta] {
+	return rangeSlice(result.SimpleCodeList)
+}
+
+// RangeWords 迭代多字词全码条目
+func (result *Result) RangeWords() iter.Seq[*types.WordCode] {
+	return rangeSlice(result.WordCodes)
+}
+
+// RangeWordSimpleCodes 迭代多字词简码条目，含占位符（可按 IsPlaceholder 字段过滤）
+func (result *Result) RangeWordSimpleCodes() iter.Seq[*types.WordSimpleCode] {
+	return rangeSlice(result.WordSimpleCodes)
+}
+
+// RangeLinglongWords 迭代玲珑多字词全码条目
+func (result *Result) RangeLinglongWords() iter.Seq[*types.WordCode] {
+	return rangeSlice(result.LinglongCodes)
+}
+
+// RangeLinglongSimpleCodes 迭代玲珑多字词简码条目，含占位符
+func (result *Result) RangeLinglongSimpleCodes() iter.Seq[*types.WordSimpleCode] {
+	return rangeSlice(result.LinglongSimpleCodes)
+}
+
+// rangeSlice 按顺序迭代切片元素，yield 返回 false 时停止
+func rangeSlice[T any](list []T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, item := range list {
+			if !yield(item) {
+				return
+			}
+		}
+	}
+}
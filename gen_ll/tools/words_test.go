@@ -0,0 +1,45 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+
+	"gen_ll/types"
+)
+
+func TestWordCodesUppercase(t *testing.T) {
+	charCodeMap := map[string]string{"我": "abcd", "们": "efgh", "好": "i;jk", "人": "m,no"}
+	tests := []struct {
+		name      string
+		word      string
+		uppercase bool
+		want      string
+	}{
+		{"二字词", "我们", false, "abef"},
+		{"二字词大写", "我们", true, "ABEF"},
+		{"三字词大写", "我们好", true, "AEI;"},
+		{"四字词大写", "我们好人", true, "AEIM"},
+		{"标点键不变", "好人", true, "I;M,"},
+		{"标点键不变小写", "好人", false, "i;m,"},
+		{"单字词用全码", "好", true, "I;JK"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wordCodes, report := BuildWordsFullCode([]*types.WordEntry{{Word: test.word}}, charCodeMap, WordsFullCodeOptions{
+				SingleCharFullCode: true,
+				Uppercase:          test.uppercase,
+			})
+			if len(wordCodes) != 1 {
+				t.Fatalf("生成 %d 条词码，跳过 %v", len(wordCodes), report.Skipped)
+			}
+			code := wordCodes[0].Code
+			if code != test.want {
+				t.Errorf("编码 %q，期望 %q", code, test.want)
+			}
+			if test.uppercase && strings.IndexFunc(code, unicode.IsLower) >= 0 {
+				t.Errorf("大写模式下编码 %q 含小写字母", code)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestTopChars 按字频截取前 N 字：全码、简码与拆分只含字频前 N 的字（同频按字符排序），条目与简码都与全量构建相同
+// skip 时含被截掉字的词不输出，其余词不变；keep 时词表与全量构建相同
+func TestTopChars(t *testing.T) {
+	const top = 10
+	baseDir, topDir, keepDir := t.TempDir(), t.TempDir(), t.TempDir()
+	generateMinimal(t, baseDir)
+	generateMinimal(t, topDir, "-top-chars", strconv.Itoa(top))
+	generateMinimal(t, keepDir, "-top-chars", strconv.Itoa(top), "-top-chars-words", "keep")
+
+	type rankedChar struct {
+		char string
+		freq int
+	}
+	var ranked []rankedChar
+	for _, line := range strings.Split(strings.TrimSuffix(readOutput(t, filepath.Join(baseDir, "code_chars_full.txt")), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		freq, err := strconv.Atoi(fields[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ranked = append(ranked, rankedChar{fields[0], freq})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].freq != ranked[j].freq {
+			return ranked[i].freq > ranked[j].freq
+		}
+		return ranked[i].char < ranked[j].char
+	})
+	topChars := make(map[string]bool)
+	var cutChars []string
+	for i, char := range ranked {
+		if i < top {
+			topChars[char.char] = true
+		} else {
+			cutChars = append(cutChars, char.char)
+		}
+	}
+
+	// filterLines 只保留 keep 为真的行
+	filterLines := func(name string, keep func(line string) bool) string {
+		var builder strings.Builder
+		for _, line := range strings.SplitAfter(readOutput(t, filepath.Join(baseDir, name)), "\n") {
+			if line != "" && keep(line) {
+				builder.WriteString(line)
+			}
+		}
+		return builder.String()
+	}
+	for _, name := range []string{"code_chars_full.txt", "code_chars_simp.txt", "div_ll.txt"} {
+		want := filterLines(name, func(line string) bool { return topChars[strings.SplitN(line, "\t", 2)[0]] })
+		if got := readOutput(t, filepath.Join(topDir, name)); got != want {
+			t.Errorf("-top-chars %d 的 %s:\n%s\n期望:\n%s", top, name, got, want)
+		}
+	}
+	var gotChars []string
+	for _, row := range readRows(t, filepath.Join(topDir, "code_chars_full.txt")) {
+		gotChars = append(gotChars, row[0])
+	}
+	if len(gotChars) != top {
+		t.Errorf("单字全码 %d 字，期望 %d 字: %v", len(gotChars), top, gotChars)
+	}
+
+	droppedWords := 0
+	for _, name := range []string{"code_words_full.txt", "code_words_simp.txt", "linglong_full.txt", "linglong_simp.txt"} {
+		want := filterLines(name, func(line string) bool {
+			for _, char := range cutChars {
+				if strings.Contains(line, char) {
+					return false
+				}
+			}
+			return true
+		})
+		if want != readOutput(t, filepath.Join(baseDir, name)) {
+			droppedWords++
+		}
+		if got := readOutput(t, filepath.Join(topDir, name)); got != want {
+			t.Errorf("-top-chars %d 的 %s:\n%s\n期望:\n%s", top, name, got, want)
+		}
+	}
+	if droppedWords == 0 {
+		t.Error("最小示例数据中没有含低频字的词")
+	}
+	checkSameTables(t, baseDir, keepDir, "code_words_full.txt", "code_words_simp.txt", "linglong_full.txt", "linglong_simp.txt")
+	if !reflect.DeepEqual(readRows(t, filepath.Join(keepDir, "code_chars_full.txt")), readRows(t, filepath.Join(topDir, "code_chars_full.txt"))) {
+		t.Error("-top-chars-words keep 不应改变单字全码")
+	}
+}
@@ -1,12 +1,14 @@
 package types
 
+import "fmt"
+
 // Division 拆分字元
 type Division struct {
-	Char string    // 字符
-	Divs []string  // 拆分部件列表
-	Pin  string    // 拼音
-	Set  string    // 字集
-	Unicode string // Unicode编码
+	Char    string   // 字符
+	Divs    []string // 拆分部件列表
+	Pin     string   // 拼音
+	Set     string   // 字集
+	Unicode string   // Unicode编码
 }
 
 // CharSimp 简码字元
@@ -16,19 +18,56 @@ type CharSimp struct {
 }
 
 // CharMeta 编码字元
+// 构建完成后只读：写出阶段多个 goroutine 共享同一批指针，需要其他顺序时使用 tools.SortedCharMetaView
 type CharMeta struct {
-	Char string   // 字符
-	Full string   // 字符提示码
-	Code string   // 字符全码
-	Stem string   // 智能词构词码
-	Freq int64    // 字频
-	Sel  int      // 选重编号
-	Simp bool     // 字符简码
-	Back bool     // 是否后置
-	MDiv bool     // 是否首要拆分
+	Char     string    // 字符
+	Full     string    // 字符提示码
+	Code     string    // 字符全码
+	Stem     string    // 智能词构词码
+	Freq     int64     // 字频
+	Sel      int       // 选重编号
+	Simp     bool      // 字符简码
+	SimpCode string    // 该字的简码（如有），由 tools.FillSimpCodes 回填到主拆分条目
+	Back     bool      // 是否后置
+	MDiv     bool      // 是否首要拆分
 	Division *Division // 对应的拆分信息
 }
 
+// NewCharMeta 构造单字全码字元：字符的一个拆分，以及按编码规则由该拆分算出的提示码与全码
+// 字符为空、拆分为 nil 或全码为空时返回错误，全码列表中的字元因此总有拆分与编码
+func NewCharMeta(char string, division *Division, full, code string, freq int64, mainDiv bool) (*CharMeta, error) {
+	if char == "" {
+		return nil, fmt.Errorf("字符为空")
+	}
+	if division == nil {
+		return nil, fmt.Errorf("字符 %s 缺少拆分", char)
+	}
+	if code == "" {
+		return nil, fmt.Errorf("字符 %s 的拆分 %v 无法取码", char, division.Divs)
+	}
+	return &CharMeta{
+		Char:     char,
+		Full:     full,
+		Code:     code,
+		Freq:     freq,
+		MDiv:     mainDiv,
+		Division: division,
+	}, nil
+}
+
+// NewSimpCharMeta 构造单字简码字元，简码条目不带拆分信息
+func NewSimpCharMeta(char, code string, freq int64) (*CharMeta, error) {
+	if char == "" || code == "" {
+		return nil, fmt.Errorf("简码字元的字符与编码不能为空: %q %q", char, code)
+	}
+	return &CharMeta{
+		Char: char,
+		Code: code,
+		Freq: freq,
+		Simp: true,
+	}, nil
+}
+
 // PhraseMeta 智能词元
 type PhraseMeta struct {
 	Phrase string // 词汇
@@ -55,9 +94,19 @@ type WordCode struct {
 	Weight string // 权重（可选）
 }
 
+// NewWordCode 构造多字词编码，词语或编码为空时返回错误
+func NewWordCode(word, code, weight string) (*WordCode, error) {
+	if word == "" || code == "" {
+		return nil, fmt.Errorf("多字词的词语与编码不能为空: %q %q", word, code)
+	}
+	return &WordCode{Word: word, Code: code, Weight: weight}, nil
+}
+
 // WordSimpleCode 多字词简码
 type WordSimpleCode struct {
-	Word   string // 词语
-	Code   string // 简码
-	Weight string // 权重（可选）
+	Word          string // 词语
+	Code          string // 简码
+	Weight        string // 权重（可选）
+	IsPlaceholder bool   // 由占位符补位生成的空码位条目，而非词表中的词；排序等处据此区分，不看字符本身
+	BaseCode      string // 占位符补位的基础简码（所占住的空码位），真实词为空；只用于说明，不参与输出
 }
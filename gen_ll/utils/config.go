@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 配置文件格式，按扩展名识别
+const (
+	configYAML = "yaml"
+	configTOML = "toml"
+)
+
+// configEntry 配置文件中的一项
+type configEntry struct {
+	key    string
+	values []string
+	list   bool // 值为数组
+	line   int
+}
+
+// LoadConfig 读取配置文件，键为 args 的字段名（如 Words、LenCodeLimit），值作为参数的默认值
+// 优先级：命令行显式指定的参数 > 配置文件 > 内置默认值；未知的键、重复的键与无法解析的值都报错
+// 格式按扩展名识别（.yaml/.yml 或 .toml），只支持顶层的键值对：字符串、数字、布尔与字符串数组，不支持嵌套
+// 须在 ParseFlags 之后调用，由配置设置的参数之后 FlagPassed 也返回 true
+func LoadConfig(path string, args interface{}) error {
+	value := reflect.ValueOf(args)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("value is not a pointer or is nil")
+	}
+
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		format = configYAML
+	case ".toml":
+		format = configTOML
+	default:
+		return fmt.Errorf("无法识别配置文件 %s 的格式（扩展名须为 .yaml、.yml 或 .toml）", path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	entries, err := parseConfig(string(content), format)
+	if err != nil {
+		return fmt.Errorf("配置文件 %s %w", path, err)
+	}
+
+	// 字段名到参数名：逗号分隔的别名取第一个设置，任一名称在命令行出现都算显式指定
+	elemType := value.Elem().Type()
+	passed := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
+	seen := map[string]int{}
+	for _, entry := range entries {
+		if line, exists := seen[entry.key]; exists {
+			return fmt.Errorf("配置文件 %s 第 %d 行: 配置项 %s 与第 %d 行重复", path, entry.line, entry.key, line)
+		}
+		seen[entry.key] = entry.line
+
+		field, exists := elemType.FieldByName(entry.key)
+		if !exists || !field.IsExported() {
+			return fmt.Errorf("配置文件 %s 第 %d 行: 未知的配置项 %s（键须为参数的字段名）", path, entry.line, entry.key)
+		}
+		flagName := field.Tag.Get("flag")
+		if flagName == "" {
+			flagName = field.Name
+		}
+		names := strings.Split(flagName, ",")
+		if names[0] == "config" {
+			return fmt.Errorf("配置文件 %s 第 %d 行: 配置文件中不能再指定配置文件", path, entry.line)
+		}
+		if flag.Lookup(names[0]) == nil {
+			return fmt.Errorf("配置文件 %s 第 %d 行: 配置项 %s 不是命令行参数", path, entry.line, entry.key)
+		}
+		isList := field.Type.Kind() == reflect.Slice
+		if entry.list && !isList {
+			return fmt.Errorf("配置文件 %s 第 %d 行: 配置项 %s 不接受数组", path, entry.line, entry.key)
+		}
+
+		explicit := false
+		for _, name := range names {
+			explicit = explicit || passed[name]
+		}
+		if explicit {
+			continue
+		}
+		for _, item := range entry.values {
+			if err := flag.Set(names[0], item); err != nil {
+				return fmt.Errorf("配置文件 %s 第 %d 行: 配置项 %s 的值 %q 无效: %v", path, entry.line, entry.key, item, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseConfig 解析配置内容为键值对，错误信息带行号
+func parseConfig(content, format string) ([]*configEntry, error) {
+	var entries []*configEntry
+	var pending *configEntry // YAML 中值为空、等待后续 "- 项" 的键；TOML 中未闭合的多行数组
+	var arrayText string
+	lines := strings.Split(content, "\n")
+	for i, raw := range lines {
+		lineNumber := i + 1
+		line := strings.TrimSpace(stripConfigComment(strings.TrimSuffix(raw, "\r")))
+		if format == configTOML && pending != nil {
+			arrayText += " " + line
+			if !strings.HasSuffix(arrayText, "]") {
+				continue
+			}
+			values, err := parseConfigList(arrayText, format)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %w", pending.line, err)
+			}
+			pending.values = values
+			entries = append(entries, pending)
+			pending = nil
+			continue
+		}
+		if line == "" || (format == configYAML && (line == "---" || line == "...")) {
+			continue
+		}
+
+		if format == configYAML && strings.HasPrefix(line, "-") && (len(line) == 1 || line[1] == ' ') {
+			if pending == nil {
+				return nil, fmt.Errorf("第 %d 行: 数组项前没有对应的键", lineNumber)
+			}
+			item, err := parseConfigScalar(strings.TrimSpace(line[1:]), format)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %w", lineNumber, err)
+			}
+			pending.values = append(pending.values, item)
+			pending.list = true
+			continue
+		}
+		if pending != nil {
+			if !pending.list {
+				pending.values = []string{""}
+			}
+			entries = append(entries, pending)
+			pending = nil
+		}
+
+		if format == configYAML && raw != strings.TrimLeft(raw, " \t") {
+			return nil, fmt.Errorf("第 %d 行: 不支持嵌套的配置项", lineNumber)
+		}
+		if format == configTOML && strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("第 %d 行: 不支持 TOML 表 %s", lineNumber, line)
+		}
+		separator := ":"
+		if format == configTOML {
+			separator = "="
+		}
+		key, valueText, ok := strings.Cut(line, separator)
+		key, valueText = strings.TrimSpace(key), strings.TrimSpace(valueText)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("第 %d 行: 格式应为 键%s 值", lineNumber, map[string]string{configYAML: ": ", configTOML: " = "}[format])
+		}
+		entry := &configEntry{key: key, line: lineNumber}
+
+		switch {
+		case format == configYAML && valueText == "":
+			pending = entry
+			continue
+		case strings.HasPrefix(valueText, "["):
+			if format == configTOML && !strings.HasSuffix(valueText, "]") {
+				pending = entry
+				arrayText = valueText
+				continue
+			}
+			values, err := parseConfigList(valueText, format)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %w", lineNumber, err)
+			}
+			entry.values = values
+			entry.list = true
+		default:
+			item, err := parseConfigScalar(valueText, format)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行: %w", lineNumber, err)
+			}
+			entry.values = []string{item}
+		}
+		entries = append(entries, entry)
+	}
+	if pending != nil {
+		if format == configTOML {
+			return nil, fmt.Errorf("第 %d 行: 数组没有闭合", pending.line)
+		}
+		if !pending.list {
+			pending.values = []string{""}
+		}
+		entries = append(entries, pending)
+	}
+	return entries, nil
+}
+
+// stripConfigComment 去掉引号外以 # 开头的注释，# 须在行首或空白之后
+func stripConfigComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseConfigList 解析 [项, 项] 形式的数组，允许末尾多一个逗号
+func parseConfigList(text, format string) ([]string, error) {
+	if !strings.HasPrefix(text, "[") || !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("数组格式应为 [项, 项]: %s", text)
+	}
+	inner := text[1 : len(text)-1]
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i <= len(inner); i++ {
+		if i < len(inner) {
+			switch c := inner[i]; {
+			case quote == '"' && c == '\\':
+				i++
+				continue
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+				continue
+			case c == '"' || c == '\'':
+				quote = c
+				continue
+			case c != ',':
+				continue
+			}
+		}
+		itemText := strings.TrimSpace(inner[start:i])
+		start = i + 1
+		if itemText == "" {
+			if i == len(inner) {
+				break
+			}
+			return nil, fmt.Errorf("数组中有空项: %s", text)
+		}
+		item, err := parseConfigScalar(itemText, format)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseConfigScalar 解析单个值：双引号字符串按转义解析，单引号字符串原样取值（YAML 中连续两个单引号表示一个单引号）
+// 不加引号的值 YAML 中按字符串原样取值，TOML 中只能是布尔或数字
+func parseConfigScalar(text, format string) (string, error) {
+	switch {
+	case strings.HasPrefix(text, `"`):
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return "", fmt.Errorf("无法解析的字符串: %s", text)
+		}
+		return value, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return "", fmt.Errorf("无法解析的字符串: %s", text)
+		}
+		value := text[1 : len(text)-1]
+		if format == configYAML {
+			value = strings.ReplaceAll(value, "''", "'")
+		}
+		return value, nil
+	}
+	if format == configTOML {
+		if _, err := strconv.ParseBool(text); err == nil {
+			return text, nil
+		}
+		if _, err := strconv.ParseFloat(strings.ReplaceAll(text, "_", ""), 64); err == nil {
+			return strings.ReplaceAll(text, "_", ""), nil
+		}
+		return "", fmt.Errorf("TOML 中的字符串须加引号: %s", text)
+	}
+	return text, nil
+}
@@ -4,11 +4,49 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
 	"unsafe"
 )
 
+// expandDefault 展开字符串默认值中的路径变量，并转换为本系统的路径分隔符
+// $TMP/  系统临时目录（os.TempDir()）
+// $EXE/  可执行文件所在目录；该路径不存在时（如 go run）回退为相对当前目录
+func expandDefault(value string) string {
+	switch {
+	case strings.HasPrefix(value, "$TMP/"):
+		return filepath.Join(os.TempDir(), filepath.FromSlash(strings.TrimPrefix(value, "$TMP/")))
+	case strings.HasPrefix(value, "$EXE/"):
+		relative := filepath.FromSlash(strings.TrimPrefix(value, "$EXE/"))
+		if executable, err := os.Executable(); err == nil {
+			path := filepath.Join(filepath.Dir(executable), relative)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+		return relative
+	}
+	return value
+}
+
+// stringList 可重复指定的字符串参数，每次出现追加一项
+type stringList []string
+
+func (list *stringList) String() string {
+	if list == nil {
+		return ""
+	}
+	return strings.Join(*list, ",")
+}
+
+func (list *stringList) Set(value string) error {
+	*list = append(*list, value)
+	return nil
+}
+
 func ParseFlags(args interface{}) error {
 	value := reflect.ValueOf(args)
 	if value.Kind() != reflect.Ptr || value.IsNil() {
@@ -29,32 +67,80 @@ func ParseFlags(args interface{}) error {
 			flagName = fieldType.Name
 		}
 
-		switch fieldType.Type.Kind() {
-		case reflect.Bool:
-			value, _ := strconv.ParseBool(flagDefault)
-			flag.BoolVar((*bool)(fieldPtr), flagName, value, flagUsage)
-		case reflect.Int:
-			value, _ := strconv.ParseInt(flagDefault, 10, 64)
-			flag.IntVar((*int)(fieldPtr), flagName, int(value), flagUsage)
-		case reflect.Int64:
-			value, _ := strconv.ParseInt(flagDefault, 10, 64)
-			flag.Int64Var((*int64)(fieldPtr), flagName, value, flagUsage)
-		case reflect.Uint:
-			value, _ := strconv.ParseUint(flagDefault, 10, 64)
-			flag.UintVar((*uint)(fieldPtr), flagName, uint(value), flagUsage)
-		case reflect.Uint64:
-			value, _ := strconv.ParseUint(flagDefault, 10, 64)
-			flag.Uint64Var((*uint64)(fieldPtr), flagName, value, flagUsage)
-		case reflect.Float64:
-			value, _ := strconv.ParseFloat(flagDefault, 64)
-			flag.Float64Var((*float64)(fieldPtr), flagName, value, flagUsage)
-		case reflect.String:
-			flag.StringVar((*string)(fieldPtr), flagName, flagDefault, flagUsage)
-		default:
-			log.Printf("unsupported field `%s` of type `%s`, skipped", fieldType.Name, fieldType.Type)
+		// 以逗号分隔的多个名称都指向同一字段（如 "n,dry-run"），别名的说明指回第一个名称
+		names := strings.Split(flagName, ",")
+		for j, name := range names {
+			usage := flagUsage
+			if j > 0 {
+				usage = "同 -" + names[0]
+			}
+			switch fieldType.Type.Kind() {
+			case reflect.Bool:
+				value, _ := strconv.ParseBool(flagDefault)
+				flag.BoolVar((*bool)(fieldPtr), name, value, usage)
+			case reflect.Int:
+				value, _ := strconv.ParseInt(flagDefault, 10, 64)
+				flag.IntVar((*int)(fieldPtr), name, int(value), usage)
+			case reflect.Int64:
+				value, _ := strconv.ParseInt(flagDefault, 10, 64)
+				flag.Int64Var((*int64)(fieldPtr), name, value, usage)
+			case reflect.Uint:
+				value, _ := strconv.ParseUint(flagDefault, 10, 64)
+				flag.UintVar((*uint)(fieldPtr), name, uint(value), usage)
+			case reflect.Uint64:
+				value, _ := strconv.ParseUint(flagDefault, 10, 64)
+				flag.Uint64Var((*uint64)(fieldPtr), name, value, usage)
+			case reflect.Float64:
+				value, _ := strconv.ParseFloat(flagDefault, 64)
+				flag.Float64Var((*float64)(fieldPtr), name, value, usage)
+			case reflect.String:
+				flag.StringVar((*string)(fieldPtr), name, expandDefault(flagDefault), usage)
+			case reflect.Slice:
+				if fieldType.Type.Elem().Kind() != reflect.String {
+					log.Printf("unsupported field `%s` of type `%s`, skipped", fieldType.Name, fieldType.Type)
+					break
+				}
+				flag.Var((*stringList)(fieldPtr), name, usage)
+			default:
+				log.Printf("unsupported field `%s` of type `%s`, skipped", fieldType.Name, fieldType.Type)
+			}
 		}
 	}
 
 	flag.Parse()
 	return nil
 }
+
+// RebaseTmpDefaults 把默认值以 $TMP/ 开头、且命令行未显式指定的字符串参数改到 dir 下，保留 $TMP/ 之后的相对路径
+func RebaseTmpDefaults(args interface{}, dir string) {
+	elem := reflect.ValueOf(args).Elem()
+	for i := 0; i < elem.NumField(); i++ {
+		fieldType := elem.Type().Field(i)
+		flagDefault := fieldType.Tag.Get("default")
+		if fieldType.Type.Kind() != reflect.String || !strings.HasPrefix(flagDefault, "$TMP/") {
+			continue
+		}
+		flagName := fieldType.Tag.Get("flag")
+		if len(flagName) == 0 {
+			flagName = fieldType.Name
+		}
+		explicit := false
+		for _, name := range strings.Split(flagName, ",") {
+			explicit = explicit || FlagPassed(name)
+		}
+		if !explicit {
+			elem.Field(i).SetString(filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(flagDefault, "$TMP/"))))
+		}
+	}
+}
+
+// FlagPassed 判断命令行是否显式指定了名为 name 的参数（须在 ParseFlags 之后调用）
+func FlagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			passed = true
+		}
+	})
+	return passed
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// 监视文件的轮询间隔
+const watchInterval = 500 * time.Millisecond
+
+// fileState 轮询时记录的文件状态，修改时间或大小变化即视为修改
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// statFiles 记录各文件的当前状态，文件不存在时 exists 为 false
+func statFiles(paths []string) []fileState {
+	states := make([]fileState, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		states[i] = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+	}
+	return states
+}
+
+// sameStates 两次轮询的状态是否全部相同
+func sameStates(a, b []fileState) bool {
+	for i := range a {
+		if a[i].exists != b[i].exists || a[i].size != b[i].size || !a[i].modTime.Equal(b[i].modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// WatchFiles 用 os.Stat 轮询各文件，任一文件修改后调用 rebuild，直到收到 SIGINT 才返回
+// 发现修改后等到连续两次轮询状态相同再重建，避免编辑器分几次写入时重复构建；保存时先删后建的文件在重新出现后才触发
+// rebuild 执行期间收到的 SIGINT 在其返回后处理；开始时有文件不存在则返回错误
+func WatchFiles(paths []string, rebuild func()) error {
+	last := statFiles(paths)
+	for i, state := range last {
+		if !state.exists {
+			return fmt.Errorf("无法监视文件 %s: 文件不存在", paths[i])
+		}
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-interrupt:
+			return nil
+		case <-ticker.C:
+		}
+		current := statFiles(paths)
+		if sameStates(last, current) {
+			continue
+		}
+
+		// 等文件写完：状态稳定且都存在
+		for {
+			select {
+			case <-interrupt:
+				return nil
+			case <-ticker.C:
+			}
+			next := statFiles(paths)
+			stable := sameStates(current, next)
+			current = next
+			if !stable {
+				continue
+			}
+			missing := false
+			for _, state := range current {
+				missing = missing || !state.exists
+			}
+			if !missing {
+				break
+			}
+		}
+		last = current
+		rebuild()
+	}
+}
@@ -0,0 +1,61 @@
+//go:build unix
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestWatchFiles 监视的文件修改后等写完调用一次 rebuild，收到 SIGINT 后返回
+// rebuild 中向自身发送 SIGINT，在其返回后才处理
+func TestWatchFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ll_div.txt")
+	if err := os.WriteFile(path, []byte("的\t[白勹丶,de,CJK,U+7684]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rebuilds := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- WatchFiles([]string{path}, func() {
+			rebuilds++
+			if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+				t.Error(err)
+			}
+		})
+	}()
+
+	// 等 WatchFiles 记下初始状态后再修改，大小变化即视为修改
+	time.Sleep(watchInterval / 5)
+	if err := os.WriteFile(path, []byte("的\t[白勹丶,de,CJK,U+7684]\n了\t[乛亅,le,CJK,U+4E86]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(20 * watchInterval):
+		t.Fatal("修改文件后没有重新构建并停止监视")
+	}
+	if rebuilds != 1 {
+		t.Errorf("rebuild 调用 %d 次，期望 1 次", rebuilds)
+	}
+}
+
+// TestWatchFilesMissing 开始监视时文件不存在直接返回错误，不调用 rebuild
+func TestWatchFilesMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.txt")
+	err := WatchFiles([]string{path}, func() {
+		t.Error("文件不存在时不应调用 rebuild")
+	})
+	if err == nil || !strings.Contains(err.Error(), path+": 文件不存在") {
+		t.Errorf("WatchFiles() = %v", err)
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestWatchRejectsStdin 监视模式不能与标准输入同用，构建前即失败
+func TestWatchRejectsStdin(t *testing.T) {
+	code, logs := runGenLL(t, append(minimalArgs(t.TempDir()), "-watch", "-m", "-")...)
+	if code == 0 {
+		t.Fatal("-watch 与标准输入同用应当失败")
+	}
+	if want := "-watch 不能监视标准输入: -m"; !strings.Contains(logs, want) {
+		t.Errorf("日志中没有 %q:\n%s", want, logs)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestWordDupReport 词条重复编码清单恰为多字词与玲珑词全码中字词、编码都相同的条目，按多字词表顺序，每对一次
+// 玲珑优先去重只从多字词全码中去掉这些条目，玲珑词与多字词简码不变
+func TestWordDupReport(t *testing.T) {
+	inputDir := t.TempDir()
+	// 自己、起来也在多字词表中，全码相同
+	linglong := filepath.Join(inputDir, "linglong.txt")
+	if err := os.WriteFile(linglong, []byte("他们自己\t120000\n自己\t5\n起来\t7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	baseDir, dupDir := t.TempDir(), t.TempDir()
+	generateMinimal(t, baseDir, "-L", linglong)
+	report := filepath.Join(dupDir, "dup.tsv")
+	generateMinimal(t, dupDir, "-L", linglong, "-word-dup-report", report, "-word-dup-linglong-first")
+
+	linglongWeights := make(map[[2]string]string)
+	for _, line := range strings.Split(strings.TrimSuffix(readOutput(t, filepath.Join(baseDir, "linglong_full.txt")), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		if key := [2]string{fields[0], fields[1]}; linglongWeights[key] == "" {
+			linglongWeights[key] = fields[2]
+		}
+	}
+	want := []string{"词\t编码\t多字词权重\t玲珑权重"}
+	var kept strings.Builder
+	seen := make(map[[2]string]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(readOutput(t, filepath.Join(baseDir, "code_words_full.txt")), "\n"), "\n") {
+		fields := strings.Split(line, "\t")
+		key := [2]string{fields[0], fields[1]}
+		weight, dup := linglongWeights[key]
+		if !dup {
+			kept.WriteString(line + "\n")
+			continue
+		}
+		if !seen[key] {
+			seen[key] = true
+			want = append(want, line+"\t"+weight)
+		}
+	}
+	if len(want) == 1 {
+		t.Fatal("多字词与玲珑词中没有同码的词")
+	}
+	if got := strings.Split(strings.TrimSuffix(readOutput(t, report), "\n"), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("重复编码清单:\n%s\n期望:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if got := readOutput(t, filepath.Join(dupDir, "code_words_full.txt")); got != kept.String() {
+		t.Errorf("玲珑优先去重后的多字词全码:\n%s\n期望:\n%s", got, kept.String())
+	}
+	checkSameTables(t, baseDir, dupDir, "code_words_simp.txt", "linglong_full.txt", "linglong_simp.txt")
+}